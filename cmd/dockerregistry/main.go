@@ -27,6 +27,34 @@ func main() {
 	runtime.GOMAXPROCS(runtime.NumCPU())
 	flag.Parse()
 
+	// "bench" generates synthetic push/pull load against a running registry
+	if flag.NArg() > 0 && flag.Arg(0) == "bench" {
+		dockerregistry.ExecuteBench(flag.Args()[1:])
+		return
+	}
+
+	// "export" and "import" carry self-contained image bundles across an air gap
+	if flag.NArg() > 0 && flag.Arg(0) == "export" {
+		dockerregistry.ExecuteExport(flag.Args()[1:])
+		return
+	}
+	if flag.NArg() > 0 && flag.Arg(0) == "import" {
+		dockerregistry.ExecuteImport(flag.Args()[1:])
+		return
+	}
+
+	// "replay" plays recorded access-log traffic back against a test registry
+	if flag.NArg() > 0 && flag.Arg(0) == "replay" {
+		dockerregistry.ExecuteReplay(flag.Args()[1:])
+		return
+	}
+
+	// "sync" replicates a repository between registries, copying only the missing blobs
+	if flag.NArg() > 0 && flag.Arg(0) == "sync" {
+		dockerregistry.ExecuteSync(flag.Args()[1:])
+		return
+	}
+
 	// TODO convert to flags instead of a config file?
 	configurationPath := ""
 	if flag.NArg() > 0 {
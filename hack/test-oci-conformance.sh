@@ -0,0 +1,19 @@
+#!/bin/bash
+
+# This command runs the OCI distribution spec conformance checks against an in-process
+# registry with fake auth. The checks live behind the oci_conformance build tag so they are
+# not part of the regular unit test run.
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+STARTTIME=$(date +%s)
+OS_ROOT=$(dirname "${BASH_SOURCE}")/..
+cd "${OS_ROOT}"
+source "${OS_ROOT}/hack/lib/init.sh"
+os::log::stacktrace::install
+
+GOTEST_FLAGS="${GOTEST_FLAGS:-} -tags=oci_conformance -run ^TestOCIConformance$" hack/test-go.sh pkg/dockerregistry/server
+
+echo "test-oci-conformance: ok"
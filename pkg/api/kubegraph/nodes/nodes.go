@@ -5,6 +5,7 @@ import (
 
 	kapi "k8s.io/kubernetes/pkg/api"
 	"k8s.io/kubernetes/pkg/apis/autoscaling"
+	"k8s.io/kubernetes/pkg/apis/extensions"
 
 	osgraph "github.com/openshift/origin/pkg/api/graph"
 )
@@ -154,3 +155,23 @@ func EnsureHorizontalPodAutoscalerNode(g osgraph.MutableUniqueGraph, hpa *autosc
 		},
 	).(*HorizontalPodAutoscalerNode)
 }
+
+// EnsureDeploymentNode adds a graph node for the Deployment if it does not already exist.
+func EnsureDeploymentNode(g osgraph.MutableUniqueGraph, deployment *extensions.Deployment) *DeploymentNode {
+	return osgraph.EnsureUnique(g,
+		DeploymentNodeName(deployment),
+		func(node osgraph.Node) graph.Node {
+			return &DeploymentNode{Node: node, Deployment: deployment}
+		},
+	).(*DeploymentNode)
+}
+
+// EnsureReplicaSetNode adds a graph node for the ReplicaSet if it does not already exist.
+func EnsureReplicaSetNode(g osgraph.MutableUniqueGraph, rs *extensions.ReplicaSet) *ReplicaSetNode {
+	return osgraph.EnsureUnique(g,
+		ReplicaSetNodeName(rs),
+		func(node osgraph.Node) graph.Node {
+			return &ReplicaSetNode{Node: node, ReplicaSet: rs}
+		},
+	).(*ReplicaSetNode)
+}
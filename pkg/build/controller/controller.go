@@ -29,6 +29,9 @@ type BuildController struct {
 	ImageStreamClient imageStreamClient
 	Recorder          record.EventRecorder
 	RunPolicies       []policy.RunPolicy
+	// PushTokenMinter, when set, provides short-lived repository-scoped push tokens for build
+	// pods in place of the long-lived builder service account dockercfg.
+	PushTokenMinter PushTokenMinter
 }
 
 // BuildStrategy knows how to create a pod spec for a pod which can execute a build.
@@ -194,6 +197,8 @@ func (bc *BuildController) nextBuildPhase(build *buildapi.Build) error {
 	}
 	glog.V(4).Infof("Pod %s for build %s/%s is about to be created", podSpec.Name, build.Namespace, build.Name)
 
+	bc.injectPushToken(build, podSpec)
+
 	if _, err := bc.PodManager.CreatePod(build.Namespace, podSpec); err != nil {
 		if errors.IsAlreadyExists(err) {
 			bc.Recorder.Eventf(build, kapi.EventTypeWarning, "failedCreate", "Pod already exists: %s/%s", podSpec.Namespace, podSpec.Name)
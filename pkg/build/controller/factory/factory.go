@@ -67,6 +67,9 @@ type BuildControllerFactory struct {
 	DockerBuildStrategy *strategy.DockerBuildStrategy
 	SourceBuildStrategy *strategy.SourceBuildStrategy
 	CustomBuildStrategy *strategy.CustomBuildStrategy
+	// PushTokenMinter may be set to inject short-lived repository-scoped push tokens into build
+	// pods instead of relying solely on the builder service account dockercfg.
+	PushTokenMinter buildcontroller.PushTokenMinter
 	// Stop may be set to allow controllers created by this factory to be terminated.
 	Stop <-chan struct{}
 }
@@ -91,7 +94,8 @@ func (factory *BuildControllerFactory) Create() controller.RunnableController {
 			SourceBuildStrategy: factory.SourceBuildStrategy,
 			CustomBuildStrategy: factory.CustomBuildStrategy,
 		},
-		Recorder: eventBroadcaster.NewRecorder(kapi.EventSource{Component: "build-controller"}),
+		Recorder:        eventBroadcaster.NewRecorder(kapi.EventSource{Component: "build-controller"}),
+		PushTokenMinter: factory.PushTokenMinter,
 	}
 
 	return &controller.RetryController{
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/serviceaccount"
+
+	buildapi "github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/client"
+	"github.com/openshift/origin/pkg/cmd/server/bootstrappolicy"
+	oauthapi "github.com/openshift/origin/pkg/oauth/api"
+)
+
+// PushTokenEnvVar is the environment variable in which a minted push token is exposed to build
+// pods. Builders that find it set authenticate pushes with the token instead of the dockercfg
+// mounted from the builder service account.
+const PushTokenEnvVar = "PUSH_TOKEN"
+
+// PushTokenMinter requests a short-lived registry token scoped to the repository a build pushes
+// to. When set on a BuildController, the token is injected into build pods so a leaked build
+// credential expires on its own and cannot push anywhere but the build output. When unset, build
+// pods keep using the long-lived builder service account dockercfg.
+type PushTokenMinter interface {
+	MintPushToken(build *buildapi.Build) (string, error)
+}
+
+// oauthPushTokenMinter mints OAuth access tokens for the builder service account of the build's
+// namespace, scoped down to the image pusher role so the registry accepts them only for pushes
+// into that namespace.
+type oauthPushTokenMinter struct {
+	tokens     client.OAuthAccessTokensInterface
+	clientName string
+	ttl        time.Duration
+}
+
+// NewOAuthPushTokenMinter returns a PushTokenMinter that creates OAuth access tokens expiring
+// after the given ttl. The clientName must refer to an existing OAuth client.
+func NewOAuthPushTokenMinter(tokens client.OAuthAccessTokensInterface, clientName string, ttl time.Duration) PushTokenMinter {
+	return &oauthPushTokenMinter{
+		tokens:     tokens,
+		clientName: clientName,
+		ttl:        ttl,
+	}
+}
+
+func (m *oauthPushTokenMinter) MintPushToken(build *buildapi.Build) (string, error) {
+	name, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	token := &oauthapi.OAuthAccessToken{
+		ObjectMeta: kapi.ObjectMeta{Name: name},
+		ClientName: m.clientName,
+		ExpiresIn:  int64(m.ttl / time.Second),
+		Scopes:     []string{fmt.Sprintf("role:%s:%s", bootstrappolicy.ImagePusherRoleName, build.Namespace)},
+		UserName:   serviceaccount.MakeUsername(build.Namespace, bootstrappolicy.BuilderServiceAccountName),
+	}
+	if _, err := m.tokens.OAuthAccessTokens().Create(token); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// randomToken returns a new random token string long enough to pass access token validation.
+func randomToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// injectPushToken mints a token for the build and exposes it to every container of the build pod.
+// Failures are not fatal - the pod falls back to the mounted builder dockercfg.
+func (bc *BuildController) injectPushToken(build *buildapi.Build, podSpec *kapi.Pod) {
+	if bc.PushTokenMinter == nil {
+		return
+	}
+	token, err := bc.PushTokenMinter.MintPushToken(build)
+	if err != nil {
+		glog.V(2).Infof("Could not mint push token for build %s/%s, falling back to the builder dockercfg: %v", build.Namespace, build.Name, err)
+		return
+	}
+	for i := range podSpec.Spec.Containers {
+		podSpec.Spec.Containers[i].Env = append(podSpec.Spec.Containers[i].Env, kapi.EnvVar{
+			Name:  PushTokenEnvVar,
+			Value: token,
+		})
+	}
+}
@@ -25,9 +25,11 @@ type Interface interface {
 	ImageSignaturesInterfacer
 	ImageStreamsNamespacer
 	ImageStreamMappingsNamespacer
+	ImageStreamManifestListsNamespacer
 	ImageStreamTagsNamespacer
 	ImageStreamImagesNamespacer
 	ImageStreamSecretsNamespacer
+	ImagePromotionPoliciesNamespacer
 	DeploymentConfigsNamespacer
 	DeploymentLogsNamespacer
 	RoutesNamespacer
@@ -106,11 +108,21 @@ func (c *Client) ImageStreamMappings(namespace string) ImageStreamMappingInterfa
 	return newImageStreamMappings(c, namespace)
 }
 
+// ImageStreamManifestLists provides a REST client for ImageStreamManifestList
+func (c *Client) ImageStreamManifestLists(namespace string) ImageStreamManifestListInterface {
+	return newImageStreamManifestLists(c, namespace)
+}
+
 // ImageStreamTags provides a REST client for ImageStreamTag
 func (c *Client) ImageStreamTags(namespace string) ImageStreamTagInterface {
 	return newImageStreamTags(c, namespace)
 }
 
+// ImagePromotionPolicies provides a REST client for ImagePromotionPolicy
+func (c *Client) ImagePromotionPolicies(namespace string) ImagePromotionPolicyInterface {
+	return newImagePromotionPolicies(c, namespace)
+}
+
 // ImageStreamImages provides a REST client for ImageStreamImage
 func (c *Client) ImageStreamImages(namespace string) ImageStreamImageInterface {
 	return newImageStreamImages(c, namespace)
@@ -0,0 +1,85 @@
+package client
+
+import (
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/watch"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// ImagePromotionPoliciesNamespacer has methods to work with ImagePromotionPolicy resources in a namespace
+type ImagePromotionPoliciesNamespacer interface {
+	ImagePromotionPolicies(namespace string) ImagePromotionPolicyInterface
+}
+
+// ImagePromotionPolicyInterface exposes methods on imagePromotionPolicy resources.
+type ImagePromotionPolicyInterface interface {
+	List(opts kapi.ListOptions) (*imageapi.ImagePromotionPolicyList, error)
+	Get(name string) (*imageapi.ImagePromotionPolicy, error)
+	Create(policy *imageapi.ImagePromotionPolicy) (*imageapi.ImagePromotionPolicy, error)
+	Update(policy *imageapi.ImagePromotionPolicy) (*imageapi.ImagePromotionPolicy, error)
+	Delete(name string) error
+	Watch(opts kapi.ListOptions) (watch.Interface, error)
+}
+
+// imagePromotionPolicies implements ImagePromotionPolicyInterface
+type imagePromotionPolicies struct {
+	r  *Client
+	ns string
+}
+
+// newImagePromotionPolicies returns an imagePromotionPolicies
+func newImagePromotionPolicies(c *Client, namespace string) *imagePromotionPolicies {
+	return &imagePromotionPolicies{
+		r:  c,
+		ns: namespace,
+	}
+}
+
+// List returns a list of ImagePromotionPolicies that match the label and field selectors.
+func (c *imagePromotionPolicies) List(opts kapi.ListOptions) (result *imageapi.ImagePromotionPolicyList, err error) {
+	result = &imageapi.ImagePromotionPolicyList{}
+	err = c.r.Get().
+		Namespace(c.ns).
+		Resource("imagePromotionPolicies").
+		VersionedParams(&opts, kapi.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// Get returns information about a particular image promotion policy
+func (c *imagePromotionPolicies) Get(name string) (result *imageapi.ImagePromotionPolicy, err error) {
+	result = &imageapi.ImagePromotionPolicy{}
+	err = c.r.Get().Namespace(c.ns).Resource("imagePromotionPolicies").Name(name).Do().Into(result)
+	return
+}
+
+// Create creates a new ImagePromotionPolicy. Returns the server's representation of the policy and error if one occurs.
+func (c *imagePromotionPolicies) Create(policy *imageapi.ImagePromotionPolicy) (result *imageapi.ImagePromotionPolicy, err error) {
+	result = &imageapi.ImagePromotionPolicy{}
+	err = c.r.Post().Namespace(c.ns).Resource("imagePromotionPolicies").Body(policy).Do().Into(result)
+	return
+}
+
+// Update updates the ImagePromotionPolicy on the server. Returns the server's representation of the policy and error if one occurs.
+func (c *imagePromotionPolicies) Update(policy *imageapi.ImagePromotionPolicy) (result *imageapi.ImagePromotionPolicy, err error) {
+	result = &imageapi.ImagePromotionPolicy{}
+	err = c.r.Put().Namespace(c.ns).Resource("imagePromotionPolicies").Name(policy.Name).Body(policy).Do().Into(result)
+	return
+}
+
+// Delete takes the name of the ImagePromotionPolicy, and returns an error if one occurs during deletion of the policy
+func (c *imagePromotionPolicies) Delete(name string) error {
+	return c.r.Delete().Namespace(c.ns).Resource("imagePromotionPolicies").Name(name).Do().Error()
+}
+
+// Watch returns a watch.Interface that watches the requested ImagePromotionPolicies
+func (c *imagePromotionPolicies) Watch(opts kapi.ListOptions) (watch.Interface, error) {
+	return c.r.Get().
+		Prefix("watch").
+		Namespace(c.ns).
+		Resource("imagePromotionPolicies").
+		VersionedParams(&opts, kapi.ParameterCodec).
+		Watch()
+}
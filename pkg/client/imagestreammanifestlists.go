@@ -0,0 +1,37 @@
+package client
+
+import (
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// ImageStreamManifestListsNamespacer has methods to work with ImageStreamManifestList resources in a namespace
+type ImageStreamManifestListsNamespacer interface {
+	ImageStreamManifestLists(namespace string) ImageStreamManifestListInterface
+}
+
+// ImageStreamManifestListInterface exposes methods on ImageStreamManifestList resources.
+type ImageStreamManifestListInterface interface {
+	Create(list *imageapi.ImageStreamManifestList) (*imageapi.ImageStreamManifestList, error)
+}
+
+// imageStreamManifestLists implements ImageStreamManifestListsNamespacer interface
+type imageStreamManifestLists struct {
+	r  *Client
+	ns string
+}
+
+// newImageStreamManifestLists returns an imageStreamManifestLists
+func newImageStreamManifestLists(c *Client, namespace string) *imageStreamManifestLists {
+	return &imageStreamManifestLists{
+		r:  c,
+		ns: namespace,
+	}
+}
+
+// Create assembles a new manifest list on the server from images already tagged into the stream.
+// Returns the resulting list image metadata or an error if one occurs.
+func (c *imageStreamManifestLists) Create(list *imageapi.ImageStreamManifestList) (*imageapi.ImageStreamManifestList, error) {
+	result := &imageapi.ImageStreamManifestList{}
+	err := c.r.Post().Namespace(c.ns).Resource("imageStreamManifestLists").Body(list).Do().Into(result)
+	return result, err
+}
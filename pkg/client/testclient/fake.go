@@ -161,6 +161,11 @@ func (c *Fake) ImageStreamMappings(namespace string) client.ImageStreamMappingIn
 	return &FakeImageStreamMappings{Fake: c, Namespace: namespace}
 }
 
+// ImageStreamManifestLists provides a fake REST client for ImageStreamManifestLists
+func (c *Fake) ImageStreamManifestLists(namespace string) client.ImageStreamManifestListInterface {
+	return &FakeImageStreamManifestLists{Fake: c, Namespace: namespace}
+}
+
 // ImageStreamTags provides a fake REST client for ImageStreamTags
 func (c *Fake) ImageStreamTags(namespace string) client.ImageStreamTagInterface {
 	return &FakeImageStreamTags{Fake: c, Namespace: namespace}
@@ -171,6 +176,11 @@ func (c *Fake) ImageStreamImages(namespace string) client.ImageStreamImageInterf
 	return &FakeImageStreamImages{Fake: c, Namespace: namespace}
 }
 
+// ImagePromotionPolicies provides a fake REST client for ImagePromotionPolicies
+func (c *Fake) ImagePromotionPolicies(namespace string) client.ImagePromotionPolicyInterface {
+	return &FakeImagePromotionPolicies{Fake: c, Namespace: namespace}
+}
+
 // DeploymentConfigs provides a fake REST client for DeploymentConfigs
 func (c *Fake) DeploymentConfigs(namespace string) client.DeploymentConfigInterface {
 	return &FakeDeploymentConfigs{Fake: c, Namespace: namespace}
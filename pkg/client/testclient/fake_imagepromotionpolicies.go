@@ -0,0 +1,61 @@
+package testclient
+
+import (
+	kapi "k8s.io/kubernetes/pkg/api"
+	ktestclient "k8s.io/kubernetes/pkg/client/unversioned/testclient"
+	"k8s.io/kubernetes/pkg/watch"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// FakeImagePromotionPolicies implements ImagePromotionPolicyInterface. Meant to be embedded into a struct to get a default
+// implementation. This makes faking out just the methods you want to test easier.
+type FakeImagePromotionPolicies struct {
+	Fake      *Fake
+	Namespace string
+}
+
+func (c *FakeImagePromotionPolicies) Get(name string) (*imageapi.ImagePromotionPolicy, error) {
+	obj, err := c.Fake.Invokes(ktestclient.NewGetAction("imagepromotionpolicies", c.Namespace, name), &imageapi.ImagePromotionPolicy{})
+	if obj == nil {
+		return nil, err
+	}
+
+	return obj.(*imageapi.ImagePromotionPolicy), err
+}
+
+func (c *FakeImagePromotionPolicies) List(opts kapi.ListOptions) (*imageapi.ImagePromotionPolicyList, error) {
+	obj, err := c.Fake.Invokes(ktestclient.NewListAction("imagepromotionpolicies", c.Namespace, opts), &imageapi.ImagePromotionPolicyList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	return obj.(*imageapi.ImagePromotionPolicyList), err
+}
+
+func (c *FakeImagePromotionPolicies) Create(inObj *imageapi.ImagePromotionPolicy) (*imageapi.ImagePromotionPolicy, error) {
+	obj, err := c.Fake.Invokes(ktestclient.NewCreateAction("imagepromotionpolicies", c.Namespace, inObj), inObj)
+	if obj == nil {
+		return nil, err
+	}
+
+	return obj.(*imageapi.ImagePromotionPolicy), err
+}
+
+func (c *FakeImagePromotionPolicies) Update(inObj *imageapi.ImagePromotionPolicy) (*imageapi.ImagePromotionPolicy, error) {
+	obj, err := c.Fake.Invokes(ktestclient.NewUpdateAction("imagepromotionpolicies", c.Namespace, inObj), inObj)
+	if obj == nil {
+		return nil, err
+	}
+
+	return obj.(*imageapi.ImagePromotionPolicy), err
+}
+
+func (c *FakeImagePromotionPolicies) Delete(name string) error {
+	_, err := c.Fake.Invokes(ktestclient.NewDeleteAction("imagepromotionpolicies", c.Namespace, name), &imageapi.ImagePromotionPolicy{})
+	return err
+}
+
+func (c *FakeImagePromotionPolicies) Watch(opts kapi.ListOptions) (watch.Interface, error) {
+	return c.Fake.InvokesWatch(ktestclient.NewWatchAction("imagepromotionpolicies", c.Namespace, opts))
+}
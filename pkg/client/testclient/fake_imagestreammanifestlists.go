@@ -0,0 +1,27 @@
+package testclient
+
+import (
+	ktestclient "k8s.io/kubernetes/pkg/client/unversioned/testclient"
+
+	"github.com/openshift/origin/pkg/client"
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// FakeImageStreamManifestLists implements ImageStreamManifestListInterface. Meant to
+// be embedded into a struct to get a default implementation. This makes faking
+// out just the methods you want to test easier.
+type FakeImageStreamManifestLists struct {
+	Fake      *Fake
+	Namespace string
+}
+
+var _ client.ImageStreamManifestListInterface = &FakeImageStreamManifestLists{}
+
+func (c *FakeImageStreamManifestLists) Create(inObj *imageapi.ImageStreamManifestList) (*imageapi.ImageStreamManifestList, error) {
+	obj, err := c.Fake.Invokes(ktestclient.NewCreateAction("imagestreammanifestlists", c.Namespace, inObj), inObj)
+	if obj == nil {
+		return nil, err
+	}
+
+	return obj.(*imageapi.ImageStreamManifestList), err
+}
@@ -82,6 +82,7 @@ func NewCommandAdmin(name, fullName string, in io.Reader, out io.Writer, errout
 			Message: "Maintenance:",
 			Commands: []*cobra.Command{
 				diagnostics.NewCmdDiagnostics(diagnostics.DiagnosticsRecommendedName, fullName+" "+diagnostics.DiagnosticsRecommendedName, out),
+				registry.NewCmdVerifyRegistry(f, fullName, "verify-registry", out),
 				prune.NewCommandPrune(prune.PruneRecommendedName, fullName+" "+prune.PruneRecommendedName, f, out),
 				buildchain.NewCmdBuildChain(name, fullName+" "+buildchain.BuildChainRecommendedCommandName, f, out),
 				migrate.NewCommandMigrate(
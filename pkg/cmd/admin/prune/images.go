@@ -172,6 +172,20 @@ func (o *PruneImagesOptions) Complete(f *clientcmd.Factory, cmd *cobra.Command,
 		return err
 	}
 
+	allDeployments, err := kClient.Extensions().Deployments(kapi.NamespaceAll).List(kapi.ListOptions{})
+	// We need to tolerate 'not found' errors since extensions resources may be disabled
+	err = oserrors.TolerateNotFoundError(err)
+	if err != nil {
+		return err
+	}
+
+	allRSs, err := kClient.Extensions().ReplicaSets(kapi.NamespaceAll).List(kapi.ListOptions{})
+	// We need to tolerate 'not found' errors since extensions resources may be disabled
+	err = oserrors.TolerateNotFoundError(err)
+	if err != nil {
+		return err
+	}
+
 	limitRangesList, err := kClient.LimitRanges(kapi.NamespaceAll).List(kapi.ListOptions{})
 	if err != nil {
 		return err
@@ -198,6 +212,8 @@ func (o *PruneImagesOptions) Complete(f *clientcmd.Factory, cmd *cobra.Command,
 		BCs:                allBCs,
 		Builds:             allBuilds,
 		DCs:                allDCs,
+		Deployments:        allDeployments,
+		RSs:                allRSs,
 		LimitRanges:        limitRangesMap,
 		DryRun:             o.Confirm == false,
 		RegistryClient:     registryClient,
@@ -0,0 +1,281 @@
+package registry
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/apis/batch"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/labels"
+	"k8s.io/kubernetes/pkg/util/wait"
+
+	"github.com/openshift/origin/pkg/client"
+	"github.com/openshift/origin/pkg/cmd/admin/policy"
+	"github.com/openshift/origin/pkg/cmd/server/bootstrappolicy"
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	"github.com/openshift/origin/pkg/cmd/util/variable"
+	projectapi "github.com/openshift/origin/pkg/project/api"
+)
+
+const (
+	verifyRegistryLong = `
+Verify the integrated Docker registry end to end
+
+This command creates a transient project, runs a job inside the cluster that logs in to the
+integrated registry with a service account token, pushes a minimal image, pulls it back and
+attempts to delete it, then prints the job output and removes the project again. Because the
+job runs on a cluster node with in-cluster credentials, a passing run exercises the same path
+builds and deployments use, which makes this a quick post-install or post-upgrade check.
+
+The job fails if login, push or pull fail. Manifest deletion is reported but does not fail
+the check because many registry configurations disable deletion on purpose.`
+
+	verifyRegistryExample = `  # Verify the integrated registry
+  %[1]s %[2]s
+
+  # Keep the test project around for inspection after a failure
+  %[1]s %[2]s --keep-project`
+)
+
+// verifyScript runs inside the cluster. It exercises the registry with nothing beyond a
+// shell, curl and sha256sum so it works in any of the supported registry images. The
+// registry location comes from the service environment variables injected into every pod,
+// which avoids a dependency on cluster DNS.
+const verifyScript = `set -e
+TOKEN="$(cat /var/run/secrets/kubernetes.io/serviceaccount/token)"
+REGISTRY="${DOCKER_REGISTRY_SERVICE_HOST}:${DOCKER_REGISTRY_SERVICE_PORT}"
+AUTH="Authorization: Bearer ${TOKEN}"
+
+SCHEME=https
+curl -sk --connect-timeout 5 "https://${REGISTRY}/v2/" >/dev/null 2>&1 || SCHEME=http
+REPO="${SCHEME}://${REGISTRY}/v2/${NAMESPACE}/registry-verify"
+
+echo "login: GET ${SCHEME}://${REGISTRY}/v2/"
+curl -skf -H "${AUTH}" "${SCHEME}://${REGISTRY}/v2/" >/dev/null
+
+CONFIG='{"architecture":"amd64","os":"linux","config":{},"rootfs":{"type":"layers","diff_ids":[]}}'
+CONFIG_DIGEST="sha256:$(printf '%s' "${CONFIG}" | sha256sum | cut -d' ' -f1)"
+
+echo "push: uploading config blob ${CONFIG_DIGEST}"
+LOCATION="$(curl -skf -D - -o /dev/null -X POST -H "${AUTH}" "${REPO}/blobs/uploads/" | awk 'tolower($1)=="location:" {print $2}' | tr -d '\r')"
+case "${LOCATION}" in
+*\?*) LOCATION="${LOCATION}&digest=${CONFIG_DIGEST}" ;;
+*) LOCATION="${LOCATION}?digest=${CONFIG_DIGEST}" ;;
+esac
+printf '%s' "${CONFIG}" | curl -skf -X PUT -H "${AUTH}" -H "Content-Type: application/octet-stream" --data-binary @- "${LOCATION}" >/dev/null
+
+MANIFEST="{\"schemaVersion\":2,\"mediaType\":\"application/vnd.docker.distribution.manifest.v2+json\",\"config\":{\"mediaType\":\"application/vnd.docker.container.image.v1+json\",\"size\":$(printf '%s' "${CONFIG}" | wc -c | tr -d ' '),\"digest\":\"${CONFIG_DIGEST}\"},\"layers\":[]}"
+MANIFEST_DIGEST="sha256:$(printf '%s' "${MANIFEST}" | sha256sum | cut -d' ' -f1)"
+
+echo "push: PUT ${REPO}/manifests/latest"
+printf '%s' "${MANIFEST}" | curl -skf -X PUT -H "${AUTH}" -H "Content-Type: application/vnd.docker.distribution.manifest.v2+json" --data-binary @- "${REPO}/manifests/latest" >/dev/null
+
+echo "pull: GET ${REPO}/manifests/latest"
+curl -skf -H "${AUTH}" -H "Accept: application/vnd.docker.distribution.manifest.v2+json" "${REPO}/manifests/latest" >/dev/null
+
+echo "delete: DELETE ${REPO}/manifests/${MANIFEST_DIGEST}"
+if ! curl -skf -X DELETE -H "${AUTH}" "${REPO}/manifests/${MANIFEST_DIGEST}" >/dev/null; then
+	echo "delete: manifest deletion not permitted (deletion is commonly disabled; not a failure)"
+fi
+
+echo "registry verification succeeded"
+`
+
+// VerifyRegistryOptions contains the configuration for the verify-registry command.
+type VerifyRegistryOptions struct {
+	Project       string
+	ImageTemplate variable.ImageTemplate
+	Timeout       time.Duration
+	KeepProject   bool
+
+	// helpers required for Run.
+	osClient client.Interface
+	kClient  *kclient.Client
+	out      io.Writer
+}
+
+// NewCmdVerifyRegistry implements the OpenShift cli verify-registry command
+func NewCmdVerifyRegistry(f *clientcmd.Factory, parentName, name string, out io.Writer) *cobra.Command {
+	opts := &VerifyRegistryOptions{
+		ImageTemplate: variable.NewDefaultImageTemplate(),
+		Timeout:       5 * time.Minute,
+	}
+
+	cmd := &cobra.Command{
+		Use:     name,
+		Short:   "Verify the integrated Docker registry with an in-cluster smoke test",
+		Long:    verifyRegistryLong,
+		Example: fmt.Sprintf(verifyRegistryExample, parentName, name),
+		Run: func(cmd *cobra.Command, args []string) {
+			kcmdutil.CheckErr(opts.Complete(f, cmd, out, args))
+			kcmdutil.CheckErr(opts.Run())
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Project, "project", "", "Name of the transient project to run the test in; generated when unset.")
+	cmd.Flags().StringVar(&opts.ImageTemplate.Format, "images", opts.ImageTemplate.Format, "The image to run the test job with - ${component} will be replaced with 'docker-registry'")
+	cmd.Flags().BoolVar(&opts.ImageTemplate.Latest, "latest-images", opts.ImageTemplate.Latest, "If true, attempt to use the latest image instead of the latest release.")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", opts.Timeout, "How long to wait for the test job to finish.")
+	cmd.Flags().BoolVar(&opts.KeepProject, "keep-project", opts.KeepProject, "If true, do not delete the test project afterwards; useful when diagnosing a failure.")
+
+	return cmd
+}
+
+// Complete completes any options that are required by validate or run steps.
+func (opts *VerifyRegistryOptions) Complete(f *clientcmd.Factory, cmd *cobra.Command, out io.Writer, args []string) error {
+	if len(args) > 0 {
+		return kcmdutil.UsageError(cmd, "No arguments are allowed to this command")
+	}
+
+	if len(opts.Project) == 0 {
+		opts.Project = kapi.SimpleNameGenerator.GenerateName("registry-verify-")
+	}
+
+	var err error
+	if opts.osClient, opts.kClient, err = f.Clients(); err != nil {
+		return fmt.Errorf("error getting client: %v", err)
+	}
+	opts.out = out
+
+	return nil
+}
+
+// Run creates the transient project and job, waits for the result, prints the job output
+// and tears the project down again.
+func (opts *VerifyRegistryOptions) Run() error {
+	if err := opts.createProject(); err != nil {
+		return err
+	}
+	if !opts.KeepProject {
+		defer func() {
+			if err := opts.osClient.Projects().Delete(opts.Project); err != nil {
+				fmt.Fprintf(opts.out, "error deleting project %q: %v\n", opts.Project, err)
+			}
+		}()
+	}
+
+	fmt.Fprintf(opts.out, "Running registry smoke test in project %q ...\n", opts.Project)
+	if _, err := opts.kClient.Batch().Jobs(opts.Project).Create(opts.verifyJob()); err != nil {
+		return fmt.Errorf("error creating test job: %v", err)
+	}
+
+	runErr := opts.waitForJob()
+	opts.printJobLogs()
+
+	if opts.KeepProject {
+		fmt.Fprintf(opts.out, "Keeping project %q for inspection\n", opts.Project)
+	}
+	if runErr != nil {
+		return fmt.Errorf("registry verification failed: %v", runErr)
+	}
+	fmt.Fprintf(opts.out, "Registry verification succeeded\n")
+	return nil
+}
+
+// createProject creates the transient project with the default service account role
+// bindings, so the job's builder service account may push to the test repository.
+func (opts *VerifyRegistryOptions) createProject() error {
+	project := &projectapi.Project{}
+	project.Name = opts.Project
+	project.Annotations = map[string]string{
+		projectapi.ProjectDescription: "Transient project for the registry smoke test; safe to delete.",
+	}
+	if _, err := opts.osClient.Projects().Create(project); err != nil {
+		return fmt.Errorf("error creating project %q: %v", opts.Project, err)
+	}
+
+	for _, binding := range bootstrappolicy.GetBootstrapServiceAccountProjectRoleBindings(opts.Project) {
+		addRole := &policy.RoleModificationOptions{
+			RoleName:            binding.RoleRef.Name,
+			RoleNamespace:       binding.RoleRef.Namespace,
+			RoleBindingAccessor: policy.NewLocalRoleBindingAccessor(opts.Project, opts.osClient),
+			Subjects:            binding.Subjects,
+		}
+		if err := addRole.AddRole(); err != nil {
+			return fmt.Errorf("error adding service accounts to the %v role: %v", binding.RoleRef.Name, err)
+		}
+	}
+	return nil
+}
+
+func (opts *VerifyRegistryOptions) verifyJob() *batch.Job {
+	deadline := int64(opts.Timeout / time.Second)
+	return &batch.Job{
+		ObjectMeta: kapi.ObjectMeta{
+			Name:   "registry-verify",
+			Labels: opts.jobLabels(),
+		},
+		Spec: batch.JobSpec{
+			ActiveDeadlineSeconds: &deadline,
+			Template: kapi.PodTemplateSpec{
+				ObjectMeta: kapi.ObjectMeta{Labels: opts.jobLabels()},
+				Spec: kapi.PodSpec{
+					RestartPolicy:      kapi.RestartPolicyNever,
+					ServiceAccountName: bootstrappolicy.BuilderServiceAccountName,
+					Containers: []kapi.Container{
+						{
+							Name:    "verify",
+							Image:   opts.ImageTemplate.ExpandOrDie("docker-registry"),
+							Command: []string{"/bin/sh", "-c", verifyScript},
+							Env: []kapi.EnvVar{
+								{
+									Name: "NAMESPACE",
+									ValueFrom: &kapi.EnvVarSource{
+										FieldRef: &kapi.ObjectFieldSelector{FieldPath: "metadata.namespace"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (opts *VerifyRegistryOptions) jobLabels() map[string]string {
+	return map[string]string{"registry-verify": opts.Project}
+}
+
+// waitForJob waits until the test job finished one way or the other.
+func (opts *VerifyRegistryOptions) waitForJob() error {
+	return wait.Poll(2*time.Second, opts.Timeout, func() (bool, error) {
+		job, err := opts.kClient.Batch().Jobs(opts.Project).Get("registry-verify")
+		if err != nil {
+			return false, err
+		}
+		if job.Status.Succeeded > 0 {
+			return true, nil
+		}
+		if job.Status.Failed > 0 {
+			return false, fmt.Errorf("test job pod failed")
+		}
+		return false, nil
+	})
+}
+
+// printJobLogs copies the output of the test job's pods to the command output, so the
+// user sees which registry operation failed without having to keep the project around.
+func (opts *VerifyRegistryOptions) printJobLogs() {
+	pods, err := opts.kClient.Pods(opts.Project).List(kapi.ListOptions{
+		LabelSelector: labels.SelectorFromSet(opts.jobLabels()),
+	})
+	if err != nil {
+		fmt.Fprintf(opts.out, "error listing test job pods: %v\n", err)
+		return
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		logs, err := opts.kClient.Pods(opts.Project).GetLogs(pod.Name, &kapi.PodLogOptions{}).Do().Raw()
+		if err != nil {
+			fmt.Fprintf(opts.out, "error reading logs of pod %q: %v\n", pod.Name, err)
+			continue
+		}
+		fmt.Fprintf(opts.out, "--- output of pod %s ---\n%s", pod.Name, logs)
+	}
+}
@@ -114,6 +114,9 @@ func NewCommandCLI(name, fullName string, in io.Reader, out, errout io.Writer) *
 				cmd.NewCmdStartBuild(fullName, f, in, out),
 				cmd.NewCmdCancelBuild(fullName, f, in, out),
 				cmd.NewCmdImportImage(fullName, f, out),
+				cmd.NewCmdImportRepository(fullName, f, out),
+				cmd.NewCmdWarmImage(fullName, f, out),
+				cmd.NewCmdRegistry(fullName, f, out),
 				cmd.NewCmdTag(fullName, f, out),
 			},
 		},
@@ -0,0 +1,136 @@
+package create
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/meta"
+	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/runtime"
+
+	"github.com/openshift/origin/pkg/client"
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+const (
+	ManifestListRecommendedName = "manifestlist"
+
+	manifestListLong = `
+Create a manifest list from images in an image stream
+
+A manifest list combines platform specific images that were already pushed or imported into an
+image stream into a single multi-arch tag. The list is assembled by the server from the current
+images of the given source tags and recorded under the destination tag, so clients pulling the
+tag receive the image matching their platform.`
+
+	manifestListExample = `  # Combine the amd64 and arm64 tags of mysql into the multi tag
+  %[1]s mysql:multi amd64 arm64`
+)
+
+type CreateManifestListOptions struct {
+	List   *imageapi.ImageStreamManifestList
+	Client client.ImageStreamManifestListsNamespacer
+
+	Mapper       meta.RESTMapper
+	OutputFormat string
+	Out          io.Writer
+	Printer      ObjectPrinter
+}
+
+// NewCmdCreateManifestList is a macro command to create a new manifest list from existing images
+func NewCmdCreateManifestList(name, fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	o := &CreateManifestListOptions{Out: out}
+
+	cmd := &cobra.Command{
+		Use:     name + " STREAM:TAG SOURCE_TAG ...",
+		Short:   "Create a manifest list from images in an image stream.",
+		Long:    manifestListLong,
+		Example: fmt.Sprintf(manifestListExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(cmd, f, args))
+			cmdutil.CheckErr(o.Validate())
+			cmdutil.CheckErr(o.Run())
+		},
+		Aliases: []string{"ml"},
+	}
+
+	cmdutil.AddOutputFlagsForMutation(cmd)
+	return cmd
+}
+
+func (o *CreateManifestListOptions) Complete(cmd *cobra.Command, f *clientcmd.Factory, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("a destination STREAM:TAG and at least one source tag are required")
+	}
+
+	destination := args[0]
+	if !strings.Contains(destination, ":") {
+		return fmt.Errorf("the destination %q must be of the form STREAM:TAG", destination)
+	}
+	name, tag, _ := imageapi.SplitImageStreamTag(destination)
+
+	o.List = &imageapi.ImageStreamManifestList{
+		ObjectMeta: kapi.ObjectMeta{Name: name},
+		Tag:        tag,
+		SourceTags: args[1:],
+	}
+
+	var err error
+	o.List.Namespace, _, err = f.DefaultNamespace()
+	if err != nil {
+		return err
+	}
+
+	o.Client, _, err = f.Clients()
+	if err != nil {
+		return err
+	}
+
+	o.Mapper, _ = f.Object(false)
+	o.OutputFormat = cmdutil.GetFlagString(cmd, "output")
+
+	o.Printer = func(obj runtime.Object, out io.Writer) error {
+		return f.PrintObject(cmd, o.Mapper, obj, out)
+	}
+
+	return nil
+}
+
+func (o *CreateManifestListOptions) Validate() error {
+	if o.List == nil {
+		return fmt.Errorf("List is required")
+	}
+	if o.Client == nil {
+		return fmt.Errorf("Client is required")
+	}
+	if o.Mapper == nil {
+		return fmt.Errorf("Mapper is required")
+	}
+	if o.Out == nil {
+		return fmt.Errorf("Out is required")
+	}
+	if o.Printer == nil {
+		return fmt.Errorf("Printer is required")
+	}
+
+	return nil
+}
+
+func (o *CreateManifestListOptions) Run() error {
+	actualObj, err := o.Client.ImageStreamManifestLists(o.List.Namespace).Create(o.List)
+	if err != nil {
+		return err
+	}
+
+	if useShortOutput := o.OutputFormat == "name"; useShortOutput || len(o.OutputFormat) == 0 {
+		cmdutil.PrintSuccess(o.Mapper, useShortOutput, o.Out, "imagestreammanifestlist", actualObj.Name, "created")
+		return nil
+	}
+
+	return o.Printer(actualObj, o.Out)
+}
@@ -50,6 +50,7 @@ func NewCmdImportImage(fullName string, f *clientcmd.Factory, out io.Writer) *co
 	cmd.Flags().BoolVar(&opts.Confirm, "confirm", false, "If true, allow the image stream import location to be set or changed")
 	cmd.Flags().BoolVar(&opts.All, "all", false, "If true, import all tags from the provided source on creation or if --from is specified")
 	opts.Insecure = cmd.Flags().Bool("insecure", false, "If true, allow importing from registries that have invalid HTTPS certificates or are hosted via HTTP. This flag will take precedence over the insecure annotation.")
+	cmd.Flags().StringSliceVar(&opts.Platforms, "platform", nil, "Import only the given platforms (e.g. 'linux/arm64' or 'arm64') when the source tag is a manifest list. May be repeated; the first matching platform wins.")
 
 	return cmd
 }
@@ -57,10 +58,11 @@ func NewCmdImportImage(fullName string, f *clientcmd.Factory, out io.Writer) *co
 // ImageImportOptions contains all the necessary information to perform an import.
 type ImportImageOptions struct {
 	// user set values
-	From     string
-	Confirm  bool
-	All      bool
-	Insecure *bool
+	From      string
+	Confirm   bool
+	All       bool
+	Insecure  *bool
+	Platforms []string
 
 	// internal values
 	Namespace string
@@ -149,6 +151,8 @@ func (o *ImportImageOptions) Run() error {
 	default:
 		fmt.Fprint(o.out, "The import completed successfully.\n\n")
 
+		o.printImportProgress(result)
+
 		// optimization, use the image stream returned by the call
 		d := describe.ImageStreamDescriber{Interface: o.osClient}
 		info, err := d.Describe(o.Namespace, stream.Name, kctl.DescriberSettings{})
@@ -205,6 +209,37 @@ func (o *ImportImageOptions) Run() error {
 	return nil
 }
 
+// printImportProgress reports per-tag progress recorded on the import result - bytes transferred
+// for completed tags and whether failed tags may succeed when the import is retried.
+func (o *ImportImageOptions) printImportProgress(isi *imageapi.ImageStreamImport) {
+	statuses := isi.Status.Images
+	if isi.Status.Repository != nil {
+		statuses = append(statuses, isi.Status.Repository.Images...)
+	}
+	for _, status := range statuses {
+		tag := status.Tag
+		if len(tag) == 0 && status.Image != nil {
+			tag = status.Image.Name
+		}
+		progress := status.Progress
+		if progress == nil {
+			continue
+		}
+		switch progress.Phase {
+		case imageapi.ImageImportPhaseComplete:
+			if progress.BytesTransferred > 0 {
+				fmt.Fprintf(o.out, "info: Tag %s imported (%d bytes transferred)\n", tag, progress.BytesTransferred)
+			}
+		case imageapi.ImageImportPhaseFailed:
+			if progress.Retriable {
+				fmt.Fprintf(o.out, "warning: Tag %s failed: %s (this error may be transient - rerun the import to retry)\n", tag, status.Status.Message)
+			} else {
+				fmt.Fprintf(o.out, "warning: Tag %s failed: %s\n", tag, status.Status.Message)
+			}
+		}
+	}
+}
+
 // TODO: move to image/api as a helper
 type importError struct {
 	annotation string
@@ -449,7 +484,7 @@ func (o *ImportImageOptions) newImageStreamImportAll(stream *imageapi.ImageStrea
 			Kind: "DockerImage",
 			Name: from,
 		},
-		ImportPolicy: imageapi.TagImportPolicy{Insecure: insecure},
+		ImportPolicy: imageapi.TagImportPolicy{Insecure: insecure, Platforms: o.Platforms},
 	}
 
 	return isi
@@ -464,7 +499,7 @@ func (o *ImportImageOptions) newImageStreamImportTags(stream *imageapi.ImageStre
 				Name: from,
 			},
 			To:           &kapi.LocalObjectReference{Name: tag},
-			ImportPolicy: imageapi.TagImportPolicy{Insecure: insecure},
+			ImportPolicy: imageapi.TagImportPolicy{Insecure: insecure, Platforms: o.Platforms},
 		})
 	}
 	return isi
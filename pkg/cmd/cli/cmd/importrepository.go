@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+
+	"github.com/openshift/origin/pkg/client"
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+const (
+	importRepositoryLong = `
+Import many Docker image repositories from an upstream registry namespace
+
+Creates or updates one image stream per listed repository, importing all tags from the
+upstream registry. Repositories are given as names relative to the source namespace and
+may be filtered with --include and --exclude glob patterns. Imports run concurrently and
+the command reports a summary of successes and failures; already imported repositories
+are safe to import again, which makes the command restartable for large migrations.`
+
+	importRepositoryExample = `  # Import two repositories from the appteam namespace on a private registry
+  %[1]s import-repository registry.example.com/appteam frontend backend --confirm
+
+  # Import all repositories listed in a file, except those ending in -test
+  cat repos.txt | xargs %[1]s import-repository registry.example.com/appteam --exclude='*-test' --confirm`
+)
+
+// NewCmdImportRepository implements the OpenShift cli import-repository command.
+func NewCmdImportRepository(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	opts := &ImportRepositoryOptions{}
+	cmd := &cobra.Command{
+		Use:     "import-repository REGISTRY/NAMESPACE REPOSITORY ...",
+		Short:   "Imports many image repositories from a registry namespace",
+		Long:    importRepositoryLong,
+		Example: fmt.Sprintf(importRepositoryExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			kcmdutil.CheckErr(opts.Complete(f, cmd, args, out))
+			kcmdutil.CheckErr(opts.Validate(cmd))
+			kcmdutil.CheckErr(opts.Run())
+		},
+	}
+	cmd.Flags().StringSliceVar(&opts.Include, "include", nil, "Only import repositories matching one of these glob patterns")
+	cmd.Flags().StringSliceVar(&opts.Exclude, "exclude", nil, "Skip repositories matching one of these glob patterns; applied after --include")
+	cmd.Flags().IntVar(&opts.MaxConcurrent, "max-concurrent", 4, "Maximum number of repositories imported at the same time")
+	cmd.Flags().BoolVar(&opts.Confirm, "confirm", false, "If true, allow image streams to be created for imported repositories")
+	cmd.Flags().BoolVar(&opts.Insecure, "insecure", false, "If true, allow importing from registries that have invalid HTTPS certificates or are hosted via HTTP")
+
+	return cmd
+}
+
+// ImportRepositoryOptions contains all the necessary information to perform a bulk import.
+type ImportRepositoryOptions struct {
+	// user set values
+	Source        string
+	Repositories  []string
+	Include       []string
+	Exclude       []string
+	MaxConcurrent int
+	Confirm       bool
+	Insecure      bool
+
+	// internal values
+	Namespace string
+
+	// helpers
+	out      io.Writer
+	isClient client.ImageStreamInterface
+}
+
+// Complete turns a partially defined ImportRepositoryOptions into a solvent structure
+// which can be validated and used for an import.
+func (o *ImportRepositoryOptions) Complete(f *clientcmd.Factory, cmd *cobra.Command, args []string, out io.Writer) error {
+	if len(args) > 0 {
+		o.Source = args[0]
+		o.Repositories = args[1:]
+	}
+
+	namespace, _, err := f.DefaultNamespace()
+	if err != nil {
+		return err
+	}
+	o.Namespace = namespace
+
+	osClient, _, err := f.Clients()
+	if err != nil {
+		return err
+	}
+	o.isClient = osClient.ImageStreams(namespace)
+	o.out = out
+
+	return nil
+}
+
+// Validate ensures that a ImportRepositoryOptions is valid and can be used to execute
+// an import.
+func (o *ImportRepositoryOptions) Validate(cmd *cobra.Command) error {
+	if len(o.Source) == 0 {
+		return kcmdutil.UsageError(cmd, "you must specify the source registry namespace, e.g. registry.example.com/appteam")
+	}
+	if strings.Count(o.Source, "/") != 1 {
+		return kcmdutil.UsageError(cmd, "the source must be of the form REGISTRY/NAMESPACE")
+	}
+	if len(o.Repositories) == 0 {
+		return kcmdutil.UsageError(cmd, "you must specify at least one repository to import")
+	}
+	if o.MaxConcurrent < 1 {
+		return kcmdutil.UsageError(cmd, "--max-concurrent must be a positive integer")
+	}
+	for _, pattern := range append(append([]string{}, o.Include...), o.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return kcmdutil.UsageError(cmd, "invalid pattern %q: %v", pattern, err)
+		}
+	}
+	for _, repo := range o.Repositories {
+		if strings.Contains(repo, "/") {
+			return kcmdutil.UsageError(cmd, "repository %q must be a name relative to the source namespace", repo)
+		}
+	}
+	return nil
+}
+
+// Run contains all the necessary functionality for the OpenShift cli import-repository command.
+func (o *ImportRepositoryOptions) Run() error {
+	repositories := filterRepositories(o.Repositories, o.Include, o.Exclude)
+	if len(repositories) == 0 {
+		return fmt.Errorf("no repositories matched the provided --include and --exclude patterns")
+	}
+
+	// refuse to start if any stream would have to be created without --confirm, so a partial
+	// migration is not left behind
+	if !o.Confirm {
+		missing := []string{}
+		for _, repo := range repositories {
+			if _, err := o.isClient.Get(repo); err != nil {
+				if !errors.IsNotFound(err) {
+					return err
+				}
+				missing = append(missing, repo)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("no image streams exist for repositories %s, pass --confirm to create and import", strings.Join(missing, ", "))
+		}
+	}
+
+	type result struct {
+		repository string
+		err        error
+	}
+	results := make([]result, len(repositories))
+
+	work := make(chan int)
+	wg := sync.WaitGroup{}
+	for i := 0; i < o.MaxConcurrent; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range work {
+				repo := repositories[index]
+				results[index] = result{repository: repo, err: o.importRepository(repo)}
+			}
+		}()
+	}
+	for i := range repositories {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	failures := 0
+	for _, r := range results {
+		if r.err != nil {
+			failures++
+			fmt.Fprintf(o.out, "error: repository %s: %v\n", r.repository, r.err)
+			continue
+		}
+		fmt.Fprintf(o.out, "imported %s\n", r.repository)
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d of %d repositories failed to import - rerun the command to retry the failed imports", failures, len(repositories))
+	}
+	fmt.Fprintf(o.out, "\nThe import completed successfully for all %d repositories.\n", len(repositories))
+	return nil
+}
+
+// importRepository imports all tags of a single upstream repository into the image stream of
+// the same name, creating the image stream when necessary.
+func (o *ImportRepositoryOptions) importRepository(repo string) error {
+	from := fmt.Sprintf("%s/%s", o.Source, repo)
+	isi := &imageapi.ImageStreamImport{
+		ObjectMeta: kapi.ObjectMeta{
+			Name:      repo,
+			Namespace: o.Namespace,
+		},
+		Spec: imageapi.ImageStreamImportSpec{
+			Import: true,
+			Repository: &imageapi.RepositoryImportSpec{
+				From: kapi.ObjectReference{
+					Kind: "DockerImage",
+					Name: from,
+				},
+				ImportPolicy: imageapi.TagImportPolicy{Insecure: o.Insecure},
+			},
+		},
+	}
+
+	result, err := o.isClient.Import(isi)
+	if err != nil {
+		if errors.IsNotFound(err) && o.Confirm {
+			stream := &imageapi.ImageStream{
+				ObjectMeta: kapi.ObjectMeta{Name: repo},
+				Spec:       imageapi.ImageStreamSpec{DockerImageRepository: from},
+			}
+			if _, err := o.isClient.Create(stream); err != nil {
+				return err
+			}
+			result, err = o.isClient.Import(isi)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if r := result.Status.Repository; r != nil && len(r.Status.Message) > 0 && r.Status.Status != unversioned.StatusSuccess {
+		return fmt.Errorf(r.Status.Message)
+	}
+	return nil
+}
+
+// filterRepositories applies include then exclude glob patterns to the repository list.
+func filterRepositories(repositories, include, exclude []string) []string {
+	filtered := []string{}
+	for _, repo := range repositories {
+		if len(include) > 0 && !matchesAnyPattern(include, repo) {
+			continue
+		}
+		if matchesAnyPattern(exclude, repo) {
+			continue
+		}
+		filtered = append(filtered, repo)
+	}
+	return filtered
+}
+
+func matchesAnyPattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
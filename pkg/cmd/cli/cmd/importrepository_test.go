@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterRepositories(t *testing.T) {
+	testCases := map[string]struct {
+		repositories []string
+		include      []string
+		exclude      []string
+		expected     []string
+	}{
+		"no patterns keeps everything": {
+			repositories: []string{"frontend", "backend"},
+			expected:     []string{"frontend", "backend"},
+		},
+		"include filters to matches": {
+			repositories: []string{"frontend", "backend", "tools"},
+			include:      []string{"front*", "back*"},
+			expected:     []string{"frontend", "backend"},
+		},
+		"exclude wins over include": {
+			repositories: []string{"frontend", "frontend-test"},
+			include:      []string{"front*"},
+			exclude:      []string{"*-test"},
+			expected:     []string{"frontend"},
+		},
+		"exclude alone": {
+			repositories: []string{"frontend", "backend-test"},
+			exclude:      []string{"*-test"},
+			expected:     []string{"frontend"},
+		},
+	}
+	for name, test := range testCases {
+		filtered := filterRepositories(test.repositories, test.include, test.exclude)
+		if !reflect.DeepEqual(filtered, test.expected) {
+			t.Errorf("%s: expected %v, got %v", name, test.expected, filtered)
+		}
+	}
+}
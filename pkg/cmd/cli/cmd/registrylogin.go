@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+
+	"github.com/openshift/origin/pkg/client"
+	cmdutil "github.com/openshift/origin/pkg/cmd/util"
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+const (
+	registryLong = `
+Interact with the integrated registry
+
+These commands help clients such as docker, podman and skopeo talk to the
+integrated registry without hand-editing credential files.`
+
+	registryLoginLong = `
+Save credentials for the integrated registry
+
+Writes an entry for the integrated registry into a docker config.json or
+containers auth.json file (both use the same format), so docker, podman and
+skopeo can authenticate with your current session token or with a service
+account token. Existing entries for other registries are preserved.`
+
+	registryLoginExample = `  # Save the current session token for the integrated registry
+  %[1]s registry login
+
+  # Save the builder service account's token into a podman auth file
+  %[1]s registry login --service-account=builder --to=${XDG_RUNTIME_DIR}/containers/auth.json`
+)
+
+// NewCmdRegistry groups the subcommands that talk to the integrated registry.
+func NewCmdRegistry(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "registry",
+		Short: "Commands for working with the integrated registry",
+		Long:  registryLong,
+		Run:   cmdutil.DefaultSubCommandRun(out),
+	}
+	cmd.AddCommand(NewCmdRegistryLogin(fullName, f, out))
+	return cmd
+}
+
+// NewCmdRegistryLogin implements the OpenShift cli registry login command.
+func NewCmdRegistryLogin(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	opts := &RegistryLoginOptions{}
+	cmd := &cobra.Command{
+		Use:     "login",
+		Short:   "Save credentials for the integrated registry",
+		Long:    registryLoginLong,
+		Example: fmt.Sprintf(registryLoginExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			kcmdutil.CheckErr(opts.Complete(f, cmd, args, out))
+			kcmdutil.CheckErr(opts.Run())
+		},
+	}
+	cmd.Flags().StringVar(&opts.RegistryURL, "registry", "", "The host and port of the integrated registry. Defaults to the registry recorded in the image streams of the current project.")
+	cmd.Flags().StringVar(&opts.To, "to", "", "The credential file to update. Defaults to ~/.docker/config.json; point it at a containers auth.json for podman and skopeo.")
+	cmd.Flags().StringVar(&opts.ServiceAccount, "service-account", "", "Save the token of this service account in the current project instead of the session token.")
+
+	return cmd
+}
+
+// RegistryLoginOptions contains all the necessary information to save registry credentials.
+type RegistryLoginOptions struct {
+	// user set values
+	RegistryURL    string
+	To             string
+	ServiceAccount string
+
+	// internal values
+	Namespace string
+
+	// helpers
+	out      io.Writer
+	osClient client.Interface
+	kClient  kclient.Interface
+	token    string
+}
+
+// Complete turns a partially defined RegistryLoginOptions into a solvent structure.
+func (o *RegistryLoginOptions) Complete(f *clientcmd.Factory, cmd *cobra.Command, args []string, out io.Writer) error {
+	if len(args) != 0 {
+		return kcmdutil.UsageError(cmd, "no arguments are accepted")
+	}
+
+	namespace, _, err := f.DefaultNamespace()
+	if err != nil {
+		return err
+	}
+	o.Namespace = namespace
+
+	osClient, kClient, err := f.Clients()
+	if err != nil {
+		return err
+	}
+	o.osClient = osClient
+	o.kClient = kClient
+
+	clientConfig, err := f.OpenShiftClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	o.token = clientConfig.BearerToken
+
+	if len(o.To) == 0 {
+		home := os.Getenv("HOME")
+		if len(home) == 0 {
+			return fmt.Errorf("cannot determine your home directory; use --to")
+		}
+		o.To = filepath.Join(home, ".docker", "config.json")
+	}
+
+	o.out = out
+	return nil
+}
+
+// Run resolves the credentials and merges them into the target file.
+func (o *RegistryLoginOptions) Run() error {
+	host, err := o.resolveRegistryHost()
+	if err != nil {
+		return err
+	}
+
+	username := "unused"
+	token := o.token
+	switch {
+	case len(o.ServiceAccount) > 0:
+		token, err = o.serviceAccountToken()
+		if err != nil {
+			return err
+		}
+		username = o.ServiceAccount
+	case len(token) == 0:
+		return fmt.Errorf("no token is currently in use for this session; log in again or use --service-account")
+	default:
+		if me, err := o.osClient.Users().Get("~"); err == nil {
+			username = me.Name
+		}
+	}
+
+	if err := writeAuthEntry(o.To, host, username, token); err != nil {
+		return err
+	}
+	fmt.Fprintf(o.out, "Saved credentials for %s to %s\n", host, o.To)
+	o.describeExpiry(token)
+	return nil
+}
+
+// resolveRegistryHost returns the host and port of the integrated registry, taken from the
+// --registry flag or from a repository recorded in the current project's image streams.
+func (o *RegistryLoginOptions) resolveRegistryHost() (string, error) {
+	if len(o.RegistryURL) > 0 {
+		host := strings.TrimPrefix(strings.TrimPrefix(o.RegistryURL, "https://"), "http://")
+		return strings.TrimRight(host, "/"), nil
+	}
+	streams, err := o.osClient.ImageStreams(o.Namespace).List(kapi.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	for i := range streams.Items {
+		repository := streams.Items[i].Status.DockerImageRepository
+		if len(repository) == 0 {
+			continue
+		}
+		ref, err := imageapi.ParseDockerImageReference(repository)
+		if err != nil {
+			continue
+		}
+		return ref.Registry, nil
+	}
+	return "", fmt.Errorf("no image stream in project %s records a registry; use --registry", o.Namespace)
+}
+
+// serviceAccountToken returns a token for the named service account in the current project.
+func (o *RegistryLoginOptions) serviceAccountToken() (string, error) {
+	secrets, err := o.kClient.Secrets(o.Namespace).List(kapi.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if secret.Type != kapi.SecretTypeServiceAccountToken {
+			continue
+		}
+		if secret.Annotations[kapi.ServiceAccountNameKey] != o.ServiceAccount {
+			continue
+		}
+		if token, ok := secret.Data[kapi.ServiceAccountTokenKey]; ok && len(token) > 0 {
+			return string(token), nil
+		}
+	}
+	return "", fmt.Errorf("no token secret found for service account %s/%s", o.Namespace, o.ServiceAccount)
+}
+
+// describeExpiry prints when the saved token stops working, when that can be determined.
+// Reading the token's expiry requires permission most users do not have; failures are
+// reported as unknown rather than as errors.
+func (o *RegistryLoginOptions) describeExpiry(token string) {
+	if len(o.ServiceAccount) > 0 {
+		fmt.Fprintf(o.out, "Service account tokens do not expire.\n")
+		return
+	}
+	accessToken, err := o.osClient.OAuthAccessTokens().Get(token)
+	if err != nil {
+		return
+	}
+	if accessToken.ExpiresIn == 0 {
+		fmt.Fprintf(o.out, "The token does not expire.\n")
+		return
+	}
+	expires := accessToken.CreationTimestamp.Add(time.Duration(accessToken.ExpiresIn) * time.Second)
+	fmt.Fprintf(o.out, "The token expires %s.\n", expires.Format(time.RFC1123))
+}
+
+// dockerAuthFile is the subset of a docker config.json or containers auth.json this command
+// reads and writes. Unknown fields in an existing file are dropped on rewrite, which matches
+// what `docker login` itself does.
+type dockerAuthFile struct {
+	Auths map[string]dockerAuthEntry `json:"auths"`
+}
+
+type dockerAuthEntry struct {
+	Auth string `json:"auth"`
+}
+
+// writeAuthEntry merges an entry for host into the credential file at path, creating the file
+// and its directory when missing.
+func writeAuthEntry(path, host, username, token string) error {
+	auths := dockerAuthFile{Auths: map[string]dockerAuthEntry{}}
+	data, err := ioutil.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return err
+	default:
+		if err := json.Unmarshal(data, &auths); err != nil {
+			return fmt.Errorf("cannot update %s: %v", path, err)
+		}
+		if auths.Auths == nil {
+			auths.Auths = map[string]dockerAuthEntry{}
+		}
+	}
+
+	auths.Auths[host] = dockerAuthEntry{
+		Auth: base64.StdEncoding.EncodeToString([]byte(username + ":" + token)),
+	}
+
+	out, err := json.MarshalIndent(auths, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, out, 0600)
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteAuthEntryCreatesFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registrylogin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "containers", "auth.json")
+	if err := writeAuthEntry(path, "registry.example.com:5000", "alice", "secret"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	auths := readAuthFile(t, path)
+	entry, ok := auths.Auths["registry.example.com:5000"]
+	if !ok {
+		t.Fatalf("expected an entry for the registry, got %#v", auths)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+	if err != nil {
+		t.Fatalf("auth is not base64: %v", err)
+	}
+	if string(decoded) != "alice:secret" {
+		t.Errorf("unexpected credentials %q", string(decoded))
+	}
+}
+
+func TestWriteAuthEntryPreservesOtherHosts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registrylogin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	existing := `{"auths":{"docker.io":{"auth":"b3RoZXI6Y3JlZHM="}}}`
+	if err := ioutil.WriteFile(path, []byte(existing), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeAuthEntry(path, "registry.example.com:5000", "alice", "secret"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	auths := readAuthFile(t, path)
+	if _, ok := auths.Auths["docker.io"]; !ok {
+		t.Errorf("existing docker.io entry was dropped: %#v", auths)
+	}
+	if _, ok := auths.Auths["registry.example.com:5000"]; !ok {
+		t.Errorf("new entry missing: %#v", auths)
+	}
+}
+
+func readAuthFile(t *testing.T, path string) dockerAuthFile {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auths := dockerAuthFile{}
+	if err := json.Unmarshal(data, &auths); err != nil {
+		t.Fatal(err)
+	}
+	return auths
+}
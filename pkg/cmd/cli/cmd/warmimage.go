@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+
+	"github.com/openshift/origin/pkg/client"
+	imageapi "github.com/openshift/origin/pkg/image/api"
+	"github.com/spf13/cobra"
+
+	"k8s.io/kubernetes/pkg/client/restclient"
+
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+)
+
+const (
+	warmImageLong = `
+Ask the integrated registry to pre-fetch the content of image stream tags
+
+Warming a tag makes the registry resolve its manifest and locate every
+referenced blob before nodes start pulling it, so a large rollout hits a warm
+registry instead of cold upstream repositories. The registry reports the
+progress of each warming job; this command polls it until every job finishes.`
+
+	warmImageExample = `  # Warm the latest tag of the mysql image stream
+  %[1]s warm-image mysql:latest`
+)
+
+// NewCmdWarmImage implements the OpenShift cli warm-image command.
+func NewCmdWarmImage(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	opts := &WarmImageOptions{}
+	cmd := &cobra.Command{
+		Use:     "warm-image IMAGESTREAM:TAG",
+		Short:   "Pre-fetch an image stream tag into the integrated registry",
+		Long:    warmImageLong,
+		Example: fmt.Sprintf(warmImageExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			kcmdutil.CheckErr(opts.Complete(f, cmd, args, out))
+			kcmdutil.CheckErr(opts.Run())
+		},
+	}
+	cmd.Flags().StringVar(&opts.RegistryURL, "registry", "", "The URL of the integrated registry. Defaults to the registry recorded in the image stream status.")
+
+	return cmd
+}
+
+// WarmImageOptions contains all the necessary information to warm a tag.
+type WarmImageOptions struct {
+	// user set values
+	RegistryURL string
+
+	// internal values
+	Namespace string
+	Name      string
+	Tag       string
+
+	// helpers
+	out        io.Writer
+	osClient   client.Interface
+	httpClient *http.Client
+	bearer     string
+}
+
+// Complete turns a partially defined WarmImageOptions into a solvent structure.
+func (o *WarmImageOptions) Complete(f *clientcmd.Factory, cmd *cobra.Command, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return kcmdutil.UsageError(cmd, "you must specify the name of an image stream tag")
+	}
+	name, tag, ok := imageapi.SplitImageStreamTag(args[0])
+	if !ok {
+		return kcmdutil.UsageError(cmd, "%q must be of the form NAME:TAG", args[0])
+	}
+	o.Name = name
+	o.Tag = tag
+
+	namespace, _, err := f.DefaultNamespace()
+	if err != nil {
+		return err
+	}
+	o.Namespace = namespace
+
+	osClient, _, err := f.Clients()
+	if err != nil {
+		return err
+	}
+	o.osClient = osClient
+
+	clientConfig, err := f.OpenShiftClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	o.bearer = clientConfig.BearerToken
+	transport, err := restclient.TransportFor(clientConfig)
+	if err != nil {
+		return err
+	}
+	o.httpClient = &http.Client{Transport: transport}
+
+	o.out = out
+	return nil
+}
+
+// Run asks the registry to warm the tag and polls the job status until it finishes.
+func (o *WarmImageOptions) Run() error {
+	registryURL, err := o.resolveRegistryURL()
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/openshift/warmup/%s/%s?tag=%s", registryURL, o.Namespace, o.Name, url.QueryEscape(o.Tag))
+	resp, err := o.request("POST", endpoint)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("the registry refused to warm %s/%s:%s: %s", o.Namespace, o.Name, o.Tag, resp.Status)
+	}
+
+	for {
+		time.Sleep(time.Second)
+
+		resp, err := o.request("GET", endpoint)
+		if err != nil {
+			return err
+		}
+		statuses := []struct {
+			Tag       string `json:"tag"`
+			State     string `json:"state"`
+			Blobs     int    `json:"blobs"`
+			BlobsDone int    `json:"blobsDone"`
+			Error     string `json:"error"`
+		}{}
+		err = json.NewDecoder(resp.Body).Decode(&statuses)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("cannot read warming status: %v", err)
+		}
+
+		for _, status := range statuses {
+			if status.Tag != o.Tag {
+				continue
+			}
+			switch status.State {
+			case "Complete":
+				fmt.Fprintf(o.out, "%s/%s:%s warmed (%d blobs)\n", o.Namespace, o.Name, o.Tag, status.Blobs)
+				return nil
+			case "Failed":
+				return fmt.Errorf("warming %s/%s:%s failed: %s", o.Namespace, o.Name, o.Tag, status.Error)
+			default:
+				fmt.Fprintf(o.out, "warming %s/%s:%s: %d/%d blobs\n", o.Namespace, o.Name, o.Tag, status.BlobsDone, status.Blobs)
+			}
+		}
+	}
+}
+
+// resolveRegistryURL returns the base URL of the integrated registry, taken from the --registry
+// flag or from the repository recorded in the image stream status.
+func (o *WarmImageOptions) resolveRegistryURL() (string, error) {
+	if len(o.RegistryURL) > 0 {
+		return strings.TrimRight(o.RegistryURL, "/"), nil
+	}
+	stream, err := o.osClient.ImageStreams(o.Namespace).Get(o.Name)
+	if err != nil {
+		return "", err
+	}
+	if len(stream.Status.DockerImageRepository) == 0 {
+		return "", fmt.Errorf("image stream %s/%s has no registry recorded; use --registry", o.Namespace, o.Name)
+	}
+	ref, err := imageapi.ParseDockerImageReference(stream.Status.DockerImageRepository)
+	if err != nil {
+		return "", err
+	}
+	return "https://" + ref.Registry, nil
+}
+
+func (o *WarmImageOptions) request(method, endpoint string) (*http.Response, error) {
+	req, err := http.NewRequest(method, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(o.bearer) > 0 {
+		req.Header.Set("Authorization", "Bearer "+o.bearer)
+	}
+	return o.httpClient.Do(req)
+}
@@ -64,6 +64,7 @@ func describerMap(c *client.Client, kclient kclient.Interface, host string) map[
 		quotaapi.Kind("ClusterResourceQuota"):         &ClusterQuotaDescriber{c},
 		quotaapi.Kind("AppliedClusterResourceQuota"):  &AppliedClusterQuotaDescriber{c},
 		sdnapi.Kind("EgressNetworkPolicy"):            &EgressNetworkPolicyDescriber{c},
+		imageapi.Kind("ImagePromotionPolicy"):         &ImagePromotionPolicyDescriber{c},
 	}
 	return m
 }
@@ -1489,6 +1490,57 @@ func (d *AppliedClusterQuotaDescriber) Describe(namespace, name string, settings
 	return DescribeClusterQuota(quotaapi.ConvertAppliedClusterResourceQuotaToClusterResourceQuota(quota))
 }
 
+type ImagePromotionPolicyDescriber struct {
+	osClient client.Interface
+}
+
+// Describe returns the description of an ImagePromotionPolicy
+func (d *ImagePromotionPolicyDescriber) Describe(namespace, name string, settings kctl.DescriberSettings) (string, error) {
+	c := d.osClient.ImagePromotionPolicies(namespace)
+	policy, err := c.Get(name)
+	if err != nil {
+		return "", err
+	}
+	return tabbedString(func(out *tabwriter.Writer) error {
+		formatMeta(out, policy.ObjectMeta)
+		fmt.Fprintf(out, "Source:\t%s\n", imageapi.JoinImageStreamTag(policy.Spec.SourceStream, policy.Spec.SourceTag))
+		targetStream := policy.Spec.TargetStream
+		if len(targetStream) == 0 {
+			targetStream = policy.Spec.SourceStream
+		}
+		target := imageapi.JoinImageStreamTag(targetStream, policy.Spec.TargetTag)
+		if len(policy.Spec.TargetNamespace) != 0 {
+			target = fmt.Sprintf("%s/%s", policy.Spec.TargetNamespace, target)
+		}
+		fmt.Fprintf(out, "Target:\t%s\n", target)
+		conditions := []string{}
+		if policy.Spec.RequireSignature {
+			conditions = append(conditions, "signature")
+		}
+		if policy.Spec.RequireScanPassed {
+			conditions = append(conditions, "scan passed")
+		}
+		if policy.Spec.RequireApproval {
+			conditions = append(conditions, "approval")
+		}
+		if len(conditions) == 0 {
+			fmt.Fprintf(out, "Conditions:\t<none>\n")
+		} else {
+			fmt.Fprintf(out, "Conditions:\t%s\n", strings.Join(conditions, ", "))
+		}
+		if len(policy.Status.PromotedImage) != 0 {
+			fmt.Fprintf(out, "Promoted Image:\t%s\n", policy.Status.PromotedImage)
+		}
+		if policy.Status.LastPromotionTime != nil {
+			fmt.Fprintf(out, "Last Promotion:\t%s\n", policy.Status.LastPromotionTime.Format(time.RFC1123Z))
+		}
+		if len(policy.Status.Reason) != 0 {
+			fmt.Fprintf(out, "Reason:\t%s\n", policy.Status.Reason)
+		}
+		return nil
+	})
+}
+
 type EgressNetworkPolicyDescriber struct {
 	osClient client.Interface
 }
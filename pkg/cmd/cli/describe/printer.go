@@ -35,6 +35,7 @@ var (
 	imageStreamTagColumns   = []string{"NAME", "DOCKER REF", "UPDATED", "IMAGENAME"}
 	imageStreamImageColumns = []string{"NAME", "DOCKER REF", "UPDATED", "IMAGENAME"}
 	imageStreamColumns      = []string{"NAME", "DOCKER REPO", "TAGS", "UPDATED"}
+	promotionPolicyColumns  = []string{"NAME", "SOURCE", "TARGET", "PROMOTED", "REASON"}
 	projectColumns          = []string{"NAME", "DISPLAY NAME", "STATUS"}
 	routeColumns            = []string{"NAME", "HOST/PORT", "PATH", "SERVICE", "TERMINATION", "LABELS"}
 	deploymentConfigColumns = []string{"NAME", "REVISION", "DESIRED", "CURRENT", "TRIGGERED BY"}
@@ -80,6 +81,8 @@ func NewHumanReadablePrinter(printOptions *kctl.PrintOptions) *kctl.HumanReadabl
 	p.Handler(imageColumns, printImageList)
 	p.Handler(imageStreamColumns, printImageStream)
 	p.Handler(imageStreamColumns, printImageStreamList)
+	p.Handler(promotionPolicyColumns, printImagePromotionPolicy)
+	p.Handler(promotionPolicyColumns, printImagePromotionPolicyList)
 	p.Handler(projectColumns, printProject)
 	p.Handler(projectColumns, printProjectList)
 	p.Handler(routeColumns, printRoute)
@@ -456,6 +459,44 @@ func printImageStreamList(streams *imageapi.ImageStreamList, w io.Writer, opts k
 	return nil
 }
 
+func printImagePromotionPolicy(policy *imageapi.ImagePromotionPolicy, w io.Writer, opts kctl.PrintOptions) error {
+	name := formatResourceName(opts.Kind, policy.Name, opts.WithKind)
+	source := imageapi.JoinImageStreamTag(policy.Spec.SourceStream, policy.Spec.SourceTag)
+	targetStream := policy.Spec.TargetStream
+	if len(targetStream) == 0 {
+		targetStream = policy.Spec.SourceStream
+	}
+	target := imageapi.JoinImageStreamTag(targetStream, policy.Spec.TargetTag)
+	if len(policy.Spec.TargetNamespace) != 0 {
+		target = fmt.Sprintf("%s/%s", policy.Spec.TargetNamespace, target)
+	}
+	promoted := ""
+	if policy.Status.LastPromotionTime != nil {
+		promoted = fmt.Sprintf("%s ago", formatRelativeTime(policy.Status.LastPromotionTime.Time))
+	}
+	if opts.WithNamespace {
+		if _, err := fmt.Fprintf(w, "%s\t", policy.Namespace); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s", name, source, target, promoted, policy.Status.Reason); err != nil {
+		return err
+	}
+	if err := appendItemLabels(policy.Labels, w, opts.ColumnLabels, opts.ShowLabels); err != nil {
+		return err
+	}
+	return nil
+}
+
+func printImagePromotionPolicyList(policies *imageapi.ImagePromotionPolicyList, w io.Writer, opts kctl.PrintOptions) error {
+	for _, policy := range policies.Items {
+		if err := printImagePromotionPolicy(&policy, w, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func printProject(project *projectapi.Project, w io.Writer, opts kctl.PrintOptions) error {
 	name := formatResourceName(opts.Kind, project.Name, opts.WithKind)
 	_, err := fmt.Fprintf(w, "%s\t%s\t%s\n", name, project.Annotations[projectapi.ProjectDisplayName], project.Status.Phase)
@@ -0,0 +1,237 @@
+package dockerregistry
+
+import (
+	"bytes"
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution/digest"
+)
+
+// benchOptions configures the synthetic load generated by ExecuteBench.
+type benchOptions struct {
+	url         string
+	repo        string
+	token       string
+	concurrency int
+	count       int
+	layerSize   int64
+	pushRatio   int
+}
+
+// ExecuteBench runs the "bench" subcommand: it generates synthetic push/pull load against a
+// running registry and reports latency percentiles, so middleware regressions are measurable
+// per release. Pushes upload random layers through the blob upload API; pulls read them back.
+func ExecuteBench(args []string) {
+	options := benchOptions{}
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	flags.StringVar(&options.url, "url", "http://127.0.0.1:5000", "base URL of the registry to load")
+	flags.StringVar(&options.repo, "repo", "bench/bench", "repository to push to and pull from")
+	flags.StringVar(&options.token, "token", "", "bearer token sent with every request")
+	flags.IntVar(&options.concurrency, "concurrency", 4, "number of concurrent workers")
+	flags.IntVar(&options.count, "count", 100, "number of operations per worker")
+	flags.Int64Var(&options.layerSize, "layer-size", 1024*1024, "size in bytes of each synthetic layer")
+	flags.IntVar(&options.pushRatio, "push-percent", 50, "percentage of operations that push; the rest pull")
+	flags.Parse(args)
+
+	if options.concurrency < 1 || options.count < 1 || options.layerSize < 1 || options.pushRatio < 0 || options.pushRatio > 100 {
+		fmt.Fprintln(os.Stderr, "invalid bench options")
+		os.Exit(1)
+	}
+
+	// seed one layer so pulls have something to read even with --push-percent=0
+	bench := &benchRunner{options: options, client: http.DefaultClient}
+	seed, err := bench.push()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot push seed layer: %v\n", err)
+		os.Exit(1)
+	}
+	bench.remember(seed)
+
+	var wg sync.WaitGroup
+	for i := 0; i < options.concurrency; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for n := 0; n < options.count; n++ {
+				if (worker+n)%100 < options.pushRatio {
+					bench.observe("push", func() error {
+						dgst, err := bench.push()
+						if err == nil {
+							bench.remember(dgst)
+						}
+						return err
+					})
+				} else {
+					bench.observe("pull", func() error { return bench.pull() })
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	bench.report(os.Stdout)
+}
+
+type benchRunner struct {
+	options benchOptions
+	client  *http.Client
+
+	mu        sync.Mutex
+	digests   []digest.Digest
+	latencies map[string][]time.Duration
+	errors    map[string]int
+}
+
+func (b *benchRunner) remember(dgst digest.Digest) {
+	b.mu.Lock()
+	b.digests = append(b.digests, dgst)
+	b.mu.Unlock()
+}
+
+func (b *benchRunner) anyDigest() digest.Digest {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.digests[len(b.digests)-1]
+}
+
+// observe runs op and records its latency, or counts the error.
+func (b *benchRunner) observe(name string, op func() error) {
+	start := time.Now()
+	err := op()
+	elapsed := time.Since(start)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.latencies == nil {
+		b.latencies = map[string][]time.Duration{}
+		b.errors = map[string]int{}
+	}
+	if err != nil {
+		b.errors[name]++
+		return
+	}
+	b.latencies[name] = append(b.latencies[name], elapsed)
+}
+
+func (b *benchRunner) do(req *http.Request) (*http.Response, error) {
+	if len(b.options.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+b.options.token)
+	}
+	return b.client.Do(req)
+}
+
+// push uploads one random layer through the two-step blob upload API.
+func (b *benchRunner) push() (digest.Digest, error) {
+	layer := make([]byte, b.options.layerSize)
+	if _, err := rand.Read(layer); err != nil {
+		return "", err
+	}
+	dgst := digest.FromBytes(layer)
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/v2/%s/blobs/uploads/", b.options.url, b.options.repo), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := b.do(req)
+	if err != nil {
+		return "", err
+	}
+	location := resp.Header.Get("Location")
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return "", fmt.Errorf("starting upload: unexpected status %s", resp.Status)
+	}
+
+	req, err = http.NewRequest("PUT", location, bytes.NewReader(layer))
+	if err != nil {
+		return "", err
+	}
+	query := req.URL.Query()
+	query.Set("digest", dgst.String())
+	req.URL.RawQuery = query.Encode()
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err = b.do(req)
+	if err != nil {
+		return "", err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("finishing upload: unexpected status %s", resp.Status)
+	}
+	return dgst, nil
+}
+
+// pull downloads one previously pushed layer.
+func (b *benchRunner) pull() error {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/v2/%s/blobs/%s", b.options.url, b.options.repo, b.anyDigest()), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := b.do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("pulling blob: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func (b *benchRunner) report(out io.Writer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	names := []string{}
+	for name := range b.latencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		latencies := b.latencies[name]
+		sort.Sort(byDuration(latencies))
+		fmt.Fprintf(out, "%s: count=%d errors=%d min=%s p50=%s p90=%s p99=%s max=%s\n",
+			name, len(latencies), b.errors[name],
+			latencies[0],
+			percentile(latencies, 50),
+			percentile(latencies, 90),
+			percentile(latencies, 99),
+			latencies[len(latencies)-1],
+		)
+	}
+	for name, count := range b.errors {
+		if len(b.latencies[name]) == 0 {
+			fmt.Fprintf(out, "%s: count=0 errors=%d\n", name, count)
+		}
+	}
+}
+
+type byDuration []time.Duration
+
+func (d byDuration) Len() int           { return len(d) }
+func (d byDuration) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d byDuration) Less(i, j int) bool { return d[i] < d[j] }
+
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := len(sorted) * p / 100
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
@@ -0,0 +1,423 @@
+package dockerregistry
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/docker/distribution/digest"
+)
+
+// bundleEntry records one exported tag: which repository and tag it came from, the manifest
+// digest and the media type the manifest must be pushed back with.
+type bundleEntry struct {
+	Repo      string `json:"repo"`
+	Tag       string `json:"tag"`
+	Digest    string `json:"digest"`
+	MediaType string `json:"mediaType"`
+}
+
+// bundleIndex is the table of contents written as index.json at the end of the bundle.
+type bundleIndex struct {
+	Entries []bundleEntry `json:"entries"`
+}
+
+const bundleManifestAccept = "application/vnd.docker.distribution.manifest.v2+json, " +
+	"application/vnd.docker.distribution.manifest.list.v2+json, " +
+	"application/vnd.docker.distribution.manifest.v1+prettyjws"
+
+// bundleClient is the minimal registry API client the export and import modes share.
+type bundleClient struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (c *bundleClient) do(req *http.Request) (*http.Response, error) {
+	if len(c.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return c.client.Do(req)
+}
+
+// get fetches the given API path and returns the body, failing on any status but 200.
+func (c *bundleClient) get(apiPath string, accept string) ([]byte, http.Header, error) {
+	req, err := http.NewRequest("GET", c.url+apiPath, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(accept) > 0 {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("GET %s: unexpected status %s", apiPath, resp.Status)
+	}
+	return body, resp.Header, nil
+}
+
+// ExecuteExport runs the "export" subcommand: every tag of the namespace's repositories is
+// written into a self-contained tar bundle - blobs, manifests and a tag index - that can be
+// carried across an air gap and loaded with the import mode.
+func ExecuteExport(args []string) {
+	var url, token, namespace, bundlePath string
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	flags.StringVar(&url, "url", "http://127.0.0.1:5000", "base URL of the registry to export from")
+	flags.StringVar(&token, "token", "", "bearer token sent with every request")
+	flags.StringVar(&namespace, "namespace", "", "namespace whose repositories are exported")
+	flags.StringVar(&bundlePath, "bundle", "registry-bundle.tar", "path of the bundle to write")
+	flags.Parse(args)
+
+	if len(namespace) == 0 {
+		fmt.Fprintln(os.Stderr, "--namespace is required")
+		os.Exit(1)
+	}
+
+	client := &bundleClient{url: url, token: token, client: http.DefaultClient}
+	repos, err := catalogRepositories(client, namespace)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot list repositories: %v\n", err)
+		os.Exit(1)
+	}
+	if len(repos) == 0 {
+		fmt.Fprintf(os.Stderr, "no repositories found in namespace %s\n", namespace)
+		os.Exit(1)
+	}
+
+	out, err := os.Create(bundlePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot create bundle: %v\n", err)
+		os.Exit(1)
+	}
+	defer out.Close()
+	bundle := tar.NewWriter(out)
+
+	index := bundleIndex{}
+	writtenBlobs := map[string]bool{}
+	for _, repo := range repos {
+		body, _, err := client.get("/v2/"+repo+"/tags/list", "")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cannot list tags of %s: %v\n", repo, err)
+			os.Exit(1)
+		}
+		var tags struct {
+			Tags []string `json:"tags"`
+		}
+		if err := json.Unmarshal(body, &tags); err != nil {
+			fmt.Fprintf(os.Stderr, "cannot decode tags of %s: %v\n", repo, err)
+			os.Exit(1)
+		}
+		for _, tag := range tags.Tags {
+			entry, payload, err := exportManifest(client, repo, tag)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "cannot export %s:%s: %v\n", repo, tag, err)
+				os.Exit(1)
+			}
+			if err := writeBundleFile(bundle, path.Join("manifests", entry.Digest), payload); err != nil {
+				fmt.Fprintf(os.Stderr, "cannot write bundle: %v\n", err)
+				os.Exit(1)
+			}
+			for _, blob := range manifestBlobDigests(payload) {
+				if writtenBlobs[blob] {
+					continue
+				}
+				data, _, err := client.get("/v2/"+repo+"/blobs/"+blob, "")
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "cannot export blob %s: %v\n", blob, err)
+					os.Exit(1)
+				}
+				if err := writeBundleFile(bundle, path.Join("blobs", blob), data); err != nil {
+					fmt.Fprintf(os.Stderr, "cannot write bundle: %v\n", err)
+					os.Exit(1)
+				}
+				writtenBlobs[blob] = true
+			}
+			index.Entries = append(index.Entries, entry)
+			fmt.Printf("exported %s:%s (%s)\n", repo, tag, entry.Digest)
+		}
+	}
+
+	indexData, err := json.MarshalIndent(index, "", "  ")
+	if err == nil {
+		err = writeBundleFile(bundle, "index.json", indexData)
+	}
+	if err == nil {
+		err = bundle.Close()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot finish bundle: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("wrote %s: %d tags, %d blobs\n", bundlePath, len(index.Entries), len(writtenBlobs))
+}
+
+// ExecuteImport runs the "import" subcommand: the bundle's blobs and manifests are verified
+// against the digests they were exported under and pushed into the target registry, recreating
+// every exported tag.
+func ExecuteImport(args []string) {
+	var url, token, bundlePath string
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	flags.StringVar(&url, "url", "http://127.0.0.1:5000", "base URL of the registry to import into")
+	flags.StringVar(&token, "token", "", "bearer token sent with every request")
+	flags.StringVar(&bundlePath, "bundle", "registry-bundle.tar", "path of the bundle to read")
+	flags.Parse(args)
+
+	files, index, err := readBundle(bundlePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot read bundle: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := &bundleClient{url: url, token: token, client: http.DefaultClient}
+	for _, entry := range index.Entries {
+		payload, ok := files[path.Join("manifests", entry.Digest)]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "bundle is missing the manifest for %s:%s\n", entry.Repo, entry.Tag)
+			os.Exit(1)
+		}
+		for _, blob := range manifestBlobDigests(payload) {
+			data, ok := files[path.Join("blobs", blob)]
+			if !ok {
+				fmt.Fprintf(os.Stderr, "bundle is missing blob %s of %s:%s\n", blob, entry.Repo, entry.Tag)
+				os.Exit(1)
+			}
+			if err := pushBlob(client, entry.Repo, blob, data); err != nil {
+				fmt.Fprintf(os.Stderr, "cannot push blob %s: %v\n", blob, err)
+				os.Exit(1)
+			}
+		}
+		if err := pushManifest(client, entry, payload); err != nil {
+			fmt.Fprintf(os.Stderr, "cannot push %s:%s: %v\n", entry.Repo, entry.Tag, err)
+			os.Exit(1)
+		}
+		fmt.Printf("imported %s:%s (%s)\n", entry.Repo, entry.Tag, entry.Digest)
+	}
+}
+
+// catalogRepositories pages through the catalog and returns the repositories of the namespace.
+func catalogRepositories(client *bundleClient, namespace string) ([]string, error) {
+	repos := []string{}
+	last := ""
+	for {
+		apiPath := "/v2/_catalog?n=100"
+		if len(last) > 0 {
+			apiPath += "&last=" + last
+		}
+		body, _, err := client.get(apiPath, "")
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Repositories []string `json:"repositories"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, err
+		}
+		if len(page.Repositories) == 0 {
+			return repos, nil
+		}
+		for _, repo := range page.Repositories {
+			if strings.HasPrefix(repo, namespace+"/") {
+				repos = append(repos, repo)
+			}
+		}
+		last = page.Repositories[len(page.Repositories)-1]
+	}
+}
+
+// exportManifest fetches one tag's manifest and returns its index entry and payload.
+func exportManifest(client *bundleClient, repo, tag string) (bundleEntry, []byte, error) {
+	payload, header, err := client.get("/v2/"+repo+"/manifests/"+tag, bundleManifestAccept)
+	if err != nil {
+		return bundleEntry{}, nil, err
+	}
+	dgst := header.Get("Docker-Content-Digest")
+	if len(dgst) == 0 {
+		dgst = digest.FromBytes(payload).String()
+	}
+	return bundleEntry{
+		Repo:      repo,
+		Tag:       tag,
+		Digest:    dgst,
+		MediaType: header.Get("Content-Type"),
+	}, payload, nil
+}
+
+// manifestBlobDigests returns the config and layer digests a manifest references, covering both
+// the schema2 and schema1 layouts. Manifest lists reference no blobs directly.
+func manifestBlobDigests(payload []byte) []string {
+	var manifest struct {
+		Config struct {
+			Digest string `json:"digest"`
+		} `json:"config"`
+		Layers []struct {
+			Digest string `json:"digest"`
+		} `json:"layers"`
+		FSLayers []struct {
+			BlobSum string `json:"blobSum"`
+		} `json:"fsLayers"`
+	}
+	if err := json.Unmarshal(payload, &manifest); err != nil {
+		return nil
+	}
+	digests := []string{}
+	seen := map[string]bool{}
+	add := func(dgst string) {
+		if len(dgst) > 0 && !seen[dgst] {
+			seen[dgst] = true
+			digests = append(digests, dgst)
+		}
+	}
+	add(manifest.Config.Digest)
+	for _, layer := range manifest.Layers {
+		add(layer.Digest)
+	}
+	for _, layer := range manifest.FSLayers {
+		add(layer.BlobSum)
+	}
+	return digests
+}
+
+func writeBundleFile(bundle *tar.Writer, name string, data []byte) error {
+	if err := bundle.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data))}); err != nil {
+		return err
+	}
+	_, err := bundle.Write(data)
+	return err
+}
+
+// readBundle loads the bundle into memory, verifying every blob and manifest against the digest
+// it is stored under. Content tampered with - or corrupted - in transit fails here, before
+// anything is pushed.
+func readBundle(bundlePath string) (map[string][]byte, *bundleIndex, error) {
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	files := map[string][]byte{}
+	index := &bundleIndex{}
+	reader := tar.NewReader(f)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		name := path.Clean(header.Name)
+		if name != "index.json" {
+			expected, err := digest.ParseDigest(path.Base(name))
+			if err != nil {
+				return nil, nil, fmt.Errorf("unexpected bundle entry %q", header.Name)
+			}
+			if digest.FromBytes(data) != expected {
+				return nil, nil, fmt.Errorf("digest mismatch for bundle entry %q", header.Name)
+			}
+		}
+		files[name] = data
+	}
+
+	indexData, ok := files["index.json"]
+	if !ok {
+		return nil, nil, fmt.Errorf("the bundle has no index.json")
+	}
+	if err := json.Unmarshal(indexData, index); err != nil {
+		return nil, nil, err
+	}
+	return files, index, nil
+}
+
+// pushBlob uploads the blob unless the target repository already has it.
+func pushBlob(client *bundleClient, repo, dgst string, data []byte) error {
+	req, err := http.NewRequest("HEAD", fmt.Sprintf("%s/v2/%s/blobs/%s", client.url, repo, dgst), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	req, err = http.NewRequest("POST", fmt.Sprintf("%s/v2/%s/blobs/uploads/", client.url, repo), nil)
+	if err != nil {
+		return err
+	}
+	resp, err = client.do(req)
+	if err != nil {
+		return err
+	}
+	location := resp.Header.Get("Location")
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("starting upload: unexpected status %s", resp.Status)
+	}
+
+	req, err = http.NewRequest("PUT", location, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	query := req.URL.Query()
+	query.Set("digest", dgst)
+	req.URL.RawQuery = query.Encode()
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err = client.do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("finishing upload: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// pushManifest recreates the exported tag with the media type the manifest was exported under.
+func pushManifest(client *bundleClient, entry bundleEntry, payload []byte) error {
+	req, err := http.NewRequest("PUT", fmt.Sprintf("%s/v2/%s/manifests/%s", client.url, entry.Repo, entry.Tag), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	if len(entry.MediaType) > 0 {
+		req.Header.Set("Content-Type", entry.MediaType)
+	}
+	resp, err := client.do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("pushing manifest: unexpected status %s", resp.Status)
+	}
+	return nil
+}
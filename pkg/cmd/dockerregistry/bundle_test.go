@@ -0,0 +1,83 @@
+package dockerregistry
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/docker/distribution/digest"
+)
+
+func TestManifestBlobDigests(t *testing.T) {
+	schema2 := []byte(`{
+		"config": {"digest": "sha256:aaa"},
+		"layers": [{"digest": "sha256:bbb"}, {"digest": "sha256:ccc"}, {"digest": "sha256:bbb"}]
+	}`)
+	if expected, actual := []string{"sha256:aaa", "sha256:bbb", "sha256:ccc"}, manifestBlobDigests(schema2); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("schema2: expected %v, got %v", expected, actual)
+	}
+
+	schema1 := []byte(`{"fsLayers": [{"blobSum": "sha256:ddd"}, {"blobSum": "sha256:eee"}]}`)
+	if expected, actual := []string{"sha256:ddd", "sha256:eee"}, manifestBlobDigests(schema1); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("schema1: expected %v, got %v", expected, actual)
+	}
+
+	if digests := manifestBlobDigests([]byte(`{"manifests": []}`)); len(digests) != 0 {
+		t.Errorf("manifest list: expected no blobs, got %v", digests)
+	}
+}
+
+func TestReadBundleVerifiesDigests(t *testing.T) {
+	blob := []byte("layer content")
+	dgst := digest.FromBytes(blob)
+
+	write := func(t *testing.T, corrupt bool) string {
+		f, err := ioutil.TempFile("", "bundle")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		bundle := tar.NewWriter(f)
+		data := blob
+		if corrupt {
+			data = []byte("tampered content")
+		}
+		if err := writeBundleFile(bundle, path.Join("blobs", dgst.String()), data); err != nil {
+			t.Fatal(err)
+		}
+		indexData, err := json.Marshal(bundleIndex{Entries: []bundleEntry{{Repo: "ns/app", Tag: "latest", Digest: dgst.String()}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writeBundleFile(bundle, "index.json", indexData); err != nil {
+			t.Fatal(err)
+		}
+		if err := bundle.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return f.Name()
+	}
+
+	good := write(t, false)
+	defer os.Remove(good)
+	files, index, err := readBundle(good)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(index.Entries) != 1 || index.Entries[0].Tag != "latest" {
+		t.Errorf("unexpected index: %#v", index)
+	}
+	if _, ok := files[path.Join("blobs", dgst.String())]; !ok {
+		t.Error("expected the blob to be loaded")
+	}
+
+	bad := write(t, true)
+	defer os.Remove(bad)
+	if _, _, err := readBundle(bad); err == nil {
+		t.Error("expected a digest mismatch error for the tampered bundle")
+	}
+}
@@ -2,10 +2,8 @@ package dockerregistry
 
 import (
 	"crypto/tls"
-	"crypto/x509"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
@@ -47,6 +45,10 @@ func Execute(configFile io.Reader) {
 		log.Fatalf("Error parsing configuration file: %s", err)
 	}
 
+	if err := server.ValidateConfiguration(config); err != nil {
+		log.Fatalf("Inconsistent OpenShift middleware configuration: %s", err)
+	}
+
 	tokenPath := "/openshift/token"
 
 	// If needed, generate and populate the token realm URL in the config.
@@ -69,6 +71,14 @@ func Execute(configFile io.Reader) {
 		config.Auth[server.OpenShiftAuth][server.TokenRealmKey] = tokenURL.String()
 	}
 
+	// Sign converted schema1 manifests with a persisted cluster key so their digests stay
+	// stable across restarts and replicas.
+	if keyPath := os.Getenv(server.Schema1SigningKeyEnvVar); len(keyPath) > 0 {
+		config.Compatibility.Schema1.TrustKey = keyPath
+	} else if len(config.Compatibility.Schema1.TrustKey) == 0 {
+		log.Warnf("no schema1 signing key configured (%s); converted manifests are signed with an ephemeral per-process key", server.Schema1SigningKeyEnvVar)
+	}
+
 	ctx := context.Background()
 	ctx, err = configureLogging(ctx, config)
 	if err != nil {
@@ -79,6 +89,9 @@ func Execute(configFile io.Reader) {
 	// with uuid generation under low entropy.
 	uuid.Loggerf = context.GetLogger(ctx).Warnf
 
+	// Log the resolved configuration and make it available on the admin listener
+	server.RecordConfiguration(ctx, config)
+
 	app := handlers.NewApp(ctx, config)
 
 	// Add a token handling endpoint
@@ -86,6 +99,156 @@ func Execute(configFile io.Reader) {
 		app.NewRoute().Methods("GET").PathPrefix(tokenPath).Handler(server.NewTokenHandler(ctx, server.DefaultRegistryClient))
 	}
 
+	// Publish token verification keys when signing keys are configured
+	if jwks := server.NewJWKSHandler(ctx); jwks != nil {
+		app.NewRoute().Methods("GET").Path("/openshift/token/keys").Handler(jwks)
+	}
+
+	// Advertise enabled features to clients
+	app.NewRoute().Methods("GET").Path("/openshift/capabilities").Handler(server.NewCapabilitiesHandler(ctx, config))
+
+	// Answer legacy v1 probes with an explicit deprecation payload
+	v1Compat := server.NewV1CompatHandler(ctx)
+	app.NewRoute().Methods("GET").Path("/v1/_ping").Handler(v1Compat)
+	app.NewRoute().Methods("GET").Path("/v1/search").Handler(v1Compat)
+
+	// Start leader-elected background maintenance when configured
+	server.RunMaintenance(ctx, server.DefaultRegistryClient)
+
+	// Serve metrics/pprof on a dedicated listener when configured
+	server.RunAdminServer(ctx, server.DefaultRegistryClient)
+
+	// Prime layer caches for configured namespaces in the background
+	server.RunStartupWarmup(ctx, server.DefaultRegistryClient)
+
+	// Hot-reload safe settings from a cluster ConfigMap when configured
+	server.RunRuntimeConfigWatcher(ctx, server.DefaultRegistryClient)
+
+	// Keep the local image metadata cache current when configured
+	server.RunMetadataCacheWatcher(ctx, server.DefaultRegistryClient)
+
+	// Drain asynchronously created image stream mappings
+	server.RunMappingQueue(ctx)
+
+	// Pre-warm image caches for rollouts in progress when configured
+	server.RunRolloutWarmup(ctx, server.DefaultRegistryClient)
+
+	// Keep pull-through fallback ordering current with periodic health probes
+	server.RunPullthroughProber(ctx)
+
+	// Reclaim storage promptly when tags are deleted when configured
+	server.RunGuaranteedTagDelete(ctx, server.DefaultRegistryClient)
+
+	app.RegisterRoute(
+		// POST /openshift/archive/<name> - accepts a docker save tarball and converts it into a tagged manifest
+		app.NewRoute().Path("/openshift/archive/{name:"+reference.NameRegexp.String()+"}").Methods("POST"),
+		// handler
+		server.ArchiveUploadDispatcher,
+		// repo name required in url so push access is enforced
+		handlers.NameRequired,
+		// standard repository access records
+		handlers.NoCustomAccessRecords,
+	)
+
+	app.RegisterRoute(
+		// POST/GET /openshift/warmup/<name> - pre-fetch tags into the registry caches
+		app.NewRoute().Path("/openshift/warmup/{name:"+reference.NameRegexp.String()+"}").Methods("POST", "GET"),
+		// handler
+		server.WarmupDispatcher,
+		// repo name required in url so repository access is enforced
+		handlers.NameRequired,
+		// standard repository access records
+		handlers.NoCustomAccessRecords,
+	)
+
+	app.RegisterRoute(
+		// GET/PUT /openshift/tag-metadata/<name>?tag=<tag> - read or edit per-tag metadata annotations
+		app.NewRoute().Path("/openshift/tag-metadata/{name:"+reference.NameRegexp.String()+"}").Methods("GET", "PUT"),
+		// handler
+		server.TagMetadataDispatcher,
+		// repo name required in url so pull access gates reads and push access gates writes
+		handlers.NameRequired,
+		// standard repository access records
+		handlers.NoCustomAccessRecords,
+	)
+
+	app.RegisterRoute(
+		// POST /openshift/deploy-tokens/<name>?expiry=<duration> - mint a read-only repository-scoped token
+		app.NewRoute().Path("/openshift/deploy-tokens/{name:"+reference.NameRegexp.String()+"}").Methods("POST"),
+		// handler
+		server.DeployTokenDispatcher,
+		// repo name required in url so push access gates minting
+		handlers.NameRequired,
+		// standard repository access records
+		handlers.NoCustomAccessRecords,
+	)
+
+	app.RegisterRoute(
+		// GET /openshift/search?namespace=<ns>&label=<key>=<value> - find images by config label
+		app.NewRoute().Path("/openshift/search").Methods("GET"),
+		// handler
+		server.LabelSearchDispatcher,
+		// repo name not required in url; images are read with the caller's own client
+		handlers.NameNotRequired,
+		// standard repository access records
+		handlers.NoCustomAccessRecords,
+	)
+
+	app.RegisterRoute(
+		// POST /openshift/node-tokens?namespace=<ns> - exchange a service account token for a namespace pull token
+		app.NewRoute().Path("/openshift/node-tokens").Methods("POST"),
+		// handler
+		server.NodeTokenDispatcher,
+		// repo name not required in url; the handler reviews access to the requested namespace
+		handlers.NameNotRequired,
+		// standard repository access records
+		handlers.NoCustomAccessRecords,
+	)
+
+	app.RegisterRoute(
+		// GET /openshift/resolve/<name>?tag=<tag> - resolve tags to manifest digests
+		app.NewRoute().Path("/openshift/resolve/{name:"+reference.NameRegexp.String()+"}").Methods("GET"),
+		// handler
+		server.ResolveDispatcher,
+		// repo name required in url so pull access is enforced
+		handlers.NameRequired,
+		// standard repository access records
+		handlers.NoCustomAccessRecords,
+	)
+
+	app.RegisterRoute(
+		// GET /openshift/oci-index/<name> - render the stream's tags as a single OCI index for mirroring tools
+		app.NewRoute().Path("/openshift/oci-index/{name:"+reference.NameRegexp.String()+"}").Methods("GET"),
+		// handler
+		server.OCIIndexDispatcher,
+		// repo name required in url so pull access is enforced
+		handlers.NameRequired,
+		// standard repository access records
+		handlers.NoCustomAccessRecords,
+	)
+
+	app.RegisterRoute(
+		// GET /openshift/sigstore/<name>@<algo>=<hex>/signature-<n> - serve image signatures in the lookaside layout CRI-O reads
+		app.NewRoute().Path("/openshift/sigstore/{name:"+reference.NameRegexp.String()+"}@{digest:[a-zA-Z0-9]+=[a-fA-F0-9]+}/{file:signature-[0-9]+}").Methods("GET"),
+		// handler
+		server.SigstoreDispatcher,
+		// repo name required in url so pull access is enforced
+		handlers.NameRequired,
+		// standard repository access records
+		handlers.NoCustomAccessRecords,
+	)
+
+	app.RegisterRoute(
+		// POST /openshift/peers/blobs/<digest> - blob advertisements from peer replicas
+		app.NewRoute().Path("/openshift/peers/blobs/{digest:"+reference.DigestRegexp.String()+"}").Methods("POST"),
+		// handler
+		server.PeerBlobDispatcher,
+		// repo name not required in url
+		handlers.NameNotRequired,
+		// advertisements are validated against the configured peer list
+		handlers.NoCustomAccessRecords,
+	)
+
 	// TODO add https scheme
 	adminRouter := app.NewRoute().PathPrefix("/admin/").Subrouter()
 
@@ -101,8 +264,8 @@ func Execute(configFile io.Reader) {
 	}
 
 	app.RegisterRoute(
-		// DELETE /admin/blobs/<digest>
-		adminRouter.Path("/blobs/{digest:"+reference.DigestRegexp.String()+"}").Methods("DELETE"),
+		// DELETE /admin/blobs/<digest>, GET lists the image streams and images referencing it
+		adminRouter.Path("/blobs/{digest:"+reference.DigestRegexp.String()+"}").Methods("GET", "DELETE"),
 		// handler
 		server.BlobDispatcher,
 		// repo name not required in url
@@ -111,12 +274,77 @@ func Execute(configFile io.Reader) {
 		pruneAccessRecords,
 	)
 
+	app.RegisterRoute(
+		// PUT /admin/quarantine/<digest> - refuse to serve the image, DELETE reverses it
+		adminRouter.Path("/quarantine/{digest:"+reference.DigestRegexp.String()+"}").Methods("PUT", "DELETE"),
+		// handler
+		server.QuarantineDispatcher,
+		// repo name not required in url
+		handlers.NameNotRequired,
+		// custom access records
+		pruneAccessRecords,
+	)
+
+	app.RegisterRoute(
+		// GET /admin/uploads - list in-flight blob upload sessions
+		adminRouter.Path("/uploads").Methods("GET"),
+		// handler
+		server.UploadsDispatcher,
+		// repo name not required in url
+		handlers.NameNotRequired,
+		// custom access records
+		pruneAccessRecords,
+	)
+
+	app.RegisterRoute(
+		// DELETE /admin/uploads/<uuid> - cancel an in-flight blob upload session
+		adminRouter.Path("/uploads/{uuid}").Methods("DELETE"),
+		// handler
+		server.UploadsDispatcher,
+		// repo name not required in url
+		handlers.NameNotRequired,
+		// custom access records
+		pruneAccessRecords,
+	)
+
+	app.RegisterRoute(
+		// POST /admin/compact?namespace=<ns>&name=<stream> - merge duplicate and dangling tag events
+		adminRouter.Path("/compact").Methods("POST"),
+		// handler
+		server.CompactStreamDispatcher,
+		// repo name not required in url
+		handlers.NameNotRequired,
+		// custom access records
+		pruneAccessRecords,
+	)
+
+	app.RegisterRoute(
+		// GET /admin/simulate-access?user=<user>&namespace=<ns>&name=<stream>&verb=<verb> - trace an access decision
+		adminRouter.Path("/simulate-access").Methods("GET"),
+		// handler
+		server.SimulateAccessDispatcher,
+		// repo name not required in url
+		handlers.NameNotRequired,
+		// custom access records
+		pruneAccessRecords,
+	)
+
 	app.RegisterHealthChecks()
-	handler := alive("/", app)
+	// Answer revalidations of unchanged tag lists with a 304; manifests get this upstream.
+	handler := server.NewConditionalRequestHandler(app)
+	handler = server.NewOCIConformanceHandler(handler)
+	handler = server.NewClientCompatHandler(handler)
+	// Advertise chunk sizes tuned for the storage driver on blob upload responses.
+	handler = server.NewChunkHintsHandler(handler)
+	handler = server.NewPriorityHandler(handler)
+	handler = alive("/", handler)
 	// TODO: temporarily keep for backwards compatibility; remove in the future
 	handler = alive("/healthz", handler)
 	handler = health.Handler(handler)
 	handler = panicHandler(handler)
+	handler = server.NewSLOHandler(handler)
+	handler = server.NewAccessLogHandler(handler)
+	handler = server.NewForwardedHeaderHandler(handler)
 	handler = gorillahandlers.CombinedLoggingHandler(os.Stdout, handler)
 
 	if config.HTTP.TLS.Certificate == "" {
@@ -127,36 +355,20 @@ func Execute(configFile io.Reader) {
 	} else {
 		tlsConf := crypto.SecureTLSConfig(&tls.Config{ClientAuth: tls.NoClientCert})
 
-		if len(config.HTTP.TLS.ClientCAs) != 0 {
-			pool := x509.NewCertPool()
-
-			for _, ca := range config.HTTP.TLS.ClientCAs {
-				caPem, err := ioutil.ReadFile(ca)
-				if err != nil {
-					context.GetLogger(app).Fatalln(err)
-				}
-
-				if ok := pool.AppendCertsFromPEM(caPem); !ok {
-					context.GetLogger(app).Fatalln(fmt.Errorf("Could not add CA to pool"))
-				}
-			}
-
-			for _, subj := range pool.Subjects() {
-				context.GetLogger(app).Debugf("CA Subject: %s", string(subj))
-			}
-
-			tlsConf.ClientAuth = tls.RequireAndVerifyClientCert
-			tlsConf.ClientCAs = pool
+		// The reloader builds the client CA pool and re-reads all TLS material when the
+		// files change, so certificate rotation does not require a restart.
+		reloader, err := server.NewTLSReloader(tlsConf, config.HTTP.TLS.Certificate, config.HTTP.TLS.Key, config.HTTP.TLS.ClientCAs)
+		if err != nil {
+			context.GetLogger(app).Fatalln(err)
 		}
 
 		context.GetLogger(app).Infof("listening on %v, tls", config.HTTP.Addr)
-		server := &http.Server{
-			Addr:      config.HTTP.Addr,
-			Handler:   handler,
-			TLSConfig: tlsConf,
+		httpServer := &http.Server{
+			Addr:    config.HTTP.Addr,
+			Handler: handler,
 		}
 
-		if err := server.ListenAndServeTLS(config.HTTP.TLS.Certificate, config.HTTP.TLS.Key); err != nil {
+		if err := reloader.ListenAndServe(ctx, httpServer); err != nil {
 			context.GetLogger(app).Fatalln(err)
 		}
 	}
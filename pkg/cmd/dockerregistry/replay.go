@@ -0,0 +1,281 @@
+package dockerregistry
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// replayOptions configures the traffic generated by ExecuteReplay.
+type replayOptions struct {
+	logPath     string
+	url         string
+	token       string
+	speed       float64
+	concurrency int
+	anonymize   bool
+}
+
+// replayRequest is one read request reconstructed from an access log line.
+type replayRequest struct {
+	at     time.Time
+	method string
+	path   string
+	status int
+}
+
+// replayCLFRegexp matches the CLF lines the registry's own access log writes.
+var replayCLFRegexp = regexp.MustCompile(`^\S+ - \S+ \[([^\]]+)\] "([A-Z]+) (\S+)[^"]*" (\d+) `)
+
+// ExecuteReplay runs the "replay" subcommand: it replays read traffic from a registry access
+// log against a test registry at a configurable speed, so performance regressions are measured
+// with production-shaped workloads instead of synthetic ones. Only GET and HEAD requests are
+// replayed; writes cannot be reconstructed from a log. With anonymization on (the default),
+// repository names are replaced with stable synthetic names and recorded users and source
+// addresses are never sent.
+func ExecuteReplay(args []string) {
+	options := replayOptions{}
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	flags.StringVar(&options.logPath, "log", "", "access log to replay, in the clf or w3c format the registry writes")
+	flags.StringVar(&options.url, "url", "http://127.0.0.1:5000", "base URL of the registry to replay against")
+	flags.StringVar(&options.token, "token", "", "bearer token sent with every request")
+	flags.Float64Var(&options.speed, "speed", 1.0, "playback speed; 2 halves the recorded gaps, 0 replays as fast as possible")
+	flags.IntVar(&options.concurrency, "concurrency", 8, "number of concurrent workers")
+	flags.BoolVar(&options.anonymize, "anonymize", true, "replace repository names with stable synthetic names")
+	flags.Parse(args)
+
+	if len(options.logPath) == 0 || options.speed < 0 || options.concurrency < 1 {
+		fmt.Fprintln(os.Stderr, "invalid replay options; -log is required")
+		os.Exit(1)
+	}
+
+	requests, skipped, err := readReplayLog(options.logPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot read %s: %v\n", options.logPath, err)
+		os.Exit(1)
+	}
+	if len(requests) == 0 {
+		fmt.Fprintln(os.Stderr, "no replayable requests in the log")
+		os.Exit(1)
+	}
+	if options.anonymize {
+		anonymizeRequests(requests)
+	}
+	sort.Sort(byRequestTime(requests))
+	fmt.Printf("replaying %d requests (%d lines skipped) against %s\n", len(requests), skipped, options.url)
+
+	runner := &replayRunner{options: options, client: http.DefaultClient}
+	queue := make(chan replayRequest)
+	var wg sync.WaitGroup
+	for i := 0; i < options.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for request := range queue {
+				runner.replay(request)
+			}
+		}()
+	}
+
+	base := requests[0].at
+	start := time.Now()
+	for _, request := range requests {
+		if options.speed > 0 {
+			due := start.Add(time.Duration(float64(request.at.Sub(base)) / options.speed))
+			time.Sleep(due.Sub(time.Now()))
+		}
+		queue <- request
+	}
+	close(queue)
+	wg.Wait()
+
+	runner.report(os.Stdout)
+}
+
+// readReplayLog parses the GET and HEAD requests out of an access log in either of the formats
+// the registry writes. Lines it cannot parse, and methods it cannot replay, are counted as
+// skipped.
+func readReplayLog(path string) ([]replayRequest, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	requests := []replayRequest{}
+	skipped := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		request, ok := parseReplayLine(line)
+		if !ok {
+			skipped++
+			continue
+		}
+		requests = append(requests, request)
+	}
+	return requests, skipped, scanner.Err()
+}
+
+func parseReplayLine(line string) (replayRequest, bool) {
+	if m := replayCLFRegexp.FindStringSubmatch(line); m != nil {
+		at, err := time.Parse("02/Jan/2006:15:04:05 -0700", m[1])
+		if err != nil {
+			return replayRequest{}, false
+		}
+		status, _ := strconv.Atoi(m[4])
+		return newReplayRequest(at, m[2], m[3], status)
+	}
+	// w3c: date time c-ip cs-method cs-uri-stem sc-status sc-bytes time-taken ...
+	fields := strings.Fields(line)
+	if len(fields) < 6 {
+		return replayRequest{}, false
+	}
+	at, err := time.Parse("2006-01-02 15:04:05", fields[0]+" "+fields[1])
+	if err != nil {
+		return replayRequest{}, false
+	}
+	status, _ := strconv.Atoi(fields[5])
+	return newReplayRequest(at, fields[3], fields[4], status)
+}
+
+func newReplayRequest(at time.Time, method, uri string, status int) (replayRequest, bool) {
+	if method != "GET" && method != "HEAD" {
+		return replayRequest{}, false
+	}
+	// strip any absolute prefix and query so only the registry path remains
+	if i := strings.Index(uri, "/v2/"); i > 0 {
+		uri = uri[i:]
+	}
+	if i := strings.Index(uri, "?"); i >= 0 {
+		uri = uri[:i]
+	}
+	if !strings.HasPrefix(uri, "/v2/") {
+		return replayRequest{}, false
+	}
+	return replayRequest{at: at, method: method, path: uri, status: status}, true
+}
+
+// anonymizeRequests rewrites every repository name to a stable synthetic one, preserving the
+// cardinality and repetition pattern of the workload without leaking project names.
+func anonymizeRequests(requests []replayRequest) {
+	names := map[string]string{}
+	for i := range requests {
+		repo, rest := splitReplayRepository(requests[i].path)
+		if len(repo) == 0 {
+			continue
+		}
+		anonymous, ok := names[repo]
+		if !ok {
+			anonymous = fmt.Sprintf("replay/repo-%d", len(names))
+			names[repo] = anonymous
+		}
+		requests[i].path = "/v2/" + anonymous + rest
+	}
+}
+
+// splitReplayRepository splits a v2 API path into the repository name and the remainder
+// starting at /manifests/, /blobs/ or /tags/.
+func splitReplayRepository(path string) (string, string) {
+	trimmed := strings.TrimPrefix(path, "/v2/")
+	for _, marker := range []string{"/manifests/", "/blobs/", "/tags/"} {
+		if i := strings.Index(trimmed, marker); i > 0 {
+			return trimmed[:i], trimmed[i:]
+		}
+	}
+	return "", ""
+}
+
+type replayRunner struct {
+	options replayOptions
+	client  *http.Client
+
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+	errors    map[string]int
+	divergent int
+}
+
+// replay issues one request and records its latency. A response status differing from the
+// recorded one is counted as divergent: expected when the test registry holds different
+// content, but a spike in divergence usually means the replay target is misconfigured.
+func (r *replayRunner) replay(request replayRequest) {
+	req, err := http.NewRequest(request.method, r.options.url+request.path, nil)
+	if err != nil {
+		r.record(request.method, 0, err, false)
+		return
+	}
+	if len(r.options.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+r.options.token)
+	}
+	start := time.Now()
+	resp, err := r.client.Do(req)
+	if err != nil {
+		r.record(request.method, 0, err, false)
+		return
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	r.record(request.method, time.Since(start), nil, resp.StatusCode != request.status)
+}
+
+func (r *replayRunner) record(name string, elapsed time.Duration, err error, divergent bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.latencies == nil {
+		r.latencies = map[string][]time.Duration{}
+		r.errors = map[string]int{}
+	}
+	if err != nil {
+		r.errors[name]++
+		return
+	}
+	if divergent {
+		r.divergent++
+	}
+	r.latencies[name] = append(r.latencies[name], elapsed)
+}
+
+func (r *replayRunner) report(out io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := []string{}
+	for name := range r.latencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		latencies := r.latencies[name]
+		sort.Sort(byDuration(latencies))
+		fmt.Fprintf(out, "%s: count=%d errors=%d min=%s p50=%s p90=%s p99=%s max=%s\n",
+			name, len(latencies), r.errors[name],
+			latencies[0],
+			percentile(latencies, 50),
+			percentile(latencies, 90),
+			percentile(latencies, 99),
+			latencies[len(latencies)-1],
+		)
+	}
+	fmt.Fprintf(out, "divergent responses: %d\n", r.divergent)
+}
+
+type byRequestTime []replayRequest
+
+func (r byRequestTime) Len() int           { return len(r) }
+func (r byRequestTime) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r byRequestTime) Less(i, j int) bool { return r[i].at.Before(r[j].at) }
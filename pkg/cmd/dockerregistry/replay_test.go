@@ -0,0 +1,71 @@
+package dockerregistry
+
+import (
+	"testing"
+)
+
+func TestParseReplayLine(t *testing.T) {
+	testCases := []struct {
+		line   string
+		ok     bool
+		method string
+		path   string
+		status int
+	}{
+		{
+			line:   `10.1.2.3 - alice [02/Jan/2016:15:04:05 +0000] "GET /v2/myproject/app/manifests/latest HTTP/1.1" 200 1234 "myproject/app" "-"`,
+			ok:     true,
+			method: "GET",
+			path:   "/v2/myproject/app/manifests/latest",
+			status: 200,
+		},
+		{
+			line:   `2016-01-02 15:04:05 10.1.2.3 HEAD /v2/myproject/app/blobs/sha256:abc 404 0 0.012 myproject/app sha256:abc -`,
+			ok:     true,
+			method: "HEAD",
+			path:   "/v2/myproject/app/blobs/sha256:abc",
+			status: 404,
+		},
+		{
+			// writes cannot be replayed
+			line: `10.1.2.3 - alice [02/Jan/2016:15:04:05 +0000] "PUT /v2/myproject/app/manifests/latest HTTP/1.1" 201 0 "myproject/app" "-"`,
+		},
+		{
+			line: `not an access log line`,
+		},
+	}
+
+	for _, tc := range testCases {
+		request, ok := parseReplayLine(tc.line)
+		if ok != tc.ok {
+			t.Errorf("%q: ok=%t, expected %t", tc.line, ok, tc.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if request.method != tc.method || request.path != tc.path || request.status != tc.status {
+			t.Errorf("%q: got %s %s %d", tc.line, request.method, request.path, request.status)
+		}
+	}
+}
+
+func TestAnonymizeRequests(t *testing.T) {
+	requests := []replayRequest{
+		{method: "GET", path: "/v2/myproject/app/manifests/latest"},
+		{method: "GET", path: "/v2/other/db/blobs/sha256:abc"},
+		{method: "GET", path: "/v2/myproject/app/tags/list"},
+	}
+	anonymizeRequests(requests)
+
+	if requests[0].path != "/v2/replay/repo-0/manifests/latest" {
+		t.Errorf("unexpected path %s", requests[0].path)
+	}
+	if requests[1].path != "/v2/replay/repo-1/blobs/sha256:abc" {
+		t.Errorf("unexpected path %s", requests[1].path)
+	}
+	// the same repository maps to the same synthetic name
+	if requests[2].path != "/v2/replay/repo-0/tags/list" {
+		t.Errorf("unexpected path %s", requests[2].path)
+	}
+}
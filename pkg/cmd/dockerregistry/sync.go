@@ -0,0 +1,158 @@
+package dockerregistry
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/reference"
+	registryclient "github.com/docker/distribution/registry/client"
+
+	"github.com/openshift/origin/pkg/dockerregistry/replication"
+)
+
+// syncTransport sends the bearer token for one side of the sync with every request.
+type syncTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t *syncTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(t.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// ExecuteSync runs the "sync" subcommand: every tag of the repository is replicated from the
+// source registry to the target, copying only the blobs the target does not already have. With
+// -state the completed blobs are recorded on disk, so an interrupted sync of a large image
+// stream resumes where it stopped instead of restarting from scratch.
+func ExecuteSync(args []string) {
+	var from, to, fromToken, toToken, repoName, statePath string
+	var concurrency int
+	flags := flag.NewFlagSet("sync", flag.ExitOnError)
+	flags.StringVar(&from, "from", "", "base URL of the registry to copy from")
+	flags.StringVar(&to, "to", "", "base URL of the registry to copy into")
+	flags.StringVar(&fromToken, "from-token", "", "bearer token sent to the source registry")
+	flags.StringVar(&toToken, "to-token", "", "bearer token sent to the target registry")
+	flags.StringVar(&repoName, "repo", "", "repository to replicate, e.g. myproject/app")
+	flags.StringVar(&statePath, "state", "", "file recording completed blobs, reused by later runs to resume")
+	flags.IntVar(&concurrency, "concurrency", 4, "number of parallel blob checks and copies")
+	flags.Parse(args)
+
+	if len(from) == 0 || len(to) == 0 || len(repoName) == 0 {
+		fmt.Fprintln(os.Stderr, "-from, -to and -repo are required")
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	source, err := syncRepository(ctx, from, fromToken, repoName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot reach the source registry: %v\n", err)
+		os.Exit(1)
+	}
+	target, err := syncRepository(ctx, to, toToken, repoName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot reach the target registry: %v\n", err)
+		os.Exit(1)
+	}
+
+	state := replication.NewSyncState()
+	if len(statePath) > 0 {
+		state, err = replication.LoadSyncState(statePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cannot load state from %s: %v\n", statePath, err)
+			os.Exit(1)
+		}
+	}
+	saveState := func() {
+		if len(statePath) == 0 {
+			return
+		}
+		if err := state.Save(statePath); err != nil {
+			fmt.Fprintf(os.Stderr, "cannot save state to %s: %v\n", statePath, err)
+		}
+	}
+
+	tags, err := source.Tags(ctx).All(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot list tags of %s: %v\n", repoName, err)
+		os.Exit(1)
+	}
+	sourceManifests, err := source.Manifests(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot access source manifests: %v\n", err)
+		os.Exit(1)
+	}
+
+	type taggedManifest struct {
+		tag      string
+		manifest distribution.Manifest
+	}
+	manifests := []taggedManifest{}
+	blobs := []digest.Digest{}
+	seen := map[digest.Digest]struct{}{}
+	for _, tag := range tags {
+		desc, err := source.Tags(ctx).Get(ctx, tag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cannot resolve %s:%s: %v\n", repoName, tag, err)
+			os.Exit(1)
+		}
+		manifest, err := sourceManifests.Get(ctx, desc.Digest)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cannot fetch the manifest of %s:%s: %v\n", repoName, tag, err)
+			os.Exit(1)
+		}
+		for _, ref := range manifest.References() {
+			if _, ok := seen[ref.Digest]; !ok {
+				seen[ref.Digest] = struct{}{}
+				blobs = append(blobs, ref.Digest)
+			}
+		}
+		manifests = append(manifests, taggedManifest{tag: tag, manifest: manifest})
+	}
+
+	sync := replication.NewBlobSync(source, target, state)
+	sync.Concurrency = concurrency
+	missing, err := sync.Missing(ctx, blobs)
+	if err != nil {
+		saveState()
+		fmt.Fprintf(os.Stderr, "cannot compute missing blobs: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%d tags reference %d blobs, %d missing on the target\n", len(tags), len(blobs), len(missing))
+
+	if err := sync.Copy(ctx, missing); err != nil {
+		saveState()
+		fmt.Fprintf(os.Stderr, "copy failed, rerun with the same -state to resume: %v\n", err)
+		os.Exit(1)
+	}
+	saveState()
+
+	targetManifests, err := target.Manifests(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot access target manifests: %v\n", err)
+		os.Exit(1)
+	}
+	for _, entry := range manifests {
+		if _, err := targetManifests.Put(ctx, entry.manifest, distribution.WithTag(entry.tag)); err != nil {
+			fmt.Fprintf(os.Stderr, "cannot push %s:%s: %v\n", repoName, entry.tag, err)
+			os.Exit(1)
+		}
+		fmt.Printf("synced %s:%s\n", repoName, entry.tag)
+	}
+}
+
+// syncRepository returns a client for the repository on the given registry.
+func syncRepository(ctx context.Context, url, token, repoName string) (distribution.Repository, error) {
+	named, err := reference.ParseNamed(repoName)
+	if err != nil {
+		return nil, err
+	}
+	return registryclient.NewRepository(ctx, named, url, &syncTransport{token: token, base: http.DefaultTransport})
+}
@@ -568,3 +568,30 @@ func HasKubernetesAPIVersion(config KubernetesMasterConfig, groupVersion unversi
 	enabledVersions := GetEnabledAPIVersionsForGroup(config, groupVersion.Group)
 	return sets.NewString(enabledVersions...).Has(groupVersion.Version)
 }
+
+// MatchesRegistryLocations returns true if the given registry host matches one of the locations.
+// The leftmost label of a location's domain name may be the wildcard "*", matching any single
+// subdomain. The insecure flag of the reference must be permitted by the matching location.
+func MatchesRegistryLocations(locations []RegistryLocation, host string, insecure bool) bool {
+	for _, location := range locations {
+		if !matchesRegistryDomain(location.DomainName, host) {
+			continue
+		}
+		if insecure && !location.Insecure {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func matchesRegistryDomain(pattern, host string) bool {
+	if pattern == host {
+		return true
+	}
+	if !strings.HasPrefix(pattern, "*.") {
+		return false
+	}
+	parts := strings.SplitN(host, ".", 2)
+	return len(parts) == 2 && parts[1] == strings.TrimPrefix(pattern, "*.")
+}
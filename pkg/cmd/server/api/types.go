@@ -351,6 +351,36 @@ type ImagePolicyConfig struct {
 	// MaxScheduledImageImportsPerMinute is the maximum number of image streams that will be imported in the background per minute.
 	// The default value is 60. Set to -1 for unlimited.
 	MaxScheduledImageImportsPerMinute int
+	// ImportRegistryPolicy controls which external registries image streams may import from. If nil,
+	// imports are allowed from any registry.
+	ImportRegistryPolicy *ImportRegistryPolicy
+	// ExternalRegistryHostname is the public hostname (optionally with a port) the integrated
+	// registry is exposed on, typically the host of its route. When set, generated service
+	// account dockercfg secrets carry credentials for this hostname in addition to the internal
+	// service locations, so builds and external tools can push to the public name.
+	ExternalRegistryHostname string
+}
+
+// ImportRegistryPolicy limits the external registries image streams may import from.
+type ImportRegistryPolicy struct {
+	// Allowed, if non-empty, restricts imports to registries matching one of these locations.
+	Allowed []RegistryLocation
+	// Denied lists registries that imports may never be performed from. Denied entries take
+	// precedence over Allowed.
+	Denied []RegistryLocation
+	// MaxImportsPerMinute is the maximum number of imports performed against a single external
+	// registry per minute. The default value is -1 for unlimited.
+	MaxImportsPerMinute int
+}
+
+// RegistryLocation identifies a registry by the domain name used in image references.
+type RegistryLocation struct {
+	// DomainName specifies a domain name for the registry. It may contain a port. The leftmost
+	// label may be the wildcard "*" to match any single subdomain.
+	DomainName string
+	// Insecure indicates whether the registry may be contacted without TLS. By default the
+	// registry is required to be secure.
+	Insecure bool
 }
 
 type ProjectConfig struct {
@@ -165,6 +165,9 @@ func addDefaultingFuncs(scheme *runtime.Scheme) {
 			if obj.ScheduledImageImportMinimumIntervalSeconds == 0 {
 				obj.ScheduledImageImportMinimumIntervalSeconds = 15 * 60
 			}
+			if obj.ImportRegistryPolicy != nil && obj.ImportRegistryPolicy.MaxImportsPerMinute == 0 {
+				obj.ImportRegistryPolicy.MaxImportsPerMinute = -1
+			}
 		},
 		func(obj *DNSConfig) {
 			if len(obj.BindNetwork) == 0 {
@@ -423,6 +423,29 @@ func ValidateImagePolicyConfig(config api.ImagePolicyConfig, fldPath *field.Path
 	if config.MaxScheduledImageImportsPerMinute == 0 || config.MaxScheduledImageImportsPerMinute < -1 {
 		errs = append(errs, field.Invalid(fldPath.Child("maxScheduledImageImportsPerMinute"), config.MaxScheduledImageImportsPerMinute, "must be a positive integer or -1"))
 	}
+	if config.ImportRegistryPolicy != nil {
+		policyPath := fldPath.Child("importRegistryPolicy")
+		if config.ImportRegistryPolicy.MaxImportsPerMinute == 0 || config.ImportRegistryPolicy.MaxImportsPerMinute < -1 {
+			errs = append(errs, field.Invalid(policyPath.Child("maxImportsPerMinute"), config.ImportRegistryPolicy.MaxImportsPerMinute, "must be a positive integer or -1"))
+		}
+		errs = append(errs, validateRegistryLocations(config.ImportRegistryPolicy.Allowed, policyPath.Child("allowed"))...)
+		errs = append(errs, validateRegistryLocations(config.ImportRegistryPolicy.Denied, policyPath.Child("denied"))...)
+	}
+	if hostname := config.ExternalRegistryHostname; len(hostname) > 0 {
+		if strings.Contains(hostname, "://") || strings.Contains(hostname, "/") {
+			errs = append(errs, field.Invalid(fldPath.Child("externalRegistryHostname"), hostname, "must be a hostname, optionally with a port, without a scheme or path"))
+		}
+	}
+	return errs
+}
+
+func validateRegistryLocations(locations []api.RegistryLocation, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	for i, location := range locations {
+		if len(location.DomainName) == 0 {
+			errs = append(errs, field.Required(fldPath.Index(i).Child("domainName"), ""))
+		}
+	}
 	return errs
 }
 
@@ -57,12 +57,14 @@ import (
 	imageimporter "github.com/openshift/origin/pkg/image/importer"
 	"github.com/openshift/origin/pkg/image/registry/image"
 	imageetcd "github.com/openshift/origin/pkg/image/registry/image/etcd"
+	imagepromotionpolicyetcd "github.com/openshift/origin/pkg/image/registry/imagepromotionpolicy/etcd"
 	"github.com/openshift/origin/pkg/image/registry/imagesecret"
 	"github.com/openshift/origin/pkg/image/registry/imagesignature"
 	"github.com/openshift/origin/pkg/image/registry/imagestream"
 	imagestreametcd "github.com/openshift/origin/pkg/image/registry/imagestream/etcd"
 	"github.com/openshift/origin/pkg/image/registry/imagestreamimage"
 	"github.com/openshift/origin/pkg/image/registry/imagestreamimport"
+	"github.com/openshift/origin/pkg/image/registry/imagestreammanifestlist"
 	"github.com/openshift/origin/pkg/image/registry/imagestreammapping"
 	"github.com/openshift/origin/pkg/image/registry/imagestreamtag"
 	oauthapi "github.com/openshift/origin/pkg/oauth/api"
@@ -474,10 +476,19 @@ func (c *MasterConfig) GetRestStorage() map[string]rest.Storage {
 	imageSignatureStorage := imagesignature.NewREST(c.PrivilegedLoopbackOpenShiftClient.Images())
 	imageStreamLimitVerifier := imageadmission.NewLimitVerifier(c.KubeClient())
 	imageStreamSecretsStorage := imagesecret.NewREST(c.ImageStreamSecretClient())
-	imageStreamStorage, imageStreamStatusStorage, internalImageStreamStorage, err := imagestreametcd.NewREST(c.RESTOptionsGetter, imageapi.DefaultRegistryFunc(defaultRegistryFunc), subjectAccessReviewRegistry, imageStreamLimitVerifier)
+	// alternative pull locations published in image stream status; today only the external
+	// registry hostname, when one is configured
+	registryVariantsFunc := imageapi.RegistryVariantsFunc(func() map[string]string {
+		if hostname := c.Options.ImagePolicyConfig.ExternalRegistryHostname; len(hostname) > 0 {
+			return map[string]string{"external": hostname}
+		}
+		return nil
+	})
+	imageStreamStorage, imageStreamStatusStorage, internalImageStreamStorage, err := imagestreametcd.NewREST(c.RESTOptionsGetter, imageapi.DefaultRegistryFunc(defaultRegistryFunc), registryVariantsFunc, subjectAccessReviewRegistry, imageStreamLimitVerifier)
 	checkStorageErr(err)
 	imageStreamRegistry := imagestream.NewRegistry(imageStreamStorage, imageStreamStatusStorage, internalImageStreamStorage)
 	imageStreamMappingStorage := imagestreammapping.NewREST(imageRegistry, imageStreamRegistry, imageapi.DefaultRegistryFunc(defaultRegistryFunc))
+	imageStreamManifestListStorage := imagestreammanifestlist.NewREST(imageRegistry, imageStreamRegistry)
 	imageStreamTagStorage := imagestreamtag.NewREST(imageRegistry, imageStreamRegistry)
 	imageStreamTagRegistry := imagestreamtag.NewRegistry(imageStreamTagStorage)
 	importerFn := func(r importer.RepositoryRetriever) imageimporter.Interface {
@@ -486,9 +497,11 @@ func (c *MasterConfig) GetRestStorage() map[string]rest.Storage {
 	importerDockerClientFn := func() dockerregistry.Client {
 		return dockerregistry.NewClient(20*time.Second, false)
 	}
-	imageStreamImportStorage := imagestreamimport.NewREST(importerFn, imageStreamRegistry, internalImageStreamStorage, imageStorage, c.ImageStreamImportSecretClient(), importTransport, insecureImportTransport, importerDockerClientFn)
+	imageStreamImportStorage := imagestreamimport.NewREST(importerFn, imageStreamRegistry, internalImageStreamStorage, imageStorage, c.ImageStreamImportSecretClient(), importTransport, insecureImportTransport, importerDockerClientFn, c.Options.ImagePolicyConfig.ImportRegistryPolicy)
 	imageStreamImageStorage := imagestreamimage.NewREST(imageRegistry, imageStreamRegistry)
 	imageStreamImageRegistry := imagestreamimage.NewRegistry(imageStreamImageStorage)
+	imagePromotionPolicyStorage, err := imagepromotionpolicyetcd.NewREST(c.RESTOptionsGetter)
+	checkStorageErr(err)
 
 	buildGenerator := &buildgenerator.BuildGenerator{
 		Client: buildgenerator.Client{
@@ -562,15 +575,18 @@ func (c *MasterConfig) GetRestStorage() map[string]rest.Storage {
 	checkStorageErr(err)
 
 	storage := map[string]rest.Storage{
-		"images":               imageStorage,
-		"imagesignatures":      imageSignatureStorage,
-		"imageStreams/secrets": imageStreamSecretsStorage,
-		"imageStreams":         imageStreamStorage,
-		"imageStreams/status":  imageStreamStatusStorage,
-		"imageStreamImports":   imageStreamImportStorage,
-		"imageStreamImages":    imageStreamImageStorage,
-		"imageStreamMappings":  imageStreamMappingStorage,
-		"imageStreamTags":      imageStreamTagStorage,
+		"images":                   imageStorage,
+		"imagesignatures":          imageSignatureStorage,
+		"imageStreams/secrets":     imageStreamSecretsStorage,
+		"imageStreams":             imageStreamStorage,
+		"imageStreams/status":      imageStreamStatusStorage,
+		"imageStreamImports":       imageStreamImportStorage,
+		"imageStreamImages":        imageStreamImageStorage,
+		"imageStreamImages/config": imagestreamimage.NewConfigREST(imageStreamImageStorage),
+		"imageStreamMappings":      imageStreamMappingStorage,
+		"imageStreamManifestLists": imageStreamManifestListStorage,
+		"imageStreamTags":          imageStreamTagStorage,
+		"imagePromotionPolicies":   imagePromotionPolicyStorage,
 
 		"deploymentConfigs":          deployConfigStorage,
 		"deploymentConfigs/scale":    deployConfigScaleStorage,
@@ -165,6 +165,9 @@ func (c *MasterConfig) RunServiceAccountPullSecretsControllers() {
 		DockercfgController:  dockercfgController,
 		DockerURLsIntialized: dockerURLsIntialized,
 	}
+	if hostname := c.Options.ImagePolicyConfig.ExternalRegistryHostname; len(hostname) > 0 {
+		dockerRegistryControllerOptions.AdditionalRegistryURLs = []string{hostname}
+	}
 	go serviceaccountcontrollers.NewDockerRegistryServiceController(c.KubeClient(), dockerRegistryControllerOptions).Run(10, make(chan struct{}))
 }
 
@@ -407,6 +410,28 @@ func (c *MasterConfig) RunImageImportController() {
 	}
 }
 
+// RunImageTagExpirationController starts the controller that removes image stream tags whose
+// time to live annotation has lapsed.
+func (c *MasterConfig) RunImageTagExpirationController() {
+	osclient := c.ImageImportControllerClient()
+	factory := imagecontroller.TagExpirationControllerFactory{
+		Client:         osclient,
+		ResyncInterval: 10 * time.Minute,
+	}
+	factory.Create().Run()
+}
+
+// RunImagePromotionController starts the controller that retags images to their promotion
+// targets once the conditions of an ImagePromotionPolicy are met.
+func (c *MasterConfig) RunImagePromotionController() {
+	osclient := c.ImageImportControllerClient()
+	factory := imagecontroller.ImagePromotionControllerFactory{
+		Client:         osclient,
+		ResyncInterval: 2 * time.Minute,
+	}
+	factory.Create().Run()
+}
+
 // RunSecurityAllocationController starts the security allocation controller process.
 func (c *MasterConfig) RunSecurityAllocationController() {
 	alloc := c.Options.ProjectConfig.SecurityAllocator
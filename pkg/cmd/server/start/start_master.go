@@ -655,6 +655,8 @@ func startControllers(oc *origin.MasterConfig, kc *kubernetes.MasterConfig) erro
 	oc.RunDeploymentTriggerController()
 	oc.RunDeploymentImageChangeTriggerController()
 	oc.RunImageImportController()
+	oc.RunImageTagExpirationController()
+	oc.RunImagePromotionController()
 	oc.RunOriginNamespaceController()
 	oc.RunSDNController()
 
@@ -0,0 +1,293 @@
+// Package client is a small helper library for talking to the integrated registry. It handles
+// OpenShift token auth including bearer challenge negotiation, retries requests the registry
+// answers with a temporary 503 (for example while pullthrough warms up), and surfaces registry
+// errors as typed values. It is shared by the pruner, the importer, CLI commands and external
+// automation.
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Config configures a Client.
+type Config struct {
+	// URL is the base URL of the integrated registry, e.g. "https://registry.example.com:5000".
+	URL string
+	// Token is the OpenShift API token presented to the registry and its token endpoint.
+	Token string
+	// Transport, when set, replaces http.DefaultTransport (e.g. for custom CA bundles).
+	Transport http.RoundTripper
+	// Retries is how often a request answered with 503 is retried; default 2.
+	Retries int
+}
+
+// Client talks to one integrated registry.
+type Client struct {
+	config Config
+	client *http.Client
+
+	// bearer is the negotiated registry bearer token per scope.
+	bearer map[string]string
+}
+
+// New returns a client for the registry at config.URL.
+func New(config Config) *Client {
+	if config.Retries == 0 {
+		config.Retries = 2
+	}
+	transport := config.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Client{
+		config: config,
+		client: &http.Client{Transport: transport},
+		bearer: map[string]string{},
+	}
+}
+
+// Error is a typed registry error.
+type Error struct {
+	// Status is the HTTP status of the response carrying the error.
+	Status int
+	// Code is the registry error code, e.g. "MANIFEST_UNKNOWN".
+	Code string
+	// Message is the human readable message from the registry.
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("registry error %s (HTTP %d): %s", e.Code, e.Status, e.Message)
+}
+
+// IsUnauthorized returns true when err says the credentials were rejected.
+func IsUnauthorized(err error) bool {
+	registryErr, ok := err.(*Error)
+	return ok && registryErr.Status == http.StatusUnauthorized
+}
+
+// IsNotFound returns true when err says the named resource does not exist.
+func IsNotFound(err error) bool {
+	registryErr, ok := err.(*Error)
+	return ok && registryErr.Status == http.StatusNotFound
+}
+
+// IsUnavailable returns true when err says the registry temporarily refused the request.
+func IsUnavailable(err error) bool {
+	registryErr, ok := err.(*Error)
+	return ok && registryErr.Status == http.StatusServiceUnavailable
+}
+
+// errorFromResponse converts an unsuccessful registry response to an *Error.
+func errorFromResponse(resp *http.Response, body []byte) error {
+	registryErr := &Error{Status: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	var payload struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil && len(payload.Errors) > 0 {
+		registryErr.Code = payload.Errors[0].Code
+		registryErr.Message = payload.Errors[0].Message
+	}
+	return registryErr
+}
+
+// Do performs the request against the registry: it attaches credentials, answers bearer
+// challenges by fetching a token from the advertised realm, and retries requests the registry
+// temporarily refused. The response body is returned fully read.
+func (c *Client) Do(method, path string, body []byte) (*http.Response, []byte, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.config.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+
+		resp, respBody, err := c.once(method, path, body, "")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized {
+			token, err := c.negotiate(resp)
+			if err != nil {
+				return nil, nil, err
+			}
+			resp, respBody, err = c.once(method, path, body, token)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			lastErr = errorFromResponse(resp, respBody)
+			continue
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+			return nil, nil, errorFromResponse(resp, respBody)
+		}
+		return resp, respBody, nil
+	}
+	return nil, nil, lastErr
+}
+
+func (c *Client) once(method, path string, body []byte, bearer string) (*http.Response, []byte, error) {
+	var reader *bytes.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.config.URL, "/")+path, reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case len(bearer) > 0:
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	case len(c.config.Token) > 0:
+		req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, respBody, nil
+}
+
+// negotiate answers a bearer challenge by requesting a token from the advertised realm with the
+// configured OpenShift token.
+func (c *Client) negotiate(resp *http.Response) (string, error) {
+	challenge := resp.Header.Get("WWW-Authenticate")
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return "", errorFromResponse(resp, nil)
+	}
+	params := parseChallenge(challenge[len("bearer "):])
+	realm := params["realm"]
+	if len(realm) == 0 {
+		return "", fmt.Errorf("bearer challenge carries no realm: %q", challenge)
+	}
+
+	if token, ok := c.bearer[params["scope"]]; ok {
+		return token, nil
+	}
+
+	realmURL, err := url.Parse(realm)
+	if err != nil {
+		return "", err
+	}
+	query := realmURL.Query()
+	if service := params["service"]; len(service) > 0 {
+		query.Set("service", service)
+	}
+	if scope := params["scope"]; len(scope) > 0 {
+		query.Set("scope", scope)
+	}
+	realmURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequest("GET", realmURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if len(c.config.Token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	}
+	tokenResp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	body, err := ioutil.ReadAll(tokenResp.Body)
+	tokenResp.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	if tokenResp.StatusCode != http.StatusOK {
+		return "", errorFromResponse(tokenResp, body)
+	}
+
+	var payload struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || len(payload.Token) == 0 {
+		return "", fmt.Errorf("token endpoint %s returned no token", realm)
+	}
+	c.bearer[params["scope"]] = payload.Token
+	return payload.Token, nil
+}
+
+// parseChallenge splits the parameter list of a bearer challenge.
+func parseChallenge(params string) map[string]string {
+	parsed := map[string]string{}
+	for _, part := range strings.Split(params, ",") {
+		pair := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(pair) != 2 {
+			continue
+		}
+		parsed[pair[0]] = strings.Trim(pair[1], `"`)
+	}
+	return parsed
+}
+
+// Tags lists the tags of the repository.
+func (c *Client) Tags(repository string) ([]string, error) {
+	_, body, err := c.Do("GET", fmt.Sprintf("/v2/%s/tags/list", repository), nil)
+	if err != nil {
+		return nil, err
+	}
+	var payload struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	return payload.Tags, nil
+}
+
+// ResolveTag resolves a tag to its manifest digest without downloading the manifest.
+func (c *Client) ResolveTag(repository, tag string) (string, error) {
+	resp, _, err := c.Do("GET", fmt.Sprintf("/openshift/resolve/%s?tag=%s", repository, url.QueryEscape(tag)), nil)
+	if err != nil {
+		return "", err
+	}
+	dgst := resp.Header.Get("Docker-Content-Digest")
+	if len(dgst) == 0 {
+		return "", fmt.Errorf("no digest resolved for %s:%s", repository, tag)
+	}
+	return dgst, nil
+}
+
+// GetManifest downloads a manifest by tag or digest, returning its body, media type and the
+// digest reported by the registry.
+func (c *Client) GetManifest(repository, reference string) ([]byte, string, string, error) {
+	resp, body, err := c.Do("GET", fmt.Sprintf("/v2/%s/manifests/%s", repository, reference), nil)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return body, resp.Header.Get("Content-Type"), resp.Header.Get("Docker-Content-Digest"), nil
+}
+
+// BlobExists checks whether the repository can serve the blob.
+func (c *Client) BlobExists(repository, dgst string) (bool, error) {
+	_, _, err := c.Do("HEAD", fmt.Sprintf("/v2/%s/blobs/%s", repository, dgst), nil)
+	if err != nil {
+		if IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestChallengeNegotiation(t *testing.T) {
+	const bearer = "negotiated-token"
+
+	var tokenServer *httptest.Server
+	tokenServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer api-token" {
+			t.Errorf("token endpoint did not receive the API token: %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Query().Get("scope") != "repository:user/app:pull" {
+			t.Errorf("unexpected scope: %q", r.URL.Query().Get("scope"))
+		}
+		fmt.Fprintf(w, `{"token": %q}`, bearer)
+	}))
+	defer tokenServer.Close()
+
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+bearer {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm=%q,service="registry",scope="repository:user/app:pull"`, tokenServer.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"name": "user/app", "tags": ["latest", "1.0"]}`)
+	}))
+	defer registry.Close()
+
+	c := New(Config{URL: registry.URL, Token: "api-token"})
+	tags, err := c.Tags("user/app")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tags) != 2 || tags[0] != "latest" {
+		t.Errorf("unexpected tags: %v", tags)
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"errors": [{"code": "MANIFEST_UNKNOWN", "message": "manifest unknown"}]}`)
+	}))
+	defer registry.Close()
+
+	c := New(Config{URL: registry.URL})
+	_, _, _, err := c.GetManifest("user/app", "latest")
+	if !IsNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+	registryErr := err.(*Error)
+	if registryErr.Code != "MANIFEST_UNKNOWN" {
+		t.Errorf("unexpected code: %q", registryErr.Code)
+	}
+}
@@ -0,0 +1,238 @@
+// Package replication copies image content between docker registries. It computes the delta of
+// blobs missing on the target with parallel HEAD checks and copies only those, keeping resumable
+// state so that repeated replication of large image streams does not restart from scratch.
+package replication
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/digest"
+)
+
+// defaultConcurrency is the number of blob existence checks and copies performed in parallel.
+const defaultConcurrency = 4
+
+// SyncState records the digests already known to exist on the target so repeated runs skip them
+// without contacting the registry. It is safe for concurrent use.
+type SyncState struct {
+	mu sync.Mutex
+	// Completed holds digests confirmed present on the target.
+	Completed map[digest.Digest]struct{}
+}
+
+// NewSyncState returns an empty sync state.
+func NewSyncState() *SyncState {
+	return &SyncState{Completed: make(map[digest.Digest]struct{})}
+}
+
+// LoadSyncState reads a previously saved state from path. A missing file yields an empty state so
+// callers do not need to special case the first run.
+func LoadSyncState(path string) (*SyncState, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewSyncState(), nil
+		}
+		return nil, err
+	}
+	completed := []digest.Digest{}
+	if err := json.Unmarshal(data, &completed); err != nil {
+		return nil, err
+	}
+	state := NewSyncState()
+	for _, dgst := range completed {
+		state.Completed[dgst] = struct{}{}
+	}
+	return state, nil
+}
+
+// Save writes the state to path. It may be called after every completed blob to make the sync
+// resumable at blob granularity.
+func (s *SyncState) Save(path string) error {
+	s.mu.Lock()
+	completed := make([]digest.Digest, 0, len(s.Completed))
+	for dgst := range s.Completed {
+		completed = append(completed, dgst)
+	}
+	s.mu.Unlock()
+
+	data, err := json.Marshal(completed)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+func (s *SyncState) has(dgst digest.Digest) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.Completed[dgst]
+	return ok
+}
+
+func (s *SyncState) add(dgst digest.Digest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Completed[dgst] = struct{}{}
+}
+
+// BlobSync copies blobs from a source repository to a target repository.
+type BlobSync struct {
+	source distribution.Repository
+	target distribution.Repository
+	state  *SyncState
+	// Concurrency limits parallel HEAD checks and blob copies. Defaults to 4.
+	Concurrency int
+}
+
+// NewBlobSync returns a sync between the given repositories. The state may be shared between
+// runs; pass NewSyncState() to start from scratch.
+func NewBlobSync(source, target distribution.Repository, state *SyncState) *BlobSync {
+	if state == nil {
+		state = NewSyncState()
+	}
+	return &BlobSync{source: source, target: target, state: state, Concurrency: defaultConcurrency}
+}
+
+// Missing returns the subset of digests not present on the target. Existence is determined with
+// parallel stat (HEAD) requests; digests recorded in the sync state are skipped entirely.
+func (s *BlobSync) Missing(ctx context.Context, digests []digest.Digest) ([]digest.Digest, error) {
+	targetBlobs := s.target.Blobs(ctx)
+
+	type result struct {
+		dgst    digest.Digest
+		missing bool
+		err     error
+	}
+
+	work := make(chan digest.Digest)
+	results := make(chan result, len(digests))
+	wg := sync.WaitGroup{}
+	for i := 0; i < s.concurrency(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for dgst := range work {
+				_, err := targetBlobs.Stat(ctx, dgst)
+				switch err {
+				case nil:
+					s.state.add(dgst)
+					results <- result{dgst: dgst}
+				case distribution.ErrBlobUnknown:
+					results <- result{dgst: dgst, missing: true}
+				default:
+					results <- result{dgst: dgst, err: err}
+				}
+			}
+		}()
+	}
+
+	checked := 0
+	for _, dgst := range digests {
+		if s.state.has(dgst) {
+			continue
+		}
+		work <- dgst
+		checked++
+	}
+	close(work)
+	wg.Wait()
+
+	missing := []digest.Digest{}
+	var firstErr error
+	for i := 0; i < checked; i++ {
+		r := <-results
+		switch {
+		case r.err != nil:
+			context.GetLogger(ctx).Errorf("failed to check blob %s on target: %v", r.dgst, r.err)
+			if firstErr == nil {
+				firstErr = r.err
+			}
+		case r.missing:
+			missing = append(missing, r.dgst)
+		}
+	}
+	return missing, firstErr
+}
+
+// Copy transfers the given blobs from the source to the target, recording each completed digest
+// in the sync state. Blobs already recorded as completed are skipped.
+func (s *BlobSync) Copy(ctx context.Context, digests []digest.Digest) error {
+	sourceBlobs := s.source.Blobs(ctx)
+	targetBlobs := s.target.Blobs(ctx)
+
+	work := make(chan digest.Digest)
+	errs := make(chan error, len(digests))
+	wg := sync.WaitGroup{}
+	for i := 0; i < s.concurrency(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for dgst := range work {
+				if err := copyBlob(ctx, sourceBlobs, targetBlobs, dgst); err != nil {
+					context.GetLogger(ctx).Errorf("failed to copy blob %s: %v", dgst, err)
+					errs <- err
+					continue
+				}
+				s.state.add(dgst)
+			}
+		}()
+	}
+
+	for _, dgst := range digests {
+		if s.state.has(dgst) {
+			continue
+		}
+		work <- dgst
+	}
+	close(work)
+	wg.Wait()
+
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return nil
+	}
+}
+
+func (s *BlobSync) concurrency() int {
+	if s.Concurrency > 0 {
+		return s.Concurrency
+	}
+	return defaultConcurrency
+}
+
+// copyBlob streams a single blob from source to target, verifying the digest on commit.
+func copyBlob(ctx context.Context, source, target distribution.BlobStore, dgst digest.Digest) error {
+	desc, err := source.Stat(ctx, dgst)
+	if err != nil {
+		return err
+	}
+
+	reader, err := source.Open(ctx, dgst)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	writer, err := target.Create(ctx)
+	if err != nil {
+		return err
+	}
+	defer writer.Cancel(ctx)
+
+	if _, err := io.Copy(writer, reader); err != nil {
+		return err
+	}
+	if _, err := writer.Commit(ctx, desc); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package replication
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/digest"
+)
+
+// fakeBlobStore answers Stat from a fixed set of digests, standing in for a target registry.
+type fakeBlobStore struct {
+	distribution.BlobStore
+
+	present map[digest.Digest]struct{}
+
+	mu       sync.Mutex
+	statted  []digest.Digest
+	statErrs map[digest.Digest]error
+}
+
+func (f *fakeBlobStore) Stat(ctx context.Context, dgst digest.Digest) (distribution.Descriptor, error) {
+	f.mu.Lock()
+	f.statted = append(f.statted, dgst)
+	f.mu.Unlock()
+	if err, ok := f.statErrs[dgst]; ok {
+		return distribution.Descriptor{}, err
+	}
+	if _, ok := f.present[dgst]; ok {
+		return distribution.Descriptor{Digest: dgst}, nil
+	}
+	return distribution.Descriptor{}, distribution.ErrBlobUnknown
+}
+
+// fakeRepository exposes only the blob service the sync uses.
+type fakeRepository struct {
+	distribution.Repository
+
+	blobs distribution.BlobStore
+}
+
+func (f *fakeRepository) Blobs(ctx context.Context) distribution.BlobStore { return f.blobs }
+
+func sortedDigests(digests []digest.Digest) []string {
+	names := []string{}
+	for _, dgst := range digests {
+		names = append(names, dgst.String())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestBlobSyncMissing(t *testing.T) {
+	present := digest.FromBytes([]byte("present"))
+	absent := digest.FromBytes([]byte("absent"))
+	completed := digest.FromBytes([]byte("completed"))
+
+	target := &fakeBlobStore{present: map[digest.Digest]struct{}{present: {}}}
+	state := NewSyncState()
+	state.add(completed)
+
+	sync := NewBlobSync(&fakeRepository{}, &fakeRepository{blobs: target}, state)
+	missing, err := sync.Missing(context.Background(), []digest.Digest{present, absent, completed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != 1 || missing[0] != absent {
+		t.Fatalf("unexpected delta: %v", missing)
+	}
+
+	// digests recorded in the state are skipped without contacting the target
+	for _, dgst := range target.statted {
+		if dgst == completed {
+			t.Fatal("expected the completed digest not to be statted")
+		}
+	}
+	// blobs confirmed present are recorded so the next run skips them too
+	if !state.has(present) {
+		t.Fatal("expected the present digest to be recorded in the state")
+	}
+
+	// stat failures surface as an error instead of silently treating the blob as present
+	target.statErrs = map[digest.Digest]error{absent: fmt.Errorf("target unavailable")}
+	if _, err := sync.Missing(context.Background(), []digest.Digest{absent}); err == nil {
+		t.Fatal("expected a stat failure to be returned")
+	}
+}
+
+func TestSyncStateRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replication")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+
+	// a missing file yields an empty state for the first run
+	state, err := LoadSyncState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Completed) != 0 {
+		t.Fatalf("expected an empty state, got %v", state.Completed)
+	}
+
+	first := digest.FromBytes([]byte("first"))
+	second := digest.FromBytes([]byte("second"))
+	state.add(first)
+	state.add(second)
+	if err := state.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadSyncState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := []digest.Digest{}
+	for dgst := range loaded.Completed {
+		saved = append(saved, dgst)
+	}
+	if expected, actual := sortedDigests([]digest.Digest{first, second}), sortedDigests(saved); len(actual) != 2 || actual[0] != expected[0] || actual[1] != expected[1] {
+		t.Fatalf("expected %v after the round trip, got %v", expected, actual)
+	}
+
+	// a corrupt state file is an error, not a silent restart from scratch
+	if err := ioutil.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSyncState(path); err == nil {
+		t.Fatal("expected loading a corrupt state file to fail")
+	}
+}
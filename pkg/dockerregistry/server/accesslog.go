@@ -0,0 +1,170 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/reference"
+)
+
+// Site log analytics stacks already know how to ingest standard web log formats. These variables
+// enable an access log carrying the registry-specific fields (repository, digest, user) as
+// extensions of those formats, so registry traffic can be analyzed without custom parsers.
+const (
+	// AccessLogPathEnvVar is the file the access log is appended to. Empty disables the log.
+	AccessLogPathEnvVar = "REGISTRY_ACCESS_LOG_PATH"
+	// AccessLogFormatEnvVar selects the format: "clf" (Common Log Format with the extension
+	// fields appended, the default) or "w3c" (W3C extended log file format).
+	AccessLogFormatEnvVar = "REGISTRY_ACCESS_LOG_FORMAT"
+)
+
+const (
+	accessLogFormatCLF = "clf"
+	accessLogFormatW3C = "w3c"
+)
+
+var (
+	// accessLogRepositoryRegexp extracts the repository name from a v2 API path.
+	accessLogRepositoryRegexp = regexp.MustCompile(`^/v2/(` + reference.NameRegexp.String() + `)/(?:manifests|blobs|tags)/`)
+	// accessLogDigestRegexp extracts the first digest appearing in a request path.
+	accessLogDigestRegexp = regexp.MustCompile(digest.DigestRegexp.String())
+)
+
+// NewAccessLogHandler returns handler wrapped with an access log writer when one is configured
+// by environment, and handler unchanged otherwise.
+func NewAccessLogHandler(handler http.Handler) http.Handler {
+	path := os.Getenv(AccessLogPathEnvVar)
+	if len(path) == 0 {
+		return handler
+	}
+	format := os.Getenv(AccessLogFormatEnvVar)
+	switch format {
+	case "":
+		format = accessLogFormatCLF
+	case accessLogFormatCLF, accessLogFormatW3C:
+	default:
+		log.Errorf("ignoring invalid %s=%q", AccessLogFormatEnvVar, format)
+		format = accessLogFormatCLF
+	}
+
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		log.Errorf("unable to open access log %s: %v", path, err)
+		return handler
+	}
+	logger := &accessLogger{out: out, format: format}
+	if format == accessLogFormatW3C {
+		logger.writeW3CHeader()
+	}
+	log.Infof("writing %s access log to %s", format, path)
+
+	return &accessLogHandler{handler: handler, logger: logger}
+}
+
+type accessLogHandler struct {
+	handler http.Handler
+	logger  *accessLogger
+}
+
+func (h *accessLogHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	recorder := &accessLogResponseWriter{ResponseWriter: w, status: http.StatusOK}
+	start := time.Now()
+	h.handler.ServeHTTP(recorder, req)
+	h.logger.log(req, recorder.status, recorder.size, start)
+}
+
+// accessLogResponseWriter records the status and size of the response passing through it.
+type accessLogResponseWriter struct {
+	http.ResponseWriter
+
+	status int
+	size   int64
+}
+
+func (w *accessLogResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessLogResponseWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+type accessLogger struct {
+	mu     sync.Mutex
+	out    *os.File
+	format string
+}
+
+func (l *accessLogger) writeW3CHeader() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.out, "#Version: 1.0\n")
+	fmt.Fprintf(l.out, "#Date: %s\n", time.Now().UTC().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(l.out, "#Fields: date time c-ip cs-method cs-uri-stem sc-status sc-bytes time-taken x-repository x-digest x-user\n")
+}
+
+func (l *accessLogger) log(req *http.Request, status int, size int64, start time.Time) {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	repo := accessLogField(accessLogRepositoryPath(req.URL.Path))
+	dgst := accessLogField(accessLogDigestRegexp.FindString(req.URL.Path))
+	user := accessLogUser(req)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch l.format {
+	case accessLogFormatW3C:
+		fmt.Fprintf(l.out, "%s %s %s %s %d %d %.3f %s %s %s\n",
+			start.UTC().Format("2006-01-02 15:04:05"), host, req.Method, req.URL.Path,
+			status, size, time.Since(start).Seconds(), repo, dgst, user)
+	default:
+		fmt.Fprintf(l.out, "%s - %s [%s] %q %d %d %q %q\n",
+			host, user, start.Format("02/Jan/2006:15:04:05 -0700"),
+			fmt.Sprintf("%s %s %s", req.Method, req.RequestURI, req.Proto),
+			status, size, repo, dgst)
+	}
+}
+
+// accessLogRepositoryPath returns the repository name addressed by path, if any.
+func accessLogRepositoryPath(path string) string {
+	if m := accessLogRepositoryRegexp.FindStringSubmatch(path); m != nil {
+		return m[1]
+	}
+	// uploads have no trailing path segment after the repository name
+	if strings.HasPrefix(path, "/v2/") {
+		if i := strings.Index(path, "/blobs/uploads"); i > len("/v2/") {
+			return path[len("/v2/"):i]
+		}
+	}
+	return ""
+}
+
+// accessLogUser returns the authenticated user name when the client used basic credentials.
+// Bearer-token requests carry no user name at this layer.
+func accessLogUser(req *http.Request) string {
+	if user, _, ok := req.BasicAuth(); ok {
+		return user
+	}
+	return "-"
+}
+
+func accessLogField(value string) string {
+	if len(value) == 0 {
+		return "-"
+	}
+	return value
+}
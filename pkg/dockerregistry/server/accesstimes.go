@@ -0,0 +1,184 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/boltdb/bolt"
+)
+
+// Last-read and last-write times per manifest power lifecycle dashboards and give retention
+// policies the data to tell an unused tag from a busy one. Updates are batched in memory and,
+// when the metadata cache is enabled, flushed to its boltdb file so the times survive a
+// restart. Without the cache the times are kept in memory only.
+const (
+	// accessTimesFlushInterval is how often batched updates are persisted.
+	accessTimesFlushInterval = time.Minute
+	// accessTimesMaxEntries bounds the in-memory table; the oldest entries are dropped first.
+	accessTimesMaxEntries = 100000
+)
+
+var metadataAccessTimesBucket = []byte("accesstimes")
+
+// manifestAccessTimes is the recorded access history of one manifest.
+type manifestAccessTimes struct {
+	LastRead  time.Time `json:"lastRead,omitempty"`
+	LastWrite time.Time `json:"lastWrite,omitempty"`
+}
+
+var accessTimes = newAccessTimesRecorder()
+
+// accessTimesRecorder batches access time updates. Safe for concurrent use.
+type accessTimesRecorder struct {
+	mu      sync.Mutex
+	entries map[string]manifestAccessTimes
+	dirty   map[string]bool
+}
+
+func newAccessTimesRecorder() *accessTimesRecorder {
+	r := &accessTimesRecorder{
+		entries: map[string]manifestAccessTimes{},
+		dirty:   map[string]bool{},
+	}
+	if metadataCache != nil {
+		r.load()
+		go r.flushLoop()
+	}
+	return r
+}
+
+// touchRead records a manifest read. The key is "namespace/name@digest".
+func (r *accessTimesRecorder) touchRead(key string) {
+	r.touch(key, func(t *manifestAccessTimes) { t.LastRead = time.Now() })
+}
+
+// touchWrite records a manifest write.
+func (r *accessTimesRecorder) touchWrite(key string) {
+	r.touch(key, func(t *manifestAccessTimes) { t.LastWrite = time.Now() })
+}
+
+func (r *accessTimesRecorder) touch(key string, update func(*manifestAccessTimes)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	times := r.entries[key]
+	update(&times)
+	r.entries[key] = times
+	r.dirty[key] = true
+	if len(r.entries) > accessTimesMaxEntries {
+		r.prune()
+	}
+}
+
+// prune drops the least recently accessed tenth of the table. Callers must hold the lock.
+func (r *accessTimesRecorder) prune() {
+	entries := make(byLastAccess, 0, len(r.entries))
+	for key, times := range r.entries {
+		last := times.LastRead
+		if times.LastWrite.After(last) {
+			last = times.LastWrite
+		}
+		entries = append(entries, keyedAccess{key: key, last: last})
+	}
+	sort.Sort(entries)
+	for _, entry := range entries[:len(entries)/10] {
+		delete(r.entries, entry.key)
+		delete(r.dirty, entry.key)
+	}
+}
+
+type keyedAccess struct {
+	key  string
+	last time.Time
+}
+
+type byLastAccess []keyedAccess
+
+func (b byLastAccess) Len() int           { return len(b) }
+func (b byLastAccess) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byLastAccess) Less(i, j int) bool { return b[i].last.Before(b[j].last) }
+
+// snapshot returns the entries under the given namespace, or all entries when it is empty.
+func (r *accessTimesRecorder) snapshot(namespace string) map[string]manifestAccessTimes {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := map[string]manifestAccessTimes{}
+	for key, times := range r.entries {
+		if len(namespace) > 0 && !strings.HasPrefix(key, namespace+"/") {
+			continue
+		}
+		result[key] = times
+	}
+	return result
+}
+
+// load restores persisted times from the metadata cache.
+func (r *accessTimesRecorder) load() {
+	metadataCache.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(metadataAccessTimesBucket)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(key, value []byte) error {
+			times := manifestAccessTimes{}
+			if json.Unmarshal(value, &times) == nil {
+				r.entries[string(key)] = times
+			}
+			return nil
+		})
+	})
+}
+
+// flushLoop persists dirty entries in batches, bounding write amplification on busy
+// registries to one transaction per interval.
+func (r *accessTimesRecorder) flushLoop() {
+	for {
+		time.Sleep(accessTimesFlushInterval)
+		r.flush()
+	}
+}
+
+func (r *accessTimesRecorder) flush() {
+	r.mu.Lock()
+	batch := make(map[string]manifestAccessTimes, len(r.dirty))
+	for key := range r.dirty {
+		batch[key] = r.entries[key]
+	}
+	r.dirty = map[string]bool{}
+	r.mu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+
+	err := metadataCache.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(metadataAccessTimesBucket)
+		if err != nil {
+			return err
+		}
+		for key, times := range batch {
+			value, err := json.Marshal(times)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(key), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("error persisting access times: %v", err)
+	}
+}
+
+// accessTimesHandler serves the recorded access times as JSON on the admin listener. The
+// optional namespace query parameter restricts the result to one namespace.
+func accessTimesHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(accessTimes.snapshot(req.URL.Query().Get("namespace")))
+}
@@ -1,8 +1,10 @@
 package server
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 
 	"github.com/docker/distribution"
 	"github.com/docker/distribution/context"
@@ -14,6 +16,8 @@ import (
 	"github.com/docker/distribution/registry/storage"
 	storagedriver "github.com/docker/distribution/registry/storage/driver"
 	gorillahandlers "github.com/gorilla/handlers"
+
+	kapi "k8s.io/kubernetes/pkg/api"
 )
 
 // BlobDispatcher takes the request context and builds the appropriate handler
@@ -28,6 +32,7 @@ func BlobDispatcher(ctx *handlers.Context, r *http.Request) http.Handler {
 	}
 
 	return gorillahandlers.MethodHandler{
+		"GET":    http.HandlerFunc(blobHandler.References),
 		"DELETE": http.HandlerFunc(blobHandler.Delete),
 	}
 }
@@ -39,6 +44,91 @@ type blobHandler struct {
 	Digest digest.Digest
 }
 
+// blobReferences maps a layer digest to everything referencing it.
+type blobReferences struct {
+	Digest string `json:"digest"`
+	// Images are the images whose layers include the digest.
+	Images []string `json:"images"`
+	// ImageStreams are the streams currently tagging one of those images.
+	ImageStreams []blobReferenceStream `json:"imageStreams"`
+}
+
+type blobReferenceStream struct {
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	Tags      []string `json:"tags"`
+}
+
+// References lists the images and image streams referencing the blob, so a compromised layer
+// can be tracked down during incident response.
+func (bh *blobHandler) References(w http.ResponseWriter, req *http.Request) {
+	if len(bh.Digest) == 0 {
+		bh.Errors = append(bh.Errors, v2.ErrorCodeBlobUnknown)
+		return
+	}
+
+	osClient, _, err := DefaultRegistryClient.Clients()
+	if err != nil {
+		bh.Errors = append(bh.Errors, errcode.ErrorCodeUnknown.WithDetail(fmt.Sprintf("error getting clients: %v", err)))
+		return
+	}
+
+	images, err := osClient.Images().List(kapi.ListOptions{})
+	if err != nil {
+		bh.Errors = append(bh.Errors, errcode.ErrorCodeUnknown.WithDetail(fmt.Sprintf("error listing images: %v", err)))
+		return
+	}
+	referencing := map[string]bool{}
+	references := blobReferences{Digest: bh.Digest.String(), Images: []string{}, ImageStreams: []blobReferenceStream{}}
+	for _, image := range images.Items {
+		if image.Name == bh.Digest.String() {
+			// the manifest blob itself
+			referencing[image.Name] = true
+		}
+		for _, layer := range image.DockerImageLayers {
+			if layer.Name == bh.Digest.String() {
+				referencing[image.Name] = true
+				break
+			}
+		}
+	}
+	for name := range referencing {
+		references.Images = append(references.Images, name)
+	}
+	sort.Strings(references.Images)
+
+	streams, err := osClient.ImageStreams(kapi.NamespaceAll).List(kapi.ListOptions{})
+	if err != nil {
+		bh.Errors = append(bh.Errors, errcode.ErrorCodeUnknown.WithDetail(fmt.Sprintf("error listing image streams: %v", err)))
+		return
+	}
+	for _, stream := range streams.Items {
+		tags := []string{}
+		for tag, history := range stream.Status.Tags {
+			for _, event := range history.Items {
+				if referencing[event.Image] {
+					tags = append(tags, tag)
+					break
+				}
+			}
+		}
+		if len(tags) == 0 {
+			continue
+		}
+		sort.Strings(tags)
+		references.ImageStreams = append(references.ImageStreams, blobReferenceStream{
+			Namespace: stream.Namespace,
+			Name:      stream.Name,
+			Tags:      tags,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(references); err != nil {
+		bh.Errors = append(bh.Errors, errcode.ErrorCodeUnknown.WithDetail(err))
+	}
+}
+
 // Delete deletes the blob from the storage backend.
 func (bh *blobHandler) Delete(w http.ResponseWriter, req *http.Request) {
 	defer req.Body.Close()
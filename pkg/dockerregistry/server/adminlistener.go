@@ -0,0 +1,121 @@
+package server
+
+import (
+	"crypto/subtle"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"strings"
+
+	"github.com/docker/distribution/context"
+)
+
+const (
+	// AdminAddrEnvVar is the address of the dedicated listener for operational endpoints
+	// (metrics, pprof, health). When empty, no admin listener is started, and exposing the
+	// registry route never exposes these endpoints.
+	AdminAddrEnvVar = "REGISTRY_ADMIN_ADDR"
+
+	// AdminTLSCertificateEnvVar and AdminTLSKeyEnvVar configure TLS for the admin listener,
+	// independent of the data-path listener's certificates.
+	AdminTLSCertificateEnvVar = "REGISTRY_ADMIN_TLS_CERTIFICATE"
+	AdminTLSKeyEnvVar         = "REGISTRY_ADMIN_TLS_KEY"
+
+	// AdminTokenEnvVar is an optional static bearer token granting access to the admin listener.
+	// When unset, callers must instead present an OpenShift bearer token that passes a subject
+	// access review for getting images at the cluster scope.
+	AdminTokenEnvVar = "REGISTRY_ADMIN_TOKEN"
+)
+
+// RunAdminServer serves metrics, pprof and health endpoints on a dedicated listener with its own
+// TLS material and authentication, and returns immediately. It is a no-op unless
+// AdminAddrEnvVar is set.
+func RunAdminServer(ctx context.Context, registryClient *RegistryClient) {
+	addr := os.Getenv(AdminAddrEnvVar)
+	if len(addr) == 0 {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.Handle("/metrics", expvar.Handler())
+	mux.HandleFunc("/slo", sloSummaryHandler)
+	mux.HandleFunc("/config", configDumpHandler)
+	mux.HandleFunc("/access-times", accessTimesHandler)
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	handler := &adminAuthHandler{
+		ctx:         ctx,
+		staticToken: os.Getenv(AdminTokenEnvVar),
+		client:      registryClient,
+		delegate:    mux,
+	}
+
+	certificate := os.Getenv(AdminTLSCertificateEnvVar)
+	key := os.Getenv(AdminTLSKeyEnvVar)
+
+	go func() {
+		var err error
+		if len(certificate) > 0 {
+			context.GetLogger(ctx).Infof("admin listener on %s, tls", addr)
+			err = http.ListenAndServeTLS(addr, certificate, key, handler)
+		} else {
+			context.GetLogger(ctx).Infof("admin listener on %s", addr)
+			err = http.ListenAndServe(addr, handler)
+		}
+		context.GetLogger(ctx).Fatalf("admin listener failed: %v", err)
+	}()
+}
+
+// adminAuthHandler guards the admin listener. Requests authenticate either with the configured
+// static bearer token or, when none is configured, with an OpenShift bearer token authorized by a
+// subject access review. The health endpoint stays open for probes.
+type adminAuthHandler struct {
+	ctx         context.Context
+	staticToken string
+	client      *RegistryClient
+	delegate    http.Handler
+}
+
+func (h *adminAuthHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/healthz" {
+		h.delegate.ServeHTTP(w, req)
+		return
+	}
+
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if len(token) == 0 {
+		http.Error(w, "authorization required", http.StatusUnauthorized)
+		return
+	}
+
+	if len(h.staticToken) > 0 {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(h.staticToken)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		h.delegate.ServeHTTP(w, req)
+		return
+	}
+
+	authenticator := NewTokenAuthenticator(AuthenticatorOptions{AnonymousConfig: h.client.SafeClientConfig()})
+	osClient, err := authenticator.ClientFor(token)
+	if err != nil {
+		context.GetLogger(h.ctx).Errorf("error building client for admin request: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	// require reading images at the cluster scope, the same privilege level operators that
+	// run the registry already hold
+	if err := verifyAdminAccess(h.ctx, osClient); err != nil {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	h.delegate.ServeHTTP(w, req)
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"expvar"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution/context"
+	registryauth "github.com/docker/distribution/registry/auth"
+
+	"k8s.io/kubernetes/pkg/util/sets"
+
+	authorizationapi "github.com/openshift/origin/pkg/authorization/api"
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// Anonymous pull lets clients read repositories in namespaces explicitly marked as public
+// without presenting credentials. Instead of always challenging a request without an
+// Authorization header, the access controller checks the namespace annotation and runs the pull
+// access review as the anonymous user, so cluster policy still decides what anonymous clients
+// may read. Pushes and prunes always require credentials.
+const (
+	// AnonymousPullEnvVar set to "true" enables anonymous pulls from annotated namespaces.
+	AnonymousPullEnvVar = "REGISTRY_ANONYMOUS_PULL"
+
+	// AnonymousPullAnnotation set to "true" on a namespace marks its repositories as publicly
+	// pullable. The annotation alone is not sufficient: the anonymous user must also pass the
+	// regular pull access review, which the anonymous-group role bindings grant.
+	AnonymousPullAnnotation = "openshift.io/image.anonymousPull"
+)
+
+const (
+	anonymousUserName = "system:anonymous"
+	anonymousGroup    = "system:unauthenticated"
+)
+
+// anonymousPullCacheTTL bounds how long a granted anonymous pull is reused before the namespace
+// annotation and access review are consulted again.
+const anonymousPullCacheTTL = 2 * time.Minute
+
+// anonymousPullMetrics counts anonymous pulls allowed, denied and failed by API errors.
+var anonymousPullMetrics = expvar.NewMap("registry.anonymousPull")
+
+var anonymousPullCache = &anonymousAccessCache{entries: map[string]time.Time{}}
+
+// anonymousAccessCache remembers which repositories recently passed an anonymous pull check, so
+// the layer requests that follow a manifest request do not repeat the namespace lookup and
+// access review.
+type anonymousAccessCache struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+func (c *anonymousAccessCache) allowed(namespace, name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires, ok := c.entries[namespace+"/"+name]
+	if ok && time.Now().Before(expires) {
+		return true
+	}
+	if ok {
+		delete(c.entries, namespace+"/"+name)
+	}
+	return false
+}
+
+func (c *anonymousAccessCache) remember(namespace, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[namespace+"/"+name] = time.Now().Add(anonymousPullCacheTTL)
+}
+
+// tryAnonymousPull authorizes a request without credentials when every requested access is a
+// pull from a namespace annotated for anonymous pulls and the anonymous user passes the pull
+// access review. It reports false when the request must be challenged instead.
+func (ac *AccessController) tryAnonymousPull(ctx context.Context, req *http.Request, accessRecords []registryauth.Access) (context.Context, bool) {
+	if os.Getenv(AnonymousPullEnvVar) != "true" {
+		return nil, false
+	}
+	if !pullOnlyAccess(accessRecords) {
+		return nil, false
+	}
+
+	osClient, kClient, err := DefaultRegistryClient.Clients()
+	if err != nil {
+		context.GetLogger(ctx).Errorf("anonymous pull: error getting clients: %v", err)
+		anonymousPullMetrics.Add("errors", 1)
+		return nil, false
+	}
+
+	for _, access := range accessRecords {
+		namespace, name, err := getNamespaceName(access.Resource.Name)
+		if err != nil {
+			return nil, false
+		}
+		// protected namespaces demand a verified client certificate even for anonymous pulls
+		if err := ac.verifyClientCertPolicy(ctx, req, namespace); err != nil {
+			return nil, false
+		}
+		if anonymousPullCache.allowed(namespace, name) {
+			continue
+		}
+
+		ns, err := kClient.Namespaces().Get(namespace)
+		if err != nil {
+			context.GetLogger(ctx).Errorf("anonymous pull: error getting namespace %s: %v", namespace, err)
+			anonymousPullMetrics.Add("errors", 1)
+			return nil, false
+		}
+		if ns.Annotations[AnonymousPullAnnotation] != "true" {
+			anonymousPullMetrics.Add("denied", 1)
+			return nil, false
+		}
+
+		sar := authorizationapi.LocalSubjectAccessReview{
+			Action: authorizationapi.Action{
+				Verb:         "get",
+				Group:        imageapi.GroupName,
+				Resource:     "imagestreams/layers",
+				ResourceName: name,
+			},
+			User:   anonymousUserName,
+			Groups: sets.NewString(anonymousGroup),
+		}
+		start := time.Now()
+		response, err := osClient.LocalSubjectAccessReviews(namespace).Create(&sar)
+		observeAPICall("localsubjectaccessreviews.create", start, err)
+		if err != nil {
+			context.GetLogger(ctx).Errorf("anonymous pull: access review failed for %s: %v", access.Resource.Name, err)
+			anonymousPullMetrics.Add("errors", 1)
+			return nil, false
+		}
+		if !response.Allowed {
+			context.GetLogger(ctx).Debugf("anonymous pull: access denied for %s: %s", access.Resource.Name, response.Reason)
+			anonymousPullMetrics.Add("denied", 1)
+			return nil, false
+		}
+		anonymousPullCache.remember(namespace, name)
+	}
+
+	context.GetLogger(ctx).Debugf("anonymous pull: granted access to %d repositories", len(accessRecords))
+	anonymousPullMetrics.Add("allowed", 1)
+	return WithAuthPerformed(ctx), true
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/docker/distribution/context"
+	registryauth "github.com/docker/distribution/registry/auth"
+)
+
+func TestAnonymousPullRequiresOptIn(t *testing.T) {
+	ac := &AccessController{}
+	records := []registryauth.Access{
+		{Resource: registryauth.Resource{Type: "repository", Name: "public/app"}, Action: "pull"},
+	}
+	if _, ok := ac.tryAnonymousPull(context.Background(), nil, records); ok {
+		t.Error("anonymous pull must be disabled unless opted in")
+	}
+}
+
+func TestAnonymousPullRejectsNonPullAccess(t *testing.T) {
+	os.Setenv(AnonymousPullEnvVar, "true")
+	defer os.Unsetenv(AnonymousPullEnvVar)
+
+	ac := &AccessController{}
+	testCases := map[string][]registryauth.Access{
+		"no records": {},
+		"push": {
+			{Resource: registryauth.Resource{Type: "repository", Name: "public/app"}, Action: "push"},
+		},
+		"pull and push": {
+			{Resource: registryauth.Resource{Type: "repository", Name: "public/app"}, Action: "pull"},
+			{Resource: registryauth.Resource{Type: "repository", Name: "public/app"}, Action: "push"},
+		},
+		"admin": {
+			{Resource: registryauth.Resource{Type: "admin"}, Action: "prune"},
+		},
+	}
+	for name, records := range testCases {
+		if _, ok := ac.tryAnonymousPull(context.Background(), nil, records); ok {
+			t.Errorf("%s: anonymous access granted for a non-pull request", name)
+		}
+	}
+}
+
+func TestAnonymousAccessCache(t *testing.T) {
+	cache := &anonymousAccessCache{entries: map[string]time.Time{}}
+	if cache.allowed("public", "app") {
+		t.Error("empty cache must not allow")
+	}
+	cache.remember("public", "app")
+	if !cache.allowed("public", "app") {
+		t.Error("expected a hit for the remembered repository")
+	}
+	if cache.allowed("public", "other") {
+		t.Error("a different repository must not hit")
+	}
+
+	cache.entries["public/app"] = time.Now().Add(-time.Second)
+	if cache.allowed("public", "app") {
+		t.Error("an expired entry must not hit")
+	}
+	if _, ok := cache.entries["public/app"]; ok {
+		t.Error("an expired entry should be dropped on lookup")
+	}
+}
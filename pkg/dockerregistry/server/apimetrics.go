@@ -0,0 +1,92 @@
+package server
+
+import (
+	"expvar"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/docker/distribution/digest"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// APILogSampleEnvVar logs every Nth API call the registry makes to the master, with operation
+// and latency. Combined with the per-operation counters this lets operators quantify
+// registry-induced API server load and tune the caches. Zero (the default) disables sampling.
+const APILogSampleEnvVar = "REGISTRY_API_LOG_SAMPLE"
+
+// apiCallMetrics counts, per operation, calls, errors and summed latency in milliseconds.
+var apiCallMetrics = expvar.NewMap("registry.apiclient")
+
+var (
+	apiLogSample = readAPILogSample()
+	apiCallCount int64
+)
+
+func readAPILogSample() int64 {
+	value := os.Getenv(APILogSampleEnvVar)
+	if len(value) == 0 {
+		return 0
+	}
+	sample, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || sample < 0 {
+		log.Errorf("ignoring invalid %s=%q", APILogSampleEnvVar, value)
+		return 0
+	}
+	return sample
+}
+
+// observeAPICall records one call against the master API.
+func observeAPICall(operation string, start time.Time, err error) {
+	duration := time.Since(start)
+	apiCallMetrics.Add(operation+".count", 1)
+	apiCallMetrics.Add(operation+".latencyMs", int64(duration/time.Millisecond))
+	if err != nil {
+		apiCallMetrics.Add(operation+".errors", 1)
+	}
+	if apiLogSample > 0 && atomic.AddInt64(&apiCallCount, 1)%apiLogSample == 0 {
+		log.Infof("api call sample: %s took %s err=%v", operation, duration, err)
+	}
+}
+
+// withBackendMetrics counts every image stream backend call. It wraps outside the timeout
+// layer so calls failed by a deadline are counted as errors too.
+func withBackendMetrics(backend imageStreamBackend) imageStreamBackend {
+	return metricsBackend{backend: backend}
+}
+
+type metricsBackend struct {
+	backend imageStreamBackend
+}
+
+func (b metricsBackend) GetImageStream(namespace, name string) (*imageapi.ImageStream, error) {
+	start := time.Now()
+	stream, err := b.backend.GetImageStream(namespace, name)
+	observeAPICall("imagestreams.get", start, err)
+	return stream, err
+}
+
+func (b metricsBackend) GetImage(dgst digest.Digest) (*imageapi.Image, error) {
+	start := time.Now()
+	image, err := b.backend.GetImage(dgst)
+	observeAPICall("images.get", start, err)
+	return image, err
+}
+
+func (b metricsBackend) GetImageStreamImage(namespace, name string, dgst digest.Digest) (*imageapi.ImageStreamImage, error) {
+	start := time.Now()
+	isimage, err := b.backend.GetImageStreamImage(namespace, name, dgst)
+	observeAPICall("imagestreamimages.get", start, err)
+	return isimage, err
+}
+
+func (b metricsBackend) CreateImageStreamMapping(namespace string, ism *imageapi.ImageStreamMapping) error {
+	start := time.Now()
+	err := b.backend.CreateImageStreamMapping(namespace, ism)
+	observeAPICall("imagestreammappings.create", start, err)
+	return err
+}
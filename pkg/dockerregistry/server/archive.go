@@ -0,0 +1,212 @@
+package server
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/distribution/registry/handlers"
+	gorillahandlers "github.com/gorilla/handlers"
+)
+
+// archiveManifestEntry mirrors an entry of the manifest.json file written by
+// docker save into the root of an image archive.
+type archiveManifestEntry struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+// ArchiveUploadDispatcher takes the request context and builds the handler for
+// docker archive uploads. The route carries a repository name, so the context
+// arrives with the middleware wrapped repository and push access enforced.
+func ArchiveUploadDispatcher(ctx *handlers.Context, r *http.Request) http.Handler {
+	archiveHandler := &archiveUploadHandler{
+		Context: ctx,
+	}
+
+	return gorillahandlers.MethodHandler{
+		"POST": http.HandlerFunc(archiveHandler.Upload),
+	}
+}
+
+// archiveUploadHandler converts uploaded docker save tarballs into blobs and a
+// schema 2 manifest, allowing clients that cannot speak the V2 push protocol
+// to load images with a single request. Writing the manifest through the
+// repository records the ImageStreamMapping the same way a regular push does.
+type archiveUploadHandler struct {
+	*handlers.Context
+}
+
+// Upload reads a docker save formatted tarball from the request body, uploads
+// its config and layers as blobs, and tags the resulting manifest. The tag is
+// taken from the ?tag= parameter, falling back to the RepoTags recorded in the
+// archive and finally to "latest".
+func (ah *archiveUploadHandler) Upload(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	// the archive has to be read twice - once to locate manifest.json and once
+	// to upload the referenced files - so spool it to disk
+	spool, err := ioutil.TempFile("", "registry-archive-upload")
+	if err != nil {
+		ah.Errors = append(ah.Errors, errcode.ErrorCodeUnknown.WithDetail(err))
+		return
+	}
+	defer func() {
+		spool.Close()
+		os.Remove(spool.Name())
+	}()
+	if _, err := io.Copy(spool, req.Body); err != nil {
+		ah.Errors = append(ah.Errors, errcode.ErrorCodeUnknown.WithDetail(err))
+		return
+	}
+
+	entry, err := ah.readArchiveManifest(spool)
+	if err != nil {
+		ah.Errors = append(ah.Errors, errcode.ErrorCodeUnknown.WithDetail(fmt.Sprintf("invalid image archive: %v", err)))
+		return
+	}
+
+	configDesc, err := ah.uploadArchiveFile(spool, entry.Config, schema2.MediaTypeConfig)
+	if err != nil {
+		ah.Errors = append(ah.Errors, errcode.ErrorCodeUnknown.WithDetail(err))
+		return
+	}
+	layers := make([]distribution.Descriptor, 0, len(entry.Layers))
+	for _, layer := range entry.Layers {
+		desc, err := ah.uploadArchiveFile(spool, layer, schema2.MediaTypeLayer)
+		if err != nil {
+			ah.Errors = append(ah.Errors, errcode.ErrorCodeUnknown.WithDetail(err))
+			return
+		}
+		layers = append(layers, desc)
+	}
+
+	manifest, err := schema2.FromStruct(schema2.Manifest{
+		Versioned: schema2.SchemaVersion,
+		Config:    configDesc,
+		Layers:    layers,
+	})
+	if err != nil {
+		ah.Errors = append(ah.Errors, errcode.ErrorCodeUnknown.WithDetail(err))
+		return
+	}
+
+	tag := ah.archiveTag(req, entry)
+	manifests, err := ah.Repository.Manifests(ah)
+	if err != nil {
+		ah.Errors = append(ah.Errors, errcode.ErrorCodeUnknown.WithDetail(err))
+		return
+	}
+	dgst, err := manifests.Put(ah, manifest, distribution.WithTag(tag))
+	if err != nil {
+		ah.Errors = append(ah.Errors, errcode.ErrorCodeUnknown.WithDetail(err))
+		return
+	}
+
+	context.GetLogger(ah).Infof("uploaded archive to %s:%s (%s)", ah.Repository.Named().Name(), tag, dgst)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{
+		"name":   ah.Repository.Named().Name(),
+		"tag":    tag,
+		"digest": dgst.String(),
+	})
+}
+
+// readArchiveManifest locates manifest.json in the archive and returns its
+// first entry. Archives with several images are rejected so the destination of
+// every uploaded blob is unambiguous.
+func (ah *archiveUploadHandler) readArchiveManifest(spool *os.File) (*archiveManifestEntry, error) {
+	reader, err := archiveFile(spool, "manifest.json")
+	if err != nil {
+		return nil, err
+	}
+	entries := []archiveManifestEntry{}
+	if err := json.NewDecoder(reader).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("cannot parse manifest.json: %v", err)
+	}
+	switch len(entries) {
+	case 1:
+		return &entries[0], nil
+	case 0:
+		return nil, fmt.Errorf("manifest.json contains no images")
+	default:
+		return nil, fmt.Errorf("archives with multiple images are not supported")
+	}
+}
+
+// uploadArchiveFile streams the named archive member into the repository blob
+// store and returns its descriptor.
+func (ah *archiveUploadHandler) uploadArchiveFile(spool *os.File, name, mediaType string) (distribution.Descriptor, error) {
+	reader, err := archiveFile(spool, name)
+	if err != nil {
+		return distribution.Descriptor{}, err
+	}
+
+	writer, err := ah.Repository.Blobs(ah).Create(ah)
+	if err != nil {
+		return distribution.Descriptor{}, err
+	}
+	defer writer.Cancel(ah)
+
+	digester := digest.Canonical.New()
+	size, err := io.Copy(io.MultiWriter(writer, digester.Hash()), reader)
+	if err != nil {
+		return distribution.Descriptor{}, fmt.Errorf("cannot upload %s: %v", name, err)
+	}
+	desc := distribution.Descriptor{
+		MediaType: mediaType,
+		Digest:    digester.Digest(),
+		Size:      size,
+	}
+	if _, err := writer.Commit(ah, desc); err != nil {
+		return distribution.Descriptor{}, fmt.Errorf("cannot commit %s: %v", name, err)
+	}
+	return desc, nil
+}
+
+// archiveTag returns the tag the uploaded image is recorded under.
+func (ah *archiveUploadHandler) archiveTag(req *http.Request, entry *archiveManifestEntry) string {
+	if tag := req.URL.Query().Get("tag"); len(tag) > 0 {
+		return tag
+	}
+	for _, repoTag := range entry.RepoTags {
+		if i := strings.LastIndex(repoTag, ":"); i != -1 {
+			return repoTag[i+1:]
+		}
+	}
+	return "latest"
+}
+
+// archiveFile rewinds the spool file and returns a reader positioned at the
+// named archive member.
+func archiveFile(spool *os.File, name string) (io.Reader, error) {
+	if _, err := spool.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	tarReader := tar.NewReader(spool)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("the archive does not contain %q", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimPrefix(header.Name, "./") == name {
+			return tarReader, nil
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/docker/distribution/context"
+
+	kerrors "k8s.io/kubernetes/pkg/api/errors"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// AsyncMappingEnvVar opts the registry into acknowledging manifest pushes as soon as the
+// content is durably stored, leaving the ImageStreamMapping creation to a retrying queue. A
+// transient master outage then delays tagging instead of failing a multi-gigabyte push at the
+// last step. The trade-off is that a just-pushed image may take a short while to appear on the
+// stream; when the retries are finally exhausted the failure is surfaced on the stream through
+// the FailedMappingAnnotation.
+const AsyncMappingEnvVar = "REGISTRY_ASYNC_MAPPING"
+
+var asyncMappingEnabled = readAsyncMappingEnabled()
+
+func readAsyncMappingEnabled() bool {
+	return os.Getenv(AsyncMappingEnvVar) == "true"
+}
+
+const (
+	// asyncMappingQueueSize bounds the number of mappings waiting to be recorded. When the
+	// queue is full pushes fall back to creating the mapping inline.
+	asyncMappingQueueSize = 1024
+
+	// asyncMappingMaxAttempts and the doubling backoff below give the master several minutes
+	// to come back before a mapping is abandoned.
+	asyncMappingMaxAttempts   = 8
+	asyncMappingInitialDelay  = time.Second
+	asyncMappingMaxRetryDelay = 30 * time.Second
+)
+
+// asyncMappingMetrics counts queue outcomes; the depth gauge is registered in init below.
+var asyncMappingMetrics = expvar.NewMap("registry.asyncMapping")
+
+var defaultMappingQueue = newMappingQueue()
+
+func init() {
+	asyncMappingMetrics.Set("depth", expvar.Func(func() interface{} {
+		return atomic.LoadInt64(&defaultMappingQueue.depth)
+	}))
+}
+
+type pendingMapping struct {
+	repo *repository
+	ism  *imageapi.ImageStreamMapping
+}
+
+// mappingQueue records image stream mappings on the master asynchronously. A single worker
+// preserves the order pushes were acknowledged in.
+type mappingQueue struct {
+	ch    chan *pendingMapping
+	depth int64
+}
+
+func newMappingQueue() *mappingQueue {
+	return &mappingQueue{ch: make(chan *pendingMapping, asyncMappingQueueSize)}
+}
+
+// enqueue hands the mapping to the worker. It returns false when the queue is full, in which
+// case the caller must create the mapping inline.
+func (q *mappingQueue) enqueue(r *repository, ism *imageapi.ImageStreamMapping) bool {
+	// the repository is copied because the caller's is reused across requests
+	repo := *r
+	select {
+	case q.ch <- &pendingMapping{repo: &repo, ism: ism}:
+		atomic.AddInt64(&q.depth, 1)
+		asyncMappingMetrics.Add("queued", 1)
+		return true
+	default:
+		asyncMappingMetrics.Add("queueFull", 1)
+		return false
+	}
+}
+
+// RunMappingQueue starts the worker draining the async mapping queue. The worker always runs
+// so the mode can be switched on through a configuration reload; with the mode off nothing is
+// ever enqueued.
+func RunMappingQueue(ctx context.Context) {
+	if asyncMappingEnabled {
+		context.GetLogger(ctx).Infof("acknowledging pushes before mapping creation, queue size %d", asyncMappingQueueSize)
+	}
+	go defaultMappingQueue.run()
+}
+
+func (q *mappingQueue) run() {
+	for pending := range q.ch {
+		q.process(pending)
+		atomic.AddInt64(&q.depth, -1)
+	}
+}
+
+func (q *mappingQueue) process(pending *pendingMapping) {
+	repo, ism := pending.repo, pending.ism
+	delay := asyncMappingInitialDelay
+	var err error
+	for attempt := 1; attempt <= asyncMappingMaxAttempts; attempt++ {
+		if err = repo.createImageStreamMapping(ism); err == nil {
+			asyncMappingMetrics.Add("created", 1)
+			return
+		}
+		if !retryableMappingError(err) {
+			break
+		}
+		asyncMappingMetrics.Add("retries", 1)
+		log.Warnf("retrying mapping of %s into %s/%s in %s: %v", ism.Image.Name, repo.namespace, repo.name, delay.String(), err)
+		time.Sleep(delay)
+		if delay *= 2; delay > asyncMappingMaxRetryDelay {
+			delay = asyncMappingMaxRetryDelay
+		}
+	}
+
+	asyncMappingMetrics.Add("failed", 1)
+	log.Errorf("giving up mapping %s into %s/%s: %v", ism.Image.Name, repo.namespace, repo.name, err)
+	q.recordFailure(repo, ism, err)
+}
+
+// recordFailure surfaces an abandoned mapping on the stream so the failure is visible to the
+// pusher; losing the annotation update itself is tolerated.
+func (q *mappingQueue) recordFailure(repo *repository, ism *imageapi.ImageStreamMapping, failure error) {
+	stream, err := repo.registryOSClient.ImageStreams(repo.namespace).Get(repo.name)
+	if err != nil {
+		log.Errorf("cannot record failed mapping on %s/%s: %v", repo.namespace, repo.name, err)
+		return
+	}
+	if stream.Annotations == nil {
+		stream.Annotations = map[string]string{}
+	}
+	stream.Annotations[imageapi.FailedMappingAnnotation] = fmt.Sprintf("%s: %v", ism.Image.Name, failure)
+	if _, err := repo.registryOSClient.ImageStreams(repo.namespace).Update(stream); err != nil {
+		log.Errorf("cannot record failed mapping on %s/%s: %v", repo.namespace, repo.name, err)
+	}
+}
+
+// retryableMappingError separates master outages, which another attempt may outlast, from
+// errors that will repeat, like denied access or validation failures.
+func retryableMappingError(err error) bool {
+	if isConnectivityError(err) {
+		return true
+	}
+	if kerrors.IsServerTimeout(err) || kerrors.IsConflict(err) {
+		return true
+	}
+	if statusErr, ok := err.(*kerrors.StatusError); ok {
+		return statusErr.ErrStatus.Code >= http.StatusInternalServerError
+	}
+	return false
+}
@@ -3,21 +3,20 @@ package server
 import (
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
 
 	log "github.com/Sirupsen/logrus"
 	context "github.com/docker/distribution/context"
 	registryauth "github.com/docker/distribution/registry/auth"
 
-	kerrors "k8s.io/kubernetes/pkg/api/errors"
 	"k8s.io/kubernetes/pkg/client/restclient"
 	kclient "k8s.io/kubernetes/pkg/client/unversioned"
 
-	authorizationapi "github.com/openshift/origin/pkg/authorization/api"
 	"github.com/openshift/origin/pkg/client"
 	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
-	imageapi "github.com/openshift/origin/pkg/image/api"
 )
 
 type deferredErrors map[string]error
@@ -38,6 +37,11 @@ const (
 
 	RealmKey      = "realm"
 	TokenRealmKey = "token-realm"
+
+	// RealmsByHostKey is an optional map of request Host to realm overrides. It allows a registry
+	// serving multiple hostnames (service DNS, route, external CNAME) to issue challenges pointing
+	// at an auth endpoint reachable from that entry point.
+	RealmsByHostKey = "realmsbyhost"
 )
 
 // DefaultRegistryClient is exposed for testing the registry with fake client.
@@ -91,6 +95,17 @@ func AuthPerformed(ctx context.Context) bool {
 	return ok && authPerformed
 }
 
+const authenticatedUserNameKey = "openshift.auth.userName"
+
+func WithAuthenticatedUserName(parent context.Context, userName string) context.Context {
+	return context.WithValue(parent, authenticatedUserNameKey, userName)
+}
+
+func AuthenticatedUserNameFrom(ctx context.Context) (string, bool) {
+	userName, ok := ctx.Value(authenticatedUserNameKey).(string)
+	return userName, ok && len(userName) > 0
+}
+
 const deferredErrorsKey = "openshift.auth.deferredErrors"
 
 func WithDeferredErrors(parent context.Context, errs deferredErrors) context.Context {
@@ -104,7 +119,21 @@ func DeferredErrorsFrom(ctx context.Context) (deferredErrors, bool) {
 type AccessController struct {
 	realm      string
 	tokenRealm string
-	config     restclient.Config
+	// realmsByHost optionally overrides realm and tokenRealm for requests arriving with a matching
+	// Host header.
+	realmsByHost map[string]realmOverride
+	config       restclient.Config
+	// registryClient resolves the registry's own clients for policy lookups; it may be nil in
+	// tests, in which case annotation-driven policies are skipped.
+	registryClient *RegistryClient
+	authorizer     Authorizer
+}
+
+// realmOverride holds per-host overrides of the configured challenge realms. Empty fields fall back
+// to the access controller defaults.
+type realmOverride struct {
+	realm      string
+	tokenRealm string
 }
 
 var _ registryauth.AccessController = &AccessController{}
@@ -147,7 +176,101 @@ func newAccessController(options map[string]interface{}) (registryauth.AccessCon
 
 	tokenRealm, _ := options[TokenRealmKey].(string)
 
-	return &AccessController{realm: realm, tokenRealm: tokenRealm, config: DefaultRegistryClient.SafeClientConfig()}, nil
+	realmsByHost, err := getRealmsByHost(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccessController{
+		realm:          realm,
+		tokenRealm:     tokenRealm,
+		realmsByHost:   realmsByHost,
+		config:         DefaultRegistryClient.SafeClientConfig(),
+		registryClient: DefaultRegistryClient,
+		authorizer:     NewSARAuthorizer(AuthorizerOptions{}),
+	}, nil
+}
+
+// getRealmsByHost parses the optional realmsbyhost option. The configuration file gives us a map of
+// request host to a nested map holding realm and token-realm values.
+func getRealmsByHost(options map[string]interface{}) (map[string]realmOverride, error) {
+	value, ok := options[RealmsByHostKey]
+	if !ok {
+		return nil, nil
+	}
+
+	realmsByHost := map[string]realmOverride{}
+	entries, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be a map of host to realm settings", RealmsByHostKey)
+	}
+	for host, settings := range entries {
+		hostString, ok := host.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: host %v must be a string", RealmsByHostKey, host)
+		}
+		override := realmOverride{}
+		settingsMap, ok := settings.(map[interface{}]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: settings for host %q must be a map", RealmsByHostKey, hostString)
+		}
+		if realm, ok := settingsMap[RealmKey].(string); ok {
+			override.realm = realm
+		}
+		if tokenRealm, ok := settingsMap[TokenRealmKey].(string); ok {
+			override.tokenRealm = tokenRealm
+		}
+		realmsByHost[hostString] = override
+	}
+	return realmsByHost, nil
+}
+
+// realmsForRequest returns the challenge realms to use for the given request, preferring overrides
+// configured for the request's Host header.
+func (ac *AccessController) realmsForRequest(req *http.Request) (realm, tokenRealm string) {
+	realm, tokenRealm = ac.realm, ac.tokenRealm
+	if req == nil {
+		return realm, tokenRealm
+	}
+	defer func() {
+		tokenRealm = followForwardedScheme(tokenRealm, req)
+	}()
+	if len(ac.realmsByHost) == 0 {
+		return realm, tokenRealm
+	}
+	override, ok := ac.realmsByHost[req.Host]
+	if !ok {
+		// fall back to a match on the host without the port
+		if host, _, err := net.SplitHostPort(req.Host); err == nil {
+			override, ok = ac.realmsByHost[host]
+		}
+	}
+	if !ok {
+		return realm, tokenRealm
+	}
+	if len(override.realm) > 0 {
+		realm = override.realm
+	}
+	if len(override.tokenRealm) > 0 {
+		tokenRealm = override.tokenRealm
+	}
+	return realm, tokenRealm
+}
+
+// followForwardedScheme rewrites the scheme of the absolute realm URL to the scheme the client
+// used to reach the registry, so challenges issued behind a TLS-terminating proxy never point
+// clients at a plain http URL (or vice versa).
+func followForwardedScheme(realm string, req *http.Request) string {
+	scheme := forwardedScheme(req)
+	if len(scheme) == 0 || len(realm) == 0 {
+		return realm
+	}
+	realmURL, err := url.Parse(realm)
+	if err != nil || !realmURL.IsAbs() || realmURL.Scheme == scheme {
+		return realm
+	}
+	realmURL.Scheme = scheme
+	return realmURL.String()
 }
 
 // Error returns the internal error string for this authChallenge.
@@ -183,20 +306,21 @@ func (ac *tokenAuthChallenge) SetHeaders(w http.ResponseWriter) {
 }
 
 // wrapErr wraps errors related to authorization in an authChallenge error that will present a WWW-Authenticate challenge response
-func (ac *AccessController) wrapErr(err error) error {
+func (ac *AccessController) wrapErr(req *http.Request, err error) error {
+	realm, tokenRealm := ac.realmsForRequest(req)
 	switch err {
 	case ErrTokenRequired:
 		// Challenge for errors that involve missing tokens
-		if len(ac.tokenRealm) > 0 {
+		if len(tokenRealm) > 0 {
 			// Direct to token auth if we've been given a place to direct to
-			return &tokenAuthChallenge{realm: ac.tokenRealm, err: err}
+			return &tokenAuthChallenge{realm: tokenRealm, err: err}
 		} else {
 			// Otherwise just send the basic challenge
-			return &authChallenge{realm: ac.realm, err: err}
+			return &authChallenge{realm: realm, err: err}
 		}
 	case ErrTokenInvalid, ErrOpenShiftAccessDenied:
 		// Challenge for errors that involve tokens or access denied
-		return &authChallenge{realm: ac.realm, err: err}
+		return &authChallenge{realm: realm, err: err}
 	case ErrNamespaceRequired, ErrUnsupportedAction, ErrUnsupportedResource:
 		// Malformed or unsupported request, no challenge
 		return err
@@ -212,27 +336,72 @@ func (ac *AccessController) wrapErr(err error) error {
 //   origin/pkg/cmd/dockerregistry/dockerregistry.go#Execute
 //   docker/distribution/registry/handlers/app.go#appendAccessRecords
 func (ac *AccessController) Authorized(ctx context.Context, accessRecords ...registryauth.Access) (context.Context, error) {
+	authorizedCtx, err := ac.authorized(ctx, accessRecords...)
+	if err != nil {
+		if degradedCtx, ok := ac.tryDegradedMode(ctx, err, accessRecords); ok {
+			return degradedCtx, nil
+		}
+		if breakGlassCtx, ok := ac.tryBreakGlass(ctx, err, accessRecords); ok {
+			return breakGlassCtx, nil
+		}
+	}
+	return authorizedCtx, err
+}
+
+func (ac *AccessController) authorized(ctx context.Context, accessRecords ...registryauth.Access) (context.Context, error) {
 	req, err := context.GetRequest(ctx)
 	if err != nil {
-		return nil, ac.wrapErr(err)
+		return nil, ac.wrapErr(req, err)
 	}
 
-	bearerToken, err := getOpenShiftAPIToken(ctx, req)
+	// the authenticator is built per request so a hot-reloaded auth timeout takes effect
+	authenticator := NewTokenAuthenticator(AuthenticatorOptions{
+		AnonymousConfig: ac.config,
+		Timeout:         operationTimeouts.auth,
+	})
+
+	bearerToken, err := authenticator.TokenForRequest(req)
 	if err != nil {
-		return nil, ac.wrapErr(err)
+		// repositories in namespaces annotated for anonymous pulls may be read without
+		// credentials; everything else is challenged as before
+		if err == ErrTokenRequired {
+			if anonymousCtx, ok := ac.tryAnonymousPull(ctx, req, accessRecords); ok {
+				return anonymousCtx, nil
+			}
+		}
+		return nil, ac.wrapErr(req, err)
+	}
+
+	// Deploy tokens are validated locally and never reach the master API.
+	if isDeployToken(bearerToken) {
+		if err := authorizeDeployToken(ctx, bearerToken, accessRecords); err != nil {
+			return nil, ac.wrapErr(req, err)
+		}
+		return WithAuthPerformed(ctx), nil
 	}
 
-	copied := ac.config
-	copied.BearerToken = bearerToken
-	osClient, err := client.New(&copied)
+	osClient, err := authenticator.ClientFor(bearerToken)
 	if err != nil {
-		return nil, ac.wrapErr(err)
+		return nil, ac.wrapErr(req, err)
+	}
+
+	// OIDC bearer tokens are verified against the configured issuer instead of the master;
+	// access reviews then name the mapped user and run with the registry's own client.
+	authorizer := ac.authorizer
+	if userInfo, ok := authenticateOIDCToken(ctx, bearerToken); ok {
+		registryClient, _, err := DefaultRegistryClient.Clients()
+		if err != nil {
+			return nil, ac.wrapErr(req, err)
+		}
+		osClient = registryClient
+		authorizer = newOIDCAuthorizer(userInfo)
+		ctx = WithAuthenticatedUserName(ctx, userInfo.GetName())
 	}
 
 	// In case of docker login, hits endpoint /v2
 	if len(accessRecords) == 0 {
-		if err := verifyOpenShiftUser(ctx, osClient); err != nil {
-			return nil, ac.wrapErr(err)
+		if err := authorizer.VerifyUser(ctx, osClient); err != nil {
+			return nil, ac.wrapErr(req, err)
 		}
 	}
 
@@ -252,7 +421,12 @@ func (ac *AccessController) Authorized(ctx context.Context, accessRecords ...reg
 		case "repository":
 			imageStreamNS, imageStreamName, err := getNamespaceName(access.Resource.Name)
 			if err != nil {
-				return nil, ac.wrapErr(err)
+				return nil, ac.wrapErr(req, err)
+			}
+
+			// protected namespaces demand a verified client certificate on top of the token
+			if err := ac.verifyClientCertPolicy(ctx, req, imageStreamNS); err != nil {
+				return nil, ac.wrapErr(req, err)
 			}
 
 			verb := ""
@@ -265,7 +439,7 @@ func (ac *AccessController) Authorized(ctx context.Context, accessRecords ...reg
 			case "*":
 				verb = "prune"
 			default:
-				return nil, ac.wrapErr(ErrUnsupportedAction)
+				return nil, ac.wrapErr(req, ErrUnsupportedAction)
 			}
 
 			switch verb {
@@ -273,16 +447,31 @@ func (ac *AccessController) Authorized(ctx context.Context, accessRecords ...reg
 				if verifiedPrune {
 					continue
 				}
-				if err := verifyPruneAccess(ctx, osClient); err != nil {
-					return nil, ac.wrapErr(err)
+				if err := authorizer.VerifyPruneAccess(ctx, osClient); err != nil {
+					return nil, ac.wrapErr(req, err)
 				}
 				verifiedPrune = true
 			default:
-				if err := verifyImageStreamAccess(ctx, imageStreamNS, imageStreamName, verb, osClient); err != nil {
-					if access.Action != "pull" {
-						return nil, ac.wrapErr(err)
+				// a recent identical review already passed for this token; skip the round-trip
+				if sarCache.allowed(bearerToken, imageStreamNS, imageStreamName, verb) {
+					if access.Action == "pull" {
+						degradedModeCache.remember(bearerToken, access.Resource.Name)
+					}
+					continue
+				}
+				if err := authorizer.VerifyImageStreamAccess(ctx, imageStreamNS, imageStreamName, verb, osClient); err != nil {
+					// an unreachable master is not a denial, so deferring the error as a
+					// possible cross-mount rejection would only hide it; surface it here
+					// so the degraded-mode and break-glass fallbacks in Authorized see it
+					if access.Action != "pull" || isConnectivityError(err) {
+						return nil, ac.wrapErr(req, err)
+					}
+					possibleCrossMountErrors.Add(imageStreamNS, imageStreamName, ac.wrapErr(req, err))
+				} else {
+					sarCache.remember(bearerToken, imageStreamNS, imageStreamName, verb)
+					if access.Action == "pull" {
+						degradedModeCache.remember(bearerToken, access.Resource.Name)
 					}
-					possibleCrossMountErrors.Add(imageStreamNS, imageStreamName, ac.wrapErr(err))
 				}
 			}
 
@@ -292,15 +481,15 @@ func (ac *AccessController) Authorized(ctx context.Context, accessRecords ...reg
 				if verifiedPrune {
 					continue
 				}
-				if err := verifyPruneAccess(ctx, osClient); err != nil {
-					return nil, ac.wrapErr(err)
+				if err := authorizer.VerifyPruneAccess(ctx, osClient); err != nil {
+					return nil, ac.wrapErr(req, err)
 				}
 				verifiedPrune = true
 			default:
-				return nil, ac.wrapErr(ErrUnsupportedAction)
+				return nil, ac.wrapErr(req, ErrUnsupportedAction)
 			}
 		default:
-			return nil, ac.wrapErr(ErrUnsupportedResource)
+			return nil, ac.wrapErr(req, ErrUnsupportedResource)
 		}
 	}
 
@@ -321,6 +510,19 @@ func (ac *AccessController) Authorized(ctx context.Context, accessRecords ...reg
 		context.GetLogger(ctx).Debugf("Origin auth: deferring errors: %#v", possibleCrossMountErrors)
 		ctx = WithDeferredErrors(ctx, possibleCrossMountErrors)
 	}
+	// Remember who is pushing so the created mappings can be attributed to them. The registry
+	// creates ImageStreamMappings with its own identity after the push SAR above, so the user's
+	// name is otherwise lost.
+	if len(pushChecks) > 0 {
+		if _, resolved := AuthenticatedUserNameFrom(ctx); !resolved {
+			if user, err := osClient.Users().Get("~"); err == nil {
+				ctx = WithAuthenticatedUserName(ctx, user.Name)
+			} else {
+				context.GetLogger(ctx).Warnf("Origin auth: could not resolve pushing user for attribution: %v", err)
+			}
+		}
+	}
+
 	// Always add a marker to the context so we know auth was run
 	ctx = WithAuthPerformed(ctx)
 
@@ -328,7 +530,7 @@ func (ac *AccessController) Authorized(ctx context.Context, accessRecords ...reg
 }
 
 func getNamespaceName(resourceName string) (string, string, error) {
-	repoParts := strings.SplitN(resourceName, "/", 2)
+	repoParts := strings.SplitN(repositoryNameNormalizer.normalize(resourceName), "/", 2)
 	if len(repoParts) != 2 {
 		return "", "", ErrNamespaceRequired
 	}
@@ -342,96 +544,3 @@ func getNamespaceName(resourceName string) (string, string, error) {
 	}
 	return ns, name, nil
 }
-
-func getOpenShiftAPIToken(ctx context.Context, req *http.Request) (string, error) {
-	token := ""
-
-	authParts := strings.SplitN(req.Header.Get("Authorization"), " ", 2)
-	if len(authParts) != 2 {
-		return "", ErrTokenRequired
-	}
-
-	switch strings.ToLower(authParts[0]) {
-	case "bearer":
-		// This is either a direct API token, or a token issued by our docker token handler
-		token = authParts[1]
-		// Recognize the token issued to anonymous users by our docker token handler
-		if token == anonymousToken {
-			token = ""
-		}
-
-	case "basic":
-		_, password, ok := req.BasicAuth()
-		if !ok || len(password) == 0 {
-			return "", ErrTokenInvalid
-		}
-		token = password
-
-	default:
-		return "", ErrTokenRequired
-	}
-
-	return token, nil
-}
-
-func verifyOpenShiftUser(ctx context.Context, client client.UsersInterface) error {
-	if _, err := client.Users().Get("~"); err != nil {
-		context.GetLogger(ctx).Errorf("Get user failed with error: %s", err)
-		if kerrors.IsUnauthorized(err) || kerrors.IsForbidden(err) {
-			return ErrOpenShiftAccessDenied
-		}
-		return err
-	}
-
-	return nil
-}
-
-func verifyImageStreamAccess(ctx context.Context, namespace, imageRepo, verb string, client client.LocalSubjectAccessReviewsNamespacer) error {
-	sar := authorizationapi.LocalSubjectAccessReview{
-		Action: authorizationapi.Action{
-			Verb:         verb,
-			Group:        imageapi.GroupName,
-			Resource:     "imagestreams/layers",
-			ResourceName: imageRepo,
-		},
-	}
-	response, err := client.LocalSubjectAccessReviews(namespace).Create(&sar)
-
-	if err != nil {
-		context.GetLogger(ctx).Errorf("OpenShift client error: %s", err)
-		if kerrors.IsUnauthorized(err) || kerrors.IsForbidden(err) {
-			return ErrOpenShiftAccessDenied
-		}
-		return err
-	}
-
-	if !response.Allowed {
-		context.GetLogger(ctx).Errorf("OpenShift access denied: %s", response.Reason)
-		return ErrOpenShiftAccessDenied
-	}
-
-	return nil
-}
-
-func verifyPruneAccess(ctx context.Context, client client.SubjectAccessReviews) error {
-	sar := authorizationapi.SubjectAccessReview{
-		Action: authorizationapi.Action{
-			Verb:     "delete",
-			Group:    imageapi.GroupName,
-			Resource: "images",
-		},
-	}
-	response, err := client.SubjectAccessReviews().Create(&sar)
-	if err != nil {
-		context.GetLogger(ctx).Errorf("OpenShift client error: %s", err)
-		if kerrors.IsUnauthorized(err) || kerrors.IsForbidden(err) {
-			return ErrOpenShiftAccessDenied
-		}
-		return err
-	}
-	if !response.Allowed {
-		context.GetLogger(ctx).Errorf("OpenShift access denied: %s", response.Reason)
-		return ErrOpenShiftAccessDenied
-	}
-	return nil
-}
@@ -254,6 +254,8 @@ func TestAccessController(t *testing.T) {
 				"POST /oapi/v1/namespaces/pushrepo/localsubjectaccessreviews (Authorization=Bearer awesome)",
 				"POST /oapi/v1/namespaces/pushrepo/localsubjectaccessreviews (Authorization=Bearer awesome)",
 				"POST /oapi/v1/namespaces/fromrepo/localsubjectaccessreviews (Authorization=Bearer awesome)",
+				// the authorized push resolves the pushing user so the mapping can be attributed
+				"GET /oapi/v1/users/~ (Authorization=Bearer awesome)",
 			},
 		},
 		"valid openshift token": {
@@ -427,3 +429,69 @@ func simulateOpenShiftMaster(responses []response) (*httptest.Server, *[]string)
 	}))
 	return server, &actions
 }
+
+// TestRealmsByHost verifies that challenge realms are selected based on the request Host header.
+func TestRealmsByHost(t *testing.T) {
+	options := map[string]interface{}{
+		RealmKey:      "myrealm",
+		TokenRealmKey: "https://tokenrealm.com/token",
+		RealmsByHostKey: map[interface{}]interface{}{
+			"registry.example.com": map[interface{}]interface{}{
+				RealmKey:      "routerealm",
+				TokenRealmKey: "https://registry.example.com/openshift/token",
+			},
+			"172.30.0.1": map[interface{}]interface{}{
+				TokenRealmKey: "https://172.30.0.1:5000/openshift/token",
+			},
+		},
+	}
+	accessController, err := newAccessController(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ac := accessController.(*AccessController)
+
+	tests := map[string]struct {
+		host               string
+		expectedRealm      string
+		expectedTokenRealm string
+	}{
+		"no override": {
+			host:               "other.example.com",
+			expectedRealm:      "myrealm",
+			expectedTokenRealm: "https://tokenrealm.com/token",
+		},
+		"full override": {
+			host:               "registry.example.com",
+			expectedRealm:      "routerealm",
+			expectedTokenRealm: "https://registry.example.com/openshift/token",
+		},
+		"partial override falls back to defaults": {
+			host:               "172.30.0.1",
+			expectedRealm:      "myrealm",
+			expectedTokenRealm: "https://172.30.0.1:5000/openshift/token",
+		},
+		"port stripped before host match": {
+			host:               "registry.example.com:5000",
+			expectedRealm:      "routerealm",
+			expectedTokenRealm: "https://registry.example.com/openshift/token",
+		},
+		"nil request": {
+			expectedRealm:      "myrealm",
+			expectedTokenRealm: "https://tokenrealm.com/token",
+		},
+	}
+	for name, test := range tests {
+		var req *http.Request
+		if len(test.host) > 0 {
+			req = &http.Request{Host: test.host}
+		}
+		realm, tokenRealm := ac.realmsForRequest(req)
+		if realm != test.expectedRealm {
+			t.Errorf("%s: got realm %q, expected %q", name, realm, test.expectedRealm)
+		}
+		if tokenRealm != test.expectedTokenRealm {
+			t.Errorf("%s: got token realm %q, expected %q", name, tokenRealm, test.expectedTokenRealm)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/registry/auth"
+
+	"github.com/openshift/origin/pkg/api/latest"
+)
+
+func TestBreakGlassPull(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("sesame"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "breakglass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "htpasswd")
+	if err := ioutil.WriteFile(path, []byte(fmt.Sprintf("# emergency pull identities\nemergency:%s\n", hash)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv(BreakGlassHTPasswdEnvVar, path)
+	defer os.Unsetenv(BreakGlassHTPasswdEnvVar)
+
+	DefaultRegistryClient = NewRegistryClient(unreachableMaster())
+
+	options := map[string]interface{}{
+		"addr":       "https://openshift-example.com/osapi",
+		"apiVersion": latest.Version,
+		RealmKey:     "myrealm",
+	}
+	accessController, err := newAccessController(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newContext := func(username, password string) context.Context {
+		req, err := http.NewRequest("GET", options["addr"].(string), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.SetBasicAuth(username, password)
+		return context.WithValue(context.Background(), "http.request", req)
+	}
+
+	pull := []auth.Access{{Resource: auth.Resource{Type: "repository", Name: "foo/bar"}, Action: "pull"}}
+
+	// valid htpasswd credentials are granted a pull while the master is unreachable
+	authCtx, err := accessController.Authorized(newContext("emergency", "sesame"), pull...)
+	if err != nil {
+		t.Fatalf("expected the break-glass pull to succeed while the master is unreachable: %v", err)
+	}
+	if !AuthPerformed(authCtx) {
+		t.Fatal("expected AuthPerformed on the break-glass context")
+	}
+	if !BreakGlassUsedFrom(authCtx) {
+		t.Fatal("expected the context to be marked as break-glass authorized")
+	}
+
+	// the initial /v2/ ping carries no access records and must succeed as well
+	if _, err := accessController.Authorized(newContext("emergency", "sesame")); err != nil {
+		t.Fatalf("expected the /v2/ ping to succeed with break-glass credentials: %v", err)
+	}
+
+	// a wrong password is refused
+	if _, err := accessController.Authorized(newContext("emergency", "wrong"), pull...); err == nil {
+		t.Fatal("expected a pull with a wrong break-glass password to fail")
+	}
+
+	// identities missing from the file are refused
+	if _, err := accessController.Authorized(newContext("intruder", "sesame"), pull...); err == nil {
+		t.Fatal("expected a pull with an unknown break-glass identity to fail")
+	}
+
+	// pushes are never granted this way
+	push := []auth.Access{{Resource: auth.Resource{Type: "repository", Name: "foo/bar"}, Action: "push"}}
+	if _, err := accessController.Authorized(newContext("emergency", "sesame"), push...); err == nil {
+		t.Fatal("expected a break-glass push to fail")
+	}
+}
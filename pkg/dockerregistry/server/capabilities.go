@@ -0,0 +1,74 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/docker/distribution/configuration"
+	context "github.com/docker/distribution/context"
+)
+
+// Capabilities enumerates optional features enabled in this registry instance. It is served from
+// the /openshift/capabilities endpoint so that clients and tests can adapt their behavior without
+// probing each feature with trial requests.
+type Capabilities struct {
+	// Pullthrough is true if the registry will serve remote blobs referenced by image streams.
+	Pullthrough bool `json:"pullthrough"`
+	// AcceptSchema2 is true if the registry accepts manifest schema version 2 on push.
+	AcceptSchema2 bool `json:"acceptschema2"`
+	// Signatures is true if the registry stores and serves schema1 manifest signatures.
+	Signatures bool `json:"signatures"`
+	// Referrers is true if the registry can enumerate manifests referring to a given digest.
+	Referrers bool `json:"referrers"`
+	// AnonymousPull is true if unauthenticated token requests are issued an anonymous token.
+	AnonymousPull bool `json:"anonymousPull"`
+}
+
+type capabilitiesHandler struct {
+	ctx          context.Context
+	capabilities Capabilities
+}
+
+// NewCapabilitiesHandler returns a handler that serves the capabilities of this registry derived
+// from the middleware configuration.
+func NewCapabilitiesHandler(ctx context.Context, config *configuration.Configuration) http.Handler {
+	return &capabilitiesHandler{
+		ctx:          ctx,
+		capabilities: capabilitiesFromConfiguration(config),
+	}
+}
+
+// capabilitiesFromConfiguration derives enabled features from the registry configuration, applying
+// the same environment variable overrides honored by the repository middleware.
+func capabilitiesFromConfiguration(config *configuration.Configuration) Capabilities {
+	caps := Capabilities{
+		// schema1 signatures are always persisted by the middleware.
+		Signatures: true,
+		// anonymous token requests are always answered by the token handler.
+		AnonymousPull: true,
+	}
+
+	for _, middleware := range config.Middleware["repository"] {
+		if middleware.Name != "openshift" {
+			continue
+		}
+		caps.Pullthrough = getBoolOption("pullthrough", false, middleware.Options)
+		caps.AcceptSchema2 = getBoolOption("acceptschema2", false, middleware.Options)
+	}
+
+	if value := os.Getenv(AcceptSchema2EnvVar); len(value) > 0 {
+		caps.AcceptSchema2 = value == "true"
+	}
+
+	return caps
+}
+
+func (h *capabilitiesHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ctx := context.WithRequest(h.ctx, req)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.capabilities); err != nil {
+		context.GetRequestLogger(ctx).Errorf("error writing capabilities response: %v", err)
+	}
+}
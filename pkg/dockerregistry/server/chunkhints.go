@@ -0,0 +1,96 @@
+package server
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// The OCI distribution spec lets a registry advise clients what chunk sizes its backend
+// handles well. Clients that adapt (buildah, oras) then pick chunked or monolithic uploads to
+// match the storage driver instead of guessing: a driver with a multipart minimum wants chunks
+// at least that large, and a driver with no such constraint is fastest with one monolithic
+// request. The hints are advisory; uploads that ignore them are still accepted and the driver
+// itself remains the authority on what it can store.
+const (
+	// ChunkMinLengthEnvVar overrides the advertised minimum chunk size in bytes. Empty
+	// selects a default for the configured storage driver.
+	ChunkMinLengthEnvVar = "REGISTRY_CHUNK_MIN_LENGTH"
+	// ChunkMaxLengthEnvVar overrides the advertised maximum chunk size in bytes. Empty
+	// selects a default for the configured storage driver.
+	ChunkMaxLengthEnvVar = "REGISTRY_CHUNK_MAX_LENGTH"
+)
+
+const (
+	chunkMinLengthHeader = "OCI-Chunk-Min-Length"
+	chunkMaxLengthHeader = "OCI-Chunk-Max-Length"
+)
+
+// driverChunkDefaults maps storage driver names to sensible chunk bounds. S3 and swift refuse
+// multipart segments under 5 MiB; azure assembles uploads from 4 MiB blocks; gcs wants
+// resumable chunks in 256 KiB multiples. The filesystem and inmemory drivers have no
+// constraint and advertise nothing, which tells clients a monolithic upload is fine.
+var driverChunkDefaults = map[string][2]int64{
+	"s3":    {5 * 1024 * 1024, 100 * 1024 * 1024},
+	"swift": {5 * 1024 * 1024, 100 * 1024 * 1024},
+	"azure": {4 * 1024 * 1024, 100 * 1024 * 1024},
+	"gcs":   {256 * 1024, 100 * 1024 * 1024},
+}
+
+// NewChunkHintsHandler returns handler wrapped so blob upload responses carry chunk size
+// hints for the configured storage driver.
+func NewChunkHintsHandler(handler http.Handler) http.Handler {
+	return &chunkHintsHandler{handler: handler}
+}
+
+type chunkHintsHandler struct {
+	handler http.Handler
+}
+
+func (h *chunkHintsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if isBlobUploadRequest(req) {
+		min, max := chunkBounds()
+		if min > 0 {
+			w.Header().Set(chunkMinLengthHeader, strconv.FormatInt(min, 10))
+		}
+		if max > 0 {
+			w.Header().Set(chunkMaxLengthHeader, strconv.FormatInt(max, 10))
+		}
+	}
+	h.handler.ServeHTTP(w, req)
+}
+
+// isBlobUploadRequest matches the requests that start or continue a blob upload session.
+func isBlobUploadRequest(req *http.Request) bool {
+	switch req.Method {
+	case "POST", "PATCH", "GET":
+	default:
+		return false
+	}
+	return strings.Contains(req.URL.Path, "/blobs/uploads")
+}
+
+// chunkBounds returns the advertised minimum and maximum chunk sizes. A zero means no hint.
+func chunkBounds() (int64, int64) {
+	defaults := [2]int64{}
+	if dockerStorageDriver != nil {
+		defaults = driverChunkDefaults[dockerStorageDriver.Name()]
+	}
+	return chunkOverride(ChunkMinLengthEnvVar, defaults[0]), chunkOverride(ChunkMaxLengthEnvVar, defaults[1])
+}
+
+func chunkOverride(name string, fallback int64) int64 {
+	value := os.Getenv(name)
+	if len(value) == 0 {
+		return fallback
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || parsed < 0 {
+		log.Errorf("ignoring invalid %s=%q", name, value)
+		return fallback
+	}
+	return parsed
+}
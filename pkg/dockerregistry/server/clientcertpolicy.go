@@ -0,0 +1,128 @@
+package server
+
+import (
+	"errors"
+	"expvar"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution/context"
+)
+
+const (
+	// ClientCertPolicyEnvVar selects how the TLS listener treats client certificates when
+	// client CAs are configured. "require" (the default) demands a certificate from every
+	// connection, as before. "optional" verifies a certificate when one is presented and
+	// otherwise lets the connection through, so that only the namespaces that opted in
+	// require one.
+	ClientCertPolicyEnvVar = "REGISTRY_CLIENT_CERT_POLICY"
+
+	// ClientCertNamespacesEnvVar lists namespaces (comma separated) whose repositories may
+	// only be accessed by connections that presented a verified client certificate. Requests
+	// for them must carry both mTLS and a token.
+	ClientCertNamespacesEnvVar = "REGISTRY_CLIENT_CERT_NAMESPACES"
+
+	// RequireClientCertAnnotation set to "true" on a namespace has the same effect as listing
+	// it in ClientCertNamespacesEnvVar, letting project policy opt in without reconfiguring
+	// the registry.
+	RequireClientCertAnnotation = "openshift.io/registry.requireClientCert"
+)
+
+// errClientCertRequired is deliberately not an auth challenge: re-authenticating cannot
+// produce a client certificate.
+var errClientCertRequired = errors.New("this namespace requires a verified client certificate")
+
+var clientCertNamespaces = readClientCertNamespaces()
+
+// clientCertMetrics counts requests refused for lacking a client certificate.
+var clientCertMetrics = expvar.NewMap("registry.clientCert")
+
+// clientCertOptional is true when the listener should accept connections without a client
+// certificate and leave enforcement to the per-namespace policy.
+func clientCertOptional() bool {
+	return os.Getenv(ClientCertPolicyEnvVar) == "optional"
+}
+
+func readClientCertNamespaces() map[string]bool {
+	namespaces := map[string]bool{}
+	for _, namespace := range strings.Split(os.Getenv(ClientCertNamespacesEnvVar), ",") {
+		if namespace = strings.TrimSpace(namespace); len(namespace) > 0 {
+			namespaces[namespace] = true
+		}
+	}
+	return namespaces
+}
+
+// clientCertAnnotationTTL is how long a namespace's annotation verdict is cached; the check
+// runs on every authorization, which must not cost a namespace read each time.
+const clientCertAnnotationTTL = time.Minute
+
+var clientCertVerdicts = &clientCertVerdictCache{verdicts: map[string]clientCertVerdict{}}
+
+type clientCertVerdict struct {
+	required bool
+	expires  time.Time
+}
+
+type clientCertVerdictCache struct {
+	mu       sync.Mutex
+	verdicts map[string]clientCertVerdict
+}
+
+// namespaceRequiresClientCert answers whether the namespace opted into mandatory client
+// certificates, via the registry-wide list or its own annotation. Lookup errors fall back to
+// not required, so a master hiccup cannot fail every pull of unaffected projects.
+func (ac *AccessController) namespaceRequiresClientCert(ctx context.Context, namespace string) bool {
+	// with the listener already demanding a certificate from every connection there is
+	// nothing per-namespace left to enforce
+	if !clientCertOptional() {
+		return false
+	}
+	if clientCertNamespaces[namespace] {
+		return true
+	}
+
+	clientCertVerdicts.mu.Lock()
+	verdict, ok := clientCertVerdicts.verdicts[namespace]
+	clientCertVerdicts.mu.Unlock()
+	if ok && time.Now().Before(verdict.expires) {
+		return verdict.required
+	}
+
+	required := false
+	if ac.registryClient != nil {
+		_, kClient, err := ac.registryClient.Clients()
+		if err == nil {
+			ns, err := kClient.Namespaces().Get(namespace)
+			if err == nil {
+				required = ns.Annotations[RequireClientCertAnnotation] == "true"
+			} else {
+				context.GetLogger(ctx).Errorf("failed to get namespace %s for client certificate policy: %v", namespace, err)
+			}
+		}
+	}
+
+	clientCertVerdicts.mu.Lock()
+	clientCertVerdicts.verdicts[namespace] = clientCertVerdict{required: required, expires: time.Now().Add(clientCertAnnotationTTL)}
+	clientCertVerdicts.mu.Unlock()
+	return required
+}
+
+// verifyClientCertPolicy refuses access to a protected namespace unless the connection
+// presented a certificate the listener verified. Requests arriving without TLS - for example
+// through an edge-terminated route - cannot satisfy the policy; protected namespaces need a
+// passthrough or re-encrypt path to the registry.
+func (ac *AccessController) verifyClientCertPolicy(ctx context.Context, req *http.Request, namespace string) error {
+	if !ac.namespaceRequiresClientCert(ctx, namespace) {
+		return nil
+	}
+	if req.TLS != nil && len(req.TLS.VerifiedChains) > 0 {
+		return nil
+	}
+	clientCertMetrics.Add("denied", 1)
+	context.GetLogger(ctx).Infof("refusing access to namespace %s: no verified client certificate", namespace)
+	return errClientCertRequired
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
+)
+
+// ClientCompatEnvVar holds a JSON array of compatibility rules consulted before the built-in
+// table, e.g. [{"client":"docker","maxVersion":"1.9","schema2":false,"chunkSizeHint":1048576}].
+// The first rule matching the client product and version from the User-Agent header wins. This
+// lets fleets with old docker engines keep pulling without hard failures: the registry serves
+// manifests the engine understands instead of relying on every client to negotiate correctly.
+const ClientCompatEnvVar = "REGISTRY_CLIENT_COMPAT"
+
+// mediaTypeOCIManifest and mediaTypeOCIImageIndex are the OCI image spec media types. The
+// vendored distribution predates OCI support, so they are spelled out here.
+const (
+	mediaTypeOCIManifest   = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIImageIndex = "application/vnd.oci.image.index.v1+json"
+)
+
+// clientCapabilities describes what a client version is known to handle.
+type clientCapabilities struct {
+	// Schema2 is false for engines that cannot process schema 2 manifests or manifest lists.
+	Schema2 bool `json:"schema2"`
+	// OCITypes is false for engines that cannot process OCI media types.
+	OCITypes bool `json:"ociTypes"`
+	// ChunkSizeHint, when positive, is advertised as the minimum chunk length on blob
+	// upload sessions started by this client.
+	ChunkSizeHint int64 `json:"chunkSizeHint"`
+}
+
+// compatRule binds capabilities to a client product and an inclusive maximum version.
+type compatRule struct {
+	// Client matches the product name from the first User-Agent token, e.g. "docker".
+	Client string `json:"client"`
+	// MaxVersion is the highest (dotted, numeric) version the rule applies to. Empty
+	// matches every version of the product.
+	MaxVersion string `json:"maxVersion"`
+
+	clientCapabilities
+}
+
+// defaultClientCapabilities assumes a modern client.
+var defaultClientCapabilities = clientCapabilities{Schema2: true, OCITypes: true}
+
+// builtinCompatRules captures well-known client limitations. Entries are checked in order.
+var builtinCompatRules = []compatRule{
+	// docker before 1.10 understands only schema 1
+	{Client: "docker", MaxVersion: "1.9", clientCapabilities: clientCapabilities{Schema2: false, OCITypes: false}},
+	// no classic docker engine in this era accepts OCI media types
+	{Client: "docker", clientCapabilities: clientCapabilities{Schema2: true, OCITypes: false}},
+}
+
+var compatRules = readCompatRules()
+
+func readCompatRules() []compatRule {
+	rules := []compatRule{}
+	if value := os.Getenv(ClientCompatEnvVar); len(value) > 0 {
+		if err := json.Unmarshal([]byte(value), &rules); err != nil {
+			log.Errorf("ignoring invalid %s: %v", ClientCompatEnvVar, err)
+			rules = nil
+		}
+	}
+	return append(rules, builtinCompatRules...)
+}
+
+// parseUserAgent returns the product and version of the first User-Agent token.
+func parseUserAgent(ua string) (product, version string) {
+	token := strings.SplitN(ua, " ", 2)[0]
+	parts := strings.SplitN(token, "/", 2)
+	if len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return parts[0], ""
+}
+
+// versionLessOrEqual reports whether version falls within the inclusive max bound. Dotted
+// numeric segments compare numerically, non-numeric segments as strings, and only as many
+// segments as the bound specifies are compared, so "1.9.1" is within a "1.9" bound.
+func versionLessOrEqual(version, max string) bool {
+	vs := strings.Split(version, ".")
+	ms := strings.Split(max, ".")
+	for i := 0; i < len(vs) && i < len(ms); i++ {
+		vn, verr := strconv.Atoi(vs[i])
+		mn, merr := strconv.Atoi(ms[i])
+		switch {
+		case verr == nil && merr == nil:
+			if vn != mn {
+				return vn < mn
+			}
+		default:
+			if vs[i] != ms[i] {
+				return vs[i] < ms[i]
+			}
+		}
+	}
+	return true
+}
+
+// capabilitiesForUserAgent resolves the first matching rule for the client.
+func capabilitiesForUserAgent(rules []compatRule, ua string) clientCapabilities {
+	product, version := parseUserAgent(ua)
+	for _, rule := range rules {
+		if rule.Client != product {
+			continue
+		}
+		if len(rule.MaxVersion) > 0 && !versionLessOrEqual(version, rule.MaxVersion) {
+			continue
+		}
+		return rule.clientCapabilities
+	}
+	return defaultClientCapabilities
+}
+
+// NewClientCompatHandler adjusts requests and responses for clients with known limitations:
+// media types beyond the client's support are removed from the Accept header, so the manifest
+// handler down-converts instead of serving something the engine cannot parse, and the chunk
+// size hint is advertised on new blob upload sessions.
+func NewClientCompatHandler(handler http.Handler) http.Handler {
+	return &clientCompatHandler{handler: handler, rules: compatRules}
+}
+
+type clientCompatHandler struct {
+	handler http.Handler
+	rules   []compatRule
+}
+
+func (h *clientCompatHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	caps := capabilitiesForUserAgent(h.rules, req.Header.Get("User-Agent"))
+
+	unsupported := []string{}
+	if !caps.Schema2 {
+		unsupported = append(unsupported, schema2.MediaTypeManifest, manifestlist.MediaTypeManifestList)
+	}
+	// conformance mode trusts the client's Accept header for OCI types, as the spec requires
+	if !caps.OCITypes && !ociConformanceEnabled() {
+		unsupported = append(unsupported, mediaTypeOCIManifest, mediaTypeOCIImageIndex)
+	}
+	if len(unsupported) > 0 {
+		filterAcceptHeader(req, unsupported)
+	}
+
+	if caps.ChunkSizeHint > 0 && req.Method == "POST" && strings.HasSuffix(req.URL.Path, "/blobs/uploads/") {
+		w.Header().Set("OCI-Chunk-Min-Length", strconv.FormatInt(caps.ChunkSizeHint, 10))
+	}
+
+	h.handler.ServeHTTP(w, req)
+}
+
+// filterAcceptHeader removes the given media types from the request's Accept headers.
+func filterAcceptHeader(req *http.Request, unsupported []string) {
+	accepts := req.Header["Accept"]
+	if len(accepts) == 0 {
+		return
+	}
+	filtered := []string{}
+	for _, header := range accepts {
+		kept := []string{}
+		for _, value := range strings.Split(header, ",") {
+			mediaType := strings.TrimSpace(strings.SplitN(value, ";", 2)[0])
+			remove := false
+			for _, u := range unsupported {
+				if mediaType == u {
+					remove = true
+					break
+				}
+			}
+			if !remove {
+				kept = append(kept, strings.TrimSpace(value))
+			}
+		}
+		if len(kept) > 0 {
+			filtered = append(filtered, strings.Join(kept, ", "))
+		}
+	}
+	req.Header["Accept"] = filtered
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
+)
+
+func TestCapabilitiesForUserAgent(t *testing.T) {
+	rules := append([]compatRule{
+		{Client: "docker", MaxVersion: "1.12", clientCapabilities: clientCapabilities{Schema2: true, ChunkSizeHint: 1 << 20}},
+	}, builtinCompatRules...)
+
+	tests := map[string]clientCapabilities{
+		// the prepended rule is checked first, so it also covers the older engines it spans
+		"docker/1.9.1 go/go1.4.2 kernel/3.10.0":  {Schema2: true, ChunkSizeHint: 1 << 20},
+		"docker/1.10.3 go/go1.5.4 kernel/3.10.0": {Schema2: true, ChunkSizeHint: 1 << 20},
+		"docker/1.13.1 go/go1.7.5":               {Schema2: true, OCITypes: false},
+		"containerd/1.0.0":                       defaultClientCapabilities,
+		"Go-http-client/1.1":                     defaultClientCapabilities,
+		"":                                       defaultClientCapabilities,
+	}
+	for ua, expected := range tests {
+		if actual := capabilitiesForUserAgent(rules, ua); actual != expected {
+			t.Errorf("%q: expected %#v, got %#v", ua, expected, actual)
+		}
+	}
+
+	builtins := map[string]clientCapabilities{
+		// the builtin 1.9 bound covers every 1.9.x patch release
+		"docker/1.9.1 go/go1.4.2 kernel/3.10.0": {Schema2: false, OCITypes: false},
+		"docker/1.9 go/go1.4.2":                 {Schema2: false, OCITypes: false},
+		"docker/1.10.3 go/go1.5.4":              {Schema2: true, OCITypes: false},
+	}
+	for ua, expected := range builtins {
+		if actual := capabilitiesForUserAgent(builtinCompatRules, ua); actual != expected {
+			t.Errorf("%q: expected %#v, got %#v", ua, expected, actual)
+		}
+	}
+}
+
+func TestClientCompatHandlerFiltersAccept(t *testing.T) {
+	var seen []string
+	handler := &clientCompatHandler{
+		rules: builtinCompatRules,
+		handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			seen = req.Header["Accept"]
+		}),
+	}
+
+	req, _ := http.NewRequest("GET", "/v2/foo/bar/manifests/latest", nil)
+	req.Header.Set("User-Agent", "docker/1.9.1 go/go1.4.2")
+	req.Header.Set("Accept", strings.Join([]string{
+		schema2.MediaTypeManifest,
+		manifestlist.MediaTypeManifestList,
+		"application/json",
+	}, ", "))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(seen) != 1 || seen[0] != "application/json" {
+		t.Errorf("expected schema2 media types removed, got %v", seen)
+	}
+
+	seen = nil
+	req, _ = http.NewRequest("GET", "/v2/foo/bar/manifests/latest", nil)
+	req.Header.Set("User-Agent", "containerd/1.0.0")
+	req.Header.Set("Accept", schema2.MediaTypeManifest)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(seen) != 1 || seen[0] != schema2.MediaTypeManifest {
+		t.Errorf("expected Accept header untouched for modern client, got %v", seen)
+	}
+}
+
+func TestClientCompatHandlerChunkSizeHint(t *testing.T) {
+	handler := &clientCompatHandler{
+		rules:   []compatRule{{Client: "docker", clientCapabilities: clientCapabilities{Schema2: true, ChunkSizeHint: 4096}}},
+		handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}),
+	}
+
+	req, _ := http.NewRequest("POST", "/v2/foo/bar/blobs/uploads/", nil)
+	req.Header.Set("User-Agent", "docker/1.12.6 go/go1.6.4")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if hint := w.Header().Get("OCI-Chunk-Min-Length"); hint != "4096" {
+		t.Errorf("expected chunk size hint 4096, got %q", hint)
+	}
+}
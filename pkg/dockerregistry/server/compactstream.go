@@ -0,0 +1,120 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/distribution/registry/handlers"
+	gorillahandlers "github.com/gorilla/handlers"
+
+	kerrors "k8s.io/kubernetes/pkg/api/errors"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// compactResult reports what a compaction run changed.
+type compactResult struct {
+	Namespace         string `json:"namespace"`
+	Name              string `json:"name"`
+	DroppedDuplicates int    `json:"droppedDuplicates"`
+	DroppedMissing    int    `json:"droppedMissing"`
+	RemainingEvents   int    `json:"remainingEvents"`
+	DryRun            bool   `json:"dryRun,omitempty"`
+}
+
+// CompactStreamDispatcher builds the handler compacting an image stream's tag history: duplicate
+// consecutive tag events are merged and events referencing deleted images are dropped. Giant
+// stream objects slow every tag resolution; compaction shrinks them without touching the current
+// target of any tag. The route requires prune-level access.
+func CompactStreamDispatcher(ctx *handlers.Context, r *http.Request) http.Handler {
+	compactStreamHandler := &compactStreamHandler{
+		Context: ctx,
+	}
+
+	return gorillahandlers.MethodHandler{
+		"POST": http.HandlerFunc(compactStreamHandler.Compact),
+	}
+}
+
+type compactStreamHandler struct {
+	*handlers.Context
+}
+
+// Compact rewrites the stream named by ?namespace=<ns>&name=<stream>. With ?dryRun=true the
+// result is computed but not written back. The stream is read and updated with the admin's own
+// client, so their access to the project applies unchanged.
+func (h *compactStreamHandler) Compact(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	result := compactResult{
+		Namespace: query.Get("namespace"),
+		Name:      query.Get("name"),
+		DryRun:    query.Get("dryRun") == "true",
+	}
+	if len(result.Namespace) == 0 || len(result.Name) == 0 {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail("the namespace and name parameters are required"))
+		return
+	}
+
+	uclient, ok := UserClientFrom(h.Context)
+	if !ok {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail("user client unavailable"))
+		return
+	}
+
+	stream, err := uclient.ImageStreams(result.Namespace).Get(result.Name)
+	if err != nil {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail(fmt.Sprintf("error getting image stream %s/%s: %v", result.Namespace, result.Name, err)))
+		return
+	}
+
+	// images are looked up once per digest; a digest is only considered gone on a clean 404
+	missing := map[string]bool{}
+	imageMissing := func(digest string) bool {
+		if gone, ok := missing[digest]; ok {
+			return gone
+		}
+		_, err := uclient.Images().Get(digest)
+		gone := err != nil && kerrors.IsNotFound(err)
+		missing[digest] = gone
+		return gone
+	}
+
+	for tag, events := range stream.Status.Tags {
+		kept := make([]imageapi.TagEvent, 0, len(events.Items))
+		for i, event := range events.Items {
+			if len(kept) > 0 {
+				last := kept[len(kept)-1]
+				if len(event.Image) > 0 && last.Image == event.Image && last.DockerImageReference == event.DockerImageReference {
+					result.DroppedDuplicates++
+					continue
+				}
+			}
+			// the newest event stays even if its image is gone; dropping it would retarget the tag
+			if i > 0 && len(event.Image) > 0 && imageMissing(event.Image) {
+				result.DroppedMissing++
+				continue
+			}
+			kept = append(kept, event)
+		}
+		events.Items = kept
+		stream.Status.Tags[tag] = events
+		result.RemainingEvents += len(kept)
+	}
+
+	if !result.DryRun && result.DroppedDuplicates+result.DroppedMissing > 0 {
+		if _, err := uclient.ImageStreams(result.Namespace).UpdateStatus(stream); err != nil {
+			h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail(fmt.Sprintf("error updating image stream %s/%s: %v", result.Namespace, result.Name, err)))
+			return
+		}
+		context.GetLogger(h.Context).Infof("compacted image stream %s/%s: dropped %d duplicate and %d dangling tag events",
+			result.Namespace, result.Name, result.DroppedDuplicates, result.DroppedMissing)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail(err))
+	}
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/reference"
+)
+
+// tagsListPath matches the v2 tags list endpoint, the tag resolution surface clients poll to
+// detect rollouts.
+var tagsListPath = regexp.MustCompile(`^/v2/` + reference.NameRegexp.String() + `/tags/list$`)
+
+// NewConditionalRequestHandler adds ETag / If-None-Match support to the tags list endpoint.
+// Manifest requests already carry an ETag equal to the manifest digest; a tags list has no single
+// digest, so the ETag is the digest of the response body. Clients revalidating an unchanged list
+// get a 304 without the registry re-sending it.
+func NewConditionalRequestHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || !tagsListPath.MatchString(r.URL.Path) {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		buffer := newResponseBuffer()
+		handler.ServeHTTP(buffer, r)
+
+		if buffer.status == http.StatusOK {
+			etag := fmt.Sprintf(`"%s"`, digest.FromBytes(buffer.body.Bytes()))
+			buffer.header.Set("Etag", etag)
+			if clientHasETag(r, etag) {
+				w.Header().Set("Etag", etag)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+
+		for key, values := range buffer.header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(buffer.status)
+		w.Write(buffer.body.Bytes())
+	})
+}
+
+// clientHasETag returns true if any If-None-Match value matches etag. Like the upstream manifest
+// handler, both quoted and unquoted forms are accepted.
+func clientHasETag(r *http.Request, etag string) bool {
+	for _, headerVal := range r.Header["If-None-Match"] {
+		if headerVal == etag || fmt.Sprintf(`"%s"`, headerVal) == etag || headerVal == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// responseBuffer captures a response so its ETag can be computed before anything is sent.
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+var _ http.ResponseWriter = &responseBuffer{}
+
+func newResponseBuffer() *responseBuffer {
+	return &responseBuffer{header: http.Header{}, status: http.StatusOK}
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *responseBuffer) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
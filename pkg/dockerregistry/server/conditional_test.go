@@ -0,0 +1,56 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConditionalTagsList(t *testing.T) {
+	body := `{"name":"user/app","tags":["latest"]}`
+	handler := NewConditionalRequestHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(body))
+	}))
+
+	response := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/v2/user/app/tags/list", nil)
+	handler.ServeHTTP(response, request)
+	if response.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", response.Code)
+	}
+	etag := response.Header().Get("Etag")
+	if len(etag) == 0 {
+		t.Fatalf("no Etag header on tags list response")
+	}
+	if response.Body.String() != body {
+		t.Errorf("unexpected body %q", response.Body.String())
+	}
+
+	response = httptest.NewRecorder()
+	request.Header.Set("If-None-Match", etag)
+	handler.ServeHTTP(response, request)
+	if response.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for matching If-None-Match, got %d", response.Code)
+	}
+	if response.Body.Len() != 0 {
+		t.Errorf("unexpected body on 304: %q", response.Body.String())
+	}
+
+	body = `{"name":"user/app","tags":["1.0","latest"]}`
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	if response.Code != http.StatusOK {
+		t.Errorf("expected 200 after the tag list changed, got %d", response.Code)
+	}
+	if response.Header().Get("Etag") == etag {
+		t.Errorf("Etag did not change with the tag list")
+	}
+
+	response = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/v2/user/app/manifests/latest", nil)
+	handler.ServeHTTP(response, request)
+	if len(response.Header().Get("Etag")) != 0 {
+		t.Errorf("Etag unexpectedly added outside the tags list endpoint")
+	}
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/docker/distribution/configuration"
+	"github.com/docker/distribution/context"
+)
+
+// The configuration dump lets support confirm what the running process actually loaded versus
+// what is configured: the resolved settings are logged as a block at startup and served on the
+// authorized admin listener at /config, with secret values redacted.
+
+// redactedEnvFragments are substrings of environment variable names whose values are never
+// shown.
+var redactedEnvFragments = []string{"TOKEN", "SECRET", "PASSWORD", "PASSWD", "CREDENTIAL"}
+
+var configDump = struct {
+	mu   sync.Mutex
+	data map[string]interface{}
+}{data: map[string]interface{}{}}
+
+// RecordConfiguration captures the resolved configuration for the dump endpoint and logs it.
+// Storage and auth parameters are summarized by name only; they may carry credentials.
+func RecordConfiguration(ctx context.Context, config *configuration.Configuration) {
+	data := map[string]interface{}{
+		"http.addr":      config.HTTP.Addr,
+		"http.tls":       len(config.HTTP.TLS.Certificate) > 0,
+		"storage.driver": config.Storage.Type(),
+		"log.level":      string(config.Log.Level),
+	}
+
+	authNames := []string{}
+	for name := range config.Auth {
+		authNames = append(authNames, name)
+	}
+	sort.Strings(authNames)
+	data["auth"] = authNames
+
+	middleware := map[string][]string{}
+	for kind, entries := range config.Middleware {
+		for _, entry := range entries {
+			middleware[kind] = append(middleware[kind], entry.Name)
+		}
+	}
+	data["middleware"] = middleware
+
+	data["environment"] = redactedEnvironment()
+
+	configDump.mu.Lock()
+	configDump.data = data
+	configDump.mu.Unlock()
+
+	logConfigurationDump(ctx, data)
+}
+
+// redactedEnvironment returns the registry-related environment with secret values redacted.
+func redactedEnvironment() map[string]string {
+	env := map[string]string{}
+	for _, entry := range os.Environ() {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name := parts[0]
+		if !strings.HasPrefix(name, "REGISTRY_") && !strings.HasPrefix(name, "OPENSHIFT_") && name != DockerRegistryURLEnvVar {
+			continue
+		}
+		value := parts[1]
+		for _, fragment := range redactedEnvFragments {
+			if strings.Contains(name, fragment) {
+				value = "[REDACTED]"
+				break
+			}
+		}
+		env[name] = value
+	}
+	return env
+}
+
+func logConfigurationDump(ctx context.Context, data map[string]interface{}) {
+	keys := []string{}
+	for key := range data {
+		if key == "environment" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	logger := context.GetLogger(ctx)
+	logger.Infof("resolved configuration:")
+	for _, key := range keys {
+		logger.Infof("  %s=%v", key, data[key])
+	}
+	env, _ := data["environment"].(map[string]string)
+	envKeys := []string{}
+	for key := range env {
+		envKeys = append(envKeys, key)
+	}
+	sort.Strings(envKeys)
+	for _, key := range envKeys {
+		logger.Infof("  env %s=%s", key, env[key])
+	}
+}
+
+// configDumpHandler serves the recorded configuration on the admin listener.
+func configDumpHandler(w http.ResponseWriter, req *http.Request) {
+	configDump.mu.Lock()
+	data := configDump.data
+	configDump.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Errorf("error writing configuration dump: %v", err)
+	}
+}
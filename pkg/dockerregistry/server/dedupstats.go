@@ -0,0 +1,120 @@
+package server
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/digest"
+)
+
+// After a successful push the registry reports how well layer deduplication worked: how many of
+// the referenced blobs were uploaded for this push versus already present, and the byte totals
+// for both. CI pipelines read these headers to track cache effectiveness.
+const (
+	dedupHeaderBlobsTotal        = "X-Registry-Blobs-Total"
+	dedupHeaderBlobsUploaded     = "X-Registry-Blobs-Uploaded"
+	dedupHeaderBlobsExisting     = "X-Registry-Blobs-Existing"
+	dedupHeaderBytesUploaded     = "X-Registry-Bytes-Uploaded"
+	dedupHeaderBytesDeduplicated = "X-Registry-Bytes-Deduplicated"
+)
+
+// recentUploadTTL bounds how long a committed upload counts as "new" for a following manifest
+// put; pushes commit their manifest promptly after the last layer.
+const recentUploadTTL = time.Hour
+
+const recentUploadLimit = 10000
+
+// recentUploads remembers blob digests committed by this process per repository.
+var recentUploads = struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+}{entries: make(map[string]time.Time)}
+
+func recordBlobUpload(repo string, dgst digest.Digest) {
+	recentUploads.mu.Lock()
+	defer recentUploads.mu.Unlock()
+	if len(recentUploads.entries) >= recentUploadLimit {
+		cutoff := time.Now().Add(-recentUploadTTL)
+		for key, when := range recentUploads.entries {
+			if when.Before(cutoff) {
+				delete(recentUploads.entries, key)
+			}
+		}
+	}
+	recentUploads.entries[repo+"@"+dgst.String()] = time.Now()
+}
+
+func recentlyUploaded(repo string, dgst digest.Digest) bool {
+	recentUploads.mu.Lock()
+	defer recentUploads.mu.Unlock()
+	when, ok := recentUploads.entries[repo+"@"+dgst.String()]
+	return ok && time.Since(when) <= recentUploadTTL
+}
+
+// setDedupSavingsHeaders summarizes the manifest's blob references on the push response.
+func setDedupSavingsHeaders(ctx context.Context, repo string, manifest distribution.Manifest) {
+	w, err := context.GetResponseWriter(ctx)
+	if err != nil {
+		return
+	}
+
+	var uploaded, existing int
+	var uploadedBytes, existingBytes int64
+	for _, desc := range manifest.References() {
+		if recentlyUploaded(repo, desc.Digest) {
+			uploaded++
+			uploadedBytes += desc.Size
+		} else {
+			existing++
+			existingBytes += desc.Size
+		}
+	}
+
+	w.Header().Set(dedupHeaderBlobsTotal, strconv.Itoa(uploaded+existing))
+	w.Header().Set(dedupHeaderBlobsUploaded, strconv.Itoa(uploaded))
+	w.Header().Set(dedupHeaderBlobsExisting, strconv.Itoa(existing))
+	w.Header().Set(dedupHeaderBytesUploaded, strconv.FormatInt(uploadedBytes, 10))
+	w.Header().Set(dedupHeaderBytesDeduplicated, strconv.FormatInt(existingBytes, 10))
+}
+
+// trackingBlobStore records committed uploads so the manifest put can report dedup savings.
+type trackingBlobStore struct {
+	distribution.BlobStore
+
+	repo string
+}
+
+var _ distribution.BlobStore = &trackingBlobStore{}
+
+func (bs *trackingBlobStore) Create(ctx context.Context, options ...distribution.BlobCreateOption) (distribution.BlobWriter, error) {
+	bw, err := bs.BlobStore.Create(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &trackingBlobWriter{BlobWriter: bw, repo: bs.repo}, nil
+}
+
+func (bs *trackingBlobStore) Resume(ctx context.Context, id string) (distribution.BlobWriter, error) {
+	bw, err := bs.BlobStore.Resume(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &trackingBlobWriter{BlobWriter: bw, repo: bs.repo}, nil
+}
+
+type trackingBlobWriter struct {
+	distribution.BlobWriter
+
+	repo string
+}
+
+func (bw *trackingBlobWriter) Commit(ctx context.Context, provisional distribution.Descriptor) (distribution.Descriptor, error) {
+	desc, err := bw.BlobWriter.Commit(ctx, provisional)
+	if err == nil {
+		recordBlobUpload(bw.repo, desc.Digest)
+	}
+	return desc, err
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution/context"
+	registryauth "github.com/docker/distribution/registry/auth"
+)
+
+// Degraded mode keeps workloads schedulable during control-plane outages: when SAR calls fail
+// because the master is unavailable, pulls that this process successfully authorized recently
+// are allowed again for a bounded window. Pushes are never allowed this way.
+const (
+	// DegradedModeEnvVar set to "true" opts in to degraded-mode pulls.
+	DegradedModeEnvVar = "REGISTRY_DEGRADED_MODE"
+	// DegradedModeWindowEnvVar bounds how long a cached authorization outlives the last
+	// successful check (a duration, default 1h).
+	DegradedModeWindowEnvVar = "REGISTRY_DEGRADED_MODE_WINDOW"
+)
+
+const defaultDegradedModeWindow = time.Hour
+
+// degradedModeCacheLimit caps the entries kept; the oldest are dropped beyond it.
+const degradedModeCacheLimit = 10000
+
+var degradedModeCache = newAuthorizationCache()
+
+// authorizationCache remembers which token/repository pairs passed a pull authorization and
+// when. Tokens are stored hashed.
+type authorizationCache struct {
+	enabled bool
+	window  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+func newAuthorizationCache() *authorizationCache {
+	c := &authorizationCache{
+		enabled: os.Getenv(DegradedModeEnvVar) == "true",
+		window:  timeoutFromEnv(DegradedModeWindowEnvVar, defaultDegradedModeWindow),
+		entries: make(map[string]time.Time),
+	}
+	return c
+}
+
+func authorizationCacheKey(token, repo string) string {
+	sum := sha256.Sum256([]byte(token + "\x00" + repo))
+	return hex.EncodeToString(sum[:])
+}
+
+// remember records a successful pull authorization.
+func (c *authorizationCache) remember(token, repo string) {
+	if !c.enabled {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= degradedModeCacheLimit {
+		cutoff := time.Now().Add(-c.window)
+		for key, when := range c.entries {
+			if when.Before(cutoff) {
+				delete(c.entries, key)
+			}
+		}
+	}
+	c.entries[authorizationCacheKey(token, repo)] = time.Now()
+}
+
+// allowed is true when the pair passed authorization within the window.
+func (c *authorizationCache) allowed(token, repo string) bool {
+	if !c.enabled {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	when, ok := c.entries[authorizationCacheKey(token, repo)]
+	return ok && time.Since(when) <= c.window
+}
+
+// tryDegradedMode allows a pull that failed only because the master API is unreachable, provided
+// every requested repository was authorized for the same token within the window. The returned
+// bool reports whether degraded access was granted.
+func (ac *AccessController) tryDegradedMode(ctx context.Context, authErr error, accessRecords []registryauth.Access) (context.Context, bool) {
+	if !degradedModeCache.enabled {
+		return nil, false
+	}
+	if !isConnectivityError(unwrapChallenge(authErr)) {
+		return nil, false
+	}
+	// an empty record set is the docker client's initial /v2/ ping; it grants no repository
+	// access but must succeed during an outage or the client never asks for repository scope
+	if len(accessRecords) > 0 && !pullOnlyAccess(accessRecords) {
+		return nil, false
+	}
+	req, err := context.GetRequest(ctx)
+	if err != nil {
+		return nil, false
+	}
+	token, err := getOpenShiftAPIToken(ctx, req)
+	if err != nil {
+		return nil, false
+	}
+	for _, access := range accessRecords {
+		if !degradedModeCache.allowed(token, access.Resource.Name) {
+			return nil, false
+		}
+	}
+
+	context.GetLogger(ctx).Warnf("degraded mode: master API unreachable (%v), allowing recently authorized pull", authErr)
+	return WithAuthPerformed(ctx), true
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/registry/auth"
+
+	"k8s.io/kubernetes/pkg/client/restclient"
+
+	"github.com/openshift/origin/pkg/api/latest"
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+)
+
+// unreachableMaster returns a client config whose host refuses connections, simulating a
+// master API outage.
+func unreachableMaster() *clientcmd.Config {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	host := server.URL
+	server.Close()
+	return &clientcmd.Config{
+		CommonConfig: restclient.Config{
+			Host:     host,
+			Insecure: true,
+		},
+		SkipEnv: true,
+	}
+}
+
+func TestDegradedModePull(t *testing.T) {
+	originalCache := degradedModeCache
+	degradedModeCache = &authorizationCache{enabled: true, window: time.Hour, entries: map[string]time.Time{}}
+	defer func() { degradedModeCache = originalCache }()
+
+	DefaultRegistryClient = NewRegistryClient(unreachableMaster())
+
+	options := map[string]interface{}{
+		"addr":       "https://openshift-example.com/osapi",
+		"apiVersion": latest.Version,
+		RealmKey:     "myrealm",
+	}
+	accessController, err := newAccessController(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// basic auth for openshift:awesome, so the API token seen by the cache is "awesome"
+	newContext := func() context.Context {
+		req, err := http.NewRequest("GET", options["addr"].(string), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("Basic %s", "b3BlbnNoaWZ0OmF3ZXNvbWU="))
+		return context.WithValue(context.Background(), "http.request", req)
+	}
+
+	pull := []auth.Access{{Resource: auth.Resource{Type: "repository", Name: "foo/bar"}, Action: "pull"}}
+
+	// a cold cache grants nothing
+	if _, err := accessController.Authorized(newContext(), pull...); err == nil {
+		t.Fatal("expected a pull with a cold cache to fail while the master is unreachable")
+	}
+
+	// a recently authorized pull is allowed again within the window
+	degradedModeCache.remember("awesome", "foo/bar")
+	authCtx, err := accessController.Authorized(newContext(), pull...)
+	if err != nil {
+		t.Fatalf("expected a recently authorized pull to succeed while the master is unreachable: %v", err)
+	}
+	if !AuthPerformed(authCtx) {
+		t.Fatal("expected AuthPerformed on the degraded-mode context")
+	}
+
+	// the cache does not cover repositories the token was never authorized for
+	other := []auth.Access{{Resource: auth.Resource{Type: "repository", Name: "foo/other"}, Action: "pull"}}
+	if _, err := accessController.Authorized(newContext(), other...); err == nil {
+		t.Fatal("expected a pull of a repository missing from the cache to fail")
+	}
+
+	// the docker client's initial /v2/ ping carries no access records and must succeed, or
+	// the client aborts before ever requesting repository scope
+	if _, err := accessController.Authorized(newContext()); err != nil {
+		t.Fatalf("expected the /v2/ ping to succeed in degraded mode: %v", err)
+	}
+
+	// pushes are never granted from the cache
+	push := []auth.Access{{Resource: auth.Resource{Type: "repository", Name: "foo/bar"}, Action: "push"}}
+	if _, err := accessController.Authorized(newContext(), push...); err == nil {
+		t.Fatal("expected a push to fail while the master is unreachable")
+	}
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/registry/api/errcode"
+	registryauth "github.com/docker/distribution/registry/auth"
+	"github.com/docker/distribution/registry/handlers"
+	gorillahandlers "github.com/gorilla/handlers"
+)
+
+// Deploy tokens give external consumers (edge devices, mirroring jobs) a read-only credential
+// scoped to one repository with an explicit expiry, without creating an OpenShift user. Anyone
+// with push access to the repository may mint one; the access controller validates tokens
+// locally against the shared secret.
+const (
+	// DeployTokenSecretEnvVar is the HMAC secret deploy tokens are signed with. Unset
+	// disables both minting and acceptance.
+	DeployTokenSecretEnvVar = "REGISTRY_DEPLOY_TOKEN_SECRET"
+	// DeployTokenMaxExpiryEnvVar caps the requested token lifetime (default 2160h, 90 days).
+	DeployTokenMaxExpiryEnvVar = "REGISTRY_DEPLOY_TOKEN_MAX_EXPIRY"
+)
+
+// deployTokenPrefix distinguishes deploy tokens from OpenShift bearer tokens.
+const deployTokenPrefix = "deploy."
+
+const (
+	defaultDeployTokenExpiry   = 24 * time.Hour
+	defaultDeployTokenMaxAge   = 90 * 24 * time.Hour
+	deployTokenSignatureLength = sha256.Size * 2
+)
+
+// deployTokenClaims is the signed payload of a deploy token. Exactly one of Repository and
+// Namespace is set.
+type deployTokenClaims struct {
+	// Repository is the only repository the token grants pulls from.
+	Repository string `json:"repository,omitempty"`
+	// Namespace grants pulls from every repository of one namespace; node tokens minted
+	// through the credential exchange endpoint are scoped this way.
+	Namespace string `json:"namespace,omitempty"`
+	// ExpiresAt is the expiry as a unix timestamp.
+	ExpiresAt int64 `json:"expiresAt"`
+}
+
+// mintDeployToken signs claims into the wire form of a deploy token.
+func mintDeployToken(secret string, claims deployTokenClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return deployTokenPrefix + encoded + "." + signDeployToken(secret, encoded), nil
+}
+
+func signDeployToken(secret, encodedPayload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(encodedPayload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyDeployToken checks the signature and expiry and returns the claims.
+func verifyDeployToken(secret, token string) (deployTokenClaims, error) {
+	claims := deployTokenClaims{}
+	body := strings.TrimPrefix(token, deployTokenPrefix)
+	dot := strings.LastIndex(body, ".")
+	if dot < 0 || len(body)-dot-1 != deployTokenSignatureLength {
+		return claims, fmt.Errorf("malformed deploy token")
+	}
+	encoded, signature := body[:dot], body[dot+1:]
+	if !hmac.Equal([]byte(signature), []byte(signDeployToken(secret, encoded))) {
+		return claims, fmt.Errorf("deploy token signature mismatch")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return claims, err
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, err
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return claims, fmt.Errorf("deploy token expired")
+	}
+	return claims, nil
+}
+
+// isDeployToken is true for credentials carrying the deploy token prefix.
+func isDeployToken(token string) bool {
+	return strings.HasPrefix(token, deployTokenPrefix)
+}
+
+// authorizeDeployToken validates a deploy token against the requested accesses: only pulls
+// from the token's repository are granted. It never consults the master API.
+func authorizeDeployToken(ctx context.Context, token string, accessRecords []registryauth.Access) error {
+	secret := os.Getenv(DeployTokenSecretEnvVar)
+	if len(secret) == 0 {
+		return ErrOpenShiftAccessDenied
+	}
+	claims, err := verifyDeployToken(secret, token)
+	if err != nil {
+		context.GetLogger(ctx).Debugf("deploy token rejected: %v", err)
+		return ErrOpenShiftAccessDenied
+	}
+	if len(accessRecords) == 0 {
+		// login ping; the token is valid
+		return nil
+	}
+	if !pullOnlyAccess(accessRecords) {
+		return ErrOpenShiftAccessDenied
+	}
+	for _, access := range accessRecords {
+		switch {
+		case len(claims.Repository) > 0 && access.Resource.Name == claims.Repository:
+		case len(claims.Namespace) > 0 && strings.HasPrefix(access.Resource.Name, claims.Namespace+"/"):
+		default:
+			return ErrOpenShiftAccessDenied
+		}
+	}
+	return nil
+}
+
+// DeployTokenDispatcher constructs the handler minting deploy tokens. Reaching it requires
+// push access to the repository, which limits minting to project admins and editors.
+func DeployTokenDispatcher(ctx *handlers.Context, r *http.Request) http.Handler {
+	deployTokenHandler := &deployTokenHandler{
+		Context: ctx,
+	}
+
+	return gorillahandlers.MethodHandler{
+		"POST": http.HandlerFunc(deployTokenHandler.Mint),
+	}
+}
+
+type deployTokenHandler struct {
+	*handlers.Context
+}
+
+// deployTokenResponse is the minting response body.
+type deployTokenResponse struct {
+	Token      string `json:"token"`
+	Repository string `json:"repository"`
+	ExpiresAt  string `json:"expiresAt"`
+}
+
+// Mint issues a read-only deploy token for the repository in the URL. The optional ?expiry=
+// parameter is a duration, bounded by the configured maximum.
+func (h *deployTokenHandler) Mint(w http.ResponseWriter, req *http.Request) {
+	secret := os.Getenv(DeployTokenSecretEnvVar)
+	if len(secret) == 0 {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnavailable.WithDetail("deploy tokens are not configured"))
+		return
+	}
+
+	expiry := defaultDeployTokenExpiry
+	if value := req.URL.Query().Get("expiry"); len(value) > 0 {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail(fmt.Sprintf("invalid expiry %q", value)))
+			return
+		}
+		expiry = parsed
+	}
+	if max := timeoutFromEnv(DeployTokenMaxExpiryEnvVar, defaultDeployTokenMaxAge); expiry > max {
+		expiry = max
+	}
+
+	claims := deployTokenClaims{
+		Repository: h.Repository.Named().Name(),
+		ExpiresAt:  time.Now().Add(expiry).Unix(),
+	}
+	token, err := mintDeployToken(secret, claims)
+	if err != nil {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deployTokenResponse{
+		Token:      token,
+		Repository: claims.Repository,
+		ExpiresAt:  time.Unix(claims.ExpiresAt, 0).UTC().Format(time.RFC3339),
+	})
+}
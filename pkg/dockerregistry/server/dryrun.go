@@ -0,0 +1,19 @@
+package server
+
+import (
+	"github.com/docker/distribution/context"
+)
+
+// DryRunHeader is the header equivalent of the ?dryRun=true query parameter on manifest puts.
+const DryRunHeader = "X-Registry-Dry-Run"
+
+// dryRunRequested is true when the client asked for the push to be validated without recording
+// it. Pipelines use this to gate on validity — auth, policy and manifest checks all run, and the
+// would-be digest is returned, but no ImageStreamMapping is created.
+func dryRunRequested(ctx context.Context) bool {
+	req, err := context.GetRequest(ctx)
+	if err != nil {
+		return false
+	}
+	return req.URL.Query().Get("dryRun") == "true" || req.Header.Get(DryRunHeader) == "true"
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"os"
+	"strings"
+)
+
+// QuotaExemptNamespacesEnvVar is a comma-separated list of namespaces (e.g. "openshift,
+// kube-system") exempt from registry-side quota and rate limiting. Infrastructure namespaces
+// host images the cluster itself needs; throttling them during an incident makes recovery
+// harder. The exemption is evaluated here centrally so each enforcement point doesn't
+// special-case the same names.
+const QuotaExemptNamespacesEnvVar = "REGISTRY_QUOTA_EXEMPT_NAMESPACES"
+
+var exemptNamespaces = readExemptNamespaces()
+
+func readExemptNamespaces() map[string]bool {
+	exempt := map[string]bool{}
+	for _, namespace := range strings.Split(os.Getenv(QuotaExemptNamespacesEnvVar), ",") {
+		if namespace = strings.TrimSpace(namespace); len(namespace) > 0 {
+			exempt[namespace] = true
+		}
+	}
+	return exempt
+}
+
+// namespaceExempt is true for namespaces excluded from quota and rate limiting.
+func namespaceExempt(namespace string) bool {
+	return exemptNamespaces[namespace]
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/digest"
+	storagedriver "github.com/docker/distribution/registry/storage/driver"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// ChaosErrorRateEnvVar injects errors into a fraction of storage driver and image stream backend
+// calls. The value is a probability between 0 and 1; unset or 0 disables injection. Together with
+// ChaosLatencyEnvVar this turns a registry into a deliberately flaky one for resilience testing
+// of clients and controllers. Never enable this in production.
+const ChaosErrorRateEnvVar = "REGISTRY_CHAOS_ERROR_RATE"
+
+// ChaosLatencyEnvVar adds up to the given duration of random latency to every storage driver and
+// image stream backend call.
+const ChaosLatencyEnvVar = "REGISTRY_CHAOS_LATENCY"
+
+// chaosConfig is read once at startup.
+var chaosConfig = readChaosConfig()
+
+type chaos struct {
+	errorRate float64
+	latency   time.Duration
+}
+
+func readChaosConfig() *chaos {
+	config := &chaos{}
+	if value := os.Getenv(ChaosErrorRateEnvVar); len(value) > 0 {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			log.Errorf("ignoring invalid %s=%q", ChaosErrorRateEnvVar, value)
+		} else {
+			config.errorRate = rate
+		}
+	}
+	if value := os.Getenv(ChaosLatencyEnvVar); len(value) > 0 {
+		latency, err := time.ParseDuration(value)
+		if err != nil || latency < 0 {
+			log.Errorf("ignoring invalid %s=%q", ChaosLatencyEnvVar, value)
+		} else {
+			config.latency = latency
+		}
+	}
+	if !config.enabled() {
+		return nil
+	}
+	log.Warnf("chaos fault injection enabled: errorRate=%v latency=%s", config.errorRate, config.latency)
+	return config
+}
+
+func (c *chaos) enabled() bool {
+	return c != nil && (c.errorRate > 0 || c.latency > 0)
+}
+
+// fault sleeps the configured random latency and decides whether the operation fails.
+func (c *chaos) fault(operation string) error {
+	if c.latency > 0 {
+		time.Sleep(time.Duration(rand.Int63n(int64(c.latency))))
+	}
+	if c.errorRate > 0 && rand.Float64() < c.errorRate {
+		return fmt.Errorf("injected fault in %s", operation)
+	}
+	return nil
+}
+
+// withFaultInjection wraps the driver with the chaos layer when it is enabled.
+func withFaultInjection(driver storagedriver.StorageDriver) storagedriver.StorageDriver {
+	if !chaosConfig.enabled() {
+		return driver
+	}
+	return &faultInjectingDriver{StorageDriver: driver, chaos: chaosConfig}
+}
+
+// faultInjectingDriver fails or delays storage operations at the configured rates.
+type faultInjectingDriver struct {
+	storagedriver.StorageDriver
+
+	chaos *chaos
+}
+
+var _ storagedriver.StorageDriver = &faultInjectingDriver{}
+
+func (d *faultInjectingDriver) GetContent(ctx context.Context, path string) ([]byte, error) {
+	if err := d.chaos.fault("GetContent"); err != nil {
+		return nil, err
+	}
+	return d.StorageDriver.GetContent(ctx, path)
+}
+
+func (d *faultInjectingDriver) PutContent(ctx context.Context, path string, content []byte) error {
+	if err := d.chaos.fault("PutContent"); err != nil {
+		return err
+	}
+	return d.StorageDriver.PutContent(ctx, path, content)
+}
+
+func (d *faultInjectingDriver) Reader(ctx context.Context, path string, offset int64) (io.ReadCloser, error) {
+	if err := d.chaos.fault("Reader"); err != nil {
+		return nil, err
+	}
+	return d.StorageDriver.Reader(ctx, path, offset)
+}
+
+func (d *faultInjectingDriver) Writer(ctx context.Context, path string, append bool) (storagedriver.FileWriter, error) {
+	if err := d.chaos.fault("Writer"); err != nil {
+		return nil, err
+	}
+	return d.StorageDriver.Writer(ctx, path, append)
+}
+
+func (d *faultInjectingDriver) Stat(ctx context.Context, path string) (storagedriver.FileInfo, error) {
+	if err := d.chaos.fault("Stat"); err != nil {
+		return nil, err
+	}
+	return d.StorageDriver.Stat(ctx, path)
+}
+
+func (d *faultInjectingDriver) List(ctx context.Context, path string) ([]string, error) {
+	if err := d.chaos.fault("List"); err != nil {
+		return nil, err
+	}
+	return d.StorageDriver.List(ctx, path)
+}
+
+func (d *faultInjectingDriver) Move(ctx context.Context, sourcePath string, destPath string) error {
+	if err := d.chaos.fault("Move"); err != nil {
+		return err
+	}
+	return d.StorageDriver.Move(ctx, sourcePath, destPath)
+}
+
+func (d *faultInjectingDriver) Delete(ctx context.Context, path string) error {
+	if err := d.chaos.fault("Delete"); err != nil {
+		return err
+	}
+	return d.StorageDriver.Delete(ctx, path)
+}
+
+// withBackendFaultInjection wraps the image stream backend with the chaos layer when enabled.
+func withBackendFaultInjection(backend imageStreamBackend) imageStreamBackend {
+	if !chaosConfig.enabled() {
+		return backend
+	}
+	return faultInjectingBackend{backend: backend, chaos: chaosConfig}
+}
+
+// faultInjectingBackend fails or delays image stream metadata operations.
+type faultInjectingBackend struct {
+	backend imageStreamBackend
+	chaos   *chaos
+}
+
+var _ imageStreamBackend = faultInjectingBackend{}
+
+func (b faultInjectingBackend) GetImageStream(namespace, name string) (*imageapi.ImageStream, error) {
+	if err := b.chaos.fault("GetImageStream"); err != nil {
+		return nil, err
+	}
+	return b.backend.GetImageStream(namespace, name)
+}
+
+func (b faultInjectingBackend) GetImage(dgst digest.Digest) (*imageapi.Image, error) {
+	if err := b.chaos.fault("GetImage"); err != nil {
+		return nil, err
+	}
+	return b.backend.GetImage(dgst)
+}
+
+func (b faultInjectingBackend) GetImageStreamImage(namespace, name string, dgst digest.Digest) (*imageapi.ImageStreamImage, error) {
+	if err := b.chaos.fault("GetImageStreamImage"); err != nil {
+		return nil, err
+	}
+	return b.backend.GetImageStreamImage(namespace, name, dgst)
+}
+
+func (b faultInjectingBackend) CreateImageStreamMapping(namespace string, ism *imageapi.ImageStreamMapping) error {
+	if err := b.chaos.fault("CreateImageStreamMapping"); err != nil {
+		return err
+	}
+	return b.backend.CreateImageStreamMapping(namespace, ism)
+}
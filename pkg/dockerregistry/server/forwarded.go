@@ -0,0 +1,88 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// TrustedProxyCIDRsEnvVar is a comma-separated list of CIDRs the registry accepts
+// X-Forwarded-Proto and X-Forwarded-Host headers from. The registry URL builder honors those
+// headers when generating upload Location headers and redirects; without a trust boundary any
+// client could spoof them. When unset every peer is trusted, matching the historic behavior.
+const TrustedProxyCIDRsEnvVar = "REGISTRY_TRUSTED_PROXY_CIDRS"
+
+// forwardedHeaders are the headers that influence generated URLs and must only be honored from
+// trusted proxies.
+var forwardedHeaders = []string{"X-Forwarded-Proto", "X-Forwarded-Host", "X-Forwarded-Port", "Forwarded"}
+
+// NewForwardedHeaderHandler strips forwarded headers from requests arriving from peers outside
+// the configured trusted proxy ranges. Without configuration handler is returned unchanged.
+func NewForwardedHeaderHandler(handler http.Handler) http.Handler {
+	value := os.Getenv(TrustedProxyCIDRsEnvVar)
+	if len(value) == 0 {
+		return handler
+	}
+	trusted := []*net.IPNet{}
+	for _, spec := range strings.Split(value, ",") {
+		spec = strings.TrimSpace(spec)
+		if len(spec) == 0 {
+			continue
+		}
+		_, cidr, err := net.ParseCIDR(spec)
+		if err != nil {
+			log.Fatalf("invalid CIDR %q in %s: %v", spec, TrustedProxyCIDRsEnvVar, err)
+		}
+		trusted = append(trusted, cidr)
+	}
+	log.Infof("honoring forwarded headers from %d trusted proxy range(s)", len(trusted))
+	return &forwardedHeaderHandler{handler: handler, trusted: trusted}
+}
+
+type forwardedHeaderHandler struct {
+	handler http.Handler
+	trusted []*net.IPNet
+}
+
+func (h *forwardedHeaderHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !h.trustedPeer(req.RemoteAddr) {
+		for _, header := range forwardedHeaders {
+			req.Header.Del(header)
+		}
+	}
+	h.handler.ServeHTTP(w, req)
+}
+
+func (h *forwardedHeaderHandler) trustedPeer(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range h.trusted {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedScheme returns the scheme the client used to reach the registry, honoring
+// X-Forwarded-Proto from trusted proxies (untrusted copies have been stripped by the time
+// handlers run). Empty is returned when the request carries no forwarded scheme.
+func forwardedScheme(req *http.Request) string {
+	proto := req.Header.Get("X-Forwarded-Proto")
+	// proxies may append to an existing list; the first entry is the client-facing scheme
+	proto = strings.TrimSpace(strings.SplitN(proto, ",", 2)[0])
+	switch proto {
+	case "http", "https":
+		return proto
+	}
+	return ""
+}
@@ -0,0 +1,259 @@
+package server
+
+import (
+	"expvar"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/distribution/registry/api/v2"
+	"github.com/docker/distribution/registry/storage"
+	storagedriver "github.com/docker/distribution/registry/storage/driver"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/util/sets"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/openshift/origin/pkg/client"
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// GuaranteedTagDeleteEnvVar set to "true" makes the registry watch image streams and, when a
+// tag deletion drops the last reference to a manifest, immediately remove that manifest and
+// any layer blobs no other image references from storage instead of leaving them for the next
+// prune run. Content still referenced by another stream or image is left alone. Each outcome
+// is recorded as an Event on the image stream so `oc delete istag` has visible, prompt
+// storage semantics. Recognized values are "true" and "false".
+const GuaranteedTagDeleteEnvVar = "REGISTRY_GUARANTEED_TAG_DELETE"
+
+// guaranteedDeleteMetrics counts manifests and blobs removed when tags are deleted.
+var guaranteedDeleteMetrics = expvar.NewMap("registry.guaranteedDelete")
+
+// RunGuaranteedTagDelete watches image streams and reclaims storage for deleted tags in the
+// background. It returns immediately; it is a no-op unless GuaranteedTagDeleteEnvVar is
+// enabled.
+func RunGuaranteedTagDelete(ctx context.Context, registryClient *RegistryClient) {
+	if os.Getenv(GuaranteedTagDeleteEnvVar) != "true" {
+		return
+	}
+	osClient, kClient, err := registryClient.Clients()
+	if err != nil {
+		context.GetLogger(ctx).Fatalf("error getting clients for guaranteed tag delete: %v", err)
+	}
+
+	watcher := &tagDeleteWatcher{
+		osClient: osClient,
+		kClient:  kClient,
+		known:    map[string]sets.String{},
+	}
+	context.GetLogger(ctx).Infof("watching image streams to reclaim storage for deleted tags")
+	go func() {
+		for {
+			if err := watcher.watchForTagDeletes(ctx); err != nil {
+				context.GetLogger(ctx).Errorf("guaranteed tag delete watch interrupted: %v", err)
+			}
+			time.Sleep(10 * time.Second)
+		}
+	}()
+}
+
+// tagDeleteWatcher remembers which digests each stream's status referenced so a modification
+// can be diffed against what the stream referenced before. A single goroutine owns the map.
+type tagDeleteWatcher struct {
+	osClient client.Interface
+	kClient  kclient.Interface
+
+	known map[string]sets.String
+}
+
+// watchForTagDeletes lists all image streams to seed the reference snapshot, then applies
+// watch events until the watch breaks. The caller restarts it. Digests already gone from a
+// stream when the watch starts are not reclaimed here; that remains the pruner's job.
+func (w *tagDeleteWatcher) watchForTagDeletes(ctx context.Context) error {
+	streams, err := w.osClient.ImageStreams(kapi.NamespaceAll).List(kapi.ListOptions{})
+	if err != nil {
+		return err
+	}
+	w.known = map[string]sets.String{}
+	for i := range streams.Items {
+		stream := &streams.Items[i]
+		w.known[stream.Namespace+"/"+stream.Name] = statusDigests(stream)
+	}
+	watcher, err := w.osClient.ImageStreams(kapi.NamespaceAll).Watch(kapi.ListOptions{ResourceVersion: streams.ResourceVersion})
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+	for event := range watcher.ResultChan() {
+		stream, ok := event.Object.(*imageapi.ImageStream)
+		if !ok {
+			continue
+		}
+		key := stream.Namespace + "/" + stream.Name
+		switch event.Type {
+		case watch.Added:
+			w.known[key] = statusDigests(stream)
+		case watch.Modified:
+			current := statusDigests(stream)
+			removed := w.known[key].Difference(current).List()
+			w.known[key] = current
+			w.reclaim(ctx, stream.Namespace, stream.Name, removed)
+		case watch.Deleted:
+			removed := w.known[key].List()
+			delete(w.known, key)
+			w.reclaim(ctx, stream.Namespace, stream.Name, removed)
+		}
+	}
+	return nil
+}
+
+// reclaim removes the manifests in removed, and their otherwise unreferenced layers, from the
+// storage backend. The cluster-wide reference check is a snapshot; a tag created between the
+// check and the removal loses the race, which is the same window the pruner has.
+func (w *tagDeleteWatcher) reclaim(ctx context.Context, namespace, name string, removed []string) {
+	if len(removed) == 0 {
+		return
+	}
+	streams, err := w.osClient.ImageStreams(kapi.NamespaceAll).List(kapi.ListOptions{})
+	if err != nil {
+		guaranteedDeleteMetrics.Add("errors", 1)
+		context.GetLogger(ctx).Errorf("guaranteed tag delete: error listing image streams: %v", err)
+		return
+	}
+	images, err := w.osClient.Images().List(kapi.ListOptions{})
+	if err != nil {
+		guaranteedDeleteMetrics.Add("errors", 1)
+		context.GetLogger(ctx).Errorf("guaranteed tag delete: error listing images: %v", err)
+		return
+	}
+
+	for _, dgst := range removed {
+		if stillTagged(streams, dgst) {
+			guaranteedDeleteMetrics.Add("retained", 1)
+			w.recordEvent(ctx, namespace, name, kapi.EventTypeNormal, "ImageContentRetained",
+				fmt.Sprintf("image %s is still referenced by another image stream; storage was not reclaimed", dgst))
+			continue
+		}
+
+		blobs := 0
+		if err := removeBlob(ctx, dgst); err != nil {
+			guaranteedDeleteMetrics.Add("errors", 1)
+			context.GetLogger(ctx).Errorf("guaranteed tag delete: error removing manifest %s: %v", dgst, err)
+			continue
+		}
+		guaranteedDeleteMetrics.Add("manifests", 1)
+		for _, layer := range imageLayerNames(images, dgst) {
+			if layerReferencedElsewhere(images, layer, dgst) {
+				continue
+			}
+			if err := removeBlob(ctx, layer); err != nil {
+				guaranteedDeleteMetrics.Add("errors", 1)
+				context.GetLogger(ctx).Errorf("guaranteed tag delete: error removing blob %s: %v", layer, err)
+				continue
+			}
+			guaranteedDeleteMetrics.Add("blobs", 1)
+			blobs++
+		}
+		context.GetLogger(ctx).Infof("guaranteed tag delete: removed manifest %s and %d layer blobs for %s/%s", dgst, blobs, namespace, name)
+		w.recordEvent(ctx, namespace, name, kapi.EventTypeNormal, "ImageContentDeleted",
+			fmt.Sprintf("removed manifest %s and %d unreferenced layer blobs from the registry", dgst, blobs))
+	}
+}
+
+// stillTagged reports whether any stream's status history still references the digest. The
+// deleting stream's own updated status is part of the listing, so a digest another of its tags
+// still points at is retained as well.
+func stillTagged(streams *imageapi.ImageStreamList, dgst string) bool {
+	for i := range streams.Items {
+		if statusDigests(&streams.Items[i]).Has(dgst) {
+			return true
+		}
+	}
+	return false
+}
+
+// imageLayerNames returns the layer digests of the image named dgst, or nil when the image is
+// already gone from the master.
+func imageLayerNames(images *imageapi.ImageList, dgst string) []string {
+	for i := range images.Items {
+		if images.Items[i].Name != dgst {
+			continue
+		}
+		layers := []string{}
+		for _, layer := range images.Items[i].DockerImageLayers {
+			layers = append(layers, layer.Name)
+		}
+		return layers
+	}
+	return nil
+}
+
+// layerReferencedElsewhere reports whether any image other than the one named except lists the
+// layer.
+func layerReferencedElsewhere(images *imageapi.ImageList, layer, except string) bool {
+	for i := range images.Items {
+		if images.Items[i].Name == except {
+			continue
+		}
+		for _, l := range images.Items[i].DockerImageLayers {
+			if l.Name == layer {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// removeBlob deletes the blob from the storage backend, tolerating blobs that are already
+// gone.
+func removeBlob(ctx context.Context, dgst string) error {
+	vacuum := storage.NewVacuum(ctx, dockerStorageDriver)
+	err := vacuum.RemoveBlob(dgst)
+	switch t := err.(type) {
+	case nil, storagedriver.PathNotFoundError:
+		return nil
+	case errcode.Error:
+		if t.Code == v2.ErrorCodeBlobUnknown {
+			return nil
+		}
+	default:
+		if err == distribution.ErrBlobUnknown {
+			return nil
+		}
+	}
+	return err
+}
+
+// recordEvent reflects the outcome on the image stream so `oc get events` shows what the
+// registry did with the deleted tag's content.
+func (w *tagDeleteWatcher) recordEvent(ctx context.Context, namespace, name, eventType, reason, message string) {
+	t := unversioned.Time{Time: time.Now()}
+	event := &kapi.Event{
+		ObjectMeta: kapi.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", name, t.UnixNano()),
+			Namespace: namespace,
+		},
+		InvolvedObject: kapi.ObjectReference{
+			Kind:      "ImageStream",
+			Namespace: namespace,
+			Name:      name,
+		},
+		Reason:  reason,
+		Message: message,
+		Source: kapi.EventSource{
+			Component: "docker-registry",
+		},
+		FirstTimestamp: t,
+		LastTimestamp:  t,
+		Count:          1,
+		Type:           eventType,
+	}
+	if _, err := w.kClient.Events(namespace).Create(event); err != nil {
+		context.GetLogger(ctx).Errorf("guaranteed tag delete: could not record event for %s/%s: %v", namespace, name, err)
+	}
+}
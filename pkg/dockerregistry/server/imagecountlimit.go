@@ -0,0 +1,104 @@
+package server
+
+import (
+	"expvar"
+	"fmt"
+
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/registry/api/errcode"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/util/sets"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// imageCountMetrics counts pushes denied because a stream or a project already holds the
+// configured number of distinct images.
+var imageCountMetrics = expvar.NewMap("registry.imageCount")
+
+// imageCountLimits extracts the distinct image caps from the given limit ranges: the per-stream
+// cap (ResourceImageStreamImages) and the project-wide cap (ResourceProjectImages). When several
+// limit ranges set the same cap, the smallest one wins, consistent with master-side admission.
+func imageCountLimits(lrs *kapi.LimitRangeList) (streamMax, projectMax int64, streamSet, projectSet bool) {
+	for _, limitrange := range lrs.Items {
+		for _, item := range limitrange.Spec.Limits {
+			if item.Type != imageapi.LimitTypeImageStream {
+				continue
+			}
+			if max, ok := item.Max[imageapi.ResourceImageStreamImages]; ok {
+				if !streamSet || max.Value() < streamMax {
+					streamMax = max.Value()
+					streamSet = true
+				}
+			}
+			if max, ok := item.Max[imageapi.ResourceProjectImages]; ok {
+				if !projectSet || max.Value() < projectMax {
+					projectMax = max.Value()
+					projectSet = true
+				}
+			}
+		}
+	}
+	return streamMax, projectMax, streamSet, projectSet
+}
+
+// checkImageCountLimit refuses a manifest push introducing a new digest into a stream or a
+// project already at its distinct image cap. Pushing a digest the stream or project already
+// references never increases the count and is always allowed. Master-side admission applies the
+// per-stream cap as well, but only after the mapping reaches the master; checking here keeps the
+// error close to the push and covers the project-wide cap that admission does not.
+func (r *repository) checkImageCountLimit(ctx context.Context, dgst digest.Digest) error {
+	lrs, err := limitRangesFor(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	streamMax, projectMax, streamSet, projectSet := imageCountLimits(lrs)
+	if !streamSet && !projectSet {
+		return nil
+	}
+
+	if streamSet {
+		// a missing stream counts as empty; auto-provisioning creates it during the push
+		if stream, err := r.getImageStream(); err == nil {
+			digests := statusDigests(stream)
+			if !digests.Has(dgst.String()) && int64(digests.Len()) >= streamMax {
+				imageCountMetrics.Add("deniedStream", 1)
+				return errcode.ErrorCodeDenied.WithDetail(fmt.Sprintf("image stream %s/%s already holds %d distinct images, the limit is %d; prune unused images with 'oadm prune images' or raise the %s limit", r.namespace, r.name, digests.Len(), streamMax, imageapi.ResourceImageStreamImages))
+			}
+		}
+	}
+
+	if projectSet {
+		streams, err := r.registryOSClient.ImageStreams(r.namespace).List(kapi.ListOptions{})
+		if err != nil {
+			context.GetLogger(ctx).Errorf("failed to list image streams in namespace %s: %v", r.namespace, err)
+			return err
+		}
+		digests := sets.NewString()
+		for i := range streams.Items {
+			digests = digests.Union(statusDigests(&streams.Items[i]))
+		}
+		if !digests.Has(dgst.String()) && int64(digests.Len()) >= projectMax {
+			imageCountMetrics.Add("deniedProject", 1)
+			return errcode.ErrorCodeDenied.WithDetail(fmt.Sprintf("project %s already holds %d distinct images, the limit is %d; prune unused images with 'oadm prune images' or raise the %s limit", r.namespace, digests.Len(), projectMax, imageapi.ResourceProjectImages))
+		}
+	}
+
+	return nil
+}
+
+// statusDigests collects the distinct image digests referenced by the stream's status tags.
+func statusDigests(stream *imageapi.ImageStream) sets.String {
+	digests := sets.NewString()
+	for _, history := range stream.Status.Tags {
+		for i := range history.Items {
+			if ref := history.Items[i].Image; len(ref) > 0 {
+				digests.Insert(ref)
+			}
+		}
+	}
+	return digests
+}
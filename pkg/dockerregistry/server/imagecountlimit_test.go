@@ -0,0 +1,91 @@
+package server
+
+import (
+	"testing"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/resource"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+func TestImageCountLimits(t *testing.T) {
+	limitRange := func(limits ...kapi.LimitRangeItem) kapi.LimitRange {
+		return kapi.LimitRange{Spec: kapi.LimitRangeSpec{Limits: limits}}
+	}
+	streamItem := func(images, projectImages string) kapi.LimitRangeItem {
+		item := kapi.LimitRangeItem{Type: imageapi.LimitTypeImageStream, Max: kapi.ResourceList{}}
+		if len(images) > 0 {
+			item.Max[imageapi.ResourceImageStreamImages] = resource.MustParse(images)
+		}
+		if len(projectImages) > 0 {
+			item.Max[imageapi.ResourceProjectImages] = resource.MustParse(projectImages)
+		}
+		return item
+	}
+
+	for _, tc := range []struct {
+		name                  string
+		items                 []kapi.LimitRange
+		streamMax, projectMax int64
+		streamSet, projectSet bool
+	}{
+		{
+			name: "no limit ranges",
+		},
+		{
+			name:  "unrelated limit type",
+			items: []kapi.LimitRange{limitRange(kapi.LimitRangeItem{Type: imageapi.LimitTypeImage})},
+		},
+		{
+			name:      "stream cap only",
+			items:     []kapi.LimitRange{limitRange(streamItem("10", ""))},
+			streamMax: 10, streamSet: true,
+		},
+		{
+			name:      "both caps",
+			items:     []kapi.LimitRange{limitRange(streamItem("10", "100"))},
+			streamMax: 10, streamSet: true,
+			projectMax: 100, projectSet: true,
+		},
+		{
+			name: "smallest cap wins across ranges",
+			items: []kapi.LimitRange{
+				limitRange(streamItem("10", "50")),
+				limitRange(streamItem("5", "100")),
+			},
+			streamMax: 5, streamSet: true,
+			projectMax: 50, projectSet: true,
+		},
+	} {
+		lrs := &kapi.LimitRangeList{Items: tc.items}
+		streamMax, projectMax, streamSet, projectSet := imageCountLimits(lrs)
+		if streamSet != tc.streamSet || streamMax != tc.streamMax {
+			t.Errorf("%s: got stream cap (%d, %t), want (%d, %t)", tc.name, streamMax, streamSet, tc.streamMax, tc.streamSet)
+		}
+		if projectSet != tc.projectSet || projectMax != tc.projectMax {
+			t.Errorf("%s: got project cap (%d, %t), want (%d, %t)", tc.name, projectMax, projectSet, tc.projectMax, tc.projectSet)
+		}
+	}
+}
+
+func TestStatusDigests(t *testing.T) {
+	stream := &imageapi.ImageStream{
+		Status: imageapi.ImageStreamStatus{
+			Tags: map[string]imageapi.TagEventList{
+				"latest": {Items: []imageapi.TagEvent{
+					{Image: "sha256:aaa"},
+					{Image: "sha256:bbb"},
+				}},
+				"v1": {Items: []imageapi.TagEvent{
+					{Image: "sha256:aaa"},
+					{Image: ""},
+				}},
+			},
+		},
+	}
+	digests := statusDigests(stream)
+	if digests.Len() != 2 || !digests.Has("sha256:aaa") || !digests.Has("sha256:bbb") {
+		t.Errorf("unexpected digests: %v", digests.List())
+	}
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"github.com/docker/distribution/digest"
+
+	"github.com/openshift/origin/pkg/client"
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// imageStreamBackend abstracts the metadata store behind the repository middleware: fetching
+// image streams, resolving images and recording pushes. The OpenShift API is the default
+// implementation; tests substitute a fake, and an alternative metadata store only has to satisfy
+// this interface.
+type imageStreamBackend interface {
+	// GetImageStream retrieves an image stream.
+	GetImageStream(namespace, name string) (*imageapi.ImageStream, error)
+	// GetImage retrieves the image with the given digest.
+	GetImage(dgst digest.Digest) (*imageapi.Image, error)
+	// GetImageStreamImage retrieves the image with the given digest, verifying that it belongs
+	// to the image stream.
+	GetImageStreamImage(namespace, name string, dgst digest.Digest) (*imageapi.ImageStreamImage, error)
+	// CreateImageStreamMapping records an image and its tag in an image stream.
+	CreateImageStreamMapping(namespace string, ism *imageapi.ImageStreamMapping) error
+}
+
+// clientImageStreamBackend implements imageStreamBackend against the OpenShift API.
+type clientImageStreamBackend struct {
+	client client.Interface
+}
+
+var _ imageStreamBackend = clientImageStreamBackend{}
+
+func (b clientImageStreamBackend) GetImageStream(namespace, name string) (*imageapi.ImageStream, error) {
+	return b.client.ImageStreams(namespace).Get(name)
+}
+
+func (b clientImageStreamBackend) GetImage(dgst digest.Digest) (*imageapi.Image, error) {
+	return b.client.Images().Get(dgst.String())
+}
+
+func (b clientImageStreamBackend) GetImageStreamImage(namespace, name string, dgst digest.Digest) (*imageapi.ImageStreamImage, error) {
+	return b.client.ImageStreamImages(namespace).Get(name, dgst.String())
+}
+
+func (b clientImageStreamBackend) CreateImageStreamMapping(namespace string, ism *imageapi.ImageStreamMapping) error {
+	return b.client.ImageStreamMappings(namespace).Create(ism)
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/docker/distribution/context"
+	"github.com/docker/libtrust"
+)
+
+// TokenSigningKeysEnvVar is a comma separated list of paths to PEM encoded keys whose public
+// halves are published on the JWKS endpoint. Listing several keys allows rotation with
+// overlapping validity: add the new key, wait for verifiers to refresh the key set, then start
+// signing with it and eventually drop the old one. Both private and public key files are
+// accepted - only public material is ever published.
+const TokenSigningKeysEnvVar = "REGISTRY_TOKEN_SIGNING_KEYS"
+
+// Schema1SigningKeyEnvVar points at a persisted libtrust private key, typically mounted from a
+// secret, used to re-sign schema1 manifests converted on the fly. Without it every process
+// generates an ephemeral key and the digests of converted manifests change across registry
+// restarts and replicas. Rotating the secret and restarting picks up a new key.
+const Schema1SigningKeyEnvVar = "REGISTRY_SCHEMA1_SIGNING_KEY"
+
+// jwksHandler serves the JSON Web Key Set of the registry token service so external registries
+// and auditors can verify issued tokens offline.
+type jwksHandler struct {
+	keys []libtrust.PublicKey
+}
+
+// NewJWKSHandler loads the keys named by TokenSigningKeysEnvVar and returns a handler publishing
+// them as a JWK set. Returns nil when no keys are configured.
+func NewJWKSHandler(ctx context.Context) http.Handler {
+	paths := []string{}
+	for _, path := range strings.Split(os.Getenv(TokenSigningKeysEnvVar), ",") {
+		path = strings.TrimSpace(path)
+		if len(path) > 0 {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	keys := []libtrust.PublicKey{}
+	for _, path := range paths {
+		key, err := loadPublicKey(path)
+		if err != nil {
+			context.GetLogger(ctx).Errorf("unable to load token signing key %s: %v", path, err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		context.GetLogger(ctx).Errorf("no token signing keys could be loaded, not serving a key set")
+		return nil
+	}
+
+	context.GetLogger(ctx).Infof("publishing %d token verification keys", len(keys))
+	return &jwksHandler{keys: keys}
+}
+
+// loadPublicKey reads the public half of the key at path, accepting both private and public PEM
+// files.
+func loadPublicKey(path string) (libtrust.PublicKey, error) {
+	if key, err := libtrust.LoadKeyFile(path); err == nil {
+		return key.PublicKey(), nil
+	}
+	return libtrust.LoadPublicKeyFile(path)
+}
+
+func (h *jwksHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	// key sets are small and verifiers poll them; let proxies cache briefly so rotation still
+	// propagates quickly
+	w.Header().Set("Cache-Control", "max-age=60")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": h.keys,
+	})
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/manifest/schema2"
+	regapi "github.com/docker/distribution/registry/api/v2"
+)
+
+// LayerVerificationEnvVar controls checking that layers referenced by a pushed manifest really
+// contain what their declared media type says. Recognized values are "off" (the default), "warn"
+// which only logs mismatches, and "reject" which fails the manifest push. It overrides the
+// "verifylayers" middleware configuration option. Broken build tooling occasionally uploads
+// uncompressed tars declared as gzip; such images break only when a node pulls them.
+const LayerVerificationEnvVar = "REGISTRY_MIDDLEWARE_REPOSITORY_OPENSHIFT_VERIFYLAYERS"
+
+const (
+	layerVerificationOff    = "off"
+	layerVerificationWarn   = "warn"
+	layerVerificationReject = "reject"
+)
+
+// gzipMagic starts every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// verifyLayerMediaTypes sniffs the stored content of every gzip layer the manifest references
+// and compares it against the declared media type. Layers not present locally (pullthrough) are
+// skipped.
+func (r *repository) verifyLayerMediaTypes(ctx context.Context, manifest distribution.Manifest) error {
+	if r.layerVerification != layerVerificationWarn && r.layerVerification != layerVerificationReject {
+		return nil
+	}
+
+	blobs := r.Repository.Blobs(ctx)
+	for _, reference := range manifest.References() {
+		if reference.MediaType != schema2.MediaTypeLayer {
+			continue
+		}
+		blob, err := blobs.Open(ctx, reference.Digest)
+		if err != nil {
+			if err != distribution.ErrBlobUnknown {
+				context.GetLogger(ctx).Errorf("cannot open layer %s for verification: %v", reference.Digest.String(), err)
+			}
+			continue
+		}
+		header := make([]byte, len(gzipMagic))
+		_, err = io.ReadFull(blob, header)
+		blob.Close()
+		if err != nil {
+			context.GetLogger(ctx).Errorf("cannot read layer %s for verification: %v", reference.Digest.String(), err)
+			continue
+		}
+		if header[0] == gzipMagic[0] && header[1] == gzipMagic[1] {
+			continue
+		}
+
+		detail := fmt.Sprintf("layer %s is declared %s but its content is not gzip compressed", reference.Digest.String(), reference.MediaType)
+		if r.layerVerification == layerVerificationWarn {
+			context.GetLogger(ctx).Warnf("%s", detail)
+			continue
+		}
+		return regapi.ErrorCodeManifestInvalid.WithDetail(detail)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package server
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/registry/api/errcode"
+	storagedriver "github.com/docker/distribution/registry/storage/driver"
+)
+
+const (
+	// LoadSheddingEnvVar is a boolean environment variable enabling adaptive upload shedding.
+	// When "true" and the storage backend shows a high error rate or latency (e.g. S3
+	// throttling), new blob uploads are rejected with 503 so pulls, which are mostly served from
+	// cache and existing data, keep working instead of the whole registry collapsing.
+	LoadSheddingEnvVar = "REGISTRY_LOAD_SHEDDING"
+
+	// storageHealthWindow is how far back driver operations are considered.
+	storageHealthWindow = time.Minute
+	// storageHealthMinSamples is the number of recent operations required before the backend can
+	// be declared degraded, preventing flapping on sparse traffic.
+	storageHealthMinSamples = 10
+	// storageHealthMaxErrorRate is the fraction of failed operations above which the backend
+	// counts as degraded.
+	storageHealthMaxErrorRate = 0.5
+	// storageHealthMaxLatency is the average operation latency above which the backend counts as
+	// degraded.
+	storageHealthMaxLatency = 5 * time.Second
+)
+
+// storageHealthMonitor aggregates the outcome of recent storage driver operations. It is fed by
+// the monitored driver wrapper and consulted by the shedding blob store.
+var storageHealthMonitor = newStorageHealth(storageHealthWindow)
+
+// uploadSheddingEnabled reports whether degraded storage sheds new uploads.
+func uploadSheddingEnabled() bool {
+	return os.Getenv(LoadSheddingEnvVar) == "true"
+}
+
+// storageSample is the outcome of a single storage driver operation.
+type storageSample struct {
+	when     time.Time
+	failed   bool
+	duration time.Duration
+}
+
+// storageHealth keeps a sliding window of storage driver operation outcomes. Safe for concurrent
+// use.
+type storageHealth struct {
+	mu      sync.Mutex
+	window  time.Duration
+	samples []storageSample
+}
+
+func newStorageHealth(window time.Duration) *storageHealth {
+	return &storageHealth{window: window}
+}
+
+// observe records the outcome of a driver operation. Path errors are not failures of the
+// backend, only of the request.
+func (h *storageHealth) observe(err error, duration time.Duration) {
+	failed := err != nil
+	switch err.(type) {
+	case nil:
+	case storagedriver.PathNotFoundError, storagedriver.InvalidPathError, storagedriver.InvalidOffsetError:
+		failed = false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.prune()
+	h.samples = append(h.samples, storageSample{when: time.Now(), failed: failed, duration: duration})
+}
+
+// degraded returns true when recent driver operations show an excessive error rate or latency.
+func (h *storageHealth) degraded() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.prune()
+	if len(h.samples) < storageHealthMinSamples {
+		return false
+	}
+	failed := 0
+	var total time.Duration
+	for _, s := range h.samples {
+		if s.failed {
+			failed++
+		}
+		total += s.duration
+	}
+	if float64(failed)/float64(len(h.samples)) > storageHealthMaxErrorRate {
+		return true
+	}
+	return total/time.Duration(len(h.samples)) > storageHealthMaxLatency
+}
+
+// prune drops samples older than the window. Callers must hold the lock.
+func (h *storageHealth) prune() {
+	cutoff := time.Now().Add(-h.window)
+	kept := h.samples[:0]
+	for _, s := range h.samples {
+		if s.when.After(cutoff) {
+			kept = append(kept, s)
+		}
+	}
+	h.samples = kept
+}
+
+// monitoredDriver wraps a storage driver and feeds the outcome of every operation into the
+// storage health monitor.
+type monitoredDriver struct {
+	storagedriver.StorageDriver
+}
+
+var _ storagedriver.StorageDriver = &monitoredDriver{}
+
+func (d *monitoredDriver) observe(err error, start time.Time) {
+	storageHealthMonitor.observe(err, time.Since(start))
+}
+
+func (d *monitoredDriver) GetContent(ctx context.Context, path string) ([]byte, error) {
+	start := time.Now()
+	content, err := d.StorageDriver.GetContent(ctx, path)
+	d.observe(err, start)
+	return content, err
+}
+
+func (d *monitoredDriver) PutContent(ctx context.Context, path string, content []byte) error {
+	start := time.Now()
+	err := d.StorageDriver.PutContent(ctx, path, content)
+	d.observe(err, start)
+	return err
+}
+
+func (d *monitoredDriver) Reader(ctx context.Context, path string, offset int64) (io.ReadCloser, error) {
+	start := time.Now()
+	reader, err := d.StorageDriver.Reader(ctx, path, offset)
+	d.observe(err, start)
+	return reader, err
+}
+
+func (d *monitoredDriver) Writer(ctx context.Context, path string, append bool) (storagedriver.FileWriter, error) {
+	start := time.Now()
+	writer, err := d.StorageDriver.Writer(ctx, path, append)
+	d.observe(err, start)
+	return writer, err
+}
+
+func (d *monitoredDriver) Stat(ctx context.Context, path string) (storagedriver.FileInfo, error) {
+	start := time.Now()
+	fi, err := d.StorageDriver.Stat(ctx, path)
+	d.observe(err, start)
+	return fi, err
+}
+
+func (d *monitoredDriver) List(ctx context.Context, path string) ([]string, error) {
+	start := time.Now()
+	entries, err := d.StorageDriver.List(ctx, path)
+	d.observe(err, start)
+	return entries, err
+}
+
+func (d *monitoredDriver) Move(ctx context.Context, sourcePath string, destPath string) error {
+	start := time.Now()
+	err := d.StorageDriver.Move(ctx, sourcePath, destPath)
+	d.observe(err, start)
+	return err
+}
+
+func (d *monitoredDriver) Delete(ctx context.Context, path string) error {
+	start := time.Now()
+	err := d.StorageDriver.Delete(ctx, path)
+	d.observe(err, start)
+	return err
+}
+
+// sheddingBlobStore refuses to start new blob uploads while the storage backend is degraded,
+// returning 503 so clients retry later. Reads are never shed.
+type sheddingBlobStore struct {
+	distribution.BlobStore
+}
+
+var _ distribution.BlobStore = &sheddingBlobStore{}
+
+func (bs *sheddingBlobStore) Create(ctx context.Context, options ...distribution.BlobCreateOption) (distribution.BlobWriter, error) {
+	if storageHealthMonitor.degraded() {
+		context.GetLogger(ctx).Warnf("storage backend degraded, shedding blob upload")
+		return nil, errcode.ErrorCodeUnavailable.WithDetail("storage backend degraded, retry uploads later")
+	}
+	return bs.BlobStore.Create(ctx, options...)
+}
+
+func (bs *sheddingBlobStore) Resume(ctx context.Context, id string) (distribution.BlobWriter, error) {
+	if storageHealthMonitor.degraded() {
+		context.GetLogger(ctx).Warnf("storage backend degraded, shedding blob upload")
+		return nil, errcode.ErrorCodeUnavailable.WithDetail("storage backend degraded, retry uploads later")
+	}
+	return bs.BlobStore.Resume(ctx, id)
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/uuid"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/client/leaderelection"
+	"k8s.io/kubernetes/pkg/client/record"
+	"k8s.io/kubernetes/pkg/util/wait"
+)
+
+const (
+	// LeaderElectionNamespaceEnvVar is the namespace holding the maintenance leader lock. When
+	// set, registry replicas elect a single leader to run background maintenance tasks so scaling
+	// the deployment does not multiply the work or race concurrent runs. When empty, no
+	// maintenance tasks run.
+	LeaderElectionNamespaceEnvVar = "REGISTRY_LEADER_ELECTION_NAMESPACE"
+
+	// maintenanceLockName is the name of the endpoints object used as the leader lock.
+	maintenanceLockName = "docker-registry-maintenance"
+
+	maintenanceLeaseDuration = 15 * time.Second
+	maintenanceRenewDeadline = 10 * time.Second
+	maintenanceRetryPeriod   = 2 * time.Second
+)
+
+// MaintenanceTask is a periodic background task that must run on at most one registry replica at
+// a time, such as storage scrubbing or notification retries.
+type MaintenanceTask struct {
+	// Name identifies the task in logs.
+	Name string
+	// Interval is how often the task runs while this replica is the leader.
+	Interval time.Duration
+	// Run performs one round of the task.
+	Run func(ctx context.Context) error
+}
+
+var maintenance = struct {
+	sync.Mutex
+	tasks []MaintenanceTask
+}{}
+
+// RegisterMaintenanceTask adds a task to be run periodically by the replica currently holding
+// the maintenance leader lock. Tasks must be registered before RunMaintenance is called.
+func RegisterMaintenanceTask(task MaintenanceTask) {
+	maintenance.Lock()
+	defer maintenance.Unlock()
+	maintenance.tasks = append(maintenance.tasks, task)
+}
+
+// RunMaintenance starts leader election for background maintenance tasks and returns
+// immediately. It is a no-op unless LeaderElectionNamespaceEnvVar is set. The elected leader runs
+// every registered task at its interval until it loses the lease, at which point another replica
+// takes over.
+func RunMaintenance(ctx context.Context, registryClient *RegistryClient) {
+	namespace := os.Getenv(LeaderElectionNamespaceEnvVar)
+	if len(namespace) == 0 {
+		context.GetLogger(ctx).Debugf("%s not set, not running maintenance tasks", LeaderElectionNamespaceEnvVar)
+		return
+	}
+
+	_, kClient, err := registryClient.Clients()
+	if err != nil {
+		context.GetLogger(ctx).Errorf("unable to get clients for maintenance leader election: %v", err)
+		return
+	}
+
+	hostname, _ := os.Hostname()
+	identity := fmt.Sprintf("%s_%s", hostname, uuid.Generate().String())
+
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(kClient.Events(""))
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		EndpointsMeta: kapi.ObjectMeta{
+			Namespace: namespace,
+			Name:      maintenanceLockName,
+		},
+		Identity:      identity,
+		Client:        kClient,
+		EventRecorder: broadcaster.NewRecorder(kapi.EventSource{Component: "registry-maintenance"}),
+		LeaseDuration: maintenanceLeaseDuration,
+		RenewDeadline: maintenanceRenewDeadline,
+		RetryPeriod:   maintenanceRetryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(stop <-chan struct{}) {
+				context.GetLogger(ctx).Infof("became maintenance leader as %s", identity)
+				runMaintenanceTasks(ctx, stop)
+			},
+			OnStoppedLeading: func() {
+				context.GetLogger(ctx).Infof("lost maintenance leadership")
+			},
+			OnNewLeader: func(leader string) {
+				context.GetLogger(ctx).Debugf("observed maintenance leader %s", leader)
+			},
+		},
+	})
+	if err != nil {
+		context.GetLogger(ctx).Errorf("unable to set up maintenance leader election: %v", err)
+		return
+	}
+
+	go wait.Forever(elector.Run, time.Second)
+}
+
+// runMaintenanceTasks runs every registered task at its interval until stop is closed.
+func runMaintenanceTasks(ctx context.Context, stop <-chan struct{}) {
+	maintenance.Lock()
+	tasks := make([]MaintenanceTask, len(maintenance.tasks))
+	copy(tasks, maintenance.tasks)
+	maintenance.Unlock()
+
+	for i := range tasks {
+		task := tasks[i]
+		go wait.Until(func() {
+			if err := task.Run(ctx); err != nil {
+				context.GetLogger(ctx).Errorf("maintenance task %s failed: %v", task.Name, err)
+			}
+		}, task.Interval, stop)
+	}
+}
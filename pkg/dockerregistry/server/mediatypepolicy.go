@@ -0,0 +1,79 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/docker/distribution/context"
+)
+
+const (
+	// AcceptedMediaTypesEnvVar is an environment variable holding a comma separated list of manifest
+	// media types the registry accepts on push. If empty, the middleware configuration option
+	// "acceptedmediatypes" applies. If that is empty as well, every media type is accepted. The list
+	// serves as a default that namespaces may override with the accepted media types annotation.
+	AcceptedMediaTypesEnvVar = "REGISTRY_MIDDLEWARE_REPOSITORY_OPENSHIFT_ACCEPTEDMEDIATYPES"
+
+	// AcceptedMediaTypesAnnotation is a namespace annotation holding a comma separated list of
+	// manifest media types that may be pushed to repositories of the namespace. It overrides the
+	// registry wide default, allowing admins to limit individual projects to plain images while
+	// other artifact types stay enabled elsewhere. An empty or absent annotation keeps the default.
+	AcceptedMediaTypesAnnotation = "openshift.io/accepted-media-types"
+)
+
+// parseMediaTypes splits a comma separated list of media types, dropping empty entries. A nil
+// result means no restriction.
+func parseMediaTypes(value string) []string {
+	types := []string{}
+	for _, t := range strings.Split(value, ",") {
+		t = strings.TrimSpace(t)
+		if len(t) > 0 {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 0 {
+		return nil
+	}
+	return types
+}
+
+// mediaTypeAccepted returns true if the given media type is allowed by the list. An empty list
+// accepts everything.
+func mediaTypeAccepted(accepted []string, mediatype string) bool {
+	if len(accepted) == 0 {
+		return true
+	}
+	for _, t := range accepted {
+		if t == mediatype {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptedMediaTypes returns the list of manifest media types that may be pushed to the
+// repository's namespace. The namespace annotation takes precedence over the registry wide
+// default. Lookup errors fall back to the default so a hiccup talking to the master cannot make
+// pushes fail for a namespace without a policy.
+func (r *repository) acceptedMediaTypes() []string {
+	ns, err := r.nsClient.Namespaces().Get(r.namespace)
+	if err != nil {
+		context.GetLogger(r.ctx).Errorf("failed to get namespace %s for media type policy: %v", r.namespace, err)
+		return r.acceptedMediaTypesDefault
+	}
+	if value, ok := ns.Annotations[AcceptedMediaTypesAnnotation]; ok {
+		if types := parseMediaTypes(value); types != nil {
+			return types
+		}
+	}
+	return r.acceptedMediaTypesDefault
+}
+
+// getStringOption returns a string option from the middleware configuration, or defval when unset.
+func getStringOption(name string, defval string, options map[string]interface{}) string {
+	if value, ok := options[name]; ok {
+		if s, ok := value.(string); ok {
+			return s
+		}
+	}
+	return defval
+}
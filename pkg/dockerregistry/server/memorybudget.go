@@ -0,0 +1,142 @@
+package server
+
+import (
+	"os"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/registry/api/errcode"
+
+	kresource "k8s.io/kubernetes/pkg/api/resource"
+)
+
+// MemoryBudgetEnvVar caps the aggregate memory the registry may commit to in-flight uploads,
+// e.g. "256Mi". Each upload reserves an estimated buffer from the budget and new uploads are
+// rejected with 503 once it is exhausted, so a burst of parallel pushes cannot OOM-kill a
+// registry pod running with small limits. Unset disables accounting.
+const MemoryBudgetEnvVar = "REGISTRY_MEMORY_BUDGET"
+
+// uploadBufferEstimate is the memory attributed to one in-flight blob upload; it approximates
+// the write buffers held by the storage drivers.
+const uploadBufferEstimate int64 = 8 << 20
+
+// memoryBudget is nil unless MemoryBudgetEnvVar is set.
+var memoryBudget = newMemoryBudget()
+
+type memoryBudgetTracker struct {
+	mu    sync.Mutex
+	limit int64
+	used  int64
+}
+
+func newMemoryBudget() *memoryBudgetTracker {
+	value := os.Getenv(MemoryBudgetEnvVar)
+	if len(value) == 0 {
+		return nil
+	}
+	quantity, err := kresource.ParseQuantity(value)
+	if err != nil || quantity.Value() <= 0 {
+		log.Errorf("ignoring invalid %s=%q", MemoryBudgetEnvVar, value)
+		return nil
+	}
+	log.Infof("upload memory budget: %s", value)
+	return &memoryBudgetTracker{limit: quantity.Value()}
+}
+
+// reserve claims n bytes from the budget, or reports that it is exhausted.
+func (m *memoryBudgetTracker) reserve(n int64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.used+n > m.limit {
+		return false
+	}
+	m.used += n
+	return true
+}
+
+func (m *memoryBudgetTracker) release(n int64) {
+	m.mu.Lock()
+	m.used -= n
+	m.mu.Unlock()
+}
+
+// budgetBlobStore reserves memory from the aggregate budget for each upload and releases it when
+// the upload finishes.
+type budgetBlobStore struct {
+	distribution.BlobStore
+}
+
+var _ distribution.BlobStore = &budgetBlobStore{}
+
+func (bs *budgetBlobStore) Put(ctx context.Context, mediaType string, p []byte) (distribution.Descriptor, error) {
+	size := int64(len(p))
+	if !memoryBudget.reserve(size) {
+		return distribution.Descriptor{}, errcode.ErrorCodeUnavailable.WithDetail("upload memory budget exhausted, retry later")
+	}
+	defer memoryBudget.release(size)
+	return bs.BlobStore.Put(ctx, mediaType, p)
+}
+
+func (bs *budgetBlobStore) Create(ctx context.Context, options ...distribution.BlobCreateOption) (distribution.BlobWriter, error) {
+	if !memoryBudget.reserve(uploadBufferEstimate) {
+		return nil, errcode.ErrorCodeUnavailable.WithDetail("upload memory budget exhausted, retry later")
+	}
+	bw, err := bs.BlobStore.Create(ctx, options...)
+	if err != nil {
+		memoryBudget.release(uploadBufferEstimate)
+		return nil, err
+	}
+	return &budgetBlobWriter{BlobWriter: bw}, nil
+}
+
+func (bs *budgetBlobStore) Resume(ctx context.Context, id string) (distribution.BlobWriter, error) {
+	if !memoryBudget.reserve(uploadBufferEstimate) {
+		return nil, errcode.ErrorCodeUnavailable.WithDetail("upload memory budget exhausted, retry later")
+	}
+	bw, err := bs.BlobStore.Resume(ctx, id)
+	if err != nil {
+		memoryBudget.release(uploadBufferEstimate)
+		return nil, err
+	}
+	return &budgetBlobWriter{BlobWriter: bw}, nil
+}
+
+// budgetBlobWriter returns the upload's reservation to the budget exactly once, whichever of
+// Close, Cancel or Commit ends the session first.
+type budgetBlobWriter struct {
+	distribution.BlobWriter
+
+	mu       sync.Mutex
+	released bool
+}
+
+func (bw *budgetBlobWriter) releaseOnce() {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	if bw.released {
+		return
+	}
+	bw.released = true
+	memoryBudget.release(uploadBufferEstimate)
+}
+
+func (bw *budgetBlobWriter) Close() error {
+	bw.releaseOnce()
+	return bw.BlobWriter.Close()
+}
+
+func (bw *budgetBlobWriter) Cancel(ctx context.Context) error {
+	bw.releaseOnce()
+	return bw.BlobWriter.Cancel(ctx)
+}
+
+func (bw *budgetBlobWriter) Commit(ctx context.Context, provisional distribution.Descriptor) (distribution.Descriptor, error) {
+	desc, err := bw.BlobWriter.Commit(ctx, provisional)
+	if err == nil {
+		bw.releaseOnce()
+	}
+	return desc, err
+}
@@ -0,0 +1,277 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/boltdb/bolt"
+
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/digest"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/openshift/origin/pkg/client"
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// MetadataCachePathEnvVar points at a boltdb file in which the registry keeps a local copy of
+// the image metadata it reads from the master: image streams (tag to digest) and images
+// (digest to layers). The cache is kept current through a watch and is consulted only when the
+// master API is unreachable, which removes the pull path's p99 latency dependence on API
+// server health. Pulls served from the cache may see tags that are slightly stale; pushes
+// still require the API. Empty (the default) disables the cache.
+const MetadataCachePathEnvVar = "REGISTRY_METADATA_CACHE_PATH"
+
+var (
+	metadataStreamBucket = []byte("imagestreams")
+	metadataImageBucket  = []byte("images")
+)
+
+var metadataCache = openMetadataCache()
+
+// imageMetadataCache persists image metadata in a boltdb file. The values are JSON encodings
+// of the internal API objects; the format is private to the registry process and a cache file
+// an upgraded registry cannot decode is simply treated as empty.
+type imageMetadataCache struct {
+	db *bolt.DB
+}
+
+func openMetadataCache() *imageMetadataCache {
+	path := os.Getenv(MetadataCachePathEnvVar)
+	if len(path) == 0 {
+		return nil
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		log.Errorf("error opening metadata cache %s: %v", path, err)
+		return nil
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{metadataStreamBucket, metadataImageBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("error initializing metadata cache %s: %v", path, err)
+		db.Close()
+		return nil
+	}
+	return &imageMetadataCache{db: db}
+}
+
+func streamKey(namespace, name string) []byte {
+	return []byte(namespace + "/" + name)
+}
+
+func (c *imageMetadataCache) putImageStream(stream *imageapi.ImageStream) {
+	c.put(metadataStreamBucket, streamKey(stream.Namespace, stream.Name), stream)
+}
+
+func (c *imageMetadataCache) getImageStream(namespace, name string) (*imageapi.ImageStream, bool) {
+	stream := &imageapi.ImageStream{}
+	if !c.get(metadataStreamBucket, streamKey(namespace, name), stream) {
+		return nil, false
+	}
+	return stream, true
+}
+
+func (c *imageMetadataCache) deleteImageStream(namespace, name string) {
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(metadataStreamBucket).Delete(streamKey(namespace, name))
+	})
+	if err != nil {
+		log.Errorf("error deleting image stream %s/%s from the metadata cache: %v", namespace, name, err)
+	}
+}
+
+func (c *imageMetadataCache) putImage(image *imageapi.Image) {
+	c.put(metadataImageBucket, []byte(image.Name), image)
+}
+
+func (c *imageMetadataCache) getImage(dgst digest.Digest) (*imageapi.Image, bool) {
+	image := &imageapi.Image{}
+	if !c.get(metadataImageBucket, []byte(dgst.String()), image) {
+		return nil, false
+	}
+	return image, true
+}
+
+func (c *imageMetadataCache) put(bucket, key []byte, obj interface{}) {
+	value, err := json.Marshal(obj)
+	if err != nil {
+		log.Errorf("error encoding %s for the metadata cache: %v", key, err)
+		return
+	}
+	err = c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put(key, value)
+	})
+	if err != nil {
+		log.Errorf("error writing %s to the metadata cache: %v", key, err)
+	}
+}
+
+func (c *imageMetadataCache) get(bucket, key []byte, obj interface{}) bool {
+	found := false
+	c.db.View(func(tx *bolt.Tx) error {
+		if value := tx.Bucket(bucket).Get(key); value != nil {
+			found = json.Unmarshal(value, obj) == nil
+		}
+		return nil
+	})
+	return found
+}
+
+// withBackendCache keeps the metadata cache current from successful reads and serves reads
+// from it when the master is unreachable. It wraps outside the timeout layer so a deadline
+// counts as the master being unreachable.
+func withBackendCache(backend imageStreamBackend) imageStreamBackend {
+	if metadataCache == nil {
+		return backend
+	}
+	return cachingBackend{backend: backend, cache: metadataCache}
+}
+
+type cachingBackend struct {
+	backend imageStreamBackend
+	cache   *imageMetadataCache
+}
+
+func (b cachingBackend) GetImageStream(namespace, name string) (*imageapi.ImageStream, error) {
+	stream, err := b.backend.GetImageStream(namespace, name)
+	switch {
+	case err == nil:
+		b.cache.putImageStream(stream)
+	case isConnectivityError(err):
+		if cached, ok := b.cache.getImageStream(namespace, name); ok {
+			log.Warnf("serving image stream %s/%s from the metadata cache: %v", namespace, name, err)
+			return cached, nil
+		}
+	}
+	return stream, err
+}
+
+func (b cachingBackend) GetImage(dgst digest.Digest) (*imageapi.Image, error) {
+	image, err := b.backend.GetImage(dgst)
+	switch {
+	case err == nil:
+		b.cache.putImage(image)
+	case isConnectivityError(err):
+		if cached, ok := b.cache.getImage(dgst); ok {
+			log.Warnf("serving image %s from the metadata cache: %v", dgst, err)
+			return cached, nil
+		}
+	}
+	return image, err
+}
+
+func (b cachingBackend) GetImageStreamImage(namespace, name string, dgst digest.Digest) (*imageapi.ImageStreamImage, error) {
+	isimage, err := b.backend.GetImageStreamImage(namespace, name, dgst)
+	switch {
+	case err == nil:
+		b.cache.putImage(&isimage.Image)
+	case isConnectivityError(err):
+		if cached, ok := b.cache.imageStreamImage(namespace, name, dgst); ok {
+			log.Warnf("serving image %s of %s/%s from the metadata cache: %v", dgst, namespace, name, err)
+			return cached, nil
+		}
+	}
+	return isimage, err
+}
+
+func (b cachingBackend) CreateImageStreamMapping(namespace string, ism *imageapi.ImageStreamMapping) error {
+	return b.backend.CreateImageStreamMapping(namespace, ism)
+}
+
+// imageStreamImage reconstructs an ImageStreamImage from the cache. The digest must appear in
+// the cached stream's history, preserving the membership check the API performs.
+func (c *imageMetadataCache) imageStreamImage(namespace, name string, dgst digest.Digest) (*imageapi.ImageStreamImage, bool) {
+	stream, ok := c.getImageStream(namespace, name)
+	if !ok || !streamHasDigest(stream, dgst) {
+		return nil, false
+	}
+	image, ok := c.getImage(dgst)
+	if !ok {
+		return nil, false
+	}
+	isimage := &imageapi.ImageStreamImage{
+		ObjectMeta: kapi.ObjectMeta{
+			Namespace: namespace,
+			Name:      fmt.Sprintf("%s@%s", name, dgst),
+		},
+		Image: *image,
+	}
+	return isimage, true
+}
+
+func streamHasDigest(stream *imageapi.ImageStream, dgst digest.Digest) bool {
+	for _, history := range stream.Status.Tags {
+		for _, event := range history.Items {
+			if event.Image == dgst.String() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RunMetadataCacheWatcher keeps the metadata cache's image streams current through a watch,
+// so the cache does not serve long-stale tags after the master comes back. It returns
+// immediately; it is a no-op unless the cache is enabled.
+func RunMetadataCacheWatcher(ctx context.Context, registryClient *RegistryClient) {
+	if metadataCache == nil {
+		return
+	}
+	osClient, _, err := registryClient.Clients()
+	if err != nil {
+		context.GetLogger(ctx).Fatalf("error getting clients for the metadata cache watcher: %v", err)
+	}
+
+	context.GetLogger(ctx).Infof("watching image streams to keep the metadata cache current")
+	go func() {
+		for {
+			if err := watchImageStreams(osClient); err != nil {
+				context.GetLogger(ctx).Errorf("metadata cache watch interrupted: %v", err)
+			}
+			time.Sleep(10 * time.Second)
+		}
+	}()
+}
+
+// watchImageStreams lists all image streams into the cache, then applies watch events until
+// the watch breaks. The caller restarts it.
+func watchImageStreams(osClient client.Interface) error {
+	streams, err := osClient.ImageStreams(kapi.NamespaceAll).List(kapi.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range streams.Items {
+		metadataCache.putImageStream(&streams.Items[i])
+	}
+	w, err := osClient.ImageStreams(kapi.NamespaceAll).Watch(kapi.ListOptions{ResourceVersion: streams.ResourceVersion})
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+	for event := range w.ResultChan() {
+		stream, ok := event.Object.(*imageapi.ImageStream)
+		if !ok {
+			continue
+		}
+		switch event.Type {
+		case watch.Added, watch.Modified:
+			metadataCache.putImageStream(stream)
+		case watch.Deleted:
+			metadataCache.deleteImageStream(stream.Namespace, stream.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/distribution/registry/handlers"
+	gorillahandlers "github.com/gorilla/handlers"
+
+	authorizationapi "github.com/openshift/origin/pkg/authorization/api"
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// defaultNodeTokenExpiry keeps node tokens short-lived; the credential provider exchanges its
+// service account token again when the minted token expires.
+const defaultNodeTokenExpiry = 1 * time.Hour
+
+// NodeTokenDispatcher builds the handler exchanging a node's service account token for a
+// pull-scoped registry token. The node-side credential provider calls it instead of holding a
+// long-lived dockercfg secret: the service account token authenticates the request, an access
+// review proves pull access to the requested namespace, and the minted token - validated
+// locally against the deploy token secret - grants nothing beyond pulls from that namespace.
+func NodeTokenDispatcher(ctx *handlers.Context, r *http.Request) http.Handler {
+	nodeTokenHandler := &nodeTokenHandler{
+		Context: ctx,
+	}
+
+	return gorillahandlers.MethodHandler{
+		"POST": http.HandlerFunc(nodeTokenHandler.Exchange),
+	}
+}
+
+type nodeTokenHandler struct {
+	*handlers.Context
+}
+
+// nodeTokenResponse is the exchange response body.
+type nodeTokenResponse struct {
+	Token     string `json:"token"`
+	Namespace string `json:"namespace"`
+	ExpiresAt string `json:"expiresAt"`
+}
+
+// Exchange mints a pull token for ?namespace=<ns>, valid for the optional ?expiry= duration
+// bounded by the deploy token maximum. The access review runs with the caller's own client, so
+// the node's service account needs nothing beyond pull access to the namespace.
+func (h *nodeTokenHandler) Exchange(w http.ResponseWriter, req *http.Request) {
+	secret := os.Getenv(DeployTokenSecretEnvVar)
+	if len(secret) == 0 {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnavailable.WithDetail("registry tokens are not configured"))
+		return
+	}
+
+	namespace := req.URL.Query().Get("namespace")
+	if len(namespace) == 0 {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail("the namespace parameter is required"))
+		return
+	}
+
+	uclient, ok := UserClientFrom(h.Context)
+	if !ok {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail("user client unavailable"))
+		return
+	}
+	sar := authorizationapi.LocalSubjectAccessReview{
+		Action: authorizationapi.Action{
+			Verb:     "get",
+			Group:    imageapi.GroupName,
+			Resource: "imagestreams/layers",
+		},
+	}
+	response, err := uclient.LocalSubjectAccessReviews(namespace).Create(&sar)
+	if err != nil {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail(fmt.Sprintf("error creating subject access review: %v", err)))
+		return
+	}
+	if !response.Allowed {
+		context.GetLogger(h.Context).Infof("node token refused for namespace %s: %s", namespace, response.Reason)
+		h.Errors = append(h.Errors, errcode.ErrorCodeDenied.WithDetail(fmt.Sprintf("no pull access to namespace %s", namespace)))
+		return
+	}
+
+	expiry := defaultNodeTokenExpiry
+	if value := req.URL.Query().Get("expiry"); len(value) > 0 {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail(fmt.Sprintf("invalid expiry %q", value)))
+			return
+		}
+		expiry = parsed
+	}
+	if max := timeoutFromEnv(DeployTokenMaxExpiryEnvVar, defaultDeployTokenMaxAge); expiry > max {
+		expiry = max
+	}
+
+	claims := deployTokenClaims{
+		Namespace: namespace,
+		ExpiresAt: time.Now().Add(expiry).Unix(),
+	}
+	token, err := mintDeployToken(secret, claims)
+	if err != nil {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(nodeTokenResponse{
+		Token:     token,
+		Namespace: namespace,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0).UTC().Format(time.RFC3339),
+	})
+}
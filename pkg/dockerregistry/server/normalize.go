@@ -0,0 +1,50 @@
+package server
+
+import (
+	"os"
+	"strings"
+)
+
+// Docker clients disagree on how repository names are spelled on the wire: the docker CLI
+// prepends an implicit "library/" namespace to single-component names, while podman and
+// containerd send the name as given. These variables make the normalization applied before the
+// namespace/name split explicit and configurable instead of guessing per client.
+const (
+	// StripLibraryPrefixEnvVar set to "true" removes a leading "library/" component inserted
+	// by the docker CLI before the repository name is split into namespace and name.
+	StripLibraryPrefixEnvVar = "REGISTRY_NORMALIZE_STRIP_LIBRARY"
+	// DefaultNamespaceEnvVar is the namespace assumed for repository names that are left with
+	// a single component after normalization. Unset, such names remain an error.
+	DefaultNamespaceEnvVar = "REGISTRY_NORMALIZE_DEFAULT_NAMESPACE"
+)
+
+// repositoryNormalizer holds the configured repository name normalization rules.
+type repositoryNormalizer struct {
+	stripLibrary     bool
+	defaultNamespace string
+}
+
+var repositoryNameNormalizer = readRepositoryNormalizer()
+
+func readRepositoryNormalizer() repositoryNormalizer {
+	return repositoryNormalizer{
+		stripLibrary:     os.Getenv(StripLibraryPrefixEnvVar) == "true",
+		defaultNamespace: os.Getenv(DefaultNamespaceEnvVar),
+	}
+}
+
+// normalize applies the configured rules to a repository name sent by a client.
+func (n repositoryNormalizer) normalize(name string) string {
+	if n.stripLibrary && strings.HasPrefix(name, "library/") {
+		stripped := strings.TrimPrefix(name, "library/")
+		// only strip when the result still resolves to a namespace and name; a bare
+		// "library/foo" stays intact unless a default namespace will supply one
+		if strings.Contains(stripped, "/") || len(n.defaultNamespace) > 0 {
+			name = stripped
+		}
+	}
+	if len(n.defaultNamespace) > 0 && len(name) > 0 && !strings.Contains(name, "/") {
+		name = n.defaultNamespace + "/" + name
+	}
+	return name
+}
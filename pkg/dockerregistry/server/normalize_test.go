@@ -0,0 +1,63 @@
+package server
+
+import (
+	"testing"
+)
+
+func TestRepositoryNormalizer(t *testing.T) {
+	tests := map[string]struct {
+		normalizer repositoryNormalizer
+		name       string
+		expected   string
+	}{
+		// the docker CLI prepends "library/" to single-component names
+		"docker CLI library prefix with default namespace": {
+			normalizer: repositoryNormalizer{stripLibrary: true, defaultNamespace: "shared"},
+			name:       "library/busybox",
+			expected:   "shared/busybox",
+		},
+		"docker CLI library prefix without default namespace": {
+			normalizer: repositoryNormalizer{stripLibrary: true},
+			name:       "library/busybox",
+			expected:   "library/busybox",
+		},
+		"docker CLI library prefix on namespaced name": {
+			normalizer: repositoryNormalizer{stripLibrary: true},
+			name:       "library/myproject/app",
+			expected:   "myproject/app",
+		},
+		// podman and containerd send the name exactly as given
+		"podman namespaced name": {
+			normalizer: repositoryNormalizer{stripLibrary: true, defaultNamespace: "shared"},
+			name:       "myproject/app",
+			expected:   "myproject/app",
+		},
+		"containerd single-component name": {
+			normalizer: repositoryNormalizer{stripLibrary: true, defaultNamespace: "shared"},
+			name:       "busybox",
+			expected:   "shared/busybox",
+		},
+		"containerd single-component name without default namespace": {
+			normalizer: repositoryNormalizer{stripLibrary: true},
+			name:       "busybox",
+			expected:   "busybox",
+		},
+		// a project actually named library keeps working when stripping is off
+		"normalization disabled": {
+			normalizer: repositoryNormalizer{},
+			name:       "library/app",
+			expected:   "library/app",
+		},
+		"deep name below library namespace": {
+			normalizer: repositoryNormalizer{stripLibrary: true},
+			name:       "library/a/b/c",
+			expected:   "a/b/c",
+		},
+	}
+
+	for name, test := range tests {
+		if actual := test.normalizer.normalize(test.name); actual != test.expected {
+			t.Errorf("%s: expected %q, got %q", name, test.expected, actual)
+		}
+	}
+}
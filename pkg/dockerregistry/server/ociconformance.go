@@ -0,0 +1,45 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// OCIConformanceEnvVar is a boolean environment variable enabling OCI distribution spec
+// conformance mode. The registry then advertises the API version header on every /v2/
+// response, emits RFC 5988 Link headers for paginated tag listings and stops filtering OCI
+// media types out of Accept headers for clients not known to support them. The mode is off by
+// default because some older docker daemons misbehave when offered OCI content.
+const OCIConformanceEnvVar = "REGISTRY_OCI_CONFORMANCE"
+
+// ociConformanceEnabled reports whether conformance mode is on. Read per request so the
+// runtime config watcher can toggle it.
+func ociConformanceEnabled() bool {
+	return os.Getenv(OCIConformanceEnvVar) == "true"
+}
+
+// NewOCIConformanceHandler strictens responses as the OCI distribution spec requires. It is a
+// pass-through unless conformance mode is enabled.
+func NewOCIConformanceHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if ociConformanceEnabled() && strings.HasPrefix(req.URL.Path, "/v2/") {
+			// the spec requires the version header on the base endpoint; setting it on
+			// every API response lets clients detect the API from any entry point
+			w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		}
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// setTagsListLinkHeader emits the pagination Link header for a truncated tags listing, as the
+// OCI distribution spec requires.
+func setTagsListLinkHeader(w http.ResponseWriter, req *http.Request, lastTag string, n int) {
+	query := req.URL.Query()
+	query.Set("last", lastTag)
+	query.Set("n", fmt.Sprintf("%d", n))
+	next := *req.URL
+	next.RawQuery = query.Encode()
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+}
@@ -0,0 +1,134 @@
+//go:build oci_conformance
+// +build oci_conformance
+
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/docker/distribution/configuration"
+	"github.com/docker/distribution/context"
+	registryauth "github.com/docker/distribution/registry/auth"
+	"github.com/docker/distribution/registry/handlers"
+	_ "github.com/docker/distribution/registry/storage/driver/inmemory"
+)
+
+// fakeAccessController admits every request, so the conformance checks exercise the API
+// surface without a master to authenticate against.
+type fakeAccessController struct{}
+
+func (fakeAccessController) Authorized(ctx context.Context, access ...registryauth.Access) (context.Context, error) {
+	return ctx, nil
+}
+
+func init() {
+	registryauth.Register("fake", func(options map[string]interface{}) (registryauth.AccessController, error) {
+		return fakeAccessController{}, nil
+	})
+}
+
+// TestOCIConformance runs the OCI distribution spec checks against an in-process registry
+// with fake auth. It is behind the oci_conformance build tag and run by
+// hack/test-oci-conformance.sh because it depends on conformance mode being enabled.
+func TestOCIConformance(t *testing.T) {
+	os.Setenv(OCIConformanceEnvVar, "true")
+	defer os.Unsetenv(OCIConformanceEnvVar)
+
+	config := &configuration.Configuration{
+		Storage: configuration.Storage{"inmemory": configuration.Parameters{}},
+		Auth:    configuration.Auth{"fake": configuration.Parameters{}},
+	}
+	app := handlers.NewApp(context.Background(), config)
+	server := httptest.NewServer(NewOCIConformanceHandler(app))
+	defer server.Close()
+
+	client := &http.Client{}
+	do := func(method, path string, body string, header map[string]string) *http.Response {
+		var req *http.Request
+		var err error
+		if len(body) > 0 {
+			req, err = http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		} else {
+			req, err = http.NewRequest(method, server.URL+path, nil)
+		}
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		return resp
+	}
+
+	// the base endpoint must exist and advertise the API version
+	resp := do("GET", "/v2/", "", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /v2/: got status %d, expected 200", resp.StatusCode)
+	}
+	if version := resp.Header.Get("Docker-Distribution-API-Version"); version != "registry/2.0" {
+		t.Errorf("GET /v2/: got API version header %q", version)
+	}
+
+	// errors must come back as a JSON envelope with a spec error code
+	resp = do("GET", "/v2/conformance/missing/manifests/latest", "", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown manifest: got status %d, expected 404", resp.StatusCode)
+	}
+	envelope := struct {
+		Errors []struct {
+			Code string `json:"code"`
+		} `json:"errors"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		t.Errorf("unknown manifest: undecodable error body: %v", err)
+	}
+	resp.Body.Close()
+	if len(envelope.Errors) == 0 {
+		t.Errorf("unknown manifest: no error codes in the envelope")
+	}
+
+	// a monolithic blob upload must succeed and the blob must be retrievable by digest
+	blob := "oci conformance test blob"
+	dgst := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(blob)))
+
+	resp = do("POST", "/v2/conformance/repo/blobs/uploads/", "", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("starting blob upload: got status %d, expected 202", resp.StatusCode)
+	}
+	location := resp.Header.Get("Location")
+	if len(location) == 0 {
+		t.Fatalf("starting blob upload: no Location header")
+	}
+	separator := "?"
+	if strings.Contains(location, "?") {
+		separator = "&"
+	}
+	location = strings.TrimPrefix(location, server.URL)
+	resp = do("PUT", location+separator+"digest="+dgst, blob, map[string]string{"Content-Type": "application/octet-stream"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("completing blob upload: got status %d, expected 201", resp.StatusCode)
+	}
+
+	resp = do("HEAD", "/v2/conformance/repo/blobs/"+dgst, "", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("blob stat: got status %d, expected 200", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Docker-Content-Digest"); got != dgst {
+		t.Errorf("blob stat: got content digest %q, expected %q", got, dgst)
+	}
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema1"
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/distribution/registry/api/v2"
+	"github.com/docker/distribution/registry/handlers"
+	gorillahandlers "github.com/gorilla/handlers"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// ociStreamAnnotation names the image stream an exported index was rendered from.
+const ociStreamAnnotation = "io.openshift.image-stream"
+
+// ociRefNameAnnotation is the standard OCI annotation naming the tag a descriptor belongs to;
+// skopeo sync and crane use it to recreate tags from an index.
+const ociRefNameAnnotation = "org.opencontainers.image.ref.name"
+
+// ociPlatform is the platform block of an OCI descriptor.
+type ociPlatform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+}
+
+// ociDescriptor is one manifest reference in an OCI index.
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Platform    *ociPlatform      `json:"platform,omitempty"`
+}
+
+// ociIndex is the OCI image index document.
+type ociIndex struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	Manifests     []ociDescriptor   `json:"manifests"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// OCIIndexDispatcher builds the handler rendering an image stream as a single OCI index: one
+// descriptor per tag, expanded per platform for manifest lists. External mirroring tools
+// (skopeo sync, crane) consume the index to copy the whole stream in one pass. The route
+// carries a repository name, so pull access is enforced.
+func OCIIndexDispatcher(ctx *handlers.Context, r *http.Request) http.Handler {
+	ociIndexHandler := &ociIndexHandler{
+		Context: ctx,
+	}
+
+	return gorillahandlers.MethodHandler{
+		"GET": http.HandlerFunc(ociIndexHandler.Index),
+	}
+}
+
+type ociIndexHandler struct {
+	*handlers.Context
+}
+
+// Index renders the current tags of the stream. Only image stream and image metadata is read;
+// no manifest is re-downloaded.
+func (h *ociIndexHandler) Index(w http.ResponseWriter, req *http.Request) {
+	repo, ok := h.Repository.(*repository)
+	if !ok {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail("repository not available"))
+		return
+	}
+	stream, err := repo.getImageStream()
+	if err != nil {
+		h.Errors = append(h.Errors, v2.ErrorCodeNameUnknown.WithDetail(h.Repository.Named().Name()))
+		return
+	}
+
+	index := ociIndex{
+		SchemaVersion: 2,
+		MediaType:     mediaTypeOCIImageIndex,
+		Manifests:     []ociDescriptor{},
+		Annotations: map[string]string{
+			ociStreamAnnotation: stream.Namespace + "/" + stream.Name,
+		},
+	}
+	for tag, history := range stream.Status.Tags {
+		if len(history.Items) == 0 || len(history.Items[0].Image) == 0 {
+			continue
+		}
+		dgst, err := digest.ParseDigest(history.Items[0].Image)
+		if err != nil {
+			continue
+		}
+		image, err := repo.getImage(dgst)
+		if err != nil {
+			context.GetLogger(h.Context).Errorf("cannot get image %s for tag %s: %v", dgst, tag, err)
+			continue
+		}
+		index.Manifests = append(index.Manifests, tagDescriptors(tag, image)...)
+	}
+
+	w.Header().Set("Content-Type", mediaTypeOCIImageIndex)
+	if err := json.NewEncoder(w).Encode(index); err != nil {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail(err))
+	}
+}
+
+// tagDescriptors renders one tagged image as index descriptors. A manifest list contributes one
+// descriptor per platform so mirroring tools need not resolve it themselves; everything else
+// contributes a single descriptor.
+func tagDescriptors(tag string, image *imageapi.Image) []ociDescriptor {
+	if image.DockerImageManifestMediaType == manifestlist.MediaTypeManifestList {
+		var list manifestlist.ManifestList
+		if err := json.Unmarshal([]byte(image.DockerImageManifest), &list); err == nil {
+			descriptors := []ociDescriptor{}
+			for _, descriptor := range list.Manifests {
+				descriptors = append(descriptors, ociDescriptor{
+					MediaType:   descriptor.MediaType,
+					Digest:      descriptor.Digest.String(),
+					Size:        descriptor.Size,
+					Annotations: map[string]string{ociRefNameAnnotation: tag},
+					Platform: &ociPlatform{
+						Architecture: descriptor.Platform.Architecture,
+						OS:           descriptor.Platform.OS,
+					},
+				})
+			}
+			return descriptors
+		}
+	}
+
+	mediaType := image.DockerImageManifestMediaType
+	if len(mediaType) == 0 {
+		mediaType = schema1.MediaTypeManifest
+	}
+	descriptor := ociDescriptor{
+		MediaType:   mediaType,
+		Digest:      image.Name,
+		Size:        int64(len(image.DockerImageManifest)),
+		Annotations: map[string]string{ociRefNameAnnotation: tag},
+	}
+	if arch := image.DockerImageMetadata.Architecture; len(arch) > 0 {
+		descriptor.Platform = &ociPlatform{Architecture: arch, OS: "linux"}
+	}
+	return []ociDescriptor{descriptor}
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+func TestTagDescriptorsSingleManifest(t *testing.T) {
+	image := &imageapi.Image{
+		DockerImageManifest:          `{"schemaVersion": 2}`,
+		DockerImageManifestMediaType: schema2.MediaTypeManifest,
+	}
+	image.Name = "sha256:aaa"
+	image.DockerImageMetadata.Architecture = "amd64"
+
+	descriptors := tagDescriptors("v1", image)
+	if len(descriptors) != 1 {
+		t.Fatalf("expected one descriptor, got %d", len(descriptors))
+	}
+	descriptor := descriptors[0]
+	if descriptor.Digest != "sha256:aaa" || descriptor.MediaType != schema2.MediaTypeManifest {
+		t.Errorf("unexpected descriptor: %#v", descriptor)
+	}
+	if descriptor.Size != int64(len(image.DockerImageManifest)) {
+		t.Errorf("expected size %d, got %d", len(image.DockerImageManifest), descriptor.Size)
+	}
+	if descriptor.Annotations[ociRefNameAnnotation] != "v1" {
+		t.Errorf("expected the tag annotation, got %v", descriptor.Annotations)
+	}
+	if descriptor.Platform == nil || descriptor.Platform.Architecture != "amd64" || descriptor.Platform.OS != "linux" {
+		t.Errorf("unexpected platform: %#v", descriptor.Platform)
+	}
+}
+
+func TestTagDescriptorsManifestList(t *testing.T) {
+	image := &imageapi.Image{
+		DockerImageManifest: `{
+			"manifests": [
+				{"mediaType": "` + schema2.MediaTypeManifest + `", "digest": "sha256:bbb", "size": 10, "platform": {"architecture": "amd64", "os": "linux"}},
+				{"mediaType": "` + schema2.MediaTypeManifest + `", "digest": "sha256:ccc", "size": 12, "platform": {"architecture": "s390x", "os": "linux"}}
+			]
+		}`,
+		DockerImageManifestMediaType: manifestlist.MediaTypeManifestList,
+	}
+	image.Name = "sha256:aaa"
+
+	descriptors := tagDescriptors("latest", image)
+	if len(descriptors) != 2 {
+		t.Fatalf("expected one descriptor per platform, got %d", len(descriptors))
+	}
+	if descriptors[0].Digest != "sha256:bbb" || descriptors[1].Digest != "sha256:ccc" {
+		t.Errorf("unexpected digests: %#v", descriptors)
+	}
+	for _, descriptor := range descriptors {
+		if descriptor.Annotations[ociRefNameAnnotation] != "latest" {
+			t.Errorf("expected every platform descriptor to carry the tag annotation, got %v", descriptor.Annotations)
+		}
+	}
+	if descriptors[1].Platform == nil || descriptors[1].Platform.Architecture != "s390x" {
+		t.Errorf("unexpected platform: %#v", descriptors[1].Platform)
+	}
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	context "github.com/docker/distribution/context"
+
+	"k8s.io/kubernetes/pkg/auth/user"
+	"k8s.io/kubernetes/pkg/util/sets"
+	kubeoidc "k8s.io/kubernetes/plugin/pkg/auth/authenticator/token/oidc"
+
+	authorizationapi "github.com/openshift/origin/pkg/authorization/api"
+	"github.com/openshift/origin/pkg/client"
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+const (
+	// OIDCIssuerURLEnvVar enables direct acceptance of OIDC bearer tokens: JWTs presented to
+	// the registry are verified against this issuer (https required) instead of being sent to
+	// the master as OpenShift API tokens. Clusters federated to an external IdP such as
+	// KeyCloak can then docker login with the tokens the IdP already hands out.
+	OIDCIssuerURLEnvVar = "REGISTRY_OIDC_ISSUER_URL"
+
+	// OIDCAudienceEnvVar is the client/audience ID the tokens must be issued for.
+	OIDCAudienceEnvVar = "REGISTRY_OIDC_AUDIENCE"
+
+	// OIDCCAFileEnvVar is the path of a CA bundle used to verify the issuer's TLS certificate.
+	// Empty means the host's root CA set.
+	OIDCCAFileEnvVar = "REGISTRY_OIDC_CA"
+
+	// OIDCUsernameClaimEnvVar and OIDCGroupsClaimEnvVar name the claims mapped to the OpenShift
+	// user and groups that access reviews are evaluated for. Usernames follow the kube OIDC
+	// authenticator convention: claims other than "email" are prefixed with the issuer URL.
+	OIDCUsernameClaimEnvVar = "REGISTRY_OIDC_USERNAME_CLAIM"
+	OIDCGroupsClaimEnvVar   = "REGISTRY_OIDC_GROUPS_CLAIM"
+)
+
+var (
+	oidcInitOnce      sync.Once
+	oidcAuthenticator *kubeoidc.OIDCAuthenticator
+)
+
+// getOIDCAuthenticator returns the shared OIDC authenticator, or nil when the feature is off or
+// initialization failed. Initialization is deferred to the first OIDC-shaped token because it
+// fetches the issuer's discovery document - an unreachable IdP must not stall registry startup.
+func getOIDCAuthenticator() *kubeoidc.OIDCAuthenticator {
+	issuerURL := os.Getenv(OIDCIssuerURLEnvVar)
+	if len(issuerURL) == 0 {
+		return nil
+	}
+	oidcInitOnce.Do(func() {
+		usernameClaim := os.Getenv(OIDCUsernameClaimEnvVar)
+		if len(usernameClaim) == 0 {
+			usernameClaim = "sub"
+		}
+		groupsClaim := os.Getenv(OIDCGroupsClaimEnvVar)
+		if len(groupsClaim) == 0 {
+			groupsClaim = "groups"
+		}
+		authenticator, err := kubeoidc.New(kubeoidc.OIDCOptions{
+			IssuerURL:     issuerURL,
+			ClientID:      os.Getenv(OIDCAudienceEnvVar),
+			CAFile:        os.Getenv(OIDCCAFileEnvVar),
+			UsernameClaim: usernameClaim,
+			GroupsClaim:   groupsClaim,
+		})
+		if err != nil {
+			log.Errorf("error initializing OIDC authenticator for %s: %v", issuerURL, err)
+			return
+		}
+		oidcAuthenticator = authenticator
+	})
+	return oidcAuthenticator
+}
+
+// authenticateOIDCToken verifies the token against the configured issuer and returns the mapped
+// user. Tokens that are not JWTs, or that the issuer rejects, fall through to the regular
+// OpenShift token path.
+func authenticateOIDCToken(ctx context.Context, token string) (user.Info, bool) {
+	if strings.Count(token, ".") != 2 {
+		return nil, false
+	}
+	authenticator := getOIDCAuthenticator()
+	if authenticator == nil {
+		return nil, false
+	}
+	info, ok, err := authenticator.AuthenticateToken(token)
+	if err != nil || !ok {
+		context.GetLogger(ctx).Debugf("OIDC token rejected: %v", err)
+		return nil, false
+	}
+	return info, true
+}
+
+// oidcAuthorizer evaluates access reviews for an identity established from a verified OIDC
+// token. The reviews name the mapped user and groups explicitly and run with the registry's own
+// client, since the token itself carries no OpenShift API credentials.
+type oidcAuthorizer struct {
+	user     user.Info
+	resource string
+}
+
+var _ Authorizer = &oidcAuthorizer{}
+
+func newOIDCAuthorizer(info user.Info) Authorizer {
+	return &oidcAuthorizer{user: info, resource: "imagestreams/layers"}
+}
+
+// VerifyUser is satisfied by the token verification itself.
+func (a *oidcAuthorizer) VerifyUser(ctx context.Context, osClient client.UsersInterface) error {
+	return nil
+}
+
+func (a *oidcAuthorizer) VerifyImageStreamAccess(ctx context.Context, namespace, name, verb string, osClient client.LocalSubjectAccessReviewsNamespacer) error {
+	sar := authorizationapi.LocalSubjectAccessReview{
+		Action: authorizationapi.Action{
+			Verb:         verb,
+			Group:        imageapi.GroupName,
+			Resource:     a.resource,
+			ResourceName: name,
+		},
+		User:   a.user.GetName(),
+		Groups: sets.NewString(a.user.GetGroups()...),
+	}
+	start := time.Now()
+	response, err := osClient.LocalSubjectAccessReviews(namespace).Create(&sar)
+	observeAPICall("localsubjectaccessreviews.create", start, err)
+	if err != nil {
+		context.GetLogger(ctx).Errorf("OpenShift client error: %s", err)
+		return err
+	}
+	if !response.Allowed {
+		context.GetLogger(ctx).Errorf("OpenShift access denied for OIDC user %s: %s", a.user.GetName(), response.Reason)
+		return ErrOpenShiftAccessDenied
+	}
+	return nil
+}
+
+func (a *oidcAuthorizer) VerifyPruneAccess(ctx context.Context, osClient client.SubjectAccessReviews) error {
+	sar := authorizationapi.SubjectAccessReview{
+		Action: authorizationapi.Action{
+			Verb:     "delete",
+			Group:    imageapi.GroupName,
+			Resource: "images",
+		},
+		User:   a.user.GetName(),
+		Groups: sets.NewString(a.user.GetGroups()...),
+	}
+	return verifyClusterSAR(ctx, &sar, osClient)
+}
+
+func (a *oidcAuthorizer) VerifyAdminAccess(ctx context.Context, osClient client.SubjectAccessReviews) error {
+	sar := authorizationapi.SubjectAccessReview{
+		Action: authorizationapi.Action{
+			Verb:     "get",
+			Resource: "images",
+		},
+		User:   a.user.GetName(),
+		Groups: sets.NewString(a.user.GetGroups()...),
+	}
+	return verifyClusterSAR(ctx, &sar, osClient)
+}
@@ -0,0 +1,276 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+	ctxu "github.com/docker/distribution/context"
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/distribution/registry/handlers"
+	gorillahandlers "github.com/gorilla/handlers"
+)
+
+const (
+	// PeerEndpointsEnvVar is a comma separated list of base URLs of other registry replicas. When
+	// set together with PeerAdvertiseURLEnvVar, replicas advertise every committed blob to the
+	// listed peers and pulls of blobs missing locally are redirected to a replica that advertised
+	// them, keeping large blob transfers inside a zone.
+	PeerEndpointsEnvVar = "REGISTRY_PEER_ENDPOINTS"
+
+	// PeerAdvertiseURLEnvVar is the base URL under which this replica is reachable by clients and
+	// peers, e.g. "https://registry-a.example.com:5000".
+	PeerAdvertiseURLEnvVar = "REGISTRY_PEER_ADVERTISE_URL"
+
+	// defaultPeerBlobTTL is how long an advertised blob location is trusted before the peer has
+	// to advertise it again.
+	defaultPeerBlobTTL = 30 * time.Minute
+
+	// peerAnnounceTimeout bounds a single advertisement request to a peer.
+	peerAnnounceTimeout = 5 * time.Second
+)
+
+// blobPeers holds the peer distribution state. It stays nil unless peer distribution is
+// configured through the environment, in which case every middleware layer shares it.
+var blobPeers = newPeerDistribution()
+
+// peerDistribution describes the optional edge cache mode in which registry replicas advertise
+// blob availability to each other. Each replica remembers which peer holds which blob and serves
+// a redirect instead of a miss when the blob is present elsewhere.
+type peerDistribution struct {
+	// advertiseURL is the base URL of this replica as seen by clients and peers.
+	advertiseURL string
+	// endpoints are the base URLs of the other replicas.
+	endpoints []string
+	// cache remembers advertised blob locations.
+	cache *peerBlobCache
+	// client performs advertisement requests.
+	client *http.Client
+}
+
+// newPeerDistribution builds the peer distribution state from the environment, returning nil
+// when the mode is not configured.
+func newPeerDistribution() *peerDistribution {
+	advertiseURL := strings.TrimRight(os.Getenv(PeerAdvertiseURLEnvVar), "/")
+	endpoints := []string{}
+	for _, endpoint := range strings.Split(os.Getenv(PeerEndpointsEnvVar), ",") {
+		endpoint = strings.TrimRight(strings.TrimSpace(endpoint), "/")
+		if len(endpoint) > 0 && endpoint != advertiseURL {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	if len(advertiseURL) == 0 || len(endpoints) == 0 {
+		return nil
+	}
+	return &peerDistribution{
+		advertiseURL: advertiseURL,
+		endpoints:    endpoints,
+		cache:        newPeerBlobCache(defaultPeerBlobTTL),
+		client:       &http.Client{Timeout: peerAnnounceTimeout},
+	}
+}
+
+// knownPeer returns true if the given endpoint is one of the configured replicas. Advertisements
+// from endpoints outside the replica set are refused.
+func (pd *peerDistribution) knownPeer(endpoint string) bool {
+	for _, e := range pd.endpoints {
+		if e == endpoint {
+			return true
+		}
+	}
+	return false
+}
+
+// announce tells every peer that this replica now holds the given blob. Failures are logged and
+// otherwise ignored - a missed advertisement only costs a redirect opportunity.
+func (pd *peerDistribution) announce(ctx context.Context, dgst digest.Digest, size int64) {
+	for _, endpoint := range pd.endpoints {
+		u := fmt.Sprintf("%s/openshift/peers/blobs/%s?endpoint=%s&size=%d", endpoint, dgst.String(), url.QueryEscape(pd.advertiseURL), size)
+		resp, err := pd.client.Post(u, "text/plain", nil)
+		if err != nil {
+			context.GetLogger(ctx).Debugf("failed to advertise blob %s to peer %s: %v", dgst.String(), endpoint, err)
+			continue
+		}
+		resp.Body.Close()
+	}
+}
+
+// peerBlobLocation is a cached advertisement of a blob held by a peer replica.
+type peerBlobLocation struct {
+	endpoint string
+	size     int64
+	expires  time.Time
+}
+
+// peerBlobCache remembers which peer replica advertised which blob. Entries expire so replicas
+// that pruned a blob or went away stop receiving redirects. Safe for concurrent use.
+type peerBlobCache struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	blobs map[digest.Digest]peerBlobLocation
+}
+
+func newPeerBlobCache(ttl time.Duration) *peerBlobCache {
+	return &peerBlobCache{
+		ttl:   ttl,
+		blobs: make(map[digest.Digest]peerBlobLocation),
+	}
+}
+
+// add records that the given endpoint holds the blob.
+func (c *peerBlobCache) add(dgst digest.Digest, endpoint string, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.blobs[dgst] = peerBlobLocation{
+		endpoint: endpoint,
+		size:     size,
+		expires:  time.Now().Add(c.ttl),
+	}
+}
+
+// get returns the advertised location of the blob, if any.
+func (c *peerBlobCache) get(dgst digest.Digest) (peerBlobLocation, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	location, ok := c.blobs[dgst]
+	if !ok {
+		return peerBlobLocation{}, false
+	}
+	if location.expires.Before(time.Now()) {
+		delete(c.blobs, dgst)
+		return peerBlobLocation{}, false
+	}
+	return location, true
+}
+
+// PeerBlobDispatcher takes the request context and builds the handler accepting blob
+// advertisements from peer replicas.
+func PeerBlobDispatcher(ctx *handlers.Context, r *http.Request) http.Handler {
+	reference := ctxu.GetStringValue(ctx, "vars.digest")
+	dgst, _ := digest.ParseDigest(reference)
+
+	peerHandler := &peerBlobHandler{
+		Context: ctx,
+		Digest:  dgst,
+	}
+
+	return gorillahandlers.MethodHandler{
+		"POST": http.HandlerFunc(peerHandler.Advertise),
+	}
+}
+
+// peerBlobHandler records blob advertisements from peer replicas.
+type peerBlobHandler struct {
+	*handlers.Context
+
+	Digest digest.Digest
+}
+
+// Advertise records that the peer named by the endpoint parameter holds the blob.
+func (h *peerBlobHandler) Advertise(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	if blobPeers == nil {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnsupported)
+		return
+	}
+	if len(h.Digest) == 0 {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail("invalid digest"))
+		return
+	}
+	endpoint := strings.TrimRight(req.URL.Query().Get("endpoint"), "/")
+	if !blobPeers.knownPeer(endpoint) {
+		h.Errors = append(h.Errors, errcode.ErrorCodeDenied.WithDetail(fmt.Sprintf("%q is not a configured peer", endpoint)))
+		return
+	}
+	size, err := strconv.ParseInt(req.URL.Query().Get("size"), 10, 64)
+	if err != nil || size < 0 {
+		size = 0
+	}
+
+	blobPeers.cache.add(h.Digest, endpoint, size)
+	context.GetLogger(h).Debugf("peer %s advertised blob %s", endpoint, h.Digest.String())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// peerBlobStore wraps a blob store for peer distribution. Committed blobs are advertised to the
+// configured peers and requests for blobs missing locally are redirected to a replica that
+// advertised them.
+type peerBlobStore struct {
+	distribution.BlobStore
+
+	repo *repository
+}
+
+var _ distribution.BlobStore = &peerBlobStore{}
+
+// Stat returns the descriptor of the blob, consulting peer advertisements when the blob is not
+// present locally.
+func (pbs *peerBlobStore) Stat(ctx context.Context, dgst digest.Digest) (distribution.Descriptor, error) {
+	desc, err := pbs.BlobStore.Stat(ctx, dgst)
+	if err != distribution.ErrBlobUnknown {
+		return desc, err
+	}
+	location, ok := blobPeers.cache.get(dgst)
+	if !ok {
+		return desc, err
+	}
+	return distribution.Descriptor{Digest: dgst, Size: location.size}, nil
+}
+
+// ServeBlob serves the blob from local storage, redirecting the client to a peer replica holding
+// the blob when it is missing locally.
+func (pbs *peerBlobStore) ServeBlob(ctx context.Context, w http.ResponseWriter, req *http.Request, dgst digest.Digest) error {
+	_, err := pbs.BlobStore.Stat(ctx, dgst)
+	if err != distribution.ErrBlobUnknown {
+		return pbs.BlobStore.ServeBlob(ctx, w, req, dgst)
+	}
+	location, ok := blobPeers.cache.get(dgst)
+	if !ok {
+		return pbs.BlobStore.ServeBlob(ctx, w, req, dgst)
+	}
+	target := fmt.Sprintf("%s/v2/%s/blobs/%s", location.endpoint, pbs.repo.Named().Name(), dgst.String())
+	context.GetLogger(ctx).Debugf("redirecting blob %s to peer %s", dgst.String(), location.endpoint)
+	http.Redirect(w, req, target, http.StatusTemporaryRedirect)
+	return nil
+}
+
+// Create wraps the returned writer so committed blobs are advertised to peers.
+func (pbs *peerBlobStore) Create(ctx context.Context, options ...distribution.BlobCreateOption) (distribution.BlobWriter, error) {
+	bw, err := pbs.BlobStore.Create(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &peerBlobWriter{BlobWriter: bw}, nil
+}
+
+// Resume wraps the returned writer so committed blobs are advertised to peers.
+func (pbs *peerBlobStore) Resume(ctx context.Context, id string) (distribution.BlobWriter, error) {
+	bw, err := pbs.BlobStore.Resume(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &peerBlobWriter{BlobWriter: bw}, nil
+}
+
+// peerBlobWriter advertises every committed blob to the configured peer replicas.
+type peerBlobWriter struct {
+	distribution.BlobWriter
+}
+
+func (bw *peerBlobWriter) Commit(ctx context.Context, provisional distribution.Descriptor) (distribution.Descriptor, error) {
+	canonical, err := bw.BlobWriter.Commit(ctx, provisional)
+	if err != nil {
+		return canonical, err
+	}
+	go blobPeers.announce(ctx, canonical.Digest, canonical.Size)
+	return canonical, nil
+}
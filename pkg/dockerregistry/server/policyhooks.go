@@ -0,0 +1,204 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/registry/api/errcode"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+const (
+	// PolicyWebhookURLEnvVar names an external policy evaluator consulted before manifests are
+	// accepted and blobs are served. The registry POSTs a JSON document describing the operation
+	// and denies it unless the evaluator allows it, so organizations can enforce custom rules -
+	// naming conventions, required labels - without forking the middleware. Both a plain
+	// {"allowed": ..., "reason": ...} response and the OPA data API's {"result": {...}} envelope
+	// are understood.
+	PolicyWebhookURLEnvVar = "REGISTRY_POLICY_WEBHOOK_URL"
+
+	// PolicyWebhookFailClosedEnvVar set to "true" refuses operations when the evaluator is
+	// unreachable. By default evaluator outages fail open with a logged error, so a dead policy
+	// server cannot take pulls and pushes down with it.
+	PolicyWebhookFailClosedEnvVar = "REGISTRY_POLICY_WEBHOOK_FAIL_CLOSED"
+)
+
+var (
+	policyWebhookURL        = readPolicyWebhookURL()
+	policyWebhookFailClosed = os.Getenv(PolicyWebhookFailClosedEnvVar) == "true"
+)
+
+// policyMetrics counts evaluator verdicts and failures.
+var policyMetrics = expvar.NewMap("registry.policyHooks")
+
+var policyWebhookClient = &http.Client{Timeout: 10 * time.Second}
+
+func readPolicyWebhookURL() string {
+	return strings.TrimSuffix(os.Getenv(PolicyWebhookURLEnvVar), "/")
+}
+
+// manifestPutHook admits or refuses a manifest push. It runs after the mapping has been fully
+// prepared - metadata and attribution included - and before anything is recorded.
+type manifestPutHook interface {
+	AdmitManifestPut(ctx context.Context, repo *repository, ism *imageapi.ImageStreamMapping) error
+}
+
+// blobServeHook admits or refuses serving a blob to the client.
+type blobServeHook interface {
+	AdmitBlobServe(ctx context.Context, repo *repository, dgst digest.Digest) error
+}
+
+var (
+	manifestPutHooks = []manifestPutHook{policyWebhook{}}
+	blobServeHooks   = []blobServeHook{policyWebhook{}}
+)
+
+// RegisterManifestPutHook and RegisterBlobServeHook add in-process policy hooks. They are meant
+// to be called from init functions of custom builds, before the registry starts serving.
+func RegisterManifestPutHook(hook manifestPutHook) {
+	manifestPutHooks = append(manifestPutHooks, hook)
+}
+
+func RegisterBlobServeHook(hook blobServeHook) {
+	blobServeHooks = append(blobServeHooks, hook)
+}
+
+// admitManifestPut runs every manifest put hook, stopping at the first refusal.
+func (r *repository) admitManifestPut(ctx context.Context, ism *imageapi.ImageStreamMapping) error {
+	for _, hook := range manifestPutHooks {
+		if err := hook.AdmitManifestPut(ctx, r, ism); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// policyRequest is the document POSTed to the external evaluator.
+type policyRequest struct {
+	Kind      string            `json:"kind"`
+	Namespace string            `json:"namespace"`
+	Name      string            `json:"name"`
+	Tag       string            `json:"tag,omitempty"`
+	Digest    string            `json:"digest,omitempty"`
+	User      string            `json:"user,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+type policyResponse struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason"`
+}
+
+// policyWebhook forwards operations to the external evaluator named by PolicyWebhookURLEnvVar.
+type policyWebhook struct{}
+
+func (policyWebhook) AdmitManifestPut(ctx context.Context, repo *repository, ism *imageapi.ImageStreamMapping) error {
+	request := policyRequest{
+		Kind:      "manifestPut",
+		Namespace: repo.namespace,
+		Name:      repo.name,
+		Tag:       ism.Tag,
+		Digest:    ism.Image.Name,
+	}
+	if config := ism.Image.DockerImageMetadata.Config; config != nil {
+		request.Labels = config.Labels
+	}
+	return evaluatePolicyWebhook(ctx, repo, request)
+}
+
+func (policyWebhook) AdmitBlobServe(ctx context.Context, repo *repository, dgst digest.Digest) error {
+	request := policyRequest{
+		Kind:      "blobServe",
+		Namespace: repo.namespace,
+		Name:      repo.name,
+		Digest:    dgst.String(),
+	}
+	return evaluatePolicyWebhook(ctx, repo, request)
+}
+
+// evaluatePolicyWebhook asks the evaluator for a verdict. Evaluator outages fail open unless
+// configured otherwise; denials surface the evaluator's reason to the client.
+func evaluatePolicyWebhook(ctx context.Context, repo *repository, request policyRequest) error {
+	if len(policyWebhookURL) == 0 {
+		return nil
+	}
+	if userName, ok := AuthenticatedUserNameFrom(repo.ctx); ok {
+		request.User = userName
+	}
+
+	body, err := json.Marshal(map[string]policyRequest{"input": request})
+	if err != nil {
+		return err
+	}
+	resp, err := policyWebhookClient.Post(policyWebhookURL, "application/json", bytes.NewReader(body))
+	if err == nil && resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		err = fmt.Errorf("policy evaluator returned %s", resp.Status)
+	}
+	if err != nil {
+		policyMetrics.Add("errors", 1)
+		if policyWebhookFailClosed {
+			context.GetLogger(ctx).Errorf("refusing %s for %s/%s: %v", request.Kind, request.Namespace, request.Name, err)
+			return errcode.ErrorCodeUnavailable.WithDetail("the policy evaluator is unavailable")
+		}
+		context.GetLogger(ctx).Errorf("allowing %s for %s/%s, policy evaluator unavailable: %v", request.Kind, request.Namespace, request.Name, err)
+		return nil
+	}
+	defer resp.Body.Close()
+
+	var envelope struct {
+		policyResponse
+		Result *policyResponse `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		policyMetrics.Add("errors", 1)
+		if policyWebhookFailClosed {
+			return errcode.ErrorCodeUnavailable.WithDetail("the policy evaluator is unavailable")
+		}
+		context.GetLogger(ctx).Errorf("allowing %s for %s/%s, undecodable policy response: %v", request.Kind, request.Namespace, request.Name, err)
+		return nil
+	}
+	verdict := envelope.policyResponse
+	if envelope.Result != nil {
+		verdict = *envelope.Result
+	}
+	if !verdict.Allowed {
+		policyMetrics.Add("denied", 1)
+		reason := verdict.Reason
+		if len(reason) == 0 {
+			reason = fmt.Sprintf("%s refused by the configured policy", request.Kind)
+		}
+		context.GetLogger(ctx).Infof("policy denied %s for %s/%s: %s", request.Kind, request.Namespace, request.Name, reason)
+		return errcode.ErrorCodeDenied.WithDetail(reason)
+	}
+	policyMetrics.Add("allowed", 1)
+	return nil
+}
+
+// policyBlobStore runs the blob serve hooks before blobs leave the registry.
+type policyBlobStore struct {
+	distribution.BlobStore
+
+	repo *repository
+}
+
+var _ distribution.BlobStore = &policyBlobStore{}
+
+func (bs *policyBlobStore) ServeBlob(ctx context.Context, w http.ResponseWriter, req *http.Request, dgst digest.Digest) error {
+	for _, hook := range blobServeHooks {
+		if err := hook.AdmitBlobServe(ctx, bs.repo, dgst); err != nil {
+			return err
+		}
+	}
+	return bs.BlobStore.ServeBlob(ctx, w, req, dgst)
+}
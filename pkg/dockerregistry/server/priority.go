@@ -0,0 +1,176 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/docker/distribution/registry/api/errcode"
+)
+
+const (
+	// PriorityRulesEnvVar holds a JSON list of classification rules, e.g.
+	// [{"userPrefix":"system:serviceaccount:ci:","class":"ci"},{"userAgentPrefix":"Jenkins","class":"ci"}].
+	// Rules are checked in order before the built-in ones; the first match decides the
+	// request's priority class. Identity prefixes are matched against the basic auth
+	// credential as sent, so they may span the whole username but not isolate the password.
+	PriorityRulesEnvVar = "REGISTRY_PRIORITY_RULES"
+
+	// PriorityMaxInflightEnvVar is the number of in-flight requests above which the registry
+	// starts shedding low-priority traffic. CI traffic is shed first; above twice the limit
+	// everything but node-class requests is shed, so kubelet image pulls — and with them pod
+	// scheduling — keep working while the registry is saturated. Zero (the default) disables
+	// prioritization.
+	PriorityMaxInflightEnvVar = "REGISTRY_PRIORITY_MAX_INFLIGHT"
+)
+
+// The priority classes, most important first. Unmatched requests are priorityDefault.
+const (
+	priorityNode    = "node"
+	priorityDefault = "default"
+	priorityCI      = "ci"
+)
+
+var classPriorities = map[string]int{
+	priorityNode:    3,
+	priorityDefault: 2,
+	priorityCI:      1,
+}
+
+// priorityRule matches a request by identity or User-Agent prefix and assigns a class.
+type priorityRule struct {
+	UserPrefix      string `json:"userPrefix"`
+	UserAgentPrefix string `json:"userAgentPrefix"`
+	Class           string `json:"class"`
+}
+
+// builtinPriorityRules recognizes node traffic. CI classification depends on how a cluster
+// names its build users, so it is left to configured rules.
+var builtinPriorityRules = []priorityRule{
+	{UserPrefix: "system:node:", Class: priorityNode},
+	{UserAgentPrefix: "kubelet/", Class: priorityNode},
+}
+
+var (
+	priorityRules       = readPriorityRules()
+	priorityMaxInflight = readPriorityMaxInflight()
+)
+
+func readPriorityRules() []priorityRule {
+	var rules []priorityRule
+	if value := os.Getenv(PriorityRulesEnvVar); len(value) > 0 {
+		if err := json.Unmarshal([]byte(value), &rules); err != nil {
+			log.Errorf("ignoring invalid %s: %v", PriorityRulesEnvVar, err)
+			rules = nil
+		}
+		for _, rule := range rules {
+			if _, ok := classPriorities[rule.Class]; !ok {
+				log.Errorf("ignoring %s: unknown class %q", PriorityRulesEnvVar, rule.Class)
+				rules = nil
+				break
+			}
+		}
+	}
+	return append(rules, builtinPriorityRules...)
+}
+
+func readPriorityMaxInflight() int64 {
+	value := os.Getenv(PriorityMaxInflightEnvVar)
+	if len(value) == 0 {
+		return 0
+	}
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit < 0 {
+		log.Errorf("ignoring invalid %s=%q", PriorityMaxInflightEnvVar, value)
+		return 0
+	}
+	return limit
+}
+
+// priorityMetrics counts shed requests per class and exposes the in-flight gauge.
+var priorityMetrics = expvar.NewMap("registry.priority")
+
+var inflightRequests int64
+
+func init() {
+	priorityMetrics.Set("inflight", expvar.Func(func() interface{} {
+		return atomic.LoadInt64(&inflightRequests)
+	}))
+}
+
+// classifyRequest returns the priority class of the request. The first matching rule wins.
+func classifyRequest(req *http.Request) string {
+	credential := requestCredential(req)
+	agent := req.UserAgent()
+	for _, rule := range priorityRules {
+		if len(rule.UserPrefix) > 0 && (len(credential) == 0 || !strings.HasPrefix(credential, rule.UserPrefix)) {
+			continue
+		}
+		if len(rule.UserAgentPrefix) > 0 && !strings.HasPrefix(agent, rule.UserAgentPrefix) {
+			continue
+		}
+		if len(rule.UserPrefix) == 0 && len(rule.UserAgentPrefix) == 0 {
+			continue
+		}
+		return rule.Class
+	}
+	return priorityDefault
+}
+
+// requestCredential returns the request's decoded basic auth credential, "username:password"
+// as the client sent it. OpenShift usernames contain colons ("system:node:node-1"), so the
+// username cannot be reliably split off the way req.BasicAuth does; identity rules are prefix
+// matches against the whole credential instead.
+func requestCredential(req *http.Request) string {
+	const prefix = "Basic "
+	header := req.Header.Get("Authorization")
+	if len(header) < len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return ""
+	}
+	decoded, err := base64.StdEncoding.DecodeString(header[len(prefix):])
+	if err != nil {
+		return ""
+	}
+	return string(decoded)
+}
+
+// NewPriorityHandler sheds low-priority requests while the registry is saturated. Below the
+// in-flight limit every request passes; above it CI-class requests are rejected with 503, and
+// above twice the limit only node-class requests are served.
+func NewPriorityHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		limit := priorityMaxInflight
+		if limit <= 0 {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		inflight := atomic.AddInt64(&inflightRequests, 1)
+		defer atomic.AddInt64(&inflightRequests, -1)
+
+		minPriority := 0
+		switch {
+		case inflight > 2*limit:
+			minPriority = classPriorities[priorityNode]
+		case inflight > limit:
+			minPriority = classPriorities[priorityDefault]
+		}
+		if minPriority > 0 {
+			class := classifyRequest(req)
+			if classPriorities[class] < minPriority {
+				priorityMetrics.Add("shed."+class, 1)
+				log.Warnf("shedding %s-class request %s %s: %d requests in flight", class, req.Method, req.URL.Path, inflight)
+				w.Header().Set("Retry-After", "30")
+				errcode.ServeJSON(w, errcode.ErrorCodeUnavailable.WithDetail("registry overloaded, low-priority traffic is being shed"))
+				return
+			}
+		}
+		handler.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestClassifyRequest(t *testing.T) {
+	defer func(rules []priorityRule) { priorityRules = rules }(priorityRules)
+	priorityRules = append([]priorityRule{
+		{UserPrefix: "system:serviceaccount:ci:", Class: priorityCI},
+		{UserAgentPrefix: "Jenkins", Class: priorityCI},
+	}, builtinPriorityRules...)
+
+	for _, tc := range []struct {
+		name      string
+		user      string
+		userAgent string
+		expected  string
+	}{
+		{
+			name:     "anonymous request",
+			expected: priorityDefault,
+		},
+		{
+			name:     "node identity",
+			user:     "system:node:node-1.example.com",
+			expected: priorityNode,
+		},
+		{
+			name:      "kubelet user agent",
+			userAgent: "kubelet/v1.3.0",
+			expected:  priorityNode,
+		},
+		{
+			name:     "configured ci identity",
+			user:     "system:serviceaccount:ci:jenkins",
+			expected: priorityCI,
+		},
+		{
+			name:      "configured ci user agent",
+			userAgent: "Jenkins/2.7",
+			expected:  priorityCI,
+		},
+		{
+			name:      "human docker pull",
+			user:      "alice",
+			userAgent: "docker/1.10.3 go/go1.5.4",
+			expected:  priorityDefault,
+		},
+	} {
+		req, err := http.NewRequest("GET", "http://registry.example.com/v2/", nil)
+		if err != nil {
+			t.Fatalf("[%s] unexpected error: %v", tc.name, err)
+		}
+		if len(tc.user) > 0 {
+			req.SetBasicAuth(tc.user, "password")
+		}
+		if len(tc.userAgent) > 0 {
+			req.Header.Set("User-Agent", tc.userAgent)
+		}
+		if class := classifyRequest(req); class != tc.expected {
+			t.Errorf("[%s] got class %q, expected %q", tc.name, class, tc.expected)
+		}
+	}
+}
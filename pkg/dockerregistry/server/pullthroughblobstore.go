@@ -1,9 +1,9 @@
 package server
 
 import (
-	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/docker/distribution"
 	"github.com/docker/distribution/context"
@@ -84,13 +84,17 @@ func (r *pullthroughBlobStore) Stat(ctx context.Context, dgst digest.Digest) (di
 // r.digestToStore saves the store.
 func (r *pullthroughBlobStore) proxyStat(ctx context.Context, retriever importer.RepositoryRetriever, ref imageapi.DockerImageReference, dgst digest.Digest) (distribution.Descriptor, error) {
 	context.GetLogger(r.repo.ctx).Infof("Trying to stat %q from %q", dgst, ref.Exact())
+	start := time.Now()
 	repo, err := retriever.Repository(ctx, ref.RegistryURL(), ref.RepositoryName(), false)
 	if err != nil {
 		context.GetLogger(r.repo.ctx).Errorf("Error getting remote repository for image %q: %v", ref.Exact(), err)
+		pullthroughHealth.observe(ref.Registry, ref.RegistryURL().String(), time.Since(start), true)
 		return distribution.Descriptor{}, err
 	}
 	pullthroughBlobStore := repo.Blobs(ctx)
 	desc, err := pullthroughBlobStore.Stat(ctx, dgst)
+	// an unknown blob is a healthy answer; only transport and server errors degrade the registry
+	pullthroughHealth.observe(ref.Registry, ref.RegistryURL().String(), time.Since(start), err != nil && err != distribution.ErrBlobUnknown)
 	if err != nil {
 		if err != distribution.ErrBlobUnknown {
 			context.GetLogger(r.repo.ctx).Errorf("Error getting pullthroughBlobStore for image %q: %v", ref.Exact(), err)
@@ -120,14 +124,15 @@ func (r *pullthroughBlobStore) ServeBlob(ctx context.Context, w http.ResponseWri
 		context.GetLogger(r.repo.ctx).Errorf("Failure to open remote store %q: %v", dgst.String(), err)
 		return err
 	}
+	defer remoteReader.Close()
 
 	setResponseHeaders(w, desc.Size, desc.MediaType, dgst)
 
-	context.GetLogger(r.repo.ctx).Infof("Copying %d bytes of type %q for %q", desc.Size, desc.MediaType, dgst.String())
-	if _, err := io.CopyN(w, remoteReader, desc.Size); err != nil {
-		context.GetLogger(r.repo.ctx).Errorf("Failed copying content from remote store %q: %v", dgst.String(), err)
-		return err
-	}
+	context.GetLogger(r.repo.ctx).Infof("Serving %d bytes of type %q for %q", desc.Size, desc.MediaType, dgst.String())
+	// Serve through http.ServeContent like the local blob server does so that Range requests
+	// work; the remote reader seeks by re-requesting the remote blob with a Range header, which
+	// lets interrupted layer downloads resume through pullthrough.
+	http.ServeContent(w, req, desc.Digest.String(), time.Time{}, remoteReader)
 	return nil
 }
 
@@ -154,8 +159,9 @@ func (r *pullthroughBlobStore) findCandidateRepository(ctx context.Context, sear
 		return desc, nil
 	}
 
-	// search the remaining registries for this digest
-	for repo, ref := range search {
+	// search the remaining registries for this digest, healthiest first
+	for _, repo := range pullthroughHealth.order(search) {
+		ref := search[repo]
 		desc, err := r.proxyStat(ctx, retriever, *ref, dgst)
 		if err != nil {
 			continue
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/digest"
+)
+
+// fakeRemoteBlobStore serves a single blob from memory, standing in for a remote registry.
+type fakeRemoteBlobStore struct {
+	distribution.BlobStore
+
+	content []byte
+	desc    distribution.Descriptor
+}
+
+func (f *fakeRemoteBlobStore) Stat(ctx context.Context, dgst digest.Digest) (distribution.Descriptor, error) {
+	return f.desc, nil
+}
+
+func (f *fakeRemoteBlobStore) Open(ctx context.Context, dgst digest.Digest) (distribution.ReadSeekCloser, error) {
+	return readSeekCloser{bytes.NewReader(f.content)}, nil
+}
+
+type readSeekCloser struct {
+	*bytes.Reader
+}
+
+func (readSeekCloser) Close() error { return nil }
+
+func TestPullthroughServeBlobRanges(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	dgst := digest.FromBytes(content)
+	store := &pullthroughBlobStore{
+		repo: &repository{ctx: context.Background()},
+		digestToStore: map[string]distribution.BlobStore{
+			dgst.String(): &fakeRemoteBlobStore{
+				content: content,
+				desc:    distribution.Descriptor{Digest: dgst, Size: int64(len(content)), MediaType: "application/octet-stream"},
+			},
+		},
+	}
+
+	testCases := []struct {
+		rangeHeader string
+		status      int
+		body        string
+	}{
+		{"", http.StatusOK, string(content)},
+		{"bytes=5-9", http.StatusPartialContent, "56789"},
+		{"bytes=10-", http.StatusPartialContent, "abcdefghij"},
+	}
+	for _, test := range testCases {
+		req, _ := http.NewRequest("GET", "/v2/user/app/blobs/"+dgst.String(), nil)
+		if len(test.rangeHeader) > 0 {
+			req.Header.Set("Range", test.rangeHeader)
+		}
+		w := httptest.NewRecorder()
+		if err := store.ServeBlob(context.Background(), w, req, dgst); err != nil {
+			t.Errorf("range %q: unexpected error: %v", test.rangeHeader, err)
+			continue
+		}
+		if w.Code != test.status {
+			t.Errorf("range %q: unexpected status %d", test.rangeHeader, w.Code)
+		}
+		if w.Body.String() != test.body {
+			t.Errorf("range %q: unexpected body %q", test.rangeHeader, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"expvar"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution/context"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// pullthroughProbeInterval is how often known remote registries are health probed.
+const pullthroughProbeInterval = time.Minute
+
+// pullthroughHealthMemory is how long a failure keeps a registry ordered last. After that the
+// registry gets another chance even without a successful probe.
+const pullthroughHealthMemory = 5 * time.Minute
+
+// pullthroughProbeClient pings remote registries; probes must be cheap, so the timeout is short.
+var pullthroughProbeClient = &http.Client{Timeout: 5 * time.Second}
+
+// pullthroughHealthMetrics counts probes and their failures.
+var pullthroughHealthMetrics = expvar.NewMap("registry.pullthroughHealth")
+
+// pullthroughHealth orders pull-through fallback attempts by observed health: registries that
+// recently failed go last and the rest are tried fastest first, so a degraded mirror does not
+// put its connect timeout in front of every pull.
+var pullthroughHealth = &registryHealth{entries: map[string]*registryHealthEntry{}}
+
+type registryHealthEntry struct {
+	// url is the base URL the registry was last contacted under, reused by the prober.
+	url string
+	// latency is an exponentially weighted moving average of successful request latency.
+	latency     time.Duration
+	lastSuccess time.Time
+	lastFailure time.Time
+}
+
+type registryHealth struct {
+	mu      sync.Mutex
+	entries map[string]*registryHealthEntry
+}
+
+// observe records the outcome of one remote request. An unknown blob is a healthy answer; only
+// transport and server errors count as failures.
+func (h *registryHealth) observe(registry, url string, latency time.Duration, failed bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entry, ok := h.entries[registry]
+	if !ok {
+		entry = &registryHealthEntry{}
+		h.entries[registry] = entry
+	}
+	entry.url = url
+	if failed {
+		entry.lastFailure = time.Now()
+		return
+	}
+	entry.lastSuccess = time.Now()
+	if entry.latency == 0 {
+		entry.latency = latency
+	} else {
+		entry.latency = (3*entry.latency + latency) / 4
+	}
+}
+
+// degraded is true while the entry's most recent outcome is a failure that has not aged out.
+func (entry *registryHealthEntry) degraded() bool {
+	return entry.lastFailure.After(entry.lastSuccess) && time.Since(entry.lastFailure) < pullthroughHealthMemory
+}
+
+// order returns the repositories of search sorted best candidate first: never-seen registries
+// between the measured ones and the degraded ones, measured ones fastest first.
+func (h *registryHealth) order(search map[string]*imageapi.DockerImageReference) []string {
+	repos := make([]string, 0, len(search))
+	for repo := range search {
+		repos = append(repos, repo)
+	}
+	scores := make(map[string]time.Duration, len(repos))
+	h.mu.Lock()
+	for _, repo := range repos {
+		scores[repo] = h.score(search[repo].Registry)
+	}
+	h.mu.Unlock()
+	sort.Sort(&byHealthScore{repos: repos, scores: scores})
+	return repos
+}
+
+// score must be called with the lock held.
+func (h *registryHealth) score(registry string) time.Duration {
+	entry, ok := h.entries[registry]
+	switch {
+	case !ok:
+		// unknown registries rank behind everything measured healthy
+		return time.Hour
+	case entry.degraded():
+		return 24 * time.Hour
+	case entry.latency == 0:
+		return time.Hour
+	default:
+		return entry.latency
+	}
+}
+
+type byHealthScore struct {
+	repos  []string
+	scores map[string]time.Duration
+}
+
+func (b *byHealthScore) Len() int      { return len(b.repos) }
+func (b *byHealthScore) Swap(i, j int) { b.repos[i], b.repos[j] = b.repos[j], b.repos[i] }
+func (b *byHealthScore) Less(i, j int) bool {
+	if b.scores[b.repos[i]] != b.scores[b.repos[j]] {
+		return b.scores[b.repos[i]] < b.scores[b.repos[j]]
+	}
+	return b.repos[i] < b.repos[j]
+}
+
+// snapshot returns the registries with a known base URL, for the prober.
+func (h *registryHealth) snapshot() map[string]string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	urls := map[string]string{}
+	for registry, entry := range h.entries {
+		if len(entry.url) > 0 {
+			urls[registry] = entry.url
+		}
+	}
+	return urls
+}
+
+// RunPullthroughProber periodically pings every remote registry pull-through has talked to and
+// feeds the results back into the fallback ordering, so a mirror recovering or degrading is
+// noticed between pulls. It returns immediately.
+func RunPullthroughProber(ctx context.Context) {
+	go func() {
+		for {
+			time.Sleep(pullthroughProbeInterval)
+			for registry, url := range pullthroughHealth.snapshot() {
+				start := time.Now()
+				resp, err := pullthroughProbeClient.Get(url + "/v2/")
+				latency := time.Since(start)
+				if err != nil {
+					// unreachable; any HTTP answer - including 401 - means the registry is up
+					pullthroughHealthMetrics.Add("probeFailures", 1)
+					pullthroughHealth.observe(registry, url, latency, true)
+					context.GetLogger(ctx).Debugf("pull-through probe of %s failed: %v", registry, err)
+					continue
+				}
+				resp.Body.Close()
+				pullthroughHealthMetrics.Add("probes", 1)
+				pullthroughHealth.observe(registry, url, latency, false)
+			}
+		}
+	}()
+}
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/docker/distribution/context"
+	ctxu "github.com/docker/distribution/context"
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/distribution/registry/api/v2"
+	"github.com/docker/distribution/registry/handlers"
+	gorillahandlers "github.com/gorilla/handlers"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+
+	"github.com/openshift/origin/pkg/client"
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// isQuarantined returns true when the image may not be served.
+func isQuarantined(image *imageapi.Image) bool {
+	quarantined, ok := image.Annotations[imageapi.ImageQuarantinedAnnotation]
+	return ok && quarantined == "true"
+}
+
+// checkQuarantine refuses to serve a quarantined image and records the attempted pull in the
+// audit log. Quarantine is reversible: clearing the annotation restores service.
+func (r *repository) checkQuarantine(image *imageapi.Image) error {
+	if !isQuarantined(image) {
+		return nil
+	}
+	userName, _ := AuthenticatedUserNameFrom(r.ctx)
+	context.GetLogger(r.ctx).Warnf("audit: refused pull of quarantined image %s from %s/%s by user %q", image.Name, r.namespace, r.name, userName)
+	return errcode.ErrorCodeDenied.WithDetail(fmt.Sprintf("image %s is quarantined", image.Name))
+}
+
+// QuarantineDispatcher takes the request context and builds the appropriate handler for
+// quarantining and releasing an image digest.
+func QuarantineDispatcher(ctx *handlers.Context, r *http.Request) http.Handler {
+	reference := ctxu.GetStringValue(ctx, "vars.digest")
+	dgst, _ := digest.ParseDigest(reference)
+
+	quarantineHandler := &quarantineHandler{
+		Context: ctx,
+		Digest:  dgst,
+	}
+
+	return gorillahandlers.MethodHandler{
+		"PUT":    http.HandlerFunc(quarantineHandler.Quarantine),
+		"DELETE": http.HandlerFunc(quarantineHandler.Release),
+	}
+}
+
+// quarantineHandler handles http operations on image quarantine.
+type quarantineHandler struct {
+	*handlers.Context
+
+	Digest digest.Digest
+}
+
+// Quarantine marks the image so the registry refuses to serve it and annotates the image
+// streams whose tags point at it.
+func (qh *quarantineHandler) Quarantine(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+	qh.set(w, true)
+}
+
+// Release reverses a quarantine.
+func (qh *quarantineHandler) Release(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+	qh.set(w, false)
+}
+
+func (qh *quarantineHandler) set(w http.ResponseWriter, quarantined bool) {
+	if len(qh.Digest) == 0 {
+		qh.Errors = append(qh.Errors, v2.ErrorCodeDigestInvalid)
+		return
+	}
+
+	osClient, _, err := DefaultRegistryClient.Clients()
+	if err != nil {
+		qh.Errors = append(qh.Errors, errcode.ErrorCodeUnknown.WithDetail(fmt.Sprintf("error getting clients: %v", err)))
+		return
+	}
+
+	image, err := osClient.Images().Get(qh.Digest.String())
+	if err != nil {
+		qh.Errors = append(qh.Errors, v2.ErrorCodeManifestUnknown.WithDetail(qh.Digest.String()))
+		return
+	}
+
+	if image.Annotations == nil {
+		image.Annotations = map[string]string{}
+	}
+	if quarantined {
+		image.Annotations[imageapi.ImageQuarantinedAnnotation] = "true"
+	} else {
+		delete(image.Annotations, imageapi.ImageQuarantinedAnnotation)
+	}
+	if _, err := osClient.Images().Update(image); err != nil {
+		qh.Errors = append(qh.Errors, errcode.ErrorCodeUnknown.WithDetail(fmt.Sprintf("error updating image: %v", err)))
+		return
+	}
+
+	if err := annotateAffectedStreams(osClient, qh.Digest, quarantined); err != nil {
+		qh.Errors = append(qh.Errors, errcode.ErrorCodeUnknown.WithDetail(fmt.Sprintf("error annotating image streams: %v", err)))
+		return
+	}
+
+	context.GetLogger(qh).Infof("audit: image %s quarantined=%t", qh.Digest.String(), quarantined)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// annotateAffectedStreams maintains the quarantined-tags annotation on every image stream whose
+// tags currently point at the digest.
+func annotateAffectedStreams(osClient client.Interface, dgst digest.Digest, quarantined bool) error {
+	streams, err := osClient.ImageStreams(kapi.NamespaceAll).List(kapi.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range streams.Items {
+		stream := &streams.Items[i]
+		affected := []string{}
+		for tag, history := range stream.Status.Tags {
+			if len(history.Items) > 0 && history.Items[0].Image == dgst.String() {
+				affected = append(affected, tag)
+			}
+		}
+		if len(affected) == 0 {
+			continue
+		}
+		sort.Strings(affected)
+
+		if stream.Annotations == nil {
+			stream.Annotations = map[string]string{}
+		}
+		tags := tagSet(stream.Annotations[imageapi.QuarantinedTagsAnnotation])
+		for _, tag := range affected {
+			if quarantined {
+				tags[tag] = true
+			} else {
+				delete(tags, tag)
+			}
+		}
+		if len(tags) == 0 {
+			delete(stream.Annotations, imageapi.QuarantinedTagsAnnotation)
+		} else {
+			stream.Annotations[imageapi.QuarantinedTagsAnnotation] = joinTagSet(tags)
+		}
+		if _, err := osClient.ImageStreams(stream.Namespace).Update(stream); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func tagSet(value string) map[string]bool {
+	tags := map[string]bool{}
+	for _, tag := range strings.Split(value, ",") {
+		if len(tag) > 0 {
+			tags[tag] = true
+		}
+	}
+	return tags
+}
+
+func joinTagSet(tags map[string]bool) string {
+	sorted := make([]string, 0, len(tags))
+	for tag := range tags {
+		sorted = append(sorted, tag)
+	}
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
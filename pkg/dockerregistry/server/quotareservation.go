@@ -0,0 +1,69 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// uploadReservation records the size of a single in-flight blob upload together with the time the
+// reservation stops counting against the repository.
+type uploadReservation struct {
+	size    int64
+	expires time.Time
+}
+
+// uploadReservations tracks bytes of recently committed blob uploads per repository. Layers are
+// uploaded before the manifest that ties them together, so a single layer check cannot see how
+// much data the push has already written. By reserving the bytes of each commit for a short time
+// the sum of concurrent uploads is admitted instead of each layer in isolation. Reservations are
+// not released explicitly - they expire, which covers the window until the master admission check
+// sees the finished image. The type is safe for concurrent use.
+type uploadReservations struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	repos map[string][]uploadReservation
+}
+
+func newUploadReservations(ttl time.Duration) *uploadReservations {
+	return &uploadReservations{
+		ttl:   ttl,
+		repos: make(map[string][]uploadReservation),
+	}
+}
+
+// reserve records size bytes as in flight for the given repository.
+func (r *uploadReservations) reserve(repo string, size int64) {
+	if size < 1 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.repos[repo] = append(r.repos[repo], uploadReservation{
+		size:    size,
+		expires: time.Now().Add(r.ttl),
+	})
+}
+
+// outstanding returns the number of reserved bytes for the given repository, dropping expired
+// reservations on the way.
+func (r *uploadReservations) outstanding(repo string) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	active := r.repos[repo][:0]
+	total := int64(0)
+	for _, reservation := range r.repos[repo] {
+		if reservation.expires.Before(now) {
+			continue
+		}
+		active = append(active, reservation)
+		total += reservation.size
+	}
+	if len(active) == 0 {
+		delete(r.repos, repo)
+	} else {
+		r.repos[repo] = active
+	}
+	return total
+}
@@ -8,8 +8,9 @@
 // the layers are written). This addition allows us to refuse the layers and thus keep the storage clean.
 //
 // *Note*: Here, we take into account just a single layer, not the image as a whole because the layers are
-// uploaded before the manifest. This leads to a situation where several layers can be written until a big
-// enough layer will be received that exceeds the limit.
+// uploaded before the manifest. To keep concurrent or multi-layer pushes from collectively overshooting the
+// limit before any manifest arrives, committed layer sizes are additionally reserved against the repository
+// for a short time and counted by subsequent layer checks. See uploadReservations.
 package server
 
 import (
@@ -27,12 +28,16 @@ import (
 
 const (
 	defaultProjectCacheTTL = time.Minute
+
+	// defaultReservationTTL is how long committed upload bytes keep counting against subsequent
+	// layer checks in the same repository.
+	defaultReservationTTL = 2 * time.Minute
 )
 
 // newQuotaEnforcingConfig creates caches for quota objects. The objects are stored with given eviction
 // timeout. Caches will only be initialized if the given ttl is positive. Options are gathered from
 // configuration file and will be overriden by enforceQuota and projectCacheTTL environment variable values.
-func newQuotaEnforcingConfig(ctx context.Context, enforceQuota, projectCacheTTL string, options map[string]interface{}) *quotaEnforcingConfig {
+func newQuotaEnforcingConfig(ctx context.Context, enforceQuota, projectCacheTTL, reservationTTL string, options map[string]interface{}) *quotaEnforcingConfig {
 	buildOptionValues := func(optionName string, override string) []string {
 		optValues := []string{}
 		if value, ok := options[optionName]; ok {
@@ -77,16 +82,35 @@ func newQuotaEnforcingConfig(ctx context.Context, enforceQuota, projectCacheTTL
 		ttl = parsed
 	}
 
+	resTTL := defaultReservationTTL
+	for _, s := range buildOptionValues("reservationttl", reservationTTL) {
+		parsed, err := time.ParseDuration(s)
+		if err != nil {
+			logrus.Errorf("failed to parse quota reservation ttl %q: %v", s, err)
+			continue
+		}
+		resTTL = parsed
+	}
+	var reservations *uploadReservations
+	if resTTL > 0 {
+		context.GetLogger(ctx).Infof("reserving in-flight upload bytes with TTL %s", resTTL.String())
+		reservations = newUploadReservations(resTTL)
+	} else {
+		context.GetLogger(ctx).Info("not reserving in-flight upload bytes")
+	}
+
 	if ttl <= 0 {
 		context.GetLogger(ctx).Info("not using project caches for quota objects")
 		return &quotaEnforcingConfig{
 			projectCacheDisabled: true,
+			reservations:         reservations,
 		}
 	}
 
 	context.GetLogger(ctx).Infof("caching project quota objects with TTL %s", ttl.String())
 	return &quotaEnforcingConfig{
-		limitRanges: newProjectObjectListCache(ttl),
+		limitRanges:  newProjectObjectListCache(ttl),
+		reservations: reservations,
 	}
 }
 
@@ -99,6 +123,8 @@ type quotaEnforcingConfig struct {
 	projectCacheDisabled bool
 	// a cache of limit range objects keyed by project name
 	limitRanges projectObjectListStore
+	// in-flight upload bytes keyed by repository; nil when reservations are disabled
+	reservations *uploadReservations
 }
 
 // quotaRestrictedBlobStore wraps upstream blob store with a guard preventing big layers exceeding image quotas
@@ -170,42 +196,33 @@ func admitBlobWrite(ctx context.Context, repo *repository, size int64) error {
 		return nil
 	}
 
-	var (
-		lrs *kapi.LimitRangeList
-		err error
-	)
-
-	if !quotaEnforcing.projectCacheDisabled {
-		obj, exists, _ := quotaEnforcing.limitRanges.get(repo.namespace)
-		if exists {
-			lrs = obj.(*kapi.LimitRangeList)
-		}
+	// count bytes of other recent uploads into this repository so concurrent pushes cannot
+	// collectively pass the limit one layer at a time
+	admittedSize := size
+	repoKey := repo.namespace + "/" + repo.name
+	if quotaEnforcing.reservations != nil {
+		admittedSize += quotaEnforcing.reservations.outstanding(repoKey)
 	}
-	if lrs == nil {
-		context.GetLogger(ctx).Debugf("listing limit ranges in namespace %s", repo.namespace)
-		lrs, err = repo.limitClient.LimitRanges(repo.namespace).List(kapi.ListOptions{})
-		if err != nil {
-			context.GetLogger(ctx).Errorf("failed to list limitranges: %v", err)
-			return err
-		}
-		if !quotaEnforcing.projectCacheDisabled {
-			err = quotaEnforcing.limitRanges.add(repo.namespace, lrs)
-			if err != nil {
-				context.GetLogger(ctx).Errorf("failed to cache limit range list: %v", err)
-			}
-		}
+
+	lrs, err := limitRangesFor(ctx, repo)
+	if err != nil {
+		return err
 	}
 
 	for _, limitrange := range lrs.Items {
 		context.GetLogger(ctx).Debugf("processing limit range %s/%s", limitrange.Namespace, limitrange.Name)
 		for _, limit := range limitrange.Spec.Limits {
-			if err := imageadmission.AdmitImage(size, limit); err != nil {
+			if err := imageadmission.AdmitImage(admittedSize, limit); err != nil {
 				context.GetLogger(ctx).Errorf("refusing to write blob exceeding limit range %s: %s", limitrange.Name, err.Error())
 				return distribution.ErrAccessDenied
 			}
 		}
 	}
 
+	if quotaEnforcing.reservations != nil {
+		quotaEnforcing.reservations.reserve(repoKey, size)
+	}
+
 	// TODO(1): admit also against openshift.io/ImageStream quota resource when we have image stream cache in the
 	// registry
 	// TODO(2): admit also against openshift.io/imagestreamimages and openshift.io/imagestreamtags resources once
@@ -213,3 +230,27 @@ func admitBlobWrite(ctx context.Context, repo *repository, size int64) error {
 
 	return nil
 }
+
+// limitRangesFor returns the limit ranges that apply to the repository's namespace, going
+// through the project cache when it is enabled.
+func limitRangesFor(ctx context.Context, repo *repository) (*kapi.LimitRangeList, error) {
+	if !quotaEnforcing.projectCacheDisabled {
+		obj, exists, _ := quotaEnforcing.limitRanges.get(repo.namespace)
+		if exists {
+			return obj.(*kapi.LimitRangeList), nil
+		}
+	}
+
+	context.GetLogger(ctx).Debugf("listing limit ranges in namespace %s", repo.namespace)
+	lrs, err := repo.limitClient.LimitRanges(repo.namespace).List(kapi.ListOptions{})
+	if err != nil {
+		context.GetLogger(ctx).Errorf("failed to list limitranges: %v", err)
+		return nil, err
+	}
+	if !quotaEnforcing.projectCacheDisabled {
+		if err := quotaEnforcing.limitRanges.add(repo.namespace, lrs); err != nil {
+			context.GetLogger(ctx).Errorf("failed to cache limit range list: %v", err)
+		}
+	}
+	return lrs, nil
+}
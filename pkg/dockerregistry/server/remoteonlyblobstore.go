@@ -0,0 +1,48 @@
+package server
+
+import (
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// remoteOnly returns true when the repository's image stream is marked remote-only. Such streams
+// expose upstream content under OpenShift auth and policy without ever duplicating it into local
+// storage: reads are served by pullthrough and writes are refused.
+func (r *repository) remoteOnly() bool {
+	is, err := r.getImageStream()
+	if err != nil {
+		return false
+	}
+	return is.Annotations[imageapi.RemoteOnlyRepositoryAnnotation] == "true"
+}
+
+// remoteOnlyBlobStore wraps a blob store and refuses writes into repositories backed by
+// remote-only image streams. Reads pass through untouched and are satisfied by the pullthrough
+// layer underneath.
+type remoteOnlyBlobStore struct {
+	distribution.BlobStore
+
+	repo *repository
+}
+
+var _ distribution.BlobStore = &remoteOnlyBlobStore{}
+
+// Create refuses to start a blob upload when the image stream is remote-only.
+func (bs *remoteOnlyBlobStore) Create(ctx context.Context, options ...distribution.BlobCreateOption) (distribution.BlobWriter, error) {
+	if bs.repo.remoteOnly() {
+		context.GetLogger(ctx).Infof("refusing blob upload to remote-only repository %s/%s", bs.repo.namespace, bs.repo.name)
+		return nil, distribution.ErrUnsupported
+	}
+	return bs.BlobStore.Create(ctx, options...)
+}
+
+// Resume refuses to resume a blob upload when the image stream is remote-only.
+func (bs *remoteOnlyBlobStore) Resume(ctx context.Context, id string) (distribution.BlobWriter, error) {
+	if bs.repo.remoteOnly() {
+		context.GetLogger(ctx).Infof("refusing blob upload to remote-only repository %s/%s", bs.repo.namespace, bs.repo.name)
+		return nil, distribution.ErrUnsupported
+	}
+	return bs.BlobStore.Resume(ctx, id)
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"strings"
@@ -43,6 +44,12 @@ const (
 	// zero (e.g. "0m"), caching is disabled.
 	ProjectCacheTTLEnvVar = "REGISTRY_MIDDLEWARE_REPOSITORY_OPENSHIFT_PROJECTCACHETTL"
 
+	// QuotaReservationTTLEnvVar is an environment variable specifying how long committed upload bytes
+	// keep counting against subsequent layer checks in the same repository. It takes a valid time
+	// duration string (e.g. "2m"). If empty, you get the default timeout. If zero (e.g. "0m"),
+	// reservations are disabled.
+	QuotaReservationTTLEnvVar = "REGISTRY_MIDDLEWARE_REPOSITORY_OPENSHIFT_QUOTARESERVATIONTTL"
+
 	// AcceptSchema2EnvVar is a boolean environment variable that allows to accept manifest schema v2
 	// on manifest put requests.
 	AcceptSchema2EnvVar = "REGISTRY_MIDDLEWARE_REPOSITORY_OPENSHIFT_ACCEPTSCHEMA2"
@@ -87,17 +94,18 @@ func init() {
 				return nil, errClients
 			}
 			if quotaEnforcing == nil {
-				quotaEnforcing = newQuotaEnforcingConfig(ctx, os.Getenv(EnforceQuotaEnvVar), os.Getenv(ProjectCacheTTLEnvVar), options)
+				quotaEnforcing = newQuotaEnforcingConfig(ctx, os.Getenv(EnforceQuotaEnvVar), os.Getenv(ProjectCacheTTLEnvVar), os.Getenv(QuotaReservationTTLEnvVar), options)
 			}
-			return newRepositoryWithClient(registryOSClient, kClient, kClient, ctx, repo, options)
+			return newRepositoryWithClient(registryOSClient, kClient, kClient, kClient, ctx, repo, options)
 		},
 	)
 
-	secureTransport = http.DefaultTransport
+	secureTransport = withRequestTimeout(http.DefaultTransport, operationTimeouts.pullthrough)
 	insecureTransport, err = restclient.TransportFor(&restclient.Config{Insecure: true})
 	if err != nil {
 		panic(fmt.Sprintf("Unable to configure a default transport for importing insecure images: %v", err))
 	}
+	insecureTransport = withRequestTimeout(insecureTransport, operationTimeouts.pullthrough)
 }
 
 // repository wraps a distribution.Repository and allows manifests to be served from the OpenShift image
@@ -108,16 +116,25 @@ type repository struct {
 	ctx              context.Context
 	quotaClient      kclient.ResourceQuotasNamespacer
 	limitClient      kclient.LimitRangesNamespacer
+	nsClient         kclient.NamespacesInterface
 	registryOSClient client.Interface
-	registryAddr     string
-	namespace        string
-	name             string
+	// backend is the metadata store image stream data is read from and pushes are recorded to
+	backend      imageStreamBackend
+	registryAddr string
+	namespace    string
+	name         string
 
 	// if true, the repository will check remote references in the image stream to support pulling "through"
 	// from a remote repository
 	pullthrough bool
 	// acceptschema2 allows to refuse the manifest schema version 2
 	acceptschema2 bool
+	// acceptedMediaTypesDefault restricts which manifest media types may be pushed unless the
+	// namespace sets its own policy; empty means every media type is accepted
+	acceptedMediaTypesDefault []string
+	// layerVerification controls sniffing pushed layers against their declared media type; one
+	// of "off", "warn" or "reject"
+	layerVerification string
 	// cachedLayers remembers a mapping of layer digest to repositories recently seen with that image to avoid
 	// having to check every potential upstream repository when a blob request is made. The cache is useful only
 	// when session affinity is on for the registry, but in practice the first pull will fill the cache.
@@ -131,6 +148,7 @@ func newRepositoryWithClient(
 	registryOSClient client.Interface,
 	quotaClient kclient.ResourceQuotasNamespacer,
 	limitClient kclient.LimitRangesNamespacer,
+	nsClient kclient.NamespacesInterface,
 	ctx context.Context,
 	repo distribution.Repository,
 	options map[string]interface{},
@@ -150,6 +168,16 @@ func newRepositoryWithClient(
 		acceptschema2 = getBoolOption("acceptschema2", false, options)
 	}
 
+	acceptedMediaTypes := getStringOption("acceptedmediatypes", "", options)
+	if value := os.Getenv(AcceptedMediaTypesEnvVar); len(value) > 0 {
+		acceptedMediaTypes = value
+	}
+
+	layerVerification := getStringOption("verifylayers", layerVerificationOff, options)
+	if value := os.Getenv(LayerVerificationEnvVar); len(value) > 0 {
+		layerVerification = value
+	}
+
 	nameParts := strings.SplitN(repo.Named().Name(), "/", 2)
 	if len(nameParts) != 2 {
 		return nil, fmt.Errorf("invalid repository name %q: it must be of the format <project>/<name>", repo.Named().Name())
@@ -158,16 +186,20 @@ func newRepositoryWithClient(
 	return &repository{
 		Repository: repo,
 
-		ctx:              ctx,
-		quotaClient:      quotaClient,
-		limitClient:      limitClient,
-		registryOSClient: registryOSClient,
-		registryAddr:     registryAddr,
-		namespace:        nameParts[0],
-		name:             nameParts[1],
-		pullthrough:      pullthrough,
-		acceptschema2:    acceptschema2,
-		cachedLayers:     cachedLayers,
+		ctx:                       ctx,
+		quotaClient:               quotaClient,
+		limitClient:               limitClient,
+		nsClient:                  nsClient,
+		registryOSClient:          registryOSClient,
+		backend:                   withBackendMetrics(withBackendCache(withBackendTimeouts(withBackendFaultInjection(clientImageStreamBackend{client: registryOSClient})))),
+		registryAddr:              registryAddr,
+		namespace:                 nameParts[0],
+		name:                      nameParts[1],
+		pullthrough:               pullthrough,
+		acceptschema2:             acceptschema2,
+		acceptedMediaTypesDefault: parseMediaTypes(acceptedMediaTypes),
+		layerVerification:         layerVerification,
+		cachedLayers:              cachedLayers,
 	}, nil
 }
 
@@ -198,7 +230,15 @@ func (r *repository) Blobs(ctx context.Context) distribution.BlobStore {
 
 	bs := r.Repository.Blobs(ctx)
 
-	if !quotaEnforcing.enforcementDisabled {
+	if len(secondaryBlobRoot) > 0 {
+		bs = &secondaryBlobStore{
+			BlobStore: bs,
+
+			repo: &repo,
+		}
+	}
+
+	if !quotaEnforcing.enforcementDisabled && !namespaceExempt(r.namespace) {
 		bs = &quotaRestrictedBlobStore{
 			BlobStore: bs,
 
@@ -206,6 +246,16 @@ func (r *repository) Blobs(ctx context.Context) distribution.BlobStore {
 		}
 	}
 
+	bs = &throttledBlobStore{
+		BlobStore: bs,
+
+		repo: &repo,
+	}
+
+	bs = &dedupBlobStore{
+		BlobStore: bs,
+	}
+
 	if r.pullthrough {
 		bs = &pullthroughBlobStore{
 			BlobStore: bs,
@@ -215,6 +265,50 @@ func (r *repository) Blobs(ctx context.Context) distribution.BlobStore {
 		}
 	}
 
+	if blobPeers != nil {
+		bs = &peerBlobStore{
+			BlobStore: bs,
+
+			repo: &repo,
+		}
+	}
+
+	bs = &remoteOnlyBlobStore{
+		BlobStore: bs,
+
+		repo: &repo,
+	}
+
+	bs = &policyBlobStore{
+		BlobStore: bs,
+
+		repo: &repo,
+	}
+
+	bs = &trackingBlobStore{
+		BlobStore: bs,
+
+		repo: r.Named().Name(),
+	}
+
+	if memoryBudget != nil && !namespaceExempt(r.namespace) {
+		bs = &budgetBlobStore{
+			BlobStore: bs,
+		}
+	}
+
+	if operationTimeouts.blobUpload > 0 {
+		bs = &deadlineBlobStore{
+			BlobStore: bs,
+		}
+	}
+
+	if uploadSheddingEnabled() && !namespaceExempt(r.namespace) {
+		bs = &sheddingBlobStore{
+			BlobStore: bs,
+		}
+	}
+
 	bs = &errorBlobStore{
 		store: bs,
 		repo:  &repo,
@@ -270,9 +364,16 @@ func (r *repository) Get(ctx context.Context, dgst digest.Digest, options ...dis
 		return nil, err
 	}
 
+	if err := r.checkQuarantine(image); err != nil {
+		return nil, err
+	}
+
 	ref := imageapi.DockerImageReference{Namespace: r.namespace, Name: r.name, Registry: r.registryAddr}
 	manifest, err := r.manifestFromImageWithCachedLayers(image, ref.DockerClientDefaults().Exact())
 
+	if err == nil {
+		accessTimes.touchRead(r.Named().Name() + "@" + dgst.String())
+	}
 	return manifest, err
 }
 
@@ -307,6 +408,20 @@ func (r *repository) Put(ctx context.Context, manifest distribution.Manifest, op
 		}
 	}
 
+	if r.remoteOnly() {
+		context.GetLogger(r.ctx).Infof("refusing manifest push to remote-only repository %s/%s", r.namespace, r.name)
+		return "", distribution.ErrAccessDenied
+	}
+
+	if accepted := r.acceptedMediaTypes(); !mediaTypeAccepted(accepted, mediatype) {
+		err = fmt.Errorf("media type %s may not be pushed to namespace %s, accepted media types are: %s", mediatype, r.namespace, strings.Join(accepted, ", "))
+		return "", regapi.ErrorCodeManifestInvalid.WithDetail(err)
+	}
+
+	if err := r.verifyLayerMediaTypes(ctx, manifest); err != nil {
+		return "", err
+	}
+
 	// Calculate digest
 	dgst := digest.FromBytes(canonical)
 
@@ -336,62 +451,129 @@ func (r *repository) Put(ctx context.Context, manifest distribution.Manifest, op
 		}
 	}
 
-	if err = r.fillImageWithMetadata(manifest, &ism.Image); err != nil {
+	// enforce tag protection and tag count rules before any change to an existing stream
+	if len(ism.Tag) > 0 {
+		if stream, err := r.getImageStream(); err == nil {
+			if err := r.checkTagProtection(ctx, stream, ism.Tag); err != nil {
+				return "", err
+			}
+			if err := r.checkTagLimit(stream, ism.Tag); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	// enforce the distinct image caps from limit ranges before accepting a new digest
+	if err := r.checkImageCountLimit(ctx, dgst); err != nil {
 		return "", err
 	}
 
-	if err = r.registryOSClient.ImageStreamMappings(r.namespace).Create(&ism); err != nil {
-		// if the error was that the image stream wasn't found, try to auto provision it
-		statusErr, ok := err.(*kerrors.StatusError)
-		if !ok {
-			context.GetLogger(r.ctx).Errorf("error creating ImageStreamMapping: %s", err)
-			return "", err
+	// attribute the image to the authenticated pusher; the mapping itself is created with the
+	// registry's own identity
+	if userName, ok := AuthenticatedUserNameFrom(r.ctx); ok {
+		ism.Image.Annotations[imageapi.PushedByUserAnnotation] = userName
+	}
+	if addr := context.GetStringValue(ctx, "http.request.remoteaddr"); len(addr) > 0 {
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			addr = host
 		}
+		ism.Image.Annotations[imageapi.PushedFromAnnotation] = addr
+	}
+	if agent := context.GetStringValue(ctx, "http.request.useragent"); len(agent) > 0 {
+		ism.Image.Annotations[imageapi.PushUserAgentAnnotation] = agent
+	}
 
-		if quotautil.IsErrorQuotaExceeded(statusErr) {
-			context.GetLogger(r.ctx).Errorf("denied creating ImageStreamMapping: %v", statusErr)
-			return "", distribution.ErrAccessDenied
-		}
+	if err = r.fillImageWithMetadata(manifest, &ism.Image); err != nil {
+		return "", err
+	}
 
-		status := statusErr.ErrStatus
-		if status.Code != http.StatusNotFound || (strings.ToLower(status.Details.Kind) != "imagestream" /*pre-1.2*/ && strings.ToLower(status.Details.Kind) != "imagestreams") || status.Details.Name != r.name {
-			context.GetLogger(r.ctx).Errorf("error creating ImageStreamMapping: %s", err)
-			return "", err
-		}
+	// give the policy hooks the final say once the image carries its metadata and attribution
+	if err := r.admitManifestPut(ctx, &ism); err != nil {
+		return "", err
+	}
 
-		stream := imageapi.ImageStream{
-			ObjectMeta: kapi.ObjectMeta{
-				Name: r.name,
-			},
-		}
+	setDedupSavingsHeaders(ctx, r.Named().Name(), manifest)
 
-		uclient, ok := UserClientFrom(r.ctx)
-		if !ok {
-			context.GetLogger(r.ctx).Errorf("error creating user client to auto provision image stream: Origin user client unavailable")
-			return "", statusErr
-		}
+	// a dry-run push has passed every validation by now; report the digest without recording
+	if dryRunRequested(ctx) {
+		context.GetLogger(r.ctx).Infof("dry-run push of %s to %s/%s validated", dgst.String(), r.namespace, r.name)
+		return dgst, nil
+	}
 
-		if _, err := uclient.ImageStreams(r.namespace).Create(&stream); err != nil {
-			if quotautil.IsErrorQuotaExceeded(err) {
-				context.GetLogger(r.ctx).Errorf("denied creating ImageStream: %v", err)
-				return "", distribution.ErrAccessDenied
-			}
-			context.GetLogger(r.ctx).Errorf("error auto provisioning ImageStream: %s", err)
-			return "", statusErr
+	// in async mode the client gets its digest back as soon as the content is durable; the
+	// mapping is recorded by the queue, which retries across master outages
+	if asyncMappingEnabled && defaultMappingQueue.enqueue(r, &ism) {
+		accessTimes.touchWrite(r.Named().Name() + "@" + dgst.String())
+		return dgst, nil
+	}
+
+	if err = r.createImageStreamMapping(&ism); err != nil {
+		return "", err
+	}
+
+	accessTimes.touchWrite(r.Named().Name() + "@" + dgst.String())
+	return dgst, nil
+}
+
+// createImageStreamMapping records the mapping on the master, auto provisioning the image
+// stream on the first push to a repository. It is called inline during Put and from the async
+// mapping queue.
+func (r *repository) createImageStreamMapping(ism *imageapi.ImageStreamMapping) error {
+	err := r.backend.CreateImageStreamMapping(r.namespace, ism)
+	if err == nil {
+		return nil
+	}
+
+	// if the error was that the image stream wasn't found, try to auto provision it
+	statusErr, ok := err.(*kerrors.StatusError)
+	if !ok {
+		context.GetLogger(r.ctx).Errorf("error creating ImageStreamMapping: %s", err)
+		return err
+	}
+
+	if quotautil.IsErrorQuotaExceeded(statusErr) {
+		context.GetLogger(r.ctx).Errorf("denied creating ImageStreamMapping: %v", statusErr)
+		return distribution.ErrAccessDenied
+	}
+
+	status := statusErr.ErrStatus
+	if status.Code != http.StatusNotFound || (strings.ToLower(status.Details.Kind) != "imagestream" /*pre-1.2*/ && strings.ToLower(status.Details.Kind) != "imagestreams") || status.Details.Name != r.name {
+		context.GetLogger(r.ctx).Errorf("error creating ImageStreamMapping: %s", err)
+		return err
+	}
+
+	stream := imageapi.ImageStream{
+		ObjectMeta: kapi.ObjectMeta{
+			Name: r.name,
+		},
+	}
+
+	uclient, ok := UserClientFrom(r.ctx)
+	if !ok {
+		context.GetLogger(r.ctx).Errorf("error creating user client to auto provision image stream: Origin user client unavailable")
+		return statusErr
+	}
+
+	if _, err := uclient.ImageStreams(r.namespace).Create(&stream); err != nil {
+		if quotautil.IsErrorQuotaExceeded(err) {
+			context.GetLogger(r.ctx).Errorf("denied creating ImageStream: %v", err)
+			return distribution.ErrAccessDenied
 		}
+		context.GetLogger(r.ctx).Errorf("error auto provisioning ImageStream: %s", err)
+		return statusErr
+	}
 
-		// try to create the ISM again
-		if err := r.registryOSClient.ImageStreamMappings(r.namespace).Create(&ism); err != nil {
-			if quotautil.IsErrorQuotaExceeded(err) {
-				context.GetLogger(r.ctx).Errorf("denied a creation of ImageStreamMapping: %v", err)
-				return "", distribution.ErrAccessDenied
-			}
-			context.GetLogger(r.ctx).Errorf("error creating ImageStreamMapping: %s", err)
-			return "", err
+	// try to create the ISM again
+	if err := r.backend.CreateImageStreamMapping(r.namespace, ism); err != nil {
+		if quotautil.IsErrorQuotaExceeded(err) {
+			context.GetLogger(r.ctx).Errorf("denied a creation of ImageStreamMapping: %v", err)
+			return distribution.ErrAccessDenied
 		}
+		context.GetLogger(r.ctx).Errorf("error creating ImageStreamMapping: %s", err)
+		return err
 	}
 
-	return dgst, nil
+	return nil
 }
 
 // fillImageWithMetadata fills a given image with metadata.
@@ -502,18 +684,22 @@ func (r *repository) importContext() importer.RepositoryRetriever {
 
 // getImageStream retrieves the ImageStream for r.
 func (r *repository) getImageStream() (*imageapi.ImageStream, error) {
-	return r.registryOSClient.ImageStreams(r.namespace).Get(r.name)
+	stream, err := r.backend.GetImageStream(r.namespace, r.name)
+	if err != nil {
+		return nil, err
+	}
+	return r.mergeOverflowShards(stream), nil
 }
 
 // getImage retrieves the Image with digest `dgst`.
 func (r *repository) getImage(dgst digest.Digest) (*imageapi.Image, error) {
-	return r.registryOSClient.Images().Get(dgst.String())
+	return r.backend.GetImage(dgst)
 }
 
 // getImageStreamImage retrieves the Image with digest `dgst` for the ImageStream
 // associated with r. This ensures the image belongs to the image stream.
 func (r *repository) getImageStreamImage(dgst digest.Digest) (*imageapi.ImageStreamImage, error) {
-	return r.registryOSClient.ImageStreamImages(r.namespace).Get(r.name, dgst.String())
+	return r.backend.GetImageStreamImage(r.namespace, r.name, dgst)
 }
 
 // rememberLayers caches the provided layers
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/distribution/registry/api/v2"
+	"github.com/docker/distribution/registry/handlers"
+	gorillahandlers "github.com/gorilla/handlers"
+)
+
+// tagResolution maps one tag to its manifest digest and, for manifest lists, the per-platform
+// manifest digests keyed by "os/architecture".
+type tagResolution struct {
+	Name      string            `json:"name"`
+	Tag       string            `json:"tag"`
+	Digest    string            `json:"digest"`
+	Platforms map[string]string `json:"platforms,omitempty"`
+}
+
+// ResolveDispatcher takes the request context and builds the handler resolving tags to digests.
+// The route carries a repository name, so pull access is enforced. Resolution reads only image
+// stream metadata - the manifest is never downloaded - which makes pinning digests in deploy
+// tooling cheap.
+func ResolveDispatcher(ctx *handlers.Context, r *http.Request) http.Handler {
+	resolveHandler := &resolveHandler{
+		Context: ctx,
+	}
+
+	return gorillahandlers.MethodHandler{
+		"GET": http.HandlerFunc(resolveHandler.Resolve),
+	}
+}
+
+// resolveHandler resolves tags to digests.
+type resolveHandler struct {
+	*handlers.Context
+}
+
+// Resolve answers with the digest of every tag named by the ?tag= parameters.
+func (rh *resolveHandler) Resolve(w http.ResponseWriter, req *http.Request) {
+	tags := req.URL.Query()["tag"]
+	if len(tags) == 0 {
+		rh.Errors = append(rh.Errors, errcode.ErrorCodeUnknown.WithDetail("at least one tag parameter is required"))
+		return
+	}
+
+	name := rh.Repository.Named().Name()
+	tagService := rh.Repository.Tags(rh)
+
+	resolutions := []tagResolution{}
+	for _, tag := range tags {
+		desc, err := tagService.Get(rh, tag)
+		if err != nil {
+			rh.Errors = append(rh.Errors, v2.ErrorCodeManifestUnknown.WithDetail(fmt.Sprintf("%s:%s", name, tag)))
+			return
+		}
+		resolutions = append(resolutions, tagResolution{
+			Name:      name,
+			Tag:       tag,
+			Digest:    desc.Digest.String(),
+			Platforms: rh.platformDigests(desc.Digest.String()),
+		})
+	}
+
+	if len(resolutions) == 1 {
+		w.Header().Set("Docker-Content-Digest", resolutions[0].Digest)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(resolutions); err != nil {
+		rh.Errors = append(rh.Errors, errcode.ErrorCodeUnknown.WithDetail(err))
+	}
+}
+
+// platformDigests returns the per-platform manifest digests when the digest names a manifest
+// list stored in the image metadata. The stored manifest is parsed, not re-downloaded.
+func (rh *resolveHandler) platformDigests(dgst string) map[string]string {
+	repo, ok := rh.Repository.(*repository)
+	if !ok {
+		return nil
+	}
+	parsed, err := digest.ParseDigest(dgst)
+	if err != nil {
+		return nil
+	}
+	image, err := repo.getImage(parsed)
+	if err != nil {
+		return nil
+	}
+	if image.DockerImageManifestMediaType != manifestlist.MediaTypeManifestList {
+		return nil
+	}
+
+	var list manifestlist.ManifestList
+	if err := json.Unmarshal([]byte(image.DockerImageManifest), &list); err != nil {
+		context.GetLogger(rh).Errorf("cannot parse manifest list %s: %v", dgst, err)
+		return nil
+	}
+	platforms := map[string]string{}
+	for _, descriptor := range list.Manifests {
+		platforms[descriptor.Platform.OS+"/"+descriptor.Platform.Architecture] = descriptor.Digest.String()
+	}
+	return platforms
+}
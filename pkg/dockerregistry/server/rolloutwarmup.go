@@ -0,0 +1,166 @@
+package server
+
+import (
+	"expvar"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution/context"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/apis/extensions"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/openshift/origin/pkg/client"
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// RolloutWarmupEnvVar set to "true" makes the registry watch replication controller and
+// deployment rollouts and pre-warm its caches for the images being rolled out. A large rollout
+// makes every kubelet pull the same image at once; with warm layer and metadata caches that
+// surge is served without a matching burst of master API calls. Recognized values are "true"
+// and "false".
+const RolloutWarmupEnvVar = "REGISTRY_ROLLOUT_WARMUP"
+
+// rolloutWarmupTTL is how long a warmed stream is considered warm; rollout controllers are
+// updated on every status change and most of those updates reference the same images.
+const rolloutWarmupTTL = 10 * time.Minute
+
+// rolloutWarmupMetrics counts streams warmed ahead of rollouts.
+var rolloutWarmupMetrics = expvar.NewMap("registry.rolloutWarmup")
+
+// RunRolloutWarmup watches rollouts and primes the caches in the background. It returns
+// immediately; it is a no-op unless RolloutWarmupEnvVar is enabled.
+func RunRolloutWarmup(ctx context.Context, registryClient *RegistryClient) {
+	if os.Getenv(RolloutWarmupEnvVar) != "true" {
+		return
+	}
+	osClient, kClient, err := registryClient.Clients()
+	if err != nil {
+		context.GetLogger(ctx).Fatalf("error getting clients for the rollout warmup: %v", err)
+	}
+
+	warmer := &rolloutWarmer{
+		osClient: osClient,
+		warmed:   map[string]time.Time{},
+	}
+	context.GetLogger(ctx).Infof("watching rollouts to pre-warm image caches")
+	go func() {
+		for {
+			if err := warmer.watchReplicationControllers(ctx, kClient); err != nil {
+				context.GetLogger(ctx).Errorf("rollout warmup watch interrupted: %v", err)
+			}
+			time.Sleep(10 * time.Second)
+		}
+	}()
+	go func() {
+		for {
+			if err := warmer.watchDeployments(ctx, kClient); err != nil {
+				context.GetLogger(ctx).Errorf("rollout warmup watch interrupted: %v", err)
+			}
+			time.Sleep(10 * time.Second)
+		}
+	}()
+}
+
+// rolloutWarmer warms each stream at most once per TTL, no matter how many controllers and
+// status updates reference it.
+type rolloutWarmer struct {
+	osClient client.Interface
+
+	mu     sync.Mutex
+	warmed map[string]time.Time
+}
+
+// watchReplicationControllers warms for replication controller rollouts, which is also how
+// deployment config rollouts materialize. Only events from the watch are handled; priming
+// existing workloads at startup is what the startup warmup is for.
+func (w *rolloutWarmer) watchReplicationControllers(ctx context.Context, kClient kclient.Interface) error {
+	rcs, err := kClient.ReplicationControllers(kapi.NamespaceAll).List(kapi.ListOptions{})
+	if err != nil {
+		return err
+	}
+	watcher, err := kClient.ReplicationControllers(kapi.NamespaceAll).Watch(kapi.ListOptions{ResourceVersion: rcs.ResourceVersion})
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+	for event := range watcher.ResultChan() {
+		rc, ok := event.Object.(*kapi.ReplicationController)
+		if !ok || (event.Type != watch.Added && event.Type != watch.Modified) {
+			continue
+		}
+		if rc.Spec.Replicas == 0 || rc.Spec.Template == nil {
+			continue
+		}
+		w.warmPodSpec(ctx, &rc.Spec.Template.Spec)
+	}
+	return nil
+}
+
+// watchDeployments covers upstream extensions/v1beta1 deployments, whose replica sets the
+// replication controller watch does not see.
+func (w *rolloutWarmer) watchDeployments(ctx context.Context, kClient kclient.Interface) error {
+	deployments, err := kClient.Extensions().Deployments(kapi.NamespaceAll).List(kapi.ListOptions{})
+	if err != nil {
+		return err
+	}
+	watcher, err := kClient.Extensions().Deployments(kapi.NamespaceAll).Watch(kapi.ListOptions{ResourceVersion: deployments.ResourceVersion})
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+	for event := range watcher.ResultChan() {
+		deployment, ok := event.Object.(*extensions.Deployment)
+		if !ok || (event.Type != watch.Added && event.Type != watch.Modified) {
+			continue
+		}
+		if deployment.Spec.Replicas == 0 {
+			continue
+		}
+		w.warmPodSpec(ctx, &deployment.Spec.Template.Spec)
+	}
+	return nil
+}
+
+func (w *rolloutWarmer) warmPodSpec(ctx context.Context, spec *kapi.PodSpec) {
+	for i := range spec.Containers {
+		w.warmImage(ctx, spec.Containers[i].Image)
+	}
+}
+
+// warmImage primes the layer location and metadata caches for an image hosted by this registry.
+func (w *rolloutWarmer) warmImage(ctx context.Context, image string) {
+	ref, err := imageapi.ParseDockerImageReference(image)
+	if err != nil {
+		return
+	}
+	// only images this registry serves benefit from warm caches here
+	if ref.Registry != os.Getenv(DockerRegistryURLEnvVar) || len(ref.Namespace) == 0 || len(ref.Name) == 0 {
+		return
+	}
+
+	key := ref.Namespace + "/" + ref.Name
+	now := time.Now()
+	w.mu.Lock()
+	if last, ok := w.warmed[key]; ok && now.Sub(last) < rolloutWarmupTTL {
+		w.mu.Unlock()
+		return
+	}
+	w.warmed[key] = now
+	w.mu.Unlock()
+
+	stream, err := w.osClient.ImageStreams(ref.Namespace).Get(ref.Name)
+	if err != nil {
+		context.GetLogger(ctx).Errorf("rollout warmup: cannot get image stream %s: %v", key, err)
+		return
+	}
+	if metadataCache != nil {
+		metadataCache.putImageStream(stream)
+	}
+	warmed := warmStreamLayers(ctx, w.osClient.Images(), stream)
+	rolloutWarmupMetrics.Add("streams", 1)
+	context.GetLogger(ctx).Infof("rollout warmup: primed %s (%d layer locations)", key, warmed)
+}
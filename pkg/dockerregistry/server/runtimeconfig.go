@@ -0,0 +1,131 @@
+package server
+
+import (
+	"os"
+	"time"
+
+	"github.com/docker/distribution/context"
+)
+
+// RuntimeConfigMapEnvVar names a ConfigMap ("namespace/name") the registry watches for runtime
+// settings. Keys are the same environment variable names the registry reads at startup; safe
+// settings are hot-reloaded when the ConfigMap changes, so operators can adjust them cluster-wide
+// without re-rolling the registry deployment. Keys that are only read at startup are reported and
+// take effect on the next restart.
+const RuntimeConfigMapEnvVar = "REGISTRY_RUNTIME_CONFIGMAP"
+
+// runtimeConfigPollInterval is how often the ConfigMap is re-checked for changes.
+const runtimeConfigPollInterval = 30 * time.Second
+
+// runtimeSetting groups the environment variables one subsystem reads with the function that
+// re-reads them. Settings listed here are safe to change on a running registry.
+type runtimeSetting struct {
+	names  []string
+	reload func()
+}
+
+var runtimeSettings = []runtimeSetting{
+	{names: []string{ChaosErrorRateEnvVar, ChaosLatencyEnvVar}, reload: func() { chaosConfig = readChaosConfig() }},
+	{names: []string{ClientCompatEnvVar}, reload: func() { compatRules = readCompatRules() }},
+	{names: []string{StripLibraryPrefixEnvVar, DefaultNamespaceEnvVar}, reload: func() { repositoryNameNormalizer = readRepositoryNormalizer() }},
+	{names: []string{SLOTargetEnvVar, SLOPullLatencyEnvVar, SLOPushLatencyEnvVar}, reload: func() { sloRecorder = newSLORecorder() }},
+	{names: []string{AuthTimeoutEnvVar, ManifestPutTimeoutEnvVar, BlobUploadTimeoutEnvVar, PullthroughTimeoutEnvVar}, reload: func() { operationTimeouts = readOperationTimeouts() }},
+	{names: []string{PriorityRulesEnvVar, PriorityMaxInflightEnvVar}, reload: func() {
+		priorityRules = readPriorityRules()
+		priorityMaxInflight = readPriorityMaxInflight()
+	}},
+	{names: []string{AsyncMappingEnvVar}, reload: func() { asyncMappingEnabled = readAsyncMappingEnabled() }},
+	{names: []string{SigstoreFallbackURLEnvVar}, reload: func() { sigstoreFallbackURL = readSigstoreFallbackURL() }},
+	{names: []string{UploadBandwidthLimitEnvVar}, reload: func() { uploadBandwidthDefault = readUploadBandwidthDefault() }},
+	{names: []string{SecondaryBlobRootEnvVar}, reload: func() { secondaryBlobRoot = readSecondaryBlobRoot() }},
+	{names: []string{ClientCertNamespacesEnvVar}, reload: func() { clientCertNamespaces = readClientCertNamespaces() }},
+	{names: []string{PolicyWebhookURLEnvVar, PolicyWebhookFailClosedEnvVar}, reload: func() {
+		policyWebhookURL = readPolicyWebhookURL()
+		policyWebhookFailClosed = os.Getenv(PolicyWebhookFailClosedEnvVar) == "true"
+	}},
+	// the purge age is re-read on every maintenance run; setting the variable is enough
+	{names: []string{UploadPurgeAgeEnvVar}, reload: func() {}},
+	// conformance mode is checked on every request; setting the variable is enough
+	{names: []string{OCIConformanceEnvVar}, reload: func() {}},
+	// coalescing is checked on every commit; setting the variable is enough
+	{names: []string{UploadCoalesceEnvVar}, reload: func() {}},
+	// chunk hints are computed on every upload request; setting the variables is enough
+	{names: []string{ChunkMinLengthEnvVar, ChunkMaxLengthEnvVar}, reload: func() {}},
+	// the access review cache reads its bounds on every lookup; setting the variables is enough
+	{names: []string{SARCacheTTLEnvVar, SARCacheSizeEnvVar}, reload: func() {}},
+	// anonymous pull is checked on every unauthenticated request; setting the variable is enough
+	{names: []string{AnonymousPullEnvVar}, reload: func() {}},
+}
+
+// RunRuntimeConfigWatcher polls the configured ConfigMap and applies changed settings. It
+// returns immediately; it is a no-op unless RuntimeConfigMapEnvVar is set.
+func RunRuntimeConfigWatcher(ctx context.Context, registryClient *RegistryClient) {
+	value := os.Getenv(RuntimeConfigMapEnvVar)
+	if len(value) == 0 {
+		return
+	}
+	namespace, name, err := getNamespaceName(value)
+	if err != nil {
+		context.GetLogger(ctx).Fatalf("invalid %s=%q: expected namespace/name", RuntimeConfigMapEnvVar, value)
+	}
+	_, kClient, err := registryClient.Clients()
+	if err != nil {
+		context.GetLogger(ctx).Fatalf("error getting clients for the runtime config watcher: %v", err)
+	}
+
+	context.GetLogger(ctx).Infof("watching configmap %s/%s for runtime settings", namespace, name)
+	go func() {
+		lastVersion := ""
+		for {
+			configMap, err := kClient.ConfigMaps(namespace).Get(name)
+			switch {
+			case err != nil:
+				context.GetLogger(ctx).Errorf("error reading runtime configmap %s/%s: %v", namespace, name, err)
+			case configMap.ResourceVersion != lastVersion:
+				applyRuntimeConfig(ctx, configMap.Data)
+				lastVersion = configMap.ResourceVersion
+			}
+			time.Sleep(runtimeConfigPollInterval)
+		}
+	}()
+}
+
+// applyRuntimeConfig sets the given variables and reloads the subsystems whose values changed.
+func applyRuntimeConfig(ctx context.Context, data map[string]string) {
+	changed := map[string]bool{}
+	for name, value := range data {
+		if os.Getenv(name) == value {
+			continue
+		}
+		if !isRuntimeSetting(name) {
+			context.GetLogger(ctx).Warnf("runtime config key %s only takes effect on restart", name)
+			os.Setenv(name, value)
+			continue
+		}
+		context.GetLogger(ctx).Infof("runtime config: %s=%s", name, value)
+		os.Setenv(name, value)
+		changed[name] = true
+	}
+	if len(changed) == 0 {
+		return
+	}
+	for _, setting := range runtimeSettings {
+		for _, name := range setting.names {
+			if changed[name] {
+				setting.reload()
+				break
+			}
+		}
+	}
+}
+
+func isRuntimeSetting(name string) bool {
+	for _, setting := range runtimeSettings {
+		for _, candidate := range setting.names {
+			if candidate == name {
+				return true
+			}
+		}
+	}
+	return false
+}
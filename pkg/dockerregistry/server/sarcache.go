@@ -0,0 +1,109 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"expvar"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Every layer of a push runs the same access review against the master API, so a twenty-layer
+// push costs twenty identical round-trips. These variables enable a short-lived cache of
+// access review results so repeated requests from the same client are answered locally. Only
+// positive results are cached: a granted permission lingers for at most the TTL after it is
+// revoked, while a denial is always re-checked so newly granted access works immediately.
+const (
+	// SARCacheTTLEnvVar is how long a positive access review result may be reused, as a Go
+	// duration. Empty or "0" (the default) disables the cache.
+	SARCacheTTLEnvVar = "REGISTRY_SAR_CACHE_TTL"
+	// SARCacheSizeEnvVar caps the entries kept. The default is 4096.
+	SARCacheSizeEnvVar = "REGISTRY_SAR_CACHE_SIZE"
+)
+
+const defaultSARCacheSize = 4096
+
+// sarCacheMetrics counts cache hits, misses and evictions for the access review cache.
+var sarCacheMetrics = expvar.NewMap("registry.sarCache")
+
+var sarCache = &sarResultCache{entries: map[string]time.Time{}}
+
+// sarResultCache remembers which (token, namespace, name, verb) tuples passed an access
+// review and when. Tokens are stored hashed.
+type sarResultCache struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+func sarCacheTTL() time.Duration {
+	return timeoutFromEnv(SARCacheTTLEnvVar, 0)
+}
+
+func sarCacheSize() int {
+	value := os.Getenv(SARCacheSizeEnvVar)
+	if len(value) == 0 {
+		return defaultSARCacheSize
+	}
+	size, err := strconv.Atoi(value)
+	if err != nil || size < 1 {
+		log.Errorf("ignoring invalid %s=%q", SARCacheSizeEnvVar, value)
+		return defaultSARCacheSize
+	}
+	return size
+}
+
+func sarCacheKey(token, namespace, name, verb string) string {
+	sum := sha256.Sum256([]byte(token + "\x00" + namespace + "\x00" + name + "\x00" + verb))
+	return hex.EncodeToString(sum[:])
+}
+
+// remember records a passed access review.
+func (c *sarResultCache) remember(token, namespace, name, verb string) {
+	ttl := sarCacheTTL()
+	if ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	limit := sarCacheSize()
+	if len(c.entries) >= limit {
+		now := time.Now()
+		for key, expires := range c.entries {
+			if expires.Before(now) {
+				delete(c.entries, key)
+			}
+		}
+	}
+	// still full of live entries: drop one arbitrarily rather than grow without bound
+	for key := range c.entries {
+		if len(c.entries) < limit {
+			break
+		}
+		delete(c.entries, key)
+		sarCacheMetrics.Add("evictions", 1)
+	}
+	c.entries[sarCacheKey(token, namespace, name, verb)] = time.Now().Add(ttl)
+}
+
+// allowed is true when the tuple passed an access review within the TTL.
+func (c *sarResultCache) allowed(token, namespace, name, verb string) bool {
+	if sarCacheTTL() <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires, ok := c.entries[sarCacheKey(token, namespace, name, verb)]
+	if ok && time.Now().Before(expires) {
+		sarCacheMetrics.Add("hits", 1)
+		return true
+	}
+	if ok {
+		delete(c.entries, sarCacheKey(token, namespace, name, verb))
+	}
+	sarCacheMetrics.Add("misses", 1)
+	return false
+}
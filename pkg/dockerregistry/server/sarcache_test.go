@@ -0,0 +1,52 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSARCacheDisabledByDefault(t *testing.T) {
+	cache := &sarResultCache{entries: map[string]time.Time{}}
+	cache.remember("token", "ns", "name", "get")
+	if cache.allowed("token", "ns", "name", "get") {
+		t.Error("cache should be inert without a TTL configured")
+	}
+	if len(cache.entries) != 0 {
+		t.Errorf("cache stored %d entries while disabled", len(cache.entries))
+	}
+}
+
+func TestSARCacheRemembersPerTuple(t *testing.T) {
+	os.Setenv(SARCacheTTLEnvVar, "1m")
+	defer os.Unsetenv(SARCacheTTLEnvVar)
+
+	cache := &sarResultCache{entries: map[string]time.Time{}}
+	cache.remember("token", "ns", "name", "get")
+
+	if !cache.allowed("token", "ns", "name", "get") {
+		t.Error("expected a hit for the remembered tuple")
+	}
+	if cache.allowed("token", "ns", "name", "update") {
+		t.Error("a different verb must not hit")
+	}
+	if cache.allowed("other", "ns", "name", "get") {
+		t.Error("a different token must not hit")
+	}
+}
+
+func TestSARCacheHonorsSizeLimit(t *testing.T) {
+	os.Setenv(SARCacheTTLEnvVar, "1m")
+	os.Setenv(SARCacheSizeEnvVar, "4")
+	defer os.Unsetenv(SARCacheTTLEnvVar)
+	defer os.Unsetenv(SARCacheSizeEnvVar)
+
+	cache := &sarResultCache{entries: map[string]time.Time{}}
+	for i := 0; i < 10; i++ {
+		cache.remember(fmt.Sprintf("token%d", i), "ns", "name", "get")
+	}
+	if len(cache.entries) > 4 {
+		t.Errorf("cache grew to %d entries, limit is 4", len(cache.entries))
+	}
+}
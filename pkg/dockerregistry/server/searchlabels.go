@@ -0,0 +1,164 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/distribution/registry/handlers"
+	gorillahandlers "github.com/gorilla/handlers"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// labelSearchMatch is one image matching the requested selectors, with every repository and
+// tag in the namespace that currently references it.
+type labelSearchMatch struct {
+	Digest       string            `json:"digest"`
+	Labels       map[string]string `json:"labels"`
+	Repositories []string          `json:"repositories"`
+	Tags         []string          `json:"tags,omitempty"`
+}
+
+// LabelSearchDispatcher builds the handler answering "which images in this namespace carry
+// these config labels?" - the query ad hoc scripts otherwise answer by pulling every manifest.
+// Only image metadata the master already holds is read; no manifest is downloaded.
+func LabelSearchDispatcher(ctx *handlers.Context, r *http.Request) http.Handler {
+	labelSearchHandler := &labelSearchHandler{
+		Context: ctx,
+	}
+
+	return gorillahandlers.MethodHandler{
+		"GET": http.HandlerFunc(labelSearchHandler.Search),
+	}
+}
+
+type labelSearchHandler struct {
+	*handlers.Context
+}
+
+// Search evaluates ?namespace=<ns>&label=<key>=<value>... - every selector must match. Images
+// are read through the caller's own client as image stream images, so their access to the
+// namespace applies unchanged.
+func (h *labelSearchHandler) Search(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	namespace := query.Get("namespace")
+	if len(namespace) == 0 {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail("the namespace parameter is required"))
+		return
+	}
+	selectors := map[string]string{}
+	for _, label := range query["label"] {
+		parts := strings.SplitN(label, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 {
+			h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail(fmt.Sprintf("invalid label selector %q, expected key=value", label)))
+			return
+		}
+		selectors[parts[0]] = parts[1]
+	}
+	if len(selectors) == 0 {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail("at least one label parameter is required"))
+		return
+	}
+
+	uclient, ok := UserClientFrom(h.Context)
+	if !ok {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail("user client unavailable"))
+		return
+	}
+	streams, err := uclient.ImageStreams(namespace).List(kapi.ListOptions{})
+	if err != nil {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail(fmt.Sprintf("error listing image streams in %s: %v", namespace, err)))
+		return
+	}
+
+	// index which streams and tags currently reference each digest, so every image is
+	// inspected once no matter how often it is tagged
+	type reference struct {
+		streams map[string]bool
+		tags    map[string]bool
+	}
+	references := map[string]*reference{}
+	for i := range streams.Items {
+		stream := &streams.Items[i]
+		for tag, history := range stream.Status.Tags {
+			if len(history.Items) == 0 || len(history.Items[0].Image) == 0 {
+				continue
+			}
+			dgst := history.Items[0].Image
+			ref, ok := references[dgst]
+			if !ok {
+				ref = &reference{streams: map[string]bool{}, tags: map[string]bool{}}
+				references[dgst] = ref
+			}
+			ref.streams[stream.Name] = true
+			ref.tags[tag] = true
+		}
+	}
+
+	matches := []labelSearchMatch{}
+	for dgst, ref := range references {
+		var labels map[string]string
+		for streamName := range ref.streams {
+			isi, err := uclient.ImageStreamImages(namespace).Get(streamName, dgst)
+			if err != nil {
+				continue
+			}
+			labels = imageLabels(&isi.Image)
+			break
+		}
+		if !labelsMatch(labels, selectors) {
+			continue
+		}
+		matches = append(matches, labelSearchMatch{
+			Digest:       dgst,
+			Labels:       labels,
+			Repositories: sortedKeys(ref.streams),
+			Tags:         sortedKeys(ref.tags),
+		})
+	}
+	sort.Sort(byMatchDigest(matches))
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(matches); err != nil {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail(err))
+	}
+}
+
+// imageLabels returns the config labels of the image, falling back to the container config for
+// images whose metadata predates the config block.
+func imageLabels(image *imageapi.Image) map[string]string {
+	if config := image.DockerImageMetadata.Config; config != nil && len(config.Labels) > 0 {
+		return config.Labels
+	}
+	return image.DockerImageMetadata.ContainerConfig.Labels
+}
+
+func labelsMatch(labels, selectors map[string]string) bool {
+	for key, value := range selectors {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type byMatchDigest []labelSearchMatch
+
+func (m byMatchDigest) Len() int           { return len(m) }
+func (m byMatchDigest) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+func (m byMatchDigest) Less(i, j int) bool { return m[i].Digest < m[j].Digest }
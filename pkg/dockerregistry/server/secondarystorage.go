@@ -0,0 +1,115 @@
+package server
+
+import (
+	"expvar"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/digest"
+)
+
+// SecondaryBlobRootEnvVar points at the storage root of a retired registry volume, mounted
+// read-only. Blobs missing from the primary backend are served from it, so a migration or a
+// partial restore does not surface 404s while content is still being copied over. Blobs are
+// never written there.
+const SecondaryBlobRootEnvVar = "REGISTRY_SECONDARY_BLOB_ROOT"
+
+var secondaryBlobRoot = readSecondaryBlobRoot()
+
+// secondaryStorageMetrics counts lookups answered by the secondary backend.
+var secondaryStorageMetrics = expvar.NewMap("registry.secondaryStorage")
+
+func readSecondaryBlobRoot() string {
+	return strings.TrimSuffix(os.Getenv(SecondaryBlobRootEnvVar), "/")
+}
+
+// secondaryBlobPath returns where the upstream storage layout keeps the blob under the
+// secondary root.
+func secondaryBlobPath(dgst digest.Digest) string {
+	hex := dgst.Hex()
+	return filepath.Join(secondaryBlobRoot, "docker", "registry", "v2", "blobs", string(dgst.Algorithm()), hex[:2], hex, "data")
+}
+
+// secondaryBlobStore wraps a distribution.BlobStore and answers reads the primary backend
+// misses from the secondary storage root.
+type secondaryBlobStore struct {
+	distribution.BlobStore
+
+	repo *repository
+}
+
+var _ distribution.BlobStore = &secondaryBlobStore{}
+
+// Stat checks the primary store for the blob and falls back to the secondary root when the
+// blob is unknown there.
+func (bs *secondaryBlobStore) Stat(ctx context.Context, dgst digest.Digest) (distribution.Descriptor, error) {
+	desc, err := bs.BlobStore.Stat(ctx, dgst)
+	if err != distribution.ErrBlobUnknown {
+		return desc, err
+	}
+	fi, statErr := os.Stat(secondaryBlobPath(dgst))
+	if statErr != nil {
+		secondaryStorageMetrics.Add("misses", 1)
+		return distribution.Descriptor{}, distribution.ErrBlobUnknown
+	}
+	secondaryStorageMetrics.Add("hits", 1)
+	return distribution.Descriptor{Digest: dgst, Size: fi.Size()}, nil
+}
+
+func (bs *secondaryBlobStore) Get(ctx context.Context, dgst digest.Digest) ([]byte, error) {
+	p, err := bs.BlobStore.Get(ctx, dgst)
+	if err != distribution.ErrBlobUnknown {
+		return p, err
+	}
+	p, readErr := ioutil.ReadFile(secondaryBlobPath(dgst))
+	if readErr != nil {
+		secondaryStorageMetrics.Add("misses", 1)
+		return nil, distribution.ErrBlobUnknown
+	}
+	secondaryStorageMetrics.Add("hits", 1)
+	return p, nil
+}
+
+func (bs *secondaryBlobStore) Open(ctx context.Context, dgst digest.Digest) (distribution.ReadSeekCloser, error) {
+	rsc, err := bs.BlobStore.Open(ctx, dgst)
+	if err != distribution.ErrBlobUnknown {
+		return rsc, err
+	}
+	f, openErr := os.Open(secondaryBlobPath(dgst))
+	if openErr != nil {
+		secondaryStorageMetrics.Add("misses", 1)
+		return nil, distribution.ErrBlobUnknown
+	}
+	secondaryStorageMetrics.Add("hits", 1)
+	return f, nil
+}
+
+// ServeBlob serves the blob from the primary store, or from the secondary root when the
+// primary does not know it.
+func (bs *secondaryBlobStore) ServeBlob(ctx context.Context, w http.ResponseWriter, req *http.Request, dgst digest.Digest) error {
+	err := bs.BlobStore.ServeBlob(ctx, w, req, dgst)
+	if err != distribution.ErrBlobUnknown {
+		return err
+	}
+	f, openErr := os.Open(secondaryBlobPath(dgst))
+	if openErr != nil {
+		secondaryStorageMetrics.Add("misses", 1)
+		return distribution.ErrBlobUnknown
+	}
+	defer f.Close()
+	fi, statErr := f.Stat()
+	if statErr != nil {
+		return statErr
+	}
+	secondaryStorageMetrics.Add("hits", 1)
+	context.GetLogger(bs.repo.ctx).Infof("Serving %q from secondary storage", dgst.String())
+	setResponseHeaders(w, fi.Size(), "application/octet-stream", dgst)
+	http.ServeContent(w, req, dgst.String(), time.Time{}, f)
+	return nil
+}
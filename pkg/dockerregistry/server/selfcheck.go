@@ -0,0 +1,49 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/docker/distribution/configuration"
+
+	kutilerrors "k8s.io/kubernetes/pkg/util/errors"
+)
+
+// ValidateConfiguration verifies that the OpenShift middlewares are wired consistently in the
+// registry configuration. The middlewares only work as a set: the repository middleware stores
+// manifests through the OpenShift API and needs the registry and storage middlewares to reach the
+// blob store, and without the auth controller every request to it fails. A partial configuration
+// used to pass startup and break silently at request time, most visibly as broken pullthrough.
+// All problems found are reported at once so a misconfiguration can be fixed in one go.
+func ValidateConfiguration(config *configuration.Configuration) error {
+	_, hasAuth := config.Auth[OpenShiftAuth]
+	hasRegistry := hasOpenShiftMiddleware(config.Middleware["registry"])
+	hasRepository := hasOpenShiftMiddleware(config.Middleware["repository"])
+	hasStorage := hasOpenShiftMiddleware(config.Middleware["storage"])
+
+	errs := []error{}
+	if hasAuth && !hasRepository {
+		errs = append(errs, fmt.Errorf("openshift auth is active but the openshift repository middleware is not configured: manifests would not be stored in the OpenShift API and pullthrough would not work"))
+	}
+	if hasRepository && !hasAuth {
+		errs = append(errs, fmt.Errorf("the openshift repository middleware is configured but openshift auth is not: every repository request would be rejected"))
+	}
+	if hasRepository && !hasRegistry {
+		errs = append(errs, fmt.Errorf("the openshift repository middleware is configured but the openshift registry middleware is not: add \"openshift\" to middleware.registry"))
+	}
+	if hasRepository && !hasStorage {
+		errs = append(errs, fmt.Errorf("the openshift repository middleware is configured but the openshift storage middleware is not: add \"openshift\" to middleware.storage"))
+	}
+	if (hasRegistry || hasStorage) && !hasRepository {
+		errs = append(errs, fmt.Errorf("the openshift registry or storage middleware is configured but the openshift repository middleware is not: add \"openshift\" to middleware.repository"))
+	}
+	return kutilerrors.NewAggregate(errs)
+}
+
+func hasOpenShiftMiddleware(middlewares []configuration.Middleware) bool {
+	for _, m := range middlewares {
+		if m.Name == "openshift" {
+			return true
+		}
+	}
+	return false
+}
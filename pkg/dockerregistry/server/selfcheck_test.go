@@ -0,0 +1,63 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/docker/distribution/configuration"
+)
+
+func TestValidateConfiguration(t *testing.T) {
+	build := func(auth bool, middlewares ...string) *configuration.Configuration {
+		config := &configuration.Configuration{
+			Auth:       configuration.Auth{},
+			Middleware: map[string][]configuration.Middleware{},
+		}
+		if auth {
+			config.Auth[OpenShiftAuth] = configuration.Parameters{}
+		}
+		for _, name := range middlewares {
+			config.Middleware[name] = []configuration.Middleware{{Name: "openshift"}}
+		}
+		return config
+	}
+
+	testCases := map[string]struct {
+		config      *configuration.Configuration
+		expectError bool
+	}{
+		"complete": {
+			config:      build(true, "registry", "repository", "storage"),
+			expectError: false,
+		},
+		"no openshift middlewares": {
+			config:      build(false),
+			expectError: false,
+		},
+		"auth without repository middleware": {
+			config:      build(true),
+			expectError: true,
+		},
+		"repository middleware without auth": {
+			config:      build(false, "registry", "repository", "storage"),
+			expectError: true,
+		},
+		"repository middleware without storage middleware": {
+			config:      build(true, "registry", "repository"),
+			expectError: true,
+		},
+		"registry middleware alone": {
+			config:      build(false, "registry"),
+			expectError: true,
+		},
+	}
+
+	for name, test := range testCases {
+		err := ValidateConfiguration(test.config)
+		if test.expectError && err == nil {
+			t.Errorf("%s: expected an error, got none", name)
+		}
+		if !test.expectError && err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package server
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/docker/distribution/context"
+	storagedriver "github.com/docker/distribution/registry/storage/driver"
+	"github.com/docker/distribution/registry/storage/driver/factory"
+	registrystorage "github.com/docker/distribution/registry/storage/driver/middleware"
+)
+
+// shardVirtualNodes is the number of points each backend occupies on the hash ring. More points
+// spread blobs more evenly when backends are added or removed.
+const shardVirtualNodes = 64
+
+func init() {
+	// The "shard" storage middleware spreads blob data over several storage backends by
+	// consistent hashing of the blob path, allowing the registry to scale beyond a single
+	// bucket's throughput limits. The wrapped driver from the configuration file is the primary
+	// backend; additional ones are listed in the middleware options:
+	//
+	//   middleware:
+	//     storage:
+	//       - name: shard
+	//         options:
+	//           backends:
+	//             - driver: s3-aws
+	//               parameters: {...}
+	//
+	// Reads fan out to every backend when the placed backend misses, so rebalancing after
+	// configuration changes happens lazily without moving data up front.
+	registrystorage.Register("shard", func(driver storagedriver.StorageDriver, options map[string]interface{}) (storagedriver.StorageDriver, error) {
+		log.Info("Shard middleware for storage driver initializing")
+
+		backends := []storagedriver.StorageDriver{driver}
+		configured, _ := options["backends"].([]interface{})
+		for i, entry := range configured {
+			config, ok := entry.(map[interface{}]interface{})
+			if !ok {
+				return nil, fmt.Errorf("shard middleware: backend %d is not a map", i)
+			}
+			name, _ := config["driver"].(string)
+			if len(name) == 0 {
+				return nil, fmt.Errorf("shard middleware: backend %d has no driver", i)
+			}
+			parameters := map[string]interface{}{}
+			if raw, ok := config["parameters"].(map[interface{}]interface{}); ok {
+				for key, value := range raw {
+					if s, ok := key.(string); ok {
+						parameters[s] = value
+					}
+				}
+			}
+			backend, err := factory.Create(name, parameters)
+			if err != nil {
+				return nil, fmt.Errorf("shard middleware: cannot create backend %d (%s): %v", i, name, err)
+			}
+			backends = append(backends, backend)
+		}
+
+		if len(backends) == 1 {
+			log.Warn("shard middleware configured without additional backends")
+			return driver, nil
+		}
+		return newShardedDriver(backends), nil
+	})
+}
+
+// shardedDriver places blob data on one of several backends by consistent hashing of the path
+// and fans reads out to the others on a miss. Non-blob paths (repository metadata, uploads) stay
+// on the primary backend so registry bookkeeping remains in one place.
+type shardedDriver struct {
+	backends []storagedriver.StorageDriver
+	// ring maps hash points to backend indices.
+	ring ringPoints
+}
+
+var _ storagedriver.StorageDriver = &shardedDriver{}
+
+type ringPoint struct {
+	hash    uint64
+	backend int
+}
+
+type ringPoints []ringPoint
+
+func (r ringPoints) Len() int           { return len(r) }
+func (r ringPoints) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r ringPoints) Less(i, j int) bool { return r[i].hash < r[j].hash }
+
+func newShardedDriver(backends []storagedriver.StorageDriver) *shardedDriver {
+	d := &shardedDriver{backends: backends}
+	for i := range backends {
+		for v := 0; v < shardVirtualNodes; v++ {
+			d.ring = append(d.ring, ringPoint{
+				hash:    hashString(fmt.Sprintf("backend-%d-%d", i, v)),
+				backend: i,
+			})
+		}
+	}
+	sort.Sort(d.ring)
+	return d
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// place returns the backend the path belongs to. Only blob data is sharded.
+func (d *shardedDriver) place(path string) storagedriver.StorageDriver {
+	if !strings.Contains(path, "/blobs/") {
+		return d.backends[0]
+	}
+	hash := hashString(path)
+	i := sort.Search(len(d.ring), func(i int) bool { return d.ring[i].hash >= hash })
+	if i == len(d.ring) {
+		i = 0
+	}
+	return d.backends[d.ring[i].backend]
+}
+
+// others returns every backend except the given one, for read fan-out.
+func (d *shardedDriver) others(backend storagedriver.StorageDriver) []storagedriver.StorageDriver {
+	others := make([]storagedriver.StorageDriver, 0, len(d.backends)-1)
+	for _, b := range d.backends {
+		if b != backend {
+			others = append(others, b)
+		}
+	}
+	return others
+}
+
+func isNotFound(err error) bool {
+	_, ok := err.(storagedriver.PathNotFoundError)
+	return ok
+}
+
+func (d *shardedDriver) Name() string {
+	return "sharded(" + d.backends[0].Name() + ")"
+}
+
+func (d *shardedDriver) GetContent(ctx context.Context, path string) ([]byte, error) {
+	placed := d.place(path)
+	content, err := placed.GetContent(ctx, path)
+	if err == nil || !isNotFound(err) {
+		return content, err
+	}
+	for _, backend := range d.others(placed) {
+		if content, berr := backend.GetContent(ctx, path); berr == nil {
+			return content, nil
+		}
+	}
+	return nil, err
+}
+
+func (d *shardedDriver) PutContent(ctx context.Context, path string, content []byte) error {
+	return d.place(path).PutContent(ctx, path, content)
+}
+
+func (d *shardedDriver) Reader(ctx context.Context, path string, offset int64) (io.ReadCloser, error) {
+	placed := d.place(path)
+	reader, err := placed.Reader(ctx, path, offset)
+	if err == nil || !isNotFound(err) {
+		return reader, err
+	}
+	for _, backend := range d.others(placed) {
+		if reader, berr := backend.Reader(ctx, path, offset); berr == nil {
+			return reader, nil
+		}
+	}
+	return nil, err
+}
+
+func (d *shardedDriver) Writer(ctx context.Context, path string, append bool) (storagedriver.FileWriter, error) {
+	return d.place(path).Writer(ctx, path, append)
+}
+
+func (d *shardedDriver) Stat(ctx context.Context, path string) (storagedriver.FileInfo, error) {
+	placed := d.place(path)
+	fi, err := placed.Stat(ctx, path)
+	if err == nil || !isNotFound(err) {
+		return fi, err
+	}
+	for _, backend := range d.others(placed) {
+		if fi, berr := backend.Stat(ctx, path); berr == nil {
+			return fi, nil
+		}
+	}
+	return nil, err
+}
+
+// List merges the listings of all backends since children of a directory may be spread over
+// several of them.
+func (d *shardedDriver) List(ctx context.Context, path string) ([]string, error) {
+	seen := map[string]struct{}{}
+	merged := []string{}
+	var firstErr error
+	found := false
+	for _, backend := range d.backends {
+		entries, err := backend.List(ctx, path)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		found = true
+		for _, entry := range entries {
+			if _, ok := seen[entry]; !ok {
+				seen[entry] = struct{}{}
+				merged = append(merged, entry)
+			}
+		}
+	}
+	if !found {
+		return nil, firstErr
+	}
+	sort.Strings(merged)
+	return merged, nil
+}
+
+// Move moves within the backend holding the source, copying across backends when the destination
+// places elsewhere. Uploads commit through here, so this is where a finished blob lands on its
+// placed backend.
+func (d *shardedDriver) Move(ctx context.Context, sourcePath string, destPath string) error {
+	dest := d.place(destPath)
+	source := dest
+	if _, err := source.Stat(ctx, sourcePath); err != nil {
+		found := false
+		for _, backend := range d.others(dest) {
+			if _, berr := backend.Stat(ctx, sourcePath); berr == nil {
+				source = backend
+				found = true
+				break
+			}
+		}
+		if !found {
+			return err
+		}
+	}
+	if source == dest {
+		return source.Move(ctx, sourcePath, destPath)
+	}
+
+	if err := copyBetweenDrivers(ctx, source, dest, sourcePath, destPath); err != nil {
+		return err
+	}
+	return source.Delete(ctx, sourcePath)
+}
+
+// Delete removes the path from every backend holding it.
+func (d *shardedDriver) Delete(ctx context.Context, path string) error {
+	deleted := false
+	var firstErr error
+	for _, backend := range d.backends {
+		err := backend.Delete(ctx, path)
+		if err == nil {
+			deleted = true
+			continue
+		}
+		if !isNotFound(err) && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+	if !deleted {
+		return storagedriver.PathNotFoundError{Path: path}
+	}
+	return nil
+}
+
+func (d *shardedDriver) URLFor(ctx context.Context, path string, options map[string]interface{}) (string, error) {
+	placed := d.place(path)
+	if _, err := placed.Stat(ctx, path); err == nil {
+		return placed.URLFor(ctx, path, options)
+	}
+	for _, backend := range d.others(placed) {
+		if _, err := backend.Stat(ctx, path); err == nil {
+			return backend.URLFor(ctx, path, options)
+		}
+	}
+	return placed.URLFor(ctx, path, options)
+}
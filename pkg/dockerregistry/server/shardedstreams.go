@@ -0,0 +1,171 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/docker/distribution/context"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+
+	"github.com/openshift/origin/pkg/client"
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// Very active image streams accumulate enough tag event history to approach etcd object size
+// limits. The sharding maintenance task moves older events into linked overflow streams, and the
+// registry merges them back transparently when it reads the stream.
+const (
+	// StreamShardThresholdEnvVar is the number of tag events a stream may hold before older
+	// events are moved to an overflow stream. Zero (the default) disables sharding.
+	StreamShardThresholdEnvVar = "REGISTRY_STREAM_SHARD_THRESHOLD"
+)
+
+// streamShardInterval is how often oversized streams are looked for.
+const streamShardInterval = time.Hour
+
+func init() {
+	RegisterMaintenanceTask(MaintenanceTask{
+		Name:     "shard-streams",
+		Interval: streamShardInterval,
+		Run:      shardOversizedStreams,
+	})
+}
+
+func streamShardThreshold() int {
+	value := os.Getenv(StreamShardThresholdEnvVar)
+	if len(value) == 0 {
+		return 0
+	}
+	threshold, err := strconv.Atoi(value)
+	if err != nil || threshold < 0 {
+		log.Errorf("ignoring invalid %s=%q", StreamShardThresholdEnvVar, value)
+		return 0
+	}
+	return threshold
+}
+
+// mergeOverflowShards returns the stream with the history of its overflow shards merged back
+// in, so callers see the complete tag event history regardless of sharding.
+func (r *repository) mergeOverflowShards(stream *imageapi.ImageStream) *imageapi.ImageStream {
+	names := stream.Annotations[imageapi.ShardOverflowAnnotation]
+	if len(names) == 0 {
+		return stream
+	}
+	shards := []*imageapi.ImageStream{}
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); len(name) == 0 {
+			continue
+		}
+		shard, err := r.backend.GetImageStream(r.namespace, name)
+		if err != nil {
+			context.GetLogger(r.ctx).Errorf("error reading overflow shard %s/%s: %v", r.namespace, name, err)
+			continue
+		}
+		shards = append(shards, shard)
+	}
+	imageapi.MergeStreamShards(stream, shards...)
+	return stream
+}
+
+// shardOversizedStreams moves older tag events of oversized streams into overflow streams. It
+// runs leader elected, so only one registry replica reshapes streams at a time.
+func shardOversizedStreams(ctx context.Context) error {
+	threshold := streamShardThreshold()
+	if threshold <= 0 {
+		return nil
+	}
+	osClient, _, err := DefaultRegistryClient.Clients()
+	if err != nil {
+		return err
+	}
+	streams, err := osClient.ImageStreams(kapi.NamespaceAll).List(kapi.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range streams.Items {
+		stream := &streams.Items[i]
+		if len(stream.Annotations[imageapi.ShardOfAnnotation]) > 0 {
+			continue
+		}
+		if countTagEvents(stream) <= threshold {
+			continue
+		}
+		if err := shardStream(ctx, osClient, stream); err != nil {
+			context.GetLogger(ctx).Errorf("error sharding image stream %s/%s: %v", stream.Namespace, stream.Name, err)
+		}
+	}
+	return nil
+}
+
+func countTagEvents(stream *imageapi.ImageStream) int {
+	count := 0
+	for _, history := range stream.Status.Tags {
+		count += len(history.Items)
+	}
+	return count
+}
+
+// shardStream moves all but the most recent event of every tag into a new overflow stream. The
+// overflow object is fully written before the main stream is trimmed, so a failure part way
+// through never loses history.
+func shardStream(ctx context.Context, osClient client.Interface, stream *imageapi.ImageStream) error {
+	moved := map[string]imageapi.TagEventList{}
+	for tag, history := range stream.Status.Tags {
+		if len(history.Items) <= 1 {
+			continue
+		}
+		moved[tag] = imageapi.TagEventList{Items: history.Items[1:]}
+	}
+	if len(moved) == 0 {
+		return nil
+	}
+
+	existing := strings.Split(stream.Annotations[imageapi.ShardOverflowAnnotation], ",")
+	if stream.Annotations[imageapi.ShardOverflowAnnotation] == "" {
+		existing = nil
+	}
+	overflowName := fmt.Sprintf("%s-overflow-%d", stream.Name, len(existing)+1)
+
+	overflow := &imageapi.ImageStream{
+		ObjectMeta: kapi.ObjectMeta{
+			Name: overflowName,
+			Annotations: map[string]string{
+				imageapi.ShardOfAnnotation: stream.Name,
+			},
+		},
+	}
+	created, err := osClient.ImageStreams(stream.Namespace).Create(overflow)
+	if err != nil {
+		return err
+	}
+	created.Status.Tags = moved
+	if _, err := osClient.ImageStreams(stream.Namespace).UpdateStatus(created); err != nil {
+		return err
+	}
+
+	// link the shard, then trim the main stream's history
+	if stream.Annotations == nil {
+		stream.Annotations = map[string]string{}
+	}
+	stream.Annotations[imageapi.ShardOverflowAnnotation] = strings.Join(append(existing, overflowName), ",")
+	updated, err := osClient.ImageStreams(stream.Namespace).Update(stream)
+	if err != nil {
+		return err
+	}
+	for tag, history := range updated.Status.Tags {
+		if len(history.Items) > 1 {
+			updated.Status.Tags[tag] = imageapi.TagEventList{Items: history.Items[:1]}
+		}
+	}
+	if _, err := osClient.ImageStreams(stream.Namespace).UpdateStatus(updated); err != nil {
+		return err
+	}
+	context.GetLogger(ctx).Infof("sharded image stream %s/%s: moved %d tags of history to %s", stream.Namespace, stream.Name, len(moved), overflowName)
+	return nil
+}
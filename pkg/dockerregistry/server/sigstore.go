@@ -0,0 +1,129 @@
+package server
+
+import (
+	"expvar"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/distribution/registry/api/v2"
+	"github.com/docker/distribution/registry/handlers"
+	gorillahandlers "github.com/gorilla/handlers"
+)
+
+// SigstoreFallbackURLEnvVar is the base URL of a static web sigstore consulted for signatures
+// the cluster does not hold. Signatures imported into image objects are always preferred; the
+// fallback lets CRI-O verify images whose signatures only live in an external lookaside store,
+// for example one populated by a CI pipeline outside the cluster. Empty disables the fallback.
+const SigstoreFallbackURLEnvVar = "REGISTRY_SIGSTORE_FALLBACK_URL"
+
+var sigstoreFallbackURL = readSigstoreFallbackURL()
+
+func readSigstoreFallbackURL() string {
+	return strings.TrimSuffix(os.Getenv(SigstoreFallbackURLEnvVar), "/")
+}
+
+// sigstoreClient fetches signatures from the fallback store; lookups block image verification
+// on the node, so they are kept on a short leash.
+var sigstoreClient = &http.Client{Timeout: 10 * time.Second}
+
+// sigstoreMetrics counts signature queries served from image objects, from the fallback store
+// and missed entirely.
+var sigstoreMetrics = expvar.NewMap("registry.sigstore")
+
+// SigstoreDispatcher constructs the handler serving image signatures in the static lookaside
+// layout CRI-O's policy engine reads: <name>@<algo>=<hex>/signature-<n>. The route carries a
+// repository name, so pull access is enforced.
+func SigstoreDispatcher(ctx *handlers.Context, r *http.Request) http.Handler {
+	sigstoreHandler := &sigstoreHandler{
+		Context: ctx,
+	}
+
+	return gorillahandlers.MethodHandler{
+		"GET": http.HandlerFunc(sigstoreHandler.Get),
+	}
+}
+
+type sigstoreHandler struct {
+	*handlers.Context
+}
+
+// Get serves the n-th signature of the addressed image. Signatures stored on the image object
+// come first; indexes past them are answered from the fallback sigstore when one is
+// configured. A missing signature is a plain 404, the answer a static sigstore would give.
+func (h *sigstoreHandler) Get(w http.ResponseWriter, req *http.Request) {
+	digestVar := context.GetStringValue(h, "vars.digest")
+	dgst, err := digest.ParseDigest(strings.Replace(digestVar, "=", ":", 1))
+	if err != nil {
+		h.Errors = append(h.Errors, v2.ErrorCodeDigestInvalid.WithDetail(err))
+		return
+	}
+	file := context.GetStringValue(h, "vars.file")
+	index, err := strconv.Atoi(strings.TrimPrefix(file, "signature-"))
+	if err != nil || index < 1 {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail("signature files are named signature-1, signature-2, ..."))
+		return
+	}
+
+	uclient, ok := UserClientFrom(h.Context)
+	if !ok {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail("user client unavailable"))
+		return
+	}
+	namespace, name, err := getNamespaceName(h.Repository.Named().Name())
+	if err != nil {
+		h.Errors = append(h.Errors, v2.ErrorCodeNameInvalid.WithDetail(err))
+		return
+	}
+
+	isi, err := uclient.ImageStreamImages(namespace).Get(name, dgst.String())
+	if err != nil {
+		context.GetLogger(h).Errorf("error getting ImageStreamImage %s/%s@%s: %v", namespace, name, dgst.String(), err)
+		h.Errors = append(h.Errors, v2.ErrorCodeManifestUnknown.WithDetail(err.Error()))
+		return
+	}
+
+	if index <= len(isi.Image.Signatures) {
+		sigstoreMetrics.Add("served", 1)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(isi.Image.Signatures[index-1].Content)
+		return
+	}
+
+	if h.serveFromFallback(w, digestVar, file) {
+		return
+	}
+
+	sigstoreMetrics.Add("missed", 1)
+	http.NotFound(w, req)
+}
+
+// serveFromFallback relays the signature from the configured lookaside store, preserving its
+// path layout. It reports whether it wrote a response.
+func (h *sigstoreHandler) serveFromFallback(w http.ResponseWriter, digestVar, file string) bool {
+	if len(sigstoreFallbackURL) == 0 {
+		return false
+	}
+
+	url := sigstoreFallbackURL + "/" + h.Repository.Named().Name() + "@" + digestVar + "/" + file
+	resp, err := sigstoreClient.Get(url)
+	if err != nil {
+		context.GetLogger(h).Warnf("error querying fallback sigstore %s: %v", url, err)
+		return false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+
+	sigstoreMetrics.Add("fallback", 1)
+	w.Header().Set("Content-Type", "application/octet-stream")
+	io.Copy(w, resp.Body)
+	return true
+}
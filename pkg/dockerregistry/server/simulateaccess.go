@@ -0,0 +1,214 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/distribution/registry/handlers"
+	gorillahandlers "github.com/gorilla/handlers"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/util/sets"
+
+	authorizationapi "github.com/openshift/origin/pkg/authorization/api"
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// accessCheck is one step of a simulated access decision.
+type accessCheck struct {
+	Check   string `json:"check"`
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// accessDecision is the full trace of a simulated access decision. Allowed is the conjunction
+// of all checks.
+type accessDecision struct {
+	User      string        `json:"user"`
+	Groups    []string      `json:"groups,omitempty"`
+	Namespace string        `json:"namespace"`
+	Name      string        `json:"name"`
+	Verb      string        `json:"verb"`
+	Allowed   bool          `json:"allowed"`
+	Checks    []accessCheck `json:"checks"`
+}
+
+// SimulateAccessDispatcher builds the handler answering "would user X be allowed to push to
+// ns/name?" for cluster admins debugging access issues. The simulation walks the same checks a
+// real request passes - the access review plus the registry-side policies - and returns every
+// step, so a denial points at the rule responsible. The route requires prune-level access.
+func SimulateAccessDispatcher(ctx *handlers.Context, r *http.Request) http.Handler {
+	simulateAccessHandler := &simulateAccessHandler{
+		Context: ctx,
+	}
+
+	return gorillahandlers.MethodHandler{
+		"GET": http.HandlerFunc(simulateAccessHandler.Simulate),
+	}
+}
+
+type simulateAccessHandler struct {
+	*handlers.Context
+}
+
+// Simulate evaluates ?user=<name>&group=<g>&namespace=<ns>&name=<stream>&verb=push|pull|prune
+// and optionally &tag=<tag> for tag-level rules. Access reviews are created with the admin's
+// own client, so cluster policy on reviewing other users applies unchanged.
+func (h *simulateAccessHandler) Simulate(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	decision := accessDecision{
+		User:      query.Get("user"),
+		Groups:    query["group"],
+		Namespace: query.Get("namespace"),
+		Name:      query.Get("name"),
+		Verb:      query.Get("verb"),
+	}
+	if len(decision.Verb) == 0 {
+		decision.Verb = "push"
+	}
+	if len(decision.User) == 0 || len(decision.Namespace) == 0 || len(decision.Name) == 0 {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail("the user, namespace and name parameters are required"))
+		return
+	}
+
+	uclient, ok := UserClientFrom(h.Context)
+	if !ok {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail("user client unavailable"))
+		return
+	}
+
+	groups := sets.NewString(decision.Groups...)
+	switch decision.Verb {
+	case "push", "pull":
+		verb := "update"
+		if decision.Verb == "pull" {
+			verb = "get"
+		}
+		sar := authorizationapi.LocalSubjectAccessReview{
+			Action: authorizationapi.Action{
+				Verb:         verb,
+				Group:        imageapi.GroupName,
+				Resource:     "imagestreams/layers",
+				ResourceName: decision.Name,
+			},
+			User:   decision.User,
+			Groups: groups,
+		}
+		response, err := uclient.LocalSubjectAccessReviews(decision.Namespace).Create(&sar)
+		if err != nil {
+			h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail(fmt.Sprintf("error creating subject access review: %v", err)))
+			return
+		}
+		decision.Checks = append(decision.Checks, accessCheck{Check: "subject-access-review", Allowed: response.Allowed, Reason: response.Reason})
+	case "prune":
+		sar := authorizationapi.SubjectAccessReview{
+			Action: authorizationapi.Action{
+				Verb:     "delete",
+				Group:    imageapi.GroupName,
+				Resource: "images",
+			},
+			User:   decision.User,
+			Groups: groups,
+		}
+		response, err := uclient.SubjectAccessReviews().Create(&sar)
+		if err != nil {
+			h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail(fmt.Sprintf("error creating subject access review: %v", err)))
+			return
+		}
+		decision.Checks = append(decision.Checks, accessCheck{Check: "subject-access-review", Allowed: response.Allowed, Reason: response.Reason})
+	default:
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail("verb must be push, pull or prune"))
+		return
+	}
+
+	if decision.Verb == "push" {
+		decision.Checks = append(decision.Checks, h.pushChecks(decision, query.Get("tag"))...)
+	}
+
+	decision.Allowed = true
+	for _, check := range decision.Checks {
+		decision.Allowed = decision.Allowed && check.Allowed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(decision); err != nil {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail(err))
+	}
+}
+
+// pushChecks evaluates the registry-side push policies - the remote-only annotation, tag
+// protection, the tag count cap and the distinct image caps from limit ranges - against the
+// live stream, reading it with the registry's own client.
+func (h *simulateAccessHandler) pushChecks(decision accessDecision, tag string) []accessCheck {
+	checks := []accessCheck{}
+
+	osClient, kClient, err := DefaultRegistryClient.Clients()
+	if err != nil {
+		return append(checks, accessCheck{Check: "registry-client", Allowed: false, Reason: err.Error()})
+	}
+
+	stream, err := osClient.ImageStreams(decision.Namespace).Get(decision.Name)
+	if err != nil {
+		// a missing stream would be auto provisioned during the push
+		return append(checks, accessCheck{Check: "image-stream", Allowed: true, Reason: fmt.Sprintf("image stream not found, it would be auto provisioned: %v", err)})
+	}
+
+	if stream.Annotations[imageapi.RemoteOnlyRepositoryAnnotation] == "true" {
+		checks = append(checks, accessCheck{Check: "remote-only", Allowed: false, Reason: "the stream is annotated remote-only, pushes are refused"})
+	}
+
+	if len(tag) > 0 && tagProtected(stream, tag) && imageapi.LatestTaggedImage(stream, tag) != nil {
+		verb := os.Getenv(TagProtectionVerbEnvVar)
+		if len(verb) == 0 {
+			verb = defaultTagProtectionVerb
+		}
+		checks = append(checks, accessCheck{Check: "tag-protection", Allowed: false, Reason: fmt.Sprintf("tag %s is protected; overwriting it requires passing a %q access review on the stream", tag, verb)})
+	}
+
+	if maxTagsPerStream > 0 {
+		count := len(stream.Status.Tags)
+		newTag := len(tag) > 0
+		if _, exists := stream.Status.Tags[tag]; exists {
+			newTag = false
+		}
+		if newTag && count >= maxTagsPerStream {
+			checks = append(checks, accessCheck{Check: "tag-limit", Allowed: false, Reason: fmt.Sprintf("the stream already has %d tags, the limit is %d", count, maxTagsPerStream)})
+		} else {
+			checks = append(checks, accessCheck{Check: "tag-limit", Allowed: true, Reason: fmt.Sprintf("%d of %d tags in use", count, maxTagsPerStream)})
+		}
+	}
+
+	lrs, err := kClient.LimitRanges(decision.Namespace).List(kapi.ListOptions{})
+	if err != nil {
+		return append(checks, accessCheck{Check: "limit-ranges", Allowed: false, Reason: err.Error()})
+	}
+	streamMax, projectMax, streamSet, projectSet := imageCountLimits(lrs)
+	if streamSet {
+		count := statusDigests(stream).Len()
+		checks = append(checks, accessCheck{
+			Check:   "image-count-limit",
+			Allowed: int64(count) < streamMax,
+			Reason:  fmt.Sprintf("the stream holds %d of at most %d distinct images", count, streamMax),
+		})
+	}
+	if projectSet {
+		streams, err := osClient.ImageStreams(decision.Namespace).List(kapi.ListOptions{})
+		if err != nil {
+			return append(checks, accessCheck{Check: "project-image-count-limit", Allowed: false, Reason: err.Error()})
+		}
+		digests := sets.NewString()
+		for i := range streams.Items {
+			digests = digests.Union(statusDigests(&streams.Items[i]))
+		}
+		checks = append(checks, accessCheck{
+			Check:   "project-image-count-limit",
+			Allowed: int64(digests.Len()) < projectMax,
+			Reason:  fmt.Sprintf("the project holds %d of at most %d distinct images", digests.Len(), projectMax),
+		})
+	}
+
+	return checks
+}
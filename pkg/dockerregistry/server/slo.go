@@ -0,0 +1,223 @@
+package server
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// The SLO module computes rolling success-rate and latency SLIs for the pull and push paths
+// from in-process counters, so burn-rate alerting works without external log processing. These
+// variables tune the objectives the burn rates are computed against.
+const (
+	// SLOTargetEnvVar is the availability objective as a fraction, e.g. "0.999".
+	SLOTargetEnvVar = "REGISTRY_SLO_TARGET"
+	// SLOPullLatencyEnvVar is the latency objective for pull requests (a duration).
+	SLOPullLatencyEnvVar = "REGISTRY_SLO_PULL_LATENCY"
+	// SLOPushLatencyEnvVar is the latency objective for push requests (a duration).
+	SLOPushLatencyEnvVar = "REGISTRY_SLO_PUSH_LATENCY"
+)
+
+const (
+	defaultSLOTarget      = 0.999
+	defaultPullLatencySLO = time.Second
+	defaultPushLatencySLO = 10 * time.Second
+
+	// sloBucketSize and sloBucketCount give a one-hour rolling window in minute buckets.
+	sloBucketSize  = time.Minute
+	sloBucketCount = 60
+)
+
+var sloRecorder = newSLORecorder()
+
+func init() {
+	expvar.Publish("registry.slo", expvar.Func(func() interface{} {
+		return sloRecorder.report()
+	}))
+}
+
+// sloBucket accumulates one bucket interval of observations.
+type sloBucket struct {
+	start      time.Time
+	requests   int64
+	failures   int64
+	slow       int64
+	latencySum time.Duration
+}
+
+// sloSeries is the rolling window for one request class.
+type sloSeries struct {
+	latencyTarget time.Duration
+	buckets       [sloBucketCount]sloBucket
+}
+
+type sloRecorderT struct {
+	mu     sync.Mutex
+	target float64
+	pull   sloSeries
+	push   sloSeries
+}
+
+func newSLORecorder() *sloRecorderT {
+	r := &sloRecorderT{
+		target: defaultSLOTarget,
+		pull:   sloSeries{latencyTarget: defaultPullLatencySLO},
+		push:   sloSeries{latencyTarget: defaultPushLatencySLO},
+	}
+	if value := os.Getenv(SLOTargetEnvVar); len(value) > 0 {
+		if target, err := strconv.ParseFloat(value, 64); err == nil && target > 0 && target < 1 {
+			r.target = target
+		} else {
+			log.Errorf("ignoring invalid %s=%q", SLOTargetEnvVar, value)
+		}
+	}
+	r.pull.latencyTarget = timeoutFromEnv(SLOPullLatencyEnvVar, defaultPullLatencySLO)
+	r.push.latencyTarget = timeoutFromEnv(SLOPushLatencyEnvVar, defaultPushLatencySLO)
+	return r
+}
+
+// isPullRequest and isPushRequest classify v2 API requests; everything else is ignored for
+// SLI purposes (health checks, admin endpoints, catalog listings).
+func isPullRequest(req *http.Request) bool {
+	if req.Method != "GET" && req.Method != "HEAD" {
+		return false
+	}
+	return strings.Contains(req.URL.Path, "/manifests/") || strings.Contains(req.URL.Path, "/blobs/")
+}
+
+func isPushRequest(req *http.Request) bool {
+	switch req.Method {
+	case "POST", "PATCH", "PUT":
+	default:
+		return false
+	}
+	return strings.Contains(req.URL.Path, "/manifests/") || strings.Contains(req.URL.Path, "/blobs/")
+}
+
+func (r *sloRecorderT) observe(req *http.Request, status int, duration time.Duration) {
+	var series *sloSeries
+	switch {
+	case isPullRequest(req):
+		series = &r.pull
+	case isPushRequest(req):
+		series = &r.push
+	default:
+		return
+	}
+
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bucket := &series.buckets[int(now.Unix()/int64(sloBucketSize/time.Second))%sloBucketCount]
+	if now.Sub(bucket.start) >= sloBucketSize {
+		*bucket = sloBucket{start: now.Truncate(sloBucketSize)}
+	}
+	bucket.requests++
+	bucket.latencySum += duration
+	if status >= 500 {
+		bucket.failures++
+	}
+	if duration > series.latencyTarget {
+		bucket.slow++
+	}
+}
+
+// sloWindowSummary reports the SLIs over one rolling window.
+type sloWindowSummary struct {
+	Window   string `json:"window"`
+	Requests int64  `json:"requests"`
+	Failures int64  `json:"failures"`
+
+	// SuccessRate is the availability SLI; LatencyAttainment the fraction of requests
+	// meeting the latency objective. Both are 1 when the window saw no traffic.
+	SuccessRate       float64 `json:"successRate"`
+	LatencyAttainment float64 `json:"latencyAttainment"`
+	AvgLatencyMs      float64 `json:"avgLatencyMs"`
+
+	// BurnRate is the error rate divided by the error budget; sustained values above 1
+	// exhaust the budget before the SLO period ends.
+	BurnRate float64 `json:"burnRate"`
+}
+
+// sloClassSummary reports one request class over the standard alerting windows.
+type sloClassSummary struct {
+	LatencyTargetMs int64              `json:"latencyTargetMs"`
+	Windows         []sloWindowSummary `json:"windows"`
+}
+
+type sloReport struct {
+	Target float64         `json:"target"`
+	Pull   sloClassSummary `json:"pull"`
+	Push   sloClassSummary `json:"push"`
+}
+
+func (r *sloRecorderT) report() sloReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return sloReport{
+		Target: r.target,
+		Pull:   r.summarize(&r.pull),
+		Push:   r.summarize(&r.push),
+	}
+}
+
+func (r *sloRecorderT) summarize(series *sloSeries) sloClassSummary {
+	summary := sloClassSummary{LatencyTargetMs: int64(series.latencyTarget / time.Millisecond)}
+	for _, window := range []time.Duration{5 * time.Minute, time.Hour} {
+		summary.Windows = append(summary.Windows, r.summarizeWindow(series, window))
+	}
+	return summary
+}
+
+func (r *sloRecorderT) summarizeWindow(series *sloSeries, window time.Duration) sloWindowSummary {
+	cutoff := time.Now().Add(-window)
+	summary := sloWindowSummary{Window: window.String(), SuccessRate: 1, LatencyAttainment: 1}
+	var slow int64
+	var latencySum time.Duration
+	for i := range series.buckets {
+		bucket := &series.buckets[i]
+		if bucket.start.Before(cutoff) {
+			continue
+		}
+		summary.Requests += bucket.requests
+		summary.Failures += bucket.failures
+		slow += bucket.slow
+		latencySum += bucket.latencySum
+	}
+	if summary.Requests == 0 {
+		return summary
+	}
+	summary.SuccessRate = 1 - float64(summary.Failures)/float64(summary.Requests)
+	summary.LatencyAttainment = 1 - float64(slow)/float64(summary.Requests)
+	summary.AvgLatencyMs = float64(latencySum/time.Millisecond) / float64(summary.Requests)
+	if budget := 1 - r.target; budget > 0 {
+		summary.BurnRate = (1 - summary.SuccessRate) / budget
+	}
+	return summary
+}
+
+// NewSLOHandler records every request into the SLI series.
+func NewSLOHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		recorder := &accessLogResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		handler.ServeHTTP(recorder, req)
+		sloRecorder.observe(req, recorder.status, time.Since(start))
+	})
+}
+
+// sloSummaryHandler serves the JSON SLO report on the admin listener.
+func sloSummaryHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(sloRecorder.report()); err != nil {
+		log.Errorf("error writing SLO summary: %v", err)
+	}
+}
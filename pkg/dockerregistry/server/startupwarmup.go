@@ -0,0 +1,101 @@
+package server
+
+import (
+	"os"
+	"strings"
+
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/digest"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// StartupWarmupNamespacesEnvVar lists namespaces (comma separated) whose image streams are read
+// once at startup to prime the layer location cache. For busy namespaces this avoids hammering
+// the API server with cache misses in the first minutes after a registry restart.
+const StartupWarmupNamespacesEnvVar = "REGISTRY_STARTUP_WARMUP_NAMESPACES"
+
+// RunStartupWarmup primes the layer caches for the configured namespaces in the background. It
+// is a no-op unless StartupWarmupNamespacesEnvVar is set.
+func RunStartupWarmup(ctx context.Context, registryClient *RegistryClient) {
+	value := os.Getenv(StartupWarmupNamespacesEnvVar)
+	if len(value) == 0 {
+		return
+	}
+	namespaces := []string{}
+	for _, namespace := range strings.Split(value, ",") {
+		if namespace = strings.TrimSpace(namespace); len(namespace) > 0 {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	if len(namespaces) == 0 {
+		return
+	}
+
+	osClient, _, err := registryClient.Clients()
+	if err != nil {
+		context.GetLogger(ctx).Errorf("startup warmup: could not get clients: %v", err)
+		return
+	}
+
+	go func() {
+		warmed := 0
+		for _, namespace := range namespaces {
+			streams, err := osClient.ImageStreams(namespace).List(kapi.ListOptions{})
+			if err != nil {
+				context.GetLogger(ctx).Errorf("startup warmup: cannot list image streams in %s: %v", namespace, err)
+				continue
+			}
+			for i := range streams.Items {
+				warmed += warmStreamLayers(ctx, osClient.Images(), &streams.Items[i])
+			}
+		}
+		context.GetLogger(ctx).Infof("startup warmup: primed %d layer locations for %d namespaces", warmed, len(namespaces))
+	}()
+}
+
+// imageGetter is the part of the image client the warmup needs.
+type imageGetter interface {
+	Get(name string) (*imageapi.Image, error)
+}
+
+// warmStreamLayers records the remote repositories holding the layers of the stream's latest
+// tagged images in the shared layer cache and returns how many locations were recorded.
+func warmStreamLayers(ctx context.Context, images imageGetter, stream *imageapi.ImageStream) int {
+	var localRegistry string
+	if local, err := imageapi.ParseDockerImageReference(stream.Status.DockerImageRepository); err == nil {
+		localRegistry = local.Registry
+	}
+
+	warmed := 0
+	for _, history := range stream.Status.Tags {
+		if len(history.Items) == 0 {
+			continue
+		}
+		event := history.Items[0]
+		ref, err := imageapi.ParseDockerImageReference(event.DockerImageReference)
+		if err != nil {
+			continue
+		}
+		if len(localRegistry) != 0 && localRegistry == ref.Registry {
+			continue
+		}
+		image, err := images.Get(event.Image)
+		if err != nil {
+			continue
+		}
+		ref = ref.DockerClientDefaults()
+		repo := ref.AsRepository().Exact()
+		for _, layer := range image.DockerImageLayers {
+			dgst, err := digest.ParseDigest(layer.Name)
+			if err != nil {
+				continue
+			}
+			cachedLayers.RememberDigest(dgst, repo)
+			warmed++
+		}
+	}
+	return warmed
+}
@@ -20,7 +20,13 @@ func init() {
 		// We can do this because of an initialization sequence of middlewares.
 		// Storage driver is required to create registry. So we can be sure that
 		// this assignment will happen before registry and repository initialization.
-		dockerStorageDriver = driver
+		dockerStorageDriver = &monitoredDriver{StorageDriver: withFaultInjection(driver)}
+
+		RegisterMaintenanceTask(MaintenanceTask{
+			Name:     "purge-uploads",
+			Interval: uploadPurgeInterval,
+			Run:      purgeAbandonedUploads,
+		})
 		return dockerStorageDriver, nil
 	})
 }
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"expvar"
+	"fmt"
+	"os"
+	"strconv"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/docker/distribution/registry/api/errcode"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// MaxTagsPerStreamEnvVar caps how many status tags an image stream may accumulate through the
+// registry. Streams with very many tags produce etcd objects large enough to destabilize the
+// cluster; the cap turns that into a clear push-time error. Zero (the default) means unlimited.
+const MaxTagsPerStreamEnvVar = "REGISTRY_MAX_TAGS_PER_STREAM"
+
+var maxTagsPerStream = readMaxTagsPerStream()
+
+// tagLimitMetrics counts pushes denied by the cap and pushes to streams within 90% of it, so
+// operators can alert before streams hit the limit.
+var tagLimitMetrics = expvar.NewMap("registry.tagLimit")
+
+func readMaxTagsPerStream() int {
+	value := os.Getenv(MaxTagsPerStreamEnvVar)
+	if len(value) == 0 {
+		return 0
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		log.Errorf("ignoring invalid %s=%q", MaxTagsPerStreamEnvVar, value)
+		return 0
+	}
+	return limit
+}
+
+// checkTagLimit refuses creating a new tag on a stream already at the cap. Overwriting an
+// existing tag never increases the tag count and is always allowed.
+func (r *repository) checkTagLimit(stream *imageapi.ImageStream, tag string) error {
+	if maxTagsPerStream <= 0 || stream == nil || len(tag) == 0 {
+		return nil
+	}
+	if _, exists := stream.Status.Tags[tag]; exists {
+		return nil
+	}
+	count := len(stream.Status.Tags)
+	if count*10 >= maxTagsPerStream*9 {
+		tagLimitMetrics.Add("nearLimit", 1)
+	}
+	if count >= maxTagsPerStream {
+		tagLimitMetrics.Add("denied", 1)
+		return errcode.ErrorCodeDenied.WithDetail(fmt.Sprintf("image stream %s/%s already has %d tags, the limit is %d", r.namespace, r.name, count, maxTagsPerStream))
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/distribution/registry/api/v2"
+	"github.com/docker/distribution/registry/handlers"
+	gorillahandlers "github.com/gorilla/handlers"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// tagMetadataMaxBody bounds the metadata document size.
+const tagMetadataMaxBody = 64 * 1024
+
+// tagMetadata is the document stored as ImageStreamTag annotations, letting artifact metadata
+// be managed with registry-native tooling instead of oc.
+type tagMetadata struct {
+	Description string            `json:"description,omitempty"`
+	Owner       string            `json:"owner,omitempty"`
+	Links       map[string]string `json:"links,omitempty"`
+}
+
+// TagMetadataDispatcher constructs the handler reading and writing per-tag metadata. GET
+// requires pull access, PUT push access; the API call itself runs as the requesting user, so
+// cluster policy applies as well.
+func TagMetadataDispatcher(ctx *handlers.Context, r *http.Request) http.Handler {
+	tagMetadataHandler := &tagMetadataHandler{
+		Context: ctx,
+	}
+
+	return gorillahandlers.MethodHandler{
+		"GET": http.HandlerFunc(tagMetadataHandler.Get),
+		"PUT": http.HandlerFunc(tagMetadataHandler.Put),
+	}
+}
+
+type tagMetadataHandler struct {
+	*handlers.Context
+}
+
+func (h *tagMetadataHandler) Get(w http.ResponseWriter, req *http.Request) {
+	istag, ok := h.imageStreamTag(req)
+	if !ok {
+		return
+	}
+
+	metadata := tagMetadata{
+		Description: istag.Annotations[imageapi.TagDescriptionAnnotation],
+		Owner:       istag.Annotations[imageapi.TagOwnerAnnotation],
+	}
+	if links := istag.Annotations[imageapi.TagLinksAnnotation]; len(links) > 0 {
+		if err := json.Unmarshal([]byte(links), &metadata.Links); err != nil {
+			context.GetLogger(h).Warnf("malformed %s annotation on %s: %v", imageapi.TagLinksAnnotation, istag.Name, err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(metadata)
+}
+
+func (h *tagMetadataHandler) Put(w http.ResponseWriter, req *http.Request) {
+	metadata := tagMetadata{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, tagMetadataMaxBody)).Decode(&metadata); err != nil {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail(fmt.Sprintf("invalid metadata document: %v", err)))
+		return
+	}
+
+	istag, ok := h.imageStreamTag(req)
+	if !ok {
+		return
+	}
+	if istag.Annotations == nil {
+		istag.Annotations = map[string]string{}
+	}
+
+	setOrDeleteAnnotation(istag.Annotations, imageapi.TagDescriptionAnnotation, metadata.Description)
+	setOrDeleteAnnotation(istag.Annotations, imageapi.TagOwnerAnnotation, metadata.Owner)
+	links := ""
+	if len(metadata.Links) > 0 {
+		encoded, err := json.Marshal(metadata.Links)
+		if err != nil {
+			h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail(err.Error()))
+			return
+		}
+		links = string(encoded)
+	}
+	setOrDeleteAnnotation(istag.Annotations, imageapi.TagLinksAnnotation, links)
+
+	uclient, ok := UserClientFrom(h.Context)
+	if !ok {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail("user client unavailable"))
+		return
+	}
+	namespace, _, err := getNamespaceName(h.Repository.Named().Name())
+	if err != nil {
+		h.Errors = append(h.Errors, v2.ErrorCodeNameInvalid.WithDetail(err))
+		return
+	}
+	if _, err := uclient.ImageStreamTags(namespace).Update(istag); err != nil {
+		context.GetLogger(h).Errorf("error updating tag metadata on %s: %v", istag.Name, err)
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail(err.Error()))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// imageStreamTag loads the ImageStreamTag addressed by the ?tag= parameter as the requesting
+// user. Errors are recorded on the handler; ok reports success.
+func (h *tagMetadataHandler) imageStreamTag(req *http.Request) (*imageapi.ImageStreamTag, bool) {
+	tag := req.URL.Query().Get("tag")
+	if len(tag) == 0 {
+		h.Errors = append(h.Errors, v2.ErrorCodeManifestUnknown.WithDetail("the tag query parameter is required"))
+		return nil, false
+	}
+	uclient, ok := UserClientFrom(h.Context)
+	if !ok {
+		h.Errors = append(h.Errors, errcode.ErrorCodeUnknown.WithDetail("user client unavailable"))
+		return nil, false
+	}
+	namespace, name, err := getNamespaceName(h.Repository.Named().Name())
+	if err != nil {
+		h.Errors = append(h.Errors, v2.ErrorCodeNameInvalid.WithDetail(err))
+		return nil, false
+	}
+	istag, err := uclient.ImageStreamTags(namespace).Get(name, tag)
+	if err != nil {
+		context.GetLogger(h).Errorf("error getting ImageStreamTag %s/%s:%s: %v", namespace, name, tag, err)
+		h.Errors = append(h.Errors, v2.ErrorCodeManifestUnknown.WithDetail(err.Error()))
+		return nil, false
+	}
+	return istag, true
+}
+
+func setOrDeleteAnnotation(annotations map[string]string, name, value string) {
+	if len(value) == 0 {
+		delete(annotations, name)
+		return
+	}
+	annotations[name] = value
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"os"
+	"path"
+	"strings"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// TagProtectionVerbEnvVar is the verb of the access review a user must pass to overwrite or
+// delete a protected tag (default "delete", which project admins hold on image streams but
+// tightly scoped robot accounts typically do not).
+const TagProtectionVerbEnvVar = "REGISTRY_TAG_PROTECTION_VERB"
+
+const defaultTagProtectionVerb = "delete"
+
+// protectedTagPatterns returns the glob patterns configured on the stream.
+func protectedTagPatterns(stream *imageapi.ImageStream) []string {
+	value := stream.Annotations[imageapi.ProtectedTagsAnnotation]
+	if len(value) == 0 {
+		return nil
+	}
+	patterns := []string{}
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); len(pattern) > 0 {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// tagProtected is true when the tag matches one of the stream's protection patterns.
+func tagProtected(stream *imageapi.ImageStream, tag string) bool {
+	for _, pattern := range protectedTagPatterns(stream) {
+		if ok, err := path.Match(pattern, tag); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTagProtection enforces the stream's tag protection rules for a write to tag. Creating a
+// new tag is always allowed; overwriting or deleting an existing protected tag additionally
+// requires the user to pass the configured access review, mirroring branch protection.
+func (r *repository) checkTagProtection(ctx context.Context, stream *imageapi.ImageStream, tag string) error {
+	if len(tag) == 0 || stream == nil || !tagProtected(stream, tag) {
+		return nil
+	}
+	if imageapi.LatestTaggedImage(stream, tag) == nil {
+		// the protected tag does not exist yet; creating it is an ordinary push
+		return nil
+	}
+
+	uclient, ok := UserClientFrom(r.ctx)
+	if !ok {
+		context.GetLogger(ctx).Errorf("refusing write to protected tag %s:%s: user client unavailable", r.Named().Name(), tag)
+		return distribution.ErrAccessDenied
+	}
+	verb := os.Getenv(TagProtectionVerbEnvVar)
+	if len(verb) == 0 {
+		verb = defaultTagProtectionVerb
+	}
+	if err := verifyImageStreamAccess(ctx, r.namespace, r.name, verb, uclient); err != nil {
+		context.GetLogger(ctx).Errorf("refusing write to protected tag %s:%s: %v", r.Named().Name(), tag, err)
+		return distribution.ErrAccessDenied
+	}
+	return nil
+}
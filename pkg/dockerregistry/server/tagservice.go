@@ -91,7 +91,7 @@ func (t tagService) All(ctx context.Context) ([]string, error) {
 
 		tags = append(tags, tag)
 	}
-	return tags, nil
+	return applyTagsListParams(ctx, imageStream, tags), nil
 }
 
 func (t tagService) Lookup(ctx context.Context, desc distribution.Descriptor) ([]string, error) {
@@ -153,6 +153,14 @@ func (t tagService) Tag(ctx context.Context, tag string, dgst distribution.Descr
 		return distribution.ErrRepositoryUnknown{Name: t.repo.Named().Name()}
 	}
 
+	if err := t.repo.checkTagProtection(ctx, imageStream, tag); err != nil {
+		return err
+	}
+
+	if err := t.repo.checkTagLimit(imageStream, tag); err != nil {
+		return err
+	}
+
 	image, err := t.repo.registryOSClient.Images().Get(dgst.Digest.String())
 	if err != nil {
 		context.GetLogger(ctx).Errorf("unable to get image: %s", dgst.Digest.String())
@@ -173,7 +181,7 @@ func (t tagService) Tag(ctx context.Context, tag string, dgst distribution.Descr
 		Image: *image,
 	}
 
-	err = t.repo.registryOSClient.ImageStreamMappings(imageStream.Namespace).Create(&ism)
+	err = t.repo.backend.CreateImageStreamMapping(imageStream.Namespace, &ism)
 	if quotautil.IsErrorQuotaExceeded(err) {
 		context.GetLogger(ctx).Errorf("denied creating ImageStreamMapping: %v", err)
 		return distribution.ErrAccessDenied
@@ -194,6 +202,10 @@ func (t tagService) Untag(ctx context.Context, tag string) error {
 		return distribution.ErrTagUnknown{Tag: tag}
 	}
 
+	if err := t.repo.checkTagProtection(ctx, imageStream, tag); err != nil {
+		return err
+	}
+
 	if !t.repo.pullthrough {
 		dgst, err := digest.ParseDigest(te.Image)
 		if err != nil {
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"path"
+	"sort"
+	"strconv"
+
+	"github.com/docker/distribution/context"
+
+	"k8s.io/kubernetes/pkg/api/unversioned"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// applyTagsListParams orders, filters and paginates the tags returned from a tags/list request
+// using optional query parameters:
+//
+//	pattern - a glob pattern tags must match
+//	order   - "newest" sorts tags by the creation time of their latest tag event, newest first;
+//	          the default is lexical ordering
+//	n       - maximum number of results to return
+//	last    - return only tags after this tag in the selected ordering
+//
+// Streams with thousands of tags would otherwise always return the complete list.
+func applyTagsListParams(ctx context.Context, stream *imageapi.ImageStream, tags []string) []string {
+	sort.Strings(tags)
+
+	req, err := context.GetRequest(ctx)
+	if err != nil {
+		return tags
+	}
+	query := req.URL.Query()
+
+	if pattern := query.Get("pattern"); len(pattern) > 0 {
+		matched := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			if ok, err := path.Match(pattern, tag); err == nil && ok {
+				matched = append(matched, tag)
+			}
+		}
+		tags = matched
+	}
+
+	if query.Get("order") == "newest" {
+		sort.Stable(byNewestTagEvent{tags: tags, stream: stream})
+	}
+
+	if last := query.Get("last"); len(last) > 0 {
+		for i, tag := range tags {
+			if tag == last {
+				tags = tags[i+1:]
+				break
+			}
+		}
+	}
+
+	if n, err := strconv.Atoi(query.Get("n")); err == nil && n > 0 && n < len(tags) {
+		tags = tags[:n]
+		if ociConformanceEnabled() {
+			if w, err := context.GetResponseWriter(ctx); err == nil {
+				setTagsListLinkHeader(w, req, tags[len(tags)-1], n)
+			}
+		}
+	}
+
+	return tags
+}
+
+// byNewestTagEvent sorts tags by the creation time of their latest tag event, newest first.
+type byNewestTagEvent struct {
+	tags   []string
+	stream *imageapi.ImageStream
+}
+
+func (b byNewestTagEvent) Len() int      { return len(b.tags) }
+func (b byNewestTagEvent) Swap(i, j int) { b.tags[i], b.tags[j] = b.tags[j], b.tags[i] }
+func (b byNewestTagEvent) Less(i, j int) bool {
+	return latestTagEventTime(b.stream, b.tags[i]).After(latestTagEventTime(b.stream, b.tags[j]).Time)
+}
+
+// latestTagEventTime returns the creation time of the most recent tag event for tag, or the zero
+// time if the stream has no event for it.
+func latestTagEventTime(stream *imageapi.ImageStream, tag string) unversioned.Time {
+	event := imageapi.LatestTaggedImage(stream, tag)
+	if event == nil {
+		return unversioned.Time{}
+	}
+	return event.Created
+}
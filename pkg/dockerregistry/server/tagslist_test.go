@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/docker/distribution/context"
+
+	"k8s.io/kubernetes/pkg/api/unversioned"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+func TestApplyTagsListParams(t *testing.T) {
+	now := time.Now()
+	stream := &imageapi.ImageStream{
+		Status: imageapi.ImageStreamStatus{
+			Tags: map[string]imageapi.TagEventList{
+				"latest": {Items: []imageapi.TagEvent{{Created: unversioned.NewTime(now)}}},
+				"v1":     {Items: []imageapi.TagEvent{{Created: unversioned.NewTime(now.Add(-2 * time.Hour))}}},
+				"v2":     {Items: []imageapi.TagEvent{{Created: unversioned.NewTime(now.Add(-time.Hour))}}},
+			},
+		},
+	}
+	tags := []string{"v2", "latest", "v1"}
+
+	testCases := map[string]struct {
+		query    string
+		expected []string
+	}{
+		"no params sorts lexically": {
+			query:    "",
+			expected: []string{"latest", "v1", "v2"},
+		},
+		"pattern filter": {
+			query:    "pattern=v*",
+			expected: []string{"v1", "v2"},
+		},
+		"newest first": {
+			query:    "order=newest",
+			expected: []string{"latest", "v2", "v1"},
+		},
+		"pagination": {
+			query:    "n=1&last=v1",
+			expected: []string{"v2"},
+		},
+		"newest first with pagination": {
+			query:    "order=newest&last=latest&n=1",
+			expected: []string{"v2"},
+		},
+	}
+	for name, test := range testCases {
+		req := &http.Request{URL: &url.URL{RawQuery: test.query}}
+		ctx := context.WithRequest(context.Background(), req)
+		result := applyTagsListParams(ctx, stream, append([]string{}, tags...))
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("%s: expected %v, got %v", name, test.expected, result)
+		}
+	}
+}
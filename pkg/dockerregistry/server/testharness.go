@@ -0,0 +1,158 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/distribution/registry/storage"
+	"github.com/docker/distribution/registry/storage/driver/inmemory"
+
+	kerrors "k8s.io/kubernetes/pkg/api/errors"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// This file is an exported test harness for registry code: an in-memory registry wired to the
+// OpenShift repository middleware and a fake image stream backend. Other Origin components and
+// external users can run integration-style registry tests against it without a cluster.
+
+// FakeImageStreamBackend is an in-memory imageStreamBackend. Seed Streams and Images directly or
+// through CreateImageStreamMapping, which maintains both like the real API server does.
+type FakeImageStreamBackend struct {
+	mu sync.Mutex
+
+	// Streams maps "namespace/name" to image streams.
+	Streams map[string]*imageapi.ImageStream
+	// Images maps digests to images.
+	Images map[string]*imageapi.Image
+	// Mappings records every created image stream mapping, in order.
+	Mappings []*imageapi.ImageStreamMapping
+}
+
+var _ imageStreamBackend = &FakeImageStreamBackend{}
+
+func NewFakeImageStreamBackend() *FakeImageStreamBackend {
+	return &FakeImageStreamBackend{
+		Streams: map[string]*imageapi.ImageStream{},
+		Images:  map[string]*imageapi.Image{},
+	}
+}
+
+func (b *FakeImageStreamBackend) GetImageStream(namespace, name string) (*imageapi.ImageStream, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stream, ok := b.Streams[namespace+"/"+name]
+	if !ok {
+		return nil, kerrors.NewNotFound(imageapi.Resource("imagestreams"), name)
+	}
+	return stream, nil
+}
+
+func (b *FakeImageStreamBackend) GetImage(dgst digest.Digest) (*imageapi.Image, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	image, ok := b.Images[dgst.String()]
+	if !ok {
+		return nil, kerrors.NewNotFound(imageapi.Resource("images"), dgst.String())
+	}
+	return image, nil
+}
+
+func (b *FakeImageStreamBackend) GetImageStreamImage(namespace, name string, dgst digest.Digest) (*imageapi.ImageStreamImage, error) {
+	stream, err := b.GetImageStream(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	image, err := b.GetImage(dgst)
+	if err != nil {
+		return nil, err
+	}
+	for _, history := range stream.Status.Tags {
+		for _, event := range history.Items {
+			if event.Image == dgst.String() {
+				isi := &imageapi.ImageStreamImage{Image: *image}
+				isi.Namespace = namespace
+				isi.Name = fmt.Sprintf("%s@%s", name, dgst.String())
+				return isi, nil
+			}
+		}
+	}
+	return nil, kerrors.NewNotFound(imageapi.Resource("imagestreamimages"), fmt.Sprintf("%s@%s", name, dgst.String()))
+}
+
+func (b *FakeImageStreamBackend) CreateImageStreamMapping(namespace string, ism *imageapi.ImageStreamMapping) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := namespace + "/" + ism.Name
+	stream, ok := b.Streams[key]
+	if !ok {
+		return kerrors.NewNotFound(imageapi.Resource("imagestreams"), ism.Name)
+	}
+
+	image := ism.Image
+	b.Images[image.Name] = &image
+
+	if stream.Status.Tags == nil {
+		stream.Status.Tags = map[string]imageapi.TagEventList{}
+	}
+	if len(ism.Tag) > 0 {
+		history := stream.Status.Tags[ism.Tag]
+		history.Items = append([]imageapi.TagEvent{{
+			DockerImageReference: image.DockerImageReference,
+			Image:                image.Name,
+		}}, history.Items...)
+		stream.Status.Tags[ism.Tag] = history
+	}
+
+	b.Mappings = append(b.Mappings, ism)
+	return nil
+}
+
+// AddImageStream seeds an empty image stream and returns it for further decoration.
+func (b *FakeImageStreamBackend) AddImageStream(namespace, name string) *imageapi.ImageStream {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stream := &imageapi.ImageStream{}
+	stream.Namespace = namespace
+	stream.Name = name
+	b.Streams[namespace+"/"+name] = stream
+	return stream
+}
+
+// NewTestRepository returns the OpenShift repository middleware for namespace/name backed by an
+// in-memory storage driver and the given fake backend. Pass nil for the backend to get a fresh
+// one; it is reachable through the second return value for seeding and assertions.
+func NewTestRepository(ctx context.Context, namespace, name string, backend *FakeImageStreamBackend) (distribution.Repository, *FakeImageStreamBackend, error) {
+	if backend == nil {
+		backend = NewFakeImageStreamBackend()
+	}
+
+	registry, err := storage.NewRegistry(ctx, inmemory.New())
+	if err != nil {
+		return nil, nil, err
+	}
+	named, err := reference.WithName(fmt.Sprintf("%s/%s", namespace, name))
+	if err != nil {
+		return nil, nil, err
+	}
+	repo, err := registry.Repository(ctx, named)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &repository{
+		Repository: repo,
+
+		ctx:          ctx,
+		backend:      backend,
+		registryAddr: "127.0.0.1:5000",
+		namespace:    namespace,
+		name:         name,
+		cachedLayers: cachedLayers,
+	}, backend, nil
+}
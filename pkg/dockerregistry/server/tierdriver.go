@@ -0,0 +1,272 @@
+package server
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/docker/distribution/context"
+	storagedriver "github.com/docker/distribution/registry/storage/driver"
+	"github.com/docker/distribution/registry/storage/driver/factory"
+	registrystorage "github.com/docker/distribution/registry/storage/driver/middleware"
+)
+
+const (
+	// defaultArchiveAge is how long a blob has to stay untouched before it is archived to the
+	// cold backend.
+	defaultArchiveAge = 30 * 24 * time.Hour
+
+	// archiveInterval is how often the archiving maintenance task runs on the leader replica.
+	archiveInterval = time.Hour
+)
+
+// retrievalPendingPulls counts pulls that had to wait for a blob to be fetched from the cold
+// tier. Published through the admin listener's metrics endpoint.
+var retrievalPendingPulls = expvar.NewInt("registry_retrieval_pending_pulls")
+
+func init() {
+	// The "tier" storage middleware archives blobs untouched for a configurable period to a
+	// cheaper cold backend and retrieves them transparently on access:
+	//
+	//   middleware:
+	//     storage:
+	//       - name: tier
+	//         options:
+	//           archiveolderthan: 720h
+	//           cold:
+	//             driver: s3-aws
+	//             parameters: {...}
+	//
+	// Archiving runs as a leader-elected maintenance task, see RunMaintenance.
+	registrystorage.Register("tier", func(driver storagedriver.StorageDriver, options map[string]interface{}) (storagedriver.StorageDriver, error) {
+		log.Info("Tier middleware for storage driver initializing")
+
+		config, ok := options["cold"].(map[interface{}]interface{})
+		if !ok {
+			return nil, fmt.Errorf("tier middleware: a cold backend is required")
+		}
+		name, _ := config["driver"].(string)
+		if len(name) == 0 {
+			return nil, fmt.Errorf("tier middleware: the cold backend has no driver")
+		}
+		parameters := map[string]interface{}{}
+		if raw, ok := config["parameters"].(map[interface{}]interface{}); ok {
+			for key, value := range raw {
+				if s, ok := key.(string); ok {
+					parameters[s] = value
+				}
+			}
+		}
+		cold, err := factory.Create(name, parameters)
+		if err != nil {
+			return nil, fmt.Errorf("tier middleware: cannot create cold backend (%s): %v", name, err)
+		}
+
+		age := defaultArchiveAge
+		if value, ok := options["archiveolderthan"].(string); ok {
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("tier middleware: invalid archiveolderthan %q: %v", value, err)
+			}
+			age = parsed
+		}
+
+		tiered := newTieredDriver(driver, cold, age)
+		RegisterMaintenanceTask(MaintenanceTask{
+			Name:     "archive-cold-blobs",
+			Interval: archiveInterval,
+			Run:      tiered.archive,
+		})
+		return tiered, nil
+	})
+}
+
+// tieredDriver keeps recently used blobs on the hot backend and blobs untouched for the
+// configured age on the cold one. Reads check the hot backend first and fall back to the cold
+// tier, counting the retrieval and promoting the blob back to hot in the background.
+type tieredDriver struct {
+	storagedriver.StorageDriver
+
+	cold storagedriver.StorageDriver
+	age  time.Duration
+
+	// mu guards lastAccess, a best-effort record of blob data reads used by the archiver.
+	mu         sync.Mutex
+	lastAccess map[string]time.Time
+}
+
+var _ storagedriver.StorageDriver = &tieredDriver{}
+
+func newTieredDriver(hot, cold storagedriver.StorageDriver, age time.Duration) *tieredDriver {
+	return &tieredDriver{
+		StorageDriver: hot,
+		cold:          cold,
+		age:           age,
+		lastAccess:    make(map[string]time.Time),
+	}
+}
+
+// isBlobData returns true for the data files of blobs - the only paths worth tiering.
+func isBlobData(path string) bool {
+	return strings.Contains(path, "/blobs/") && strings.HasSuffix(path, "/data")
+}
+
+func (d *tieredDriver) touch(path string) {
+	if !isBlobData(path) {
+		return
+	}
+	d.mu.Lock()
+	d.lastAccess[path] = time.Now()
+	d.mu.Unlock()
+}
+
+func (d *tieredDriver) lastUsed(path string, fallback time.Time) time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if when, ok := d.lastAccess[path]; ok {
+		return when
+	}
+	return fallback
+}
+
+func (d *tieredDriver) GetContent(ctx context.Context, path string) ([]byte, error) {
+	d.touch(path)
+	content, err := d.StorageDriver.GetContent(ctx, path)
+	if err == nil || !isNotFound(err) || !isBlobData(path) {
+		return content, err
+	}
+	content, cerr := d.cold.GetContent(ctx, path)
+	if cerr != nil {
+		return nil, err
+	}
+	retrievalPendingPulls.Add(1)
+	go d.promote(ctx, path)
+	return content, nil
+}
+
+func (d *tieredDriver) Reader(ctx context.Context, path string, offset int64) (io.ReadCloser, error) {
+	d.touch(path)
+	reader, err := d.StorageDriver.Reader(ctx, path, offset)
+	if err == nil || !isNotFound(err) || !isBlobData(path) {
+		return reader, err
+	}
+	reader, cerr := d.cold.Reader(ctx, path, offset)
+	if cerr != nil {
+		return nil, err
+	}
+	retrievalPendingPulls.Add(1)
+	go d.promote(ctx, path)
+	return reader, nil
+}
+
+func (d *tieredDriver) Stat(ctx context.Context, path string) (storagedriver.FileInfo, error) {
+	fi, err := d.StorageDriver.Stat(ctx, path)
+	if err == nil || !isNotFound(err) || !isBlobData(path) {
+		return fi, err
+	}
+	if fi, cerr := d.cold.Stat(ctx, path); cerr == nil {
+		return fi, nil
+	}
+	return nil, err
+}
+
+// promote copies an archived blob back to the hot backend so subsequent pulls are fast again.
+func (d *tieredDriver) promote(ctx context.Context, path string) {
+	if err := copyPath(ctx, d.cold, d.StorageDriver, path); err != nil {
+		context.GetLogger(ctx).Errorf("cannot promote blob %s from the cold tier: %v", path, err)
+		return
+	}
+	if err := d.cold.Delete(ctx, path); err != nil {
+		context.GetLogger(ctx).Errorf("cannot remove promoted blob %s from the cold tier: %v", path, err)
+	}
+	d.touch(path)
+}
+
+// archive moves blob data untouched for the configured age to the cold backend. It runs as a
+// leader-elected maintenance task.
+func (d *tieredDriver) archive(ctx context.Context) error {
+	cutoff := time.Now().Add(-d.age)
+	archived := 0
+	err := d.walk(ctx, "/docker/registry/v2/blobs", func(fi storagedriver.FileInfo) error {
+		path := fi.Path()
+		if !isBlobData(path) {
+			return nil
+		}
+		if d.lastUsed(path, fi.ModTime()).After(cutoff) {
+			return nil
+		}
+		if err := copyPath(ctx, d.StorageDriver, d.cold, path); err != nil {
+			return err
+		}
+		if err := d.StorageDriver.Delete(ctx, path); err != nil {
+			return err
+		}
+		archived++
+		return nil
+	})
+	if archived > 0 {
+		context.GetLogger(ctx).Infof("archived %d cold blobs", archived)
+	}
+	return err
+}
+
+// walk visits every file below path on the hot backend.
+func (d *tieredDriver) walk(ctx context.Context, path string, visit func(storagedriver.FileInfo) error) error {
+	entries, err := d.StorageDriver.List(ctx, path)
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		fi, err := d.StorageDriver.Stat(ctx, entry)
+		if err != nil {
+			if isNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if fi.IsDir() {
+			if err := d.walk(ctx, entry, visit); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := visit(fi); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyPath streams a single file between backends.
+func copyPath(ctx context.Context, from, to storagedriver.StorageDriver, path string) error {
+	return copyBetweenDrivers(ctx, from, to, path, path)
+}
+
+// copyBetweenDrivers streams a file from one backend to another.
+func copyBetweenDrivers(ctx context.Context, from, to storagedriver.StorageDriver, fromPath, toPath string) error {
+	reader, err := from.Reader(ctx, fromPath, 0)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	writer, err := to.Writer(ctx, toPath, false)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(writer, reader); err != nil {
+		writer.Cancel()
+		return err
+	}
+	if err := writer.Commit(); err != nil {
+		return err
+	}
+	return writer.Close()
+}
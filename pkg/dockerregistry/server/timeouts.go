@@ -0,0 +1,166 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// The registry used to run every outbound call under one global client timeout, so a slow
+// upstream could consume the budget needed for the mapping creation at the end of a push. These
+// variables configure distinct deadlines per operation; a zero value disables the deadline.
+const (
+	// AuthTimeoutEnvVar bounds token verification and SAR calls made during request auth.
+	AuthTimeoutEnvVar = "REGISTRY_TIMEOUT_AUTH"
+	// ManifestPutTimeoutEnvVar bounds the ImageStreamMapping creation finishing a push.
+	ManifestPutTimeoutEnvVar = "REGISTRY_TIMEOUT_MANIFEST_PUT"
+	// BlobUploadTimeoutEnvVar bounds the total duration of one blob upload session.
+	BlobUploadTimeoutEnvVar = "REGISTRY_TIMEOUT_BLOB_UPLOAD"
+	// PullthroughTimeoutEnvVar bounds requests to remote registries made for pullthrough.
+	PullthroughTimeoutEnvVar = "REGISTRY_TIMEOUT_PULLTHROUGH"
+)
+
+// registryTimeouts holds the per-operation deadlines.
+type registryTimeouts struct {
+	auth        time.Duration
+	manifestPut time.Duration
+	blobUpload  time.Duration
+	pullthrough time.Duration
+}
+
+var operationTimeouts = readOperationTimeouts()
+
+func readOperationTimeouts() registryTimeouts {
+	return registryTimeouts{
+		auth:        timeoutFromEnv(AuthTimeoutEnvVar, 10*time.Second),
+		manifestPut: timeoutFromEnv(ManifestPutTimeoutEnvVar, 30*time.Second),
+		blobUpload:  timeoutFromEnv(BlobUploadTimeoutEnvVar, 0),
+		pullthrough: timeoutFromEnv(PullthroughTimeoutEnvVar, time.Minute),
+	}
+}
+
+func timeoutFromEnv(name string, defval time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if len(value) == 0 {
+		return defval
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		log.Errorf("ignoring invalid %s=%q", name, value)
+		return defval
+	}
+	return timeout
+}
+
+// requestCanceler is implemented by transports able to abort an in-flight request.
+type requestCanceler interface {
+	CancelRequest(*http.Request)
+}
+
+// timeoutRoundTripper aborts requests exceeding the deadline.
+type timeoutRoundTripper struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+func withRequestTimeout(rt http.RoundTripper, timeout time.Duration) http.RoundTripper {
+	if timeout <= 0 {
+		return rt
+	}
+	return &timeoutRoundTripper{base: rt, timeout: timeout}
+}
+
+func (t *timeoutRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	type result struct {
+		resp *http.Response
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := t.base.RoundTrip(req)
+		done <- result{resp: resp, err: err}
+	}()
+	select {
+	case r := <-done:
+		return r.resp, r.err
+	case <-time.After(t.timeout):
+		if canceler, ok := t.base.(requestCanceler); ok {
+			canceler.CancelRequest(req)
+		}
+		return nil, fmt.Errorf("request to %s exceeded the %s deadline", req.URL.Host, t.timeout)
+	}
+}
+
+// withBackendTimeouts bounds the mapping creation finishing a push. The other backend calls are
+// covered by the API client's own transport.
+func withBackendTimeouts(backend imageStreamBackend) imageStreamBackend {
+	if operationTimeouts.manifestPut <= 0 {
+		return backend
+	}
+	return timeoutBackend{imageStreamBackend: backend, timeout: operationTimeouts.manifestPut}
+}
+
+type timeoutBackend struct {
+	imageStreamBackend
+
+	timeout time.Duration
+}
+
+func (b timeoutBackend) CreateImageStreamMapping(namespace string, ism *imageapi.ImageStreamMapping) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- b.imageStreamBackend.CreateImageStreamMapping(namespace, ism)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(b.timeout):
+		return fmt.Errorf("creating the image stream mapping for %s/%s exceeded the %s deadline", namespace, ism.Name, b.timeout)
+	}
+}
+
+// deadlineBlobStore bounds the total duration of each upload session.
+type deadlineBlobStore struct {
+	distribution.BlobStore
+}
+
+var _ distribution.BlobStore = &deadlineBlobStore{}
+
+func (bs *deadlineBlobStore) Create(ctx context.Context, options ...distribution.BlobCreateOption) (distribution.BlobWriter, error) {
+	bw, err := bs.BlobStore.Create(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &deadlineBlobWriter{BlobWriter: bw, deadline: time.Now().Add(operationTimeouts.blobUpload)}, nil
+}
+
+func (bs *deadlineBlobStore) Resume(ctx context.Context, id string) (distribution.BlobWriter, error) {
+	bw, err := bs.BlobStore.Resume(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// resumed sessions keep only the remaining budget from the start time of the session
+	deadline := bw.StartedAt().Add(operationTimeouts.blobUpload)
+	return &deadlineBlobWriter{BlobWriter: bw, deadline: deadline}, nil
+}
+
+type deadlineBlobWriter struct {
+	distribution.BlobWriter
+
+	deadline time.Time
+}
+
+func (bw *deadlineBlobWriter) Write(p []byte) (int, error) {
+	if time.Now().After(bw.deadline) {
+		return 0, fmt.Errorf("blob upload exceeded the %s deadline", operationTimeouts.blobUpload)
+	}
+	return bw.BlobWriter.Write(p)
+}
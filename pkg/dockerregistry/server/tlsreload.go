@@ -0,0 +1,163 @@
+package server
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution/context"
+)
+
+// TLSReloadIntervalEnvVar is how often the serving certificate, key and client CA files are
+// checked for changes. Changed material is picked up by new connections without restarting the
+// process, so certificate rotation does not interrupt in-flight pushes. "0" disables reloading.
+const TLSReloadIntervalEnvVar = "REGISTRY_TLS_RELOAD_INTERVAL"
+
+const defaultTLSReloadInterval = time.Minute
+
+// TLSReloader serves TLS from certificate files that may be rotated underneath the process.
+// Connections handshake with the most recently loaded material; connections already established
+// are unaffected by a reload.
+type TLSReloader struct {
+	base     *tls.Config
+	certFile string
+	keyFile  string
+	caFiles  []string
+
+	mu          sync.Mutex
+	current     *tls.Config
+	fingerprint [sha256.Size]byte
+}
+
+// NewTLSReloader loads the initial TLS material, failing hard on errors just like serving
+// directly from the files would.
+func NewTLSReloader(base *tls.Config, certFile, keyFile string, caFiles []string) (*TLSReloader, error) {
+	r := &TLSReloader{
+		base:     base,
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFiles:  caFiles,
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload reads the configured files and swaps in a fresh config when their content changed.
+func (r *TLSReloader) reload() error {
+	contents, err := r.readFiles()
+	if err != nil {
+		return err
+	}
+	fingerprint := sha256.Sum256(contents)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.current != nil && fingerprint == r.fingerprint {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	// tls.Config contains a mutex, so the base settings are copied field by field rather than
+	// by dereferencing
+	config := tls.Config{
+		MinVersion:               r.base.MinVersion,
+		CipherSuites:             r.base.CipherSuites,
+		PreferServerCipherSuites: r.base.PreferServerCipherSuites,
+		NextProtos:               r.base.NextProtos,
+		ClientAuth:               r.base.ClientAuth,
+		Certificates:             []tls.Certificate{cert},
+	}
+
+	if len(r.caFiles) > 0 {
+		pool := x509.NewCertPool()
+		for _, ca := range r.caFiles {
+			caPem, err := ioutil.ReadFile(ca)
+			if err != nil {
+				return err
+			}
+			if ok := pool.AppendCertsFromPEM(caPem); !ok {
+				return fmt.Errorf("could not add CA %s to pool", ca)
+			}
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		if clientCertOptional() {
+			// certificates stay optional at the listener; the per-namespace policy
+			// decides which requests must have presented one
+			config.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+		config.ClientCAs = pool
+	}
+
+	r.current = &config
+	r.fingerprint = fingerprint
+	return nil
+}
+
+func (r *TLSReloader) readFiles() ([]byte, error) {
+	contents := []byte{}
+	for _, file := range append([]string{r.certFile, r.keyFile}, r.caFiles...) {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, data...)
+	}
+	return contents, nil
+}
+
+func (r *TLSReloader) config() *tls.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// ListenAndServe serves srv over TLS, rechecking the certificate files in the background. Each
+// accepted connection handshakes with the config current at accept time.
+func (r *TLSReloader) ListenAndServe(ctx context.Context, srv *http.Server) error {
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return err
+	}
+
+	if interval := timeoutFromEnv(TLSReloadIntervalEnvVar, defaultTLSReloadInterval); interval > 0 {
+		go func() {
+			for {
+				time.Sleep(interval)
+				if err := r.reload(); err != nil {
+					context.GetLogger(ctx).Errorf("error reloading TLS material, keeping the previous certificates: %v", err)
+				}
+			}
+		}()
+	}
+
+	return srv.Serve(reloadingTLSListener{Listener: ln, reloader: r})
+}
+
+type reloadingTLSListener struct {
+	net.Listener
+
+	reloader *TLSReloader
+}
+
+func (l reloadingTLSListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		tcpConn.SetKeepAlive(true)
+		tcpConn.SetKeepAlivePeriod(3 * time.Minute)
+	}
+	return tls.Server(conn, l.reloader.config()), nil
+}
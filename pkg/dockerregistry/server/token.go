@@ -5,22 +5,22 @@ import (
 	"net/http"
 
 	context "github.com/docker/distribution/context"
-
-	"k8s.io/kubernetes/pkg/client/restclient"
-
-	"github.com/openshift/origin/pkg/client"
 )
 
 type tokenHandler struct {
-	ctx             context.Context
-	anonymousConfig restclient.Config
+	ctx           context.Context
+	authenticator Authenticator
+	authorizer    Authorizer
 }
 
 // NewTokenHandler returns a handler that implements the docker token protocol
 func NewTokenHandler(ctx context.Context, client *RegistryClient) http.Handler {
 	return &tokenHandler{
-		ctx:             ctx,
-		anonymousConfig: client.SafeClientConfig(),
+		ctx: ctx,
+		authenticator: NewTokenAuthenticator(AuthenticatorOptions{
+			AnonymousConfig: client.SafeClientConfig(),
+		}),
+		authorizer: NewSARAuthorizer(AuthorizerOptions{}),
 	}
 }
 
@@ -47,16 +47,14 @@ func (t *tokenHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 
 	// TODO: if this doesn't validate as an API token, attempt to obtain an API token using the given username/password
-	copied := t.anonymousConfig
-	copied.BearerToken = token
-	osClient, err := client.New(&copied)
+	osClient, err := t.authenticator.ClientFor(token)
 	if err != nil {
 		context.GetRequestLogger(ctx).Errorf("error building client: %v", err)
 		t.writeError(w, req)
 		return
 	}
 
-	if _, err := osClient.Users().Get("~"); err != nil {
+	if err := t.authorizer.VerifyUser(ctx, osClient); err != nil {
 		context.GetRequestLogger(ctx).Debugf("invalid token: %v", err)
 		t.writeUnauthorized(w, req)
 		return
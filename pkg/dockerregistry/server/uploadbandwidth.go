@@ -0,0 +1,196 @@
+package server
+
+import (
+	"expvar"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+)
+
+const (
+	// UploadBandwidthLimitEnvVar caps how many bytes per second of blob uploads a single
+	// namespace may ingest. Every concurrent upload of the namespace draws from the same
+	// budget, so one project's massive pushes cannot starve others on a shared registry.
+	// Zero (the default) means unlimited.
+	UploadBandwidthLimitEnvVar = "REGISTRY_UPLOAD_BANDWIDTH_LIMIT"
+
+	// UploadBandwidthAnnotation is a namespace annotation overriding the registry wide upload
+	// bandwidth limit, in bytes per second. "0" lifts the limit for the namespace.
+	UploadBandwidthAnnotation = "openshift.io/upload-bandwidth-limit"
+)
+
+var uploadBandwidthDefault = readUploadBandwidthDefault()
+
+// uploadBandwidthMetrics counts how often uploads had to wait for bandwidth budget.
+var uploadBandwidthMetrics = expvar.NewMap("registry.uploadBandwidth")
+
+func readUploadBandwidthDefault() int64 {
+	value := os.Getenv(UploadBandwidthLimitEnvVar)
+	if len(value) == 0 {
+		return 0
+	}
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit < 0 {
+		log.Errorf("ignoring invalid %s=%q", UploadBandwidthLimitEnvVar, value)
+		return 0
+	}
+	return limit
+}
+
+// uploadBandwidthLimitFor returns the bytes per second budget for the repository's namespace.
+// The namespace annotation takes precedence over the registry wide default; lookup errors fall
+// back to the default, mirroring the media type policy.
+func uploadBandwidthLimitFor(repo *repository) int64 {
+	ns, err := repo.nsClient.Namespaces().Get(repo.namespace)
+	if err != nil {
+		context.GetLogger(repo.ctx).Errorf("failed to get namespace %s for bandwidth policy: %v", repo.namespace, err)
+		return uploadBandwidthDefault
+	}
+	if value, ok := ns.Annotations[UploadBandwidthAnnotation]; ok {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || limit < 0 {
+			context.GetLogger(repo.ctx).Errorf("ignoring invalid %s annotation on namespace %s: %q", UploadBandwidthAnnotation, repo.namespace, value)
+			return uploadBandwidthDefault
+		}
+		return limit
+	}
+	return uploadBandwidthDefault
+}
+
+// uploadBuckets shares one token bucket per namespace, so concurrent uploads of a project are
+// throttled together.
+var uploadBuckets = &uploadBucketRegistry{buckets: map[string]*tokenBucket{}}
+
+type uploadBucketRegistry struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+func (r *uploadBucketRegistry) bucket(namespace string, rate int64) *tokenBucket {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.buckets[namespace]
+	if !ok {
+		b = &tokenBucket{last: time.Now()}
+		r.buckets[namespace] = b
+	}
+	b.setRate(rate)
+	return b
+}
+
+// tokenBucket meters bytes against a refilling budget. Writers may overdraw it and then sleep
+// off the debt, which keeps single writes larger than the burst from deadlocking.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   int64
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) setRate(rate int64) {
+	b.mu.Lock()
+	b.rate = rate
+	b.mu.Unlock()
+}
+
+// wait charges n bytes against the budget and blocks until the bucket is out of debt.
+func (b *tokenBucket) wait(n int) {
+	b.mu.Lock()
+	rate := b.rate
+	if rate <= 0 {
+		b.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * float64(rate)
+	b.last = now
+	// allow a burst of at most one second of budget
+	if max := float64(rate); b.tokens > max {
+		b.tokens = max
+	}
+	b.tokens -= float64(n)
+	var sleep time.Duration
+	if b.tokens < 0 {
+		sleep = time.Duration(-b.tokens / float64(rate) * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if sleep > 0 {
+		uploadBandwidthMetrics.Add("waits", 1)
+		time.Sleep(sleep)
+	}
+}
+
+// throttledBlobStore wraps blob writers of a namespace with its bandwidth budget.
+type throttledBlobStore struct {
+	distribution.BlobStore
+
+	repo *repository
+}
+
+var _ distribution.BlobStore = &throttledBlobStore{}
+
+func (bs *throttledBlobStore) Create(ctx context.Context, options ...distribution.BlobCreateOption) (distribution.BlobWriter, error) {
+	bw, err := bs.BlobStore.Create(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+	return bs.throttle(bw), nil
+}
+
+func (bs *throttledBlobStore) Resume(ctx context.Context, id string) (distribution.BlobWriter, error) {
+	bw, err := bs.BlobStore.Resume(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return bs.throttle(bw), nil
+}
+
+// throttle looks the namespace budget up at upload start; pulls and other requests never pay
+// for the namespace lookup.
+func (bs *throttledBlobStore) throttle(bw distribution.BlobWriter) distribution.BlobWriter {
+	limit := uploadBandwidthLimitFor(bs.repo)
+	if limit <= 0 {
+		return bw
+	}
+	return &throttledBlobWriter{
+		BlobWriter: bw,
+		bucket:     uploadBuckets.bucket(bs.repo.namespace, limit),
+	}
+}
+
+// throttledBlobWriter meters every byte written against the namespace bucket.
+type throttledBlobWriter struct {
+	distribution.BlobWriter
+
+	bucket *tokenBucket
+}
+
+func (bw *throttledBlobWriter) Write(p []byte) (int, error) {
+	bw.bucket.wait(len(p))
+	return bw.BlobWriter.Write(p)
+}
+
+func (bw *throttledBlobWriter) ReadFrom(r io.Reader) (int64, error) {
+	return bw.BlobWriter.ReadFrom(&throttledReader{r: r, bucket: bw.bucket})
+}
+
+type throttledReader struct {
+	r      io.Reader
+	bucket *tokenBucket
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.bucket.wait(n)
+	}
+	return n, err
+}
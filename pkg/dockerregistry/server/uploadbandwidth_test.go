@@ -0,0 +1,50 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucketUnlimited(t *testing.T) {
+	b := &tokenBucket{last: time.Now()}
+	start := time.Now()
+	b.wait(1 << 30)
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("wait on an unlimited bucket blocked for %v", elapsed)
+	}
+}
+
+func TestTokenBucketCharges(t *testing.T) {
+	b := &tokenBucket{rate: 1000, tokens: 1000, last: time.Now()}
+
+	start := time.Now()
+	b.wait(600)
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("wait within the budget blocked for %v", elapsed)
+	}
+	if b.tokens > 500 {
+		t.Errorf("expected roughly 400 tokens left, got %v", b.tokens)
+	}
+
+	// overdraw the remaining budget; the debt is slept off
+	start = time.Now()
+	b.wait(600)
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("wait past the budget returned after only %v", elapsed)
+	}
+}
+
+func TestUploadBucketRegistryShares(t *testing.T) {
+	registry := &uploadBucketRegistry{buckets: map[string]*tokenBucket{}}
+	first := registry.bucket("alpha", 100)
+	second := registry.bucket("alpha", 200)
+	if first != second {
+		t.Error("expected one shared bucket per namespace")
+	}
+	if first.rate != 200 {
+		t.Errorf("expected the rate to follow the latest lookup, got %d", first.rate)
+	}
+	if other := registry.bucket("beta", 100); other == first {
+		t.Error("expected namespaces to get distinct buckets")
+	}
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution/context"
+	ctxu "github.com/docker/distribution/context"
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/distribution/registry/api/v2"
+	"github.com/docker/distribution/registry/handlers"
+	"github.com/docker/distribution/registry/storage"
+	storagedriver "github.com/docker/distribution/registry/storage/driver"
+	gorillahandlers "github.com/gorilla/handlers"
+
+	kutilerrors "k8s.io/kubernetes/pkg/util/errors"
+)
+
+// UploadPurgeAgeEnvVar configures how long an upload session may stay untouched before the purge
+// maintenance task removes it. The value is a duration; "off" disables purging. Abandoned CI
+// pushes otherwise leak their temporary upload space forever.
+const UploadPurgeAgeEnvVar = "REGISTRY_UPLOAD_PURGE_AGE"
+
+const (
+	defaultUploadPurgeAge = 24 * time.Hour
+
+	// uploadPurgeInterval is how often the purge maintenance task runs on the leader replica.
+	uploadPurgeInterval = time.Hour
+
+	// repositoriesRootPath is where repository data, including upload sessions, lives on the
+	// storage driver.
+	repositoriesRootPath = "/docker/registry/v2/repositories"
+)
+
+// uploadPurgeAge returns the configured purge age and whether purging is enabled.
+func uploadPurgeAge() (time.Duration, bool) {
+	value := os.Getenv(UploadPurgeAgeEnvVar)
+	switch {
+	case len(value) == 0:
+		return defaultUploadPurgeAge, true
+	case value == "off":
+		return 0, false
+	}
+	age, err := time.ParseDuration(value)
+	if err != nil {
+		context.GetLogger(context.Background()).Errorf("invalid %s=%q: %v, using %s", UploadPurgeAgeEnvVar, value, err, defaultUploadPurgeAge)
+		return defaultUploadPurgeAge, true
+	}
+	return age, true
+}
+
+// purgeAbandonedUploads removes upload sessions older than the configured age. It runs as a
+// leader-elected maintenance task.
+func purgeAbandonedUploads(ctx context.Context) error {
+	age, enabled := uploadPurgeAge()
+	if !enabled {
+		return nil
+	}
+	deleted, errs := storage.PurgeUploads(ctx, dockerStorageDriver, time.Now().Add(-age), true)
+	if len(deleted) > 0 {
+		context.GetLogger(ctx).Infof("purged %d abandoned upload sessions", len(deleted))
+	}
+	return kutilerrors.NewAggregate(errs)
+}
+
+// uploadSession describes an in-flight blob upload.
+type uploadSession struct {
+	Repository string    `json:"repository"`
+	UUID       string    `json:"uuid"`
+	StartedAt  time.Time `json:"startedAt"`
+}
+
+// listUploadSessions walks the repositories tree and collects every upload session that has
+// recorded its start time.
+func listUploadSessions(ctx context.Context) ([]uploadSession, error) {
+	sessions := []uploadSession{}
+	err := storage.Walk(ctx, dockerStorageDriver, repositoriesRootPath, func(fileInfo storagedriver.FileInfo) error {
+		filePath := fileInfo.Path()
+		base := path.Base(filePath)
+		if fileInfo.IsDir() {
+			// only repository directories and _uploads are worth descending into
+			if base[0] == '_' && base != "_uploads" {
+				return storage.ErrSkipDir
+			}
+			return nil
+		}
+		if base != "startedat" {
+			return nil
+		}
+		relative := strings.TrimPrefix(filePath, repositoriesRootPath+"/")
+		parts := strings.Split(relative, "/_uploads/")
+		if len(parts) != 2 {
+			return nil
+		}
+		session := uploadSession{
+			Repository: parts[0],
+			UUID:       path.Dir(parts[1]),
+		}
+		if content, err := dockerStorageDriver.GetContent(ctx, filePath); err == nil {
+			if started, err := time.Parse(time.RFC3339, string(content)); err == nil {
+				session.StartedAt = started
+			}
+		}
+		sessions = append(sessions, session)
+		return nil
+	})
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// UploadsDispatcher takes the request context and builds the appropriate handler for listing and
+// cancelling in-flight blob upload sessions.
+func UploadsDispatcher(ctx *handlers.Context, r *http.Request) http.Handler {
+	uploadsHandler := &uploadsHandler{
+		Context: ctx,
+		UUID:    ctxu.GetStringValue(ctx, "vars.uuid"),
+	}
+
+	return gorillahandlers.MethodHandler{
+		"GET":    http.HandlerFunc(uploadsHandler.List),
+		"DELETE": http.HandlerFunc(uploadsHandler.Cancel),
+	}
+}
+
+// uploadsHandler handles http operations on upload sessions.
+type uploadsHandler struct {
+	*handlers.Context
+
+	UUID string
+}
+
+// List writes all in-flight upload sessions as JSON.
+func (uh *uploadsHandler) List(w http.ResponseWriter, req *http.Request) {
+	sessions, err := listUploadSessions(uh)
+	if err != nil {
+		uh.Errors = append(uh.Errors, errcode.ErrorCodeUnknown.WithDetail(fmt.Sprintf("error listing upload sessions: %v", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(map[string][]uploadSession{"uploads": sessions}); err != nil {
+		uh.Errors = append(uh.Errors, errcode.ErrorCodeUnknown.WithDetail(err))
+	}
+}
+
+// Cancel removes the upload session identified by its uuid, reclaiming its temporary space.
+func (uh *uploadsHandler) Cancel(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	if len(uh.UUID) == 0 {
+		uh.Errors = append(uh.Errors, v2.ErrorCodeBlobUploadUnknown)
+		return
+	}
+
+	sessions, err := listUploadSessions(uh)
+	if err != nil {
+		uh.Errors = append(uh.Errors, errcode.ErrorCodeUnknown.WithDetail(fmt.Sprintf("error listing upload sessions: %v", err)))
+		return
+	}
+	for _, session := range sessions {
+		if session.UUID != uh.UUID {
+			continue
+		}
+		sessionDir := path.Join(repositoriesRootPath, session.Repository, "_uploads", session.UUID)
+		if err := dockerStorageDriver.Delete(uh, sessionDir); err != nil && !isNotFound(err) {
+			uh.Errors = append(uh.Errors, errcode.ErrorCodeUnknown.WithDetail(fmt.Sprintf("error cancelling upload %s: %v", uh.UUID, err)))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	uh.Errors = append(uh.Errors, v2.ErrorCodeBlobUploadUnknown)
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"expvar"
+	"os"
+	"sync"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+)
+
+// UploadCoalesceEnvVar set to "true" additionally coalesces identical uploads that commit at
+// the same moment: the first commit proceeds and the others wait for it, then link to its
+// result. Duplicate detection at commit time is always on; coalescing only matters in
+// CI-heavy clusters where many pipelines push the same base layers simultaneously, and it
+// holds the later commits open while the first one finishes. Recognized values are "true" and
+// "false".
+const UploadCoalesceEnvVar = "REGISTRY_UPLOAD_COALESCE"
+
+// uploadDedupeMetrics counts uploads that were linked to an already-committed copy instead of
+// being written again.
+var uploadDedupeMetrics = expvar.NewMap("registry.uploadDedupe")
+
+func coalesceUploads() bool {
+	return os.Getenv(UploadCoalesceEnvVar) == "true"
+}
+
+// uploadCommitGroup tracks blob digests with a commit in flight, so identical commits can wait
+// for the leader instead of racing it.
+type uploadCommitGroup struct {
+	mu      sync.Mutex
+	commits map[string]chan struct{}
+}
+
+var uploadCommits = &uploadCommitGroup{commits: map[string]chan struct{}{}}
+
+// begin reports whether the caller is the leader for the digest. Followers receive the channel
+// the leader closes when its commit finishes.
+func (g *uploadCommitGroup) begin(dgst string) (bool, chan struct{}) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if done, ok := g.commits[dgst]; ok {
+		return false, done
+	}
+	done := make(chan struct{})
+	g.commits[dgst] = done
+	return true, done
+}
+
+func (g *uploadCommitGroup) finish(dgst string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if done, ok := g.commits[dgst]; ok {
+		close(done)
+		delete(g.commits, dgst)
+	}
+}
+
+// dedupBlobStore links commits of already-present blobs to the existing copy instead of
+// writing the content a second time. It sits below the pull-through layer so only blobs the
+// local store already holds count as duplicates; content that merely exists upstream is still
+// committed locally.
+type dedupBlobStore struct {
+	distribution.BlobStore
+}
+
+var _ distribution.BlobStore = &dedupBlobStore{}
+
+func (bs *dedupBlobStore) Create(ctx context.Context, options ...distribution.BlobCreateOption) (distribution.BlobWriter, error) {
+	bw, err := bs.BlobStore.Create(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &dedupBlobWriter{BlobWriter: bw, store: bs.BlobStore}, nil
+}
+
+func (bs *dedupBlobStore) Resume(ctx context.Context, id string) (distribution.BlobWriter, error) {
+	bw, err := bs.BlobStore.Resume(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &dedupBlobWriter{BlobWriter: bw, store: bs.BlobStore}, nil
+}
+
+type dedupBlobWriter struct {
+	distribution.BlobWriter
+
+	store distribution.BlobStore
+}
+
+// Commit drops the upload and returns the existing descriptor when the blob is already
+// committed. The bandwidth was already spent by the time a duplicate is detectable, but the
+// second copy is never moved into the store, which is where the storage churn is.
+func (bw *dedupBlobWriter) Commit(ctx context.Context, provisional distribution.Descriptor) (distribution.Descriptor, error) {
+	dgst := provisional.Digest
+	if len(dgst) == 0 {
+		return bw.BlobWriter.Commit(ctx, provisional)
+	}
+
+	if desc, err := bw.store.Stat(ctx, dgst); err == nil {
+		bw.discard(ctx, "existing", desc.Size)
+		return desc, nil
+	}
+
+	if coalesceUploads() {
+		for {
+			leader, done := uploadCommits.begin(dgst.String())
+			if leader {
+				break
+			}
+			<-done
+			// the leader finished; if it succeeded, link to its copy
+			if desc, err := bw.store.Stat(ctx, dgst); err == nil {
+				bw.discard(ctx, "coalesced", desc.Size)
+				return desc, nil
+			}
+			// the leader failed, try to take its place
+		}
+		defer uploadCommits.finish(dgst.String())
+	}
+
+	return bw.BlobWriter.Commit(ctx, provisional)
+}
+
+// discard cancels the duplicate upload session, counting but not failing on cleanup errors.
+func (bw *dedupBlobWriter) discard(ctx context.Context, reason string, size int64) {
+	uploadDedupeMetrics.Add(reason, 1)
+	uploadDedupeMetrics.Add("bytesSaved", size)
+	if err := bw.BlobWriter.Cancel(ctx); err != nil {
+		context.GetLogger(ctx).Warnf("error canceling duplicate upload %s: %v", bw.ID(), err)
+	}
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUploadCommitGroupLeaderAndFollower(t *testing.T) {
+	group := &uploadCommitGroup{commits: map[string]chan struct{}{}}
+
+	leader, _ := group.begin("sha256:abc")
+	if !leader {
+		t.Fatal("first caller should lead")
+	}
+	follower, done := group.begin("sha256:abc")
+	if follower {
+		t.Fatal("second caller should follow")
+	}
+	// an unrelated digest gets its own leader
+	if other, _ := group.begin("sha256:def"); !other {
+		t.Error("unrelated digest should lead")
+	}
+
+	select {
+	case <-done:
+		t.Fatal("follower released before the leader finished")
+	default:
+	}
+
+	group.finish("sha256:abc")
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("follower not released after the leader finished")
+	}
+
+	// the digest is free again after the leader finishes
+	if leader, _ := group.begin("sha256:abc"); !leader {
+		t.Error("digest should be free after finish")
+	}
+}
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/openshift/origin/pkg/image/importer"
+)
+
+// UpstreamCredentialsFileEnvVar points at a .dockercfg or docker config.json file whose
+// credentials are used for pull-through against upstream registries, in addition to any
+// dockercfg secrets of the image stream. A file mounted from a secret is the simplest way to
+// give the registry cluster-wide credentials for a mirrored upstream.
+const UpstreamCredentialsFileEnvVar = "REGISTRY_UPSTREAM_CREDENTIALS_FILE"
+
+func init() {
+	path := os.Getenv(UpstreamCredentialsFileEnvVar)
+	if len(path) == 0 {
+		return
+	}
+	provider, err := importer.NewFileCredentialProvider("upstream-credentials-file", path)
+	if err != nil {
+		log.Errorf("ignoring %s=%q: %v", UpstreamCredentialsFileEnvVar, path, err)
+		return
+	}
+	importer.RegisterCredentialProvider(provider)
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/docker/distribution/context"
+)
+
+// V1DeprecationMessageEnvVar overrides the message returned by the legacy v1 endpoints. Old
+// tooling probing /v1/_ping or /v1/search otherwise fails with an unexplained 404.
+const V1DeprecationMessageEnvVar = "REGISTRY_V1_DEPRECATION_MESSAGE"
+
+// V1SearchRedirectEnvVar redirects /v1/search requests to the given URL (the query string is
+// preserved) instead of answering with the deprecation payload.
+const V1SearchRedirectEnvVar = "REGISTRY_V1_SEARCH_REDIRECT"
+
+const defaultV1DeprecationMessage = "the v1 registry API is not supported; use the v2 API"
+
+// v1DeprecationPayload is the machine-readable body returned for legacy v1 requests.
+type v1DeprecationPayload struct {
+	Deprecated bool   `json:"deprecated"`
+	Message    string `json:"message"`
+}
+
+// NewV1CompatHandler answers the legacy v1 endpoints with an informative, machine-readable
+// deprecation payload, or redirects search to a configured replacement.
+func NewV1CompatHandler(ctx context.Context) http.Handler {
+	message := os.Getenv(V1DeprecationMessageEnvVar)
+	if len(message) == 0 {
+		message = defaultV1DeprecationMessage
+	}
+	searchRedirect := os.Getenv(V1SearchRedirectEnvVar)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/search" && len(searchRedirect) > 0 {
+			target := searchRedirect
+			if len(r.URL.RawQuery) > 0 {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusTemporaryRedirect)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusGone)
+		if err := json.NewEncoder(w).Encode(v1DeprecationPayload{Deprecated: true, Message: message}); err != nil {
+			context.GetLogger(ctx).Errorf("error writing v1 deprecation payload: %v", err)
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/distribution/registry/handlers"
+	gorillahandlers "github.com/gorilla/handlers"
+)
+
+// WarmupState describes the progress of a single tag warming job.
+type WarmupState string
+
+const (
+	WarmupStateRunning  WarmupState = "Running"
+	WarmupStateComplete WarmupState = "Complete"
+	WarmupStateFailed   WarmupState = "Failed"
+)
+
+// WarmupStatus is the status object reported for a warming job. Warming fetches the manifest of
+// a tag and stats every referenced blob through the pullthrough layer, so subsequent node pulls
+// hit warm caches instead of cold upstreams.
+type WarmupStatus struct {
+	Name      string      `json:"name"`
+	Tag       string      `json:"tag"`
+	State     WarmupState `json:"state"`
+	Blobs     int         `json:"blobs"`
+	BlobsDone int         `json:"blobsDone"`
+	Error     string      `json:"error,omitempty"`
+	Started   time.Time   `json:"started"`
+	Finished  *time.Time  `json:"finished,omitempty"`
+}
+
+// warmupJobs tracks running and finished warming jobs keyed by repository and tag. Kept in
+// memory - warming is an optimization and losing progress on restart only costs a repeated
+// request.
+var warmupJobs = struct {
+	sync.Mutex
+	jobs map[string]*WarmupStatus
+}{jobs: make(map[string]*WarmupStatus)}
+
+func warmupKey(name, tag string) string {
+	return name + ":" + tag
+}
+
+// WarmupDispatcher takes the request context and builds the handler for tag warming requests.
+// The route carries a repository name, so POST requests require push access to the repository
+// and GET requests pull access.
+func WarmupDispatcher(ctx *handlers.Context, r *http.Request) http.Handler {
+	warmupHandler := &warmupHandler{
+		Context: ctx,
+	}
+
+	return gorillahandlers.MethodHandler{
+		"POST": http.HandlerFunc(warmupHandler.Start),
+		"GET":  http.HandlerFunc(warmupHandler.Status),
+	}
+}
+
+// warmupHandler pre-fetches tags into the registry caches on request.
+type warmupHandler struct {
+	*handlers.Context
+}
+
+// Start begins warming every tag named by the ?tag= parameters. Already running jobs are left
+// untouched.
+func (wh *warmupHandler) Start(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	tags := req.URL.Query()["tag"]
+	if len(tags) == 0 {
+		wh.Errors = append(wh.Errors, errcode.ErrorCodeUnknown.WithDetail("at least one tag parameter is required"))
+		return
+	}
+
+	name := wh.Repository.Named().Name()
+	statuses := []*WarmupStatus{}
+	for _, tag := range tags {
+		warmupJobs.Lock()
+		status, exists := warmupJobs.jobs[warmupKey(name, tag)]
+		if exists && status.State == WarmupStateRunning {
+			warmupJobs.Unlock()
+			statuses = append(statuses, status)
+			continue
+		}
+		status = &WarmupStatus{
+			Name:    name,
+			Tag:     tag,
+			State:   WarmupStateRunning,
+			Started: time.Now(),
+		}
+		warmupJobs.jobs[warmupKey(name, tag)] = status
+		warmupJobs.Unlock()
+
+		statuses = append(statuses, status)
+		go wh.warm(status)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(statuses)
+}
+
+// Status reports the warming jobs of this repository, optionally filtered by ?tag=.
+func (wh *warmupHandler) Status(w http.ResponseWriter, req *http.Request) {
+	name := wh.Repository.Named().Name()
+	tags := req.URL.Query()["tag"]
+
+	warmupJobs.Lock()
+	statuses := []*WarmupStatus{}
+	for _, status := range warmupJobs.jobs {
+		if status.Name != name {
+			continue
+		}
+		if len(tags) > 0 && !containsString(tags, status.Tag) {
+			continue
+		}
+		copied := *status
+		statuses = append(statuses, &copied)
+	}
+	warmupJobs.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statuses)
+}
+
+// warm fetches the manifest of the tag and stats every blob it references so the pullthrough
+// caches know where to find them.
+func (wh *warmupHandler) warm(status *WarmupStatus) {
+	err := func() error {
+		desc, err := wh.Repository.Tags(wh).Get(wh, status.Tag)
+		if err != nil {
+			return fmt.Errorf("cannot resolve tag: %v", err)
+		}
+		manifests, err := wh.Repository.Manifests(wh)
+		if err != nil {
+			return err
+		}
+		manifest, err := manifests.Get(wh, desc.Digest)
+		if err != nil {
+			return fmt.Errorf("cannot fetch manifest: %v", err)
+		}
+
+		references := manifest.References()
+		warmupJobs.Lock()
+		status.Blobs = len(references)
+		warmupJobs.Unlock()
+
+		blobs := wh.Repository.Blobs(wh)
+		for _, reference := range references {
+			if _, err := blobs.Stat(wh, reference.Digest); err != nil && err != distribution.ErrBlobUnknown {
+				return fmt.Errorf("cannot warm blob %s: %v", reference.Digest.String(), err)
+			}
+			warmupJobs.Lock()
+			status.BlobsDone++
+			warmupJobs.Unlock()
+		}
+		return nil
+	}()
+
+	now := time.Now()
+	warmupJobs.Lock()
+	defer warmupJobs.Unlock()
+	status.Finished = &now
+	if err != nil {
+		context.GetLogger(wh).Errorf("warming %s:%s failed: %v", status.Name, status.Tag, err)
+		status.State = WarmupStateFailed
+		status.Error = err.Error()
+		return
+	}
+	status.State = WarmupStateComplete
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
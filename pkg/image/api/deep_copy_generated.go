@@ -25,6 +25,7 @@ func init() {
 		DeepCopy_api_DockerV1CompatibilityImage,
 		DeepCopy_api_DockerV1CompatibilityImageSize,
 		DeepCopy_api_Image,
+		DeepCopy_api_ImageImportProgress,
 		DeepCopy_api_ImageImportSpec,
 		DeepCopy_api_ImageImportStatus,
 		DeepCopy_api_ImageLayer,
@@ -36,6 +37,7 @@ func init() {
 		DeepCopy_api_ImageStreamImportSpec,
 		DeepCopy_api_ImageStreamImportStatus,
 		DeepCopy_api_ImageStreamList,
+		DeepCopy_api_ImageStreamManifestList,
 		DeepCopy_api_ImageStreamMapping,
 		DeepCopy_api_ImageStreamSpec,
 		DeepCopy_api_ImageStreamStatus,
@@ -447,6 +449,13 @@ func DeepCopy_api_ImageImportSpec(in ImageImportSpec, out *ImageImportSpec, c *c
 	return nil
 }
 
+func DeepCopy_api_ImageImportProgress(in ImageImportProgress, out *ImageImportProgress, c *conversion.Cloner) error {
+	out.Phase = in.Phase
+	out.BytesTransferred = in.BytesTransferred
+	out.Retriable = in.Retriable
+	return nil
+}
+
 func DeepCopy_api_ImageImportStatus(in ImageImportStatus, out *ImageImportStatus, c *conversion.Cloner) error {
 	out.Tag = in.Tag
 	if err := unversioned.DeepCopy_unversioned_Status(in.Status, &out.Status, c); err != nil {
@@ -461,6 +470,15 @@ func DeepCopy_api_ImageImportStatus(in ImageImportStatus, out *ImageImportStatus
 	} else {
 		out.Image = nil
 	}
+	if in.Progress != nil {
+		in, out := in.Progress, &out.Progress
+		*out = new(ImageImportProgress)
+		if err := DeepCopy_api_ImageImportProgress(*in, *out, c); err != nil {
+			return err
+		}
+	} else {
+		out.Progress = nil
+	}
 	return nil
 }
 
@@ -682,6 +700,27 @@ func DeepCopy_api_ImageStreamList(in ImageStreamList, out *ImageStreamList, c *c
 	return nil
 }
 
+func DeepCopy_api_ImageStreamManifestList(in ImageStreamManifestList, out *ImageStreamManifestList, c *conversion.Cloner) error {
+	if err := unversioned.DeepCopy_unversioned_TypeMeta(in.TypeMeta, &out.TypeMeta, c); err != nil {
+		return err
+	}
+	if err := api.DeepCopy_api_ObjectMeta(in.ObjectMeta, &out.ObjectMeta, c); err != nil {
+		return err
+	}
+	out.Tag = in.Tag
+	if in.SourceTags != nil {
+		in, out := in.SourceTags, &out.SourceTags
+		*out = make([]string, len(in))
+		copy(*out, in)
+	} else {
+		out.SourceTags = nil
+	}
+	if err := DeepCopy_api_Image(in.Image, &out.Image, c); err != nil {
+		return err
+	}
+	return nil
+}
+
 func DeepCopy_api_ImageStreamMapping(in ImageStreamMapping, out *ImageStreamMapping, c *conversion.Cloner) error {
 	if err := unversioned.DeepCopy_unversioned_TypeMeta(in.TypeMeta, &out.TypeMeta, c); err != nil {
 		return err
@@ -717,6 +756,15 @@ func DeepCopy_api_ImageStreamSpec(in ImageStreamSpec, out *ImageStreamSpec, c *c
 
 func DeepCopy_api_ImageStreamStatus(in ImageStreamStatus, out *ImageStreamStatus, c *conversion.Cloner) error {
 	out.DockerImageRepository = in.DockerImageRepository
+	if in.DockerImageRepositoryVariants != nil {
+		in, out := in.DockerImageRepositoryVariants, &out.DockerImageRepositoryVariants
+		*out = make(map[string]string)
+		for key, val := range in {
+			(*out)[key] = val
+		}
+	} else {
+		out.DockerImageRepositoryVariants = nil
+	}
 	if in.Tags != nil {
 		in, out := in.Tags, &out.Tags
 		*out = make(map[string]TagEventList)
@@ -866,6 +914,13 @@ func DeepCopy_api_TagEvent(in TagEvent, out *TagEvent, c *conversion.Cloner) err
 	out.DockerImageReference = in.DockerImageReference
 	out.Image = in.Image
 	out.Generation = in.Generation
+	if in.Platforms != nil {
+		in, out := in.Platforms, &out.Platforms
+		*out = make([]string, len(in))
+		copy(*out, in)
+	} else {
+		out.Platforms = nil
+	}
 	return nil
 }
 
@@ -910,6 +965,13 @@ func DeepCopy_api_TagEventList(in TagEventList, out *TagEventList, c *conversion
 func DeepCopy_api_TagImportPolicy(in TagImportPolicy, out *TagImportPolicy, c *conversion.Cloner) error {
 	out.Insecure = in.Insecure
 	out.Scheduled = in.Scheduled
+	if in.Platforms != nil {
+		in, out := in.Platforms, &out.Platforms
+		*out = make([]string, len(in))
+		copy(*out, in)
+	} else {
+		out.Platforms = nil
+	}
 	return nil
 }
 
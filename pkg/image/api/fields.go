@@ -19,3 +19,11 @@ func ImageStreamToSelectableFields(ir *ImageStream) fields.Set {
 		"status.dockerImageRepository": ir.Status.DockerImageRepository,
 	}
 }
+
+// ImagePromotionPolicyToSelectableFields returns a label set that represents the object.
+func ImagePromotionPolicyToSelectableFields(policy *ImagePromotionPolicy) fields.Set {
+	return fields.Set{
+		"metadata.name":      policy.Name,
+		"metadata.namespace": policy.Namespace,
+	}
+}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/blang/semver"
 	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/manifest/manifestlist"
 	"github.com/docker/distribution/manifest/schema1"
 	"github.com/docker/distribution/manifest/schema2"
 	"github.com/golang/glog"
@@ -48,6 +49,21 @@ func (fn DefaultRegistryFunc) DefaultRegistry() (string, bool) {
 	return fn()
 }
 
+// RegistryVariants returns alternative registry locations (variant name to host or host:port)
+// a stream may also be pulled from, such as the external route or a mirror. Consumers pick the
+// variant matching their network location.
+type RegistryVariants interface {
+	RegistryVariants() map[string]string
+}
+
+// RegistryVariantsFunc implements RegistryVariants for a simple function.
+type RegistryVariantsFunc func() map[string]string
+
+// RegistryVariants implements the RegistryVariants interface for a function.
+func (fn RegistryVariantsFunc) RegistryVariants() map[string]string {
+	return fn()
+}
+
 // parseRepositoryTag splits a string into its name component and either tag or id if present.
 // TODO remove
 func parseRepositoryTag(repos string) (base string, tag string, id string) {
@@ -436,6 +452,34 @@ func ImageConfigMatchesImage(image *Image, imageConfig []byte) (bool, error) {
 	return v.Verified(), nil
 }
 
+// ImagePlatforms returns the "os/architecture" platforms provided by the image. A manifest list
+// yields one entry per platform image; otherwise the architecture recorded in the image metadata
+// is used, assuming linux. An empty slice is returned when no platform information is available.
+func ImagePlatforms(image *Image) []string {
+	if image.DockerImageManifestMediaType == manifestlist.MediaTypeManifestList && len(image.DockerImageManifest) > 0 {
+		list := manifestlist.ManifestList{}
+		if err := json.Unmarshal([]byte(image.DockerImageManifest), &list); err != nil {
+			glog.V(5).Infof("Unable to parse manifest list for image %s: %v", image.Name, err)
+			return nil
+		}
+		platforms := []string{}
+		seen := sets.NewString()
+		for _, desc := range list.Manifests {
+			platform := desc.Platform.OS + "/" + desc.Platform.Architecture
+			if seen.Has(platform) {
+				continue
+			}
+			seen.Insert(platform)
+			platforms = append(platforms, platform)
+		}
+		return platforms
+	}
+	if arch := image.DockerImageMetadata.Architecture; len(arch) > 0 {
+		return []string{"linux/" + arch}
+	}
+	return nil
+}
+
 // ImageWithMetadata returns a copy of image with the DockerImageMetadata filled in
 // from the raw DockerImageManifest data stored in the image.
 func ImageWithMetadata(image *Image) error {
@@ -628,6 +672,29 @@ func LatestTaggedImage(stream *ImageStream, tag string) *TagEvent {
 	return nil
 }
 
+// MergeStreamShards appends the tag event history held by overflow shards back onto stream.
+// Shard events for a tag are assumed to be older than the events the main stream kept, so they
+// are appended after them; shards are merged in the order given. The stream is modified in
+// place.
+func MergeStreamShards(stream *ImageStream, shards ...*ImageStream) {
+	for _, shard := range shards {
+		if shard == nil {
+			continue
+		}
+		for tag, history := range shard.Status.Tags {
+			if len(history.Items) == 0 {
+				continue
+			}
+			if stream.Status.Tags == nil {
+				stream.Status.Tags = make(map[string]TagEventList)
+			}
+			merged := stream.Status.Tags[tag]
+			merged.Items = append(merged.Items, history.Items...)
+			stream.Status.Tags[tag] = merged
+		}
+	}
+}
+
 // DifferentTagEvent returns true if the supplied tag event matches the current stream tag event.
 // Generation is not compared.
 func DifferentTagEvent(stream *ImageStream, tag string, next TagEvent) bool {
@@ -717,8 +784,26 @@ func tagsChanged(new, old []TagEvent) (changed bool, deleted bool) {
 	case len(old) == 0:
 		return true, false
 	default:
-		return new[0] != old[0], false
+		return !tagEventsEqual(new[0], old[0]), false
+	}
+}
+
+// tagEventsEqual returns true if the two events describe the same image. TagEvent carries a
+// platform slice, so it cannot be compared with ==.
+func tagEventsEqual(new, old TagEvent) bool {
+	if new.Created != old.Created ||
+		new.DockerImageReference != old.DockerImageReference ||
+		new.Image != old.Image ||
+		new.Generation != old.Generation ||
+		len(new.Platforms) != len(old.Platforms) {
+		return false
+	}
+	for i := range new.Platforms {
+		if new.Platforms[i] != old.Platforms[i] {
+			return false
+		}
 	}
+	return true
 }
 
 // UpdateTrackingTags sets updatedImage as the most recent TagEvent for all tags
@@ -824,6 +909,7 @@ func ResolveImageID(stream *ImageStream, imageID string) (*TagEvent, error) {
 			Created:              unversioned.Now(),
 			DockerImageReference: event.DockerImageReference,
 			Image:                event.Image,
+			Platforms:            event.Platforms,
 		}, nil
 	case 0:
 		return nil, errors.NewNotFound(Resource("imagestreamimage"), imageID)
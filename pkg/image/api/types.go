@@ -17,6 +17,30 @@ const (
 	// ManagedByOpenShiftAnnotation indicates that an image is managed by OpenShift's registry.
 	ManagedByOpenShiftAnnotation = "openshift.io/image.managed"
 
+	// ImageQuarantinedAnnotation marks an image as quarantined: the registry refuses to serve
+	// its manifest and logs attempted pulls until the annotation is cleared.
+	ImageQuarantinedAnnotation = "openshift.io/image.quarantined"
+
+	// QuarantinedTagsAnnotation is placed on image streams whose tags currently point at a
+	// quarantined image; the value is a comma separated list of the affected tags.
+	QuarantinedTagsAnnotation = "openshift.io/image.quarantinedTags"
+
+	// PushedByUserAnnotation records the authenticated user that pushed an image. The registry
+	// creates the image stream mapping with its own identity, so without this the pusher would
+	// not be attributed anywhere.
+	PushedByUserAnnotation = "openshift.io/image.pushedBy"
+
+	// PushedFromAnnotation records the client address the push came from and
+	// PushUserAgentAnnotation the client's user agent, so `oc describe` on the image answers
+	// who pushed it and from where without consulting registry logs.
+	PushedFromAnnotation    = "openshift.io/image.pushedFrom"
+	PushUserAgentAnnotation = "openshift.io/image.pushUserAgent"
+
+	// BackgroundImportAnnotation marks an ImageStreamImport as created by the scheduled
+	// re-import controller; the importer admits such imports only while no user-initiated
+	// import is waiting.
+	BackgroundImportAnnotation = "openshift.io/image.import.background"
+
 	// DockerImageRepositoryCheckAnnotation indicates that OpenShift has
 	// attempted to import tag and image information from an external Docker
 	// image repository.
@@ -25,9 +49,62 @@ const (
 	// InsecureRepositoryAnnotation may be set true on an image stream to allow insecure access to pull content.
 	InsecureRepositoryAnnotation = "openshift.io/image.insecureRepository"
 
+	// RemoteOnlyRepositoryAnnotation may be set true on an image stream to prevent the registry
+	// from storing any blobs for it locally. Content is always proxied from the upstream
+	// repositories referenced by the stream and pushes are refused.
+	RemoteOnlyRepositoryAnnotation = "openshift.io/image.remoteOnly"
+
 	// ExcludeImageSecretAnnotation indicates that a secret should not be returned by imagestream/secrets.
 	ExcludeImageSecretAnnotation = "openshift.io/image.excludeSecret"
 
+	// ImagePlatformsAnnotation is set on image stream tags to a comma separated list of the
+	// "os/architecture" platforms provided by the tagged image or manifest list.
+	ImagePlatformsAnnotation = "openshift.io/image.platforms"
+
+	// TagTimeToLiveAnnotation may be set on a spec tag to a duration string (e.g. "48h") after
+	// which the tag is removed, counted from the creation of its most recent tag event. Expired
+	// tags are deleted by the tag expiration controller, making their images prune-eligible.
+	TagTimeToLiveAnnotation = "openshift.io/tag.ttl"
+
+	// ShardOverflowAnnotation lists, comma-separated, the overflow image streams holding older
+	// tag events moved out of this stream to keep its object below etcd size limits. Consumers
+	// merge them back with MergeStreamShards.
+	ShardOverflowAnnotation = "openshift.io/image.shardOverflow"
+
+	// ShardOfAnnotation marks an overflow stream with the name of the stream whose history it
+	// holds. Overflow streams are not meant to be tagged or pushed to directly.
+	ShardOfAnnotation = "openshift.io/image.shardOf"
+
+	// TagDescriptionAnnotation, TagOwnerAnnotation and TagLinksAnnotation carry the free-form
+	// metadata document editable through the registry's tag metadata endpoint. Links are stored
+	// JSON-encoded as a name-to-URL map.
+	TagDescriptionAnnotation = "openshift.io/tag.description"
+	TagOwnerAnnotation       = "openshift.io/tag.owner"
+	TagLinksAnnotation       = "openshift.io/tag.links"
+
+	// ProtectedTagsAnnotation may be set on an image stream to a comma-separated list of glob
+	// patterns (e.g. "v*,release-*"). Tags matching a pattern can only be overwritten or
+	// deleted through the registry by users passing an additional access review.
+	ProtectedTagsAnnotation = "openshift.io/image.protectedTags"
+
+	// FailedMappingAnnotation records the last image digest the registry accepted but could not
+	// record as an image stream mapping, together with the final error. Set by the registry's
+	// async mapping queue after its retries are exhausted.
+	FailedMappingAnnotation = "openshift.io/image.failedMapping"
+
+	// ImageScanStatusAnnotation is set on an image by security scanners to record the outcome
+	// of the most recent scan. The promotion controller treats ImageScanStatusPassed as a
+	// clean result; any other value, or a missing annotation, blocks promotion.
+	ImageScanStatusAnnotation = "openshift.io/image.scanStatus"
+
+	// ImageScanStatusPassed is the ImageScanStatusAnnotation value that marks a clean scan.
+	ImageScanStatusPassed = "passed"
+
+	// PromotionApprovedAnnotation is set on an ImagePromotionPolicy that requires manual
+	// approval. The value is the digest of the source image being approved, so each new source
+	// image needs a fresh approval before it is promoted.
+	PromotionApprovedAnnotation = "openshift.io/promotion.approved"
+
 	// DefaultImageTag is used when an image tag is needed and the configuration does not specify a tag to use.
 	DefaultImageTag = "latest"
 
@@ -42,12 +119,18 @@ const (
 	// of a project.
 	ResourceImageStreamTags kapi.ResourceName = "openshift.io/image-tags"
 
+	// ResourceProjectImages represents a number of unique image digests referenced by all image
+	// streams of a project together. Unlike ResourceImageStreamImages, which the registry applies
+	// per stream, this caps the project as a whole.
+	ResourceProjectImages kapi.ResourceName = "openshift.io/project-images"
+
 	// Limit that applies to images. Used with a max["storage"] LimitRangeItem to set
 	// the maximum size of an image.
 	LimitTypeImage kapi.LimitType = "openshift.io/Image"
 
 	// Limit that applies to image streams. Used with a max[resource] LimitRangeItem to set the maximum number
-	// of resource. Where the resource is one of "openshift.io/images" and "openshift.io/image-tags".
+	// of resource. Where the resource is one of "openshift.io/images", "openshift.io/image-tags" and
+	// "openshift.io/project-images".
 	LimitTypeImageStream kapi.LimitType = "openshift.io/ImageStream"
 )
 
@@ -242,6 +325,10 @@ type TagImportPolicy struct {
 	Insecure bool
 	// Scheduled indicates to the server that this tag should be periodically checked to ensure it is up to date, and imported
 	Scheduled bool
+	// Platforms restricts which platforms are imported when the remote tag is a manifest list. Each entry is
+	// either "os/architecture" or a bare architecture (implying linux). When set and the remote tag is a
+	// manifest list, only the first matching platform image is imported. Ignored for single platform images.
+	Platforms []string
 }
 
 // ImageStreamStatus contains information about the state of this image stream.
@@ -249,6 +336,10 @@ type ImageStreamStatus struct {
 	// DockerImageRepository represents the effective location this stream may be accessed at. May be empty until the server
 	// determines where the repository is located
 	DockerImageRepository string
+	// DockerImageRepositoryVariants lists alternative locations this stream may be accessed at, keyed by variant
+	// name (e.g. "external" for the registry route), so consumers can pick the endpoint matching their network
+	// location. Derived from master configuration; may be empty.
+	DockerImageRepositoryVariants map[string]string
 	// A historical record of images associated with each tag. The first entry in the TagEvent array is
 	// the currently tagged image.
 	Tags map[string]TagEventList
@@ -271,6 +362,9 @@ type TagEvent struct {
 	Image string
 	// Generation is the spec tag generation that resulted in this tag being updated
 	Generation int64
+	// Platforms lists the platforms of the tagged image as "os/architecture" strings. A manifest
+	// list yields one entry per platform image; single platform images have at most one entry.
+	Platforms []string
 }
 
 type TagEventConditionType string
@@ -315,6 +409,22 @@ type ImageStreamMapping struct {
 	Tag string
 }
 
+// ImageStreamManifestList assembles a manifest list from platform specific images that were
+// already pushed to an image stream. Name identifies the stream, SourceTags name the images to
+// combine, and on success Image is filled with the manifest list image recorded under Tag.
+type ImageStreamManifestList struct {
+	unversioned.TypeMeta
+	kapi.ObjectMeta
+
+	// Tag is the status tag the assembled manifest list is recorded under.
+	Tag string
+	// SourceTags name tags in the same image stream whose current images become the entries of
+	// the manifest list. Each image must carry platform metadata.
+	SourceTags []string
+	// Image is set by the server to the manifest list image that was created.
+	Image Image
+}
+
 // ImageStreamTag has a .Name in the format <stream name>:<tag>.
 type ImageStreamTag struct {
 	unversioned.TypeMeta
@@ -433,4 +543,91 @@ type ImageImportStatus struct {
 	Tag    string
 	Status unversioned.Status
 	Image  *Image
+	// Progress reports how far the import of this image has come, and whether a failed import
+	// may succeed when retried.
+	Progress *ImageImportProgress
+}
+
+// ImageImportPhase describes how far a single image import has progressed.
+type ImageImportPhase string
+
+const (
+	// ImageImportPhasePending means the import of this image has not started yet.
+	ImageImportPhasePending ImageImportPhase = "Pending"
+	// ImageImportPhaseImporting means the manifest or blobs of this image are being retrieved.
+	ImageImportPhaseImporting ImageImportPhase = "Importing"
+	// ImageImportPhaseComplete means the image was imported successfully.
+	ImageImportPhaseComplete ImageImportPhase = "Complete"
+	// ImageImportPhaseFailed means the import of this image failed.
+	ImageImportPhaseFailed ImageImportPhase = "Failed"
+)
+
+// ImageImportProgress reports fine grained progress of a single image import.
+type ImageImportProgress struct {
+	// Phase is the current phase of this image import.
+	Phase ImageImportPhase
+	// BytesTransferred is the number of manifest and blob bytes retrieved so far.
+	BytesTransferred int64
+	// Retriable is true if the error recorded on the status is likely transient and the import
+	// may succeed when repeated. It is false for permanent errors such as denied access or a
+	// missing image.
+	Retriable bool
+}
+
+// ImagePromotionPolicy describes the automatic promotion of images from one image stream tag to
+// another, optionally in a different namespace. When the image currently tagged by the source
+// satisfies every configured condition, the promotion controller retags it to the target. The
+// promotion is a tag event referencing the same image, so the registry serves the promoted tag
+// by linking the blobs it already stores - no image data is copied.
+type ImagePromotionPolicy struct {
+	unversioned.TypeMeta
+	kapi.ObjectMeta
+
+	// Spec names the source and target tags and the conditions a source image must meet.
+	Spec ImagePromotionPolicySpec
+	// Status reports the most recent promotion performed by this policy.
+	Status ImagePromotionPolicyStatus
+}
+
+// ImagePromotionPolicySpec describes what to promote, where to, and under which conditions.
+type ImagePromotionPolicySpec struct {
+	// SourceStream is the name of an image stream in the policy's namespace to promote from.
+	SourceStream string
+	// SourceTag is the tag on the source stream that is watched for new images.
+	SourceTag string
+	// TargetNamespace is the namespace of the target stream. Empty means the policy's
+	// namespace.
+	TargetNamespace string
+	// TargetStream is the name of the image stream to promote into. Empty means SourceStream.
+	// The stream is created on first promotion if it does not exist.
+	TargetStream string
+	// TargetTag is the tag the promoted image is recorded under.
+	TargetTag string
+	// RequireSignature restricts promotion to images carrying at least one signature.
+	RequireSignature bool
+	// RequireScanPassed restricts promotion to images whose scan status annotation reports a
+	// passed scan.
+	RequireScanPassed bool
+	// RequireApproval restricts promotion to images whose digest has been recorded in the
+	// policy's approval annotation.
+	RequireApproval bool
+}
+
+// ImagePromotionPolicyStatus reports the outcome of the most recent promotion attempt.
+type ImagePromotionPolicyStatus struct {
+	// PromotedImage is the digest of the image most recently promoted by this policy.
+	PromotedImage string
+	// LastPromotionTime is when PromotedImage was promoted.
+	LastPromotionTime *unversioned.Time
+	// Reason explains why the current source image has not been promoted. It is empty while
+	// the source and target tags are in sync.
+	Reason string
+}
+
+// ImagePromotionPolicyList is a list of ImagePromotionPolicy objects.
+type ImagePromotionPolicyList struct {
+	unversioned.TypeMeta
+	unversioned.ListMeta
+
+	Items []ImagePromotionPolicy
 }
@@ -163,12 +163,14 @@ func Convert_api_ImageStreamSpec_To_v1_ImageStreamSpec(in *newer.ImageStreamSpec
 
 func Convert_v1_ImageStreamStatus_To_api_ImageStreamStatus(in *ImageStreamStatus, out *newer.ImageStreamStatus, s conversion.Scope) error {
 	out.DockerImageRepository = in.DockerImageRepository
+	out.DockerImageRepositoryVariants = in.DockerImageRepositoryVariants
 	out.Tags = make(map[string]newer.TagEventList)
 	return s.Convert(&in.Tags, &out.Tags, 0)
 }
 
 func Convert_api_ImageStreamStatus_To_v1_ImageStreamStatus(in *newer.ImageStreamStatus, out *ImageStreamStatus, s conversion.Scope) error {
 	out.DockerImageRepository = in.DockerImageRepository
+	out.DockerImageRepositoryVariants = in.DockerImageRepositoryVariants
 	if len(in.DockerImageRepository) > 0 {
 		// ensure that stored image references have no tag or ID, which was possible from 1.0.0 until 1.0.7
 		if ref, err := newer.ParseDockerImageReference(in.DockerImageRepository); err == nil {
@@ -287,4 +289,10 @@ func addConversionFuncs(scheme *runtime.Scheme) {
 	); err != nil {
 		panic(err)
 	}
+
+	if err := scheme.AddFieldLabelConversionFunc("v1", "ImagePromotionPolicy",
+		oapi.GetFieldLabelConversionFunc(newer.ImagePromotionPolicyToSelectableFields(&newer.ImagePromotionPolicy{}), nil),
+	); err != nil {
+		panic(err)
+	}
 }
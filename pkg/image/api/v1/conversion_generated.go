@@ -19,6 +19,8 @@ func init() {
 		Convert_api_Image_To_v1_Image,
 		Convert_v1_ImageImportSpec_To_api_ImageImportSpec,
 		Convert_api_ImageImportSpec_To_v1_ImageImportSpec,
+		Convert_v1_ImageImportProgress_To_api_ImageImportProgress,
+		Convert_api_ImageImportProgress_To_v1_ImageImportProgress,
 		Convert_v1_ImageImportStatus_To_api_ImageImportStatus,
 		Convert_api_ImageImportStatus_To_v1_ImageImportStatus,
 		Convert_v1_ImageLayer_To_api_ImageLayer,
@@ -39,6 +41,8 @@ func init() {
 		Convert_api_ImageStreamImportStatus_To_v1_ImageStreamImportStatus,
 		Convert_v1_ImageStreamList_To_api_ImageStreamList,
 		Convert_api_ImageStreamList_To_v1_ImageStreamList,
+		Convert_v1_ImageStreamManifestList_To_api_ImageStreamManifestList,
+		Convert_api_ImageStreamManifestList_To_v1_ImageStreamManifestList,
 		Convert_v1_ImageStreamMapping_To_api_ImageStreamMapping,
 		Convert_api_ImageStreamMapping_To_v1_ImageStreamMapping,
 		Convert_v1_ImageStreamSpec_To_api_ImageStreamSpec,
@@ -150,6 +154,28 @@ func Convert_api_ImageImportSpec_To_v1_ImageImportSpec(in *image_api.ImageImport
 	return autoConvert_api_ImageImportSpec_To_v1_ImageImportSpec(in, out, s)
 }
 
+func autoConvert_v1_ImageImportProgress_To_api_ImageImportProgress(in *ImageImportProgress, out *image_api.ImageImportProgress, s conversion.Scope) error {
+	out.Phase = image_api.ImageImportPhase(in.Phase)
+	out.BytesTransferred = in.BytesTransferred
+	out.Retriable = in.Retriable
+	return nil
+}
+
+func Convert_v1_ImageImportProgress_To_api_ImageImportProgress(in *ImageImportProgress, out *image_api.ImageImportProgress, s conversion.Scope) error {
+	return autoConvert_v1_ImageImportProgress_To_api_ImageImportProgress(in, out, s)
+}
+
+func autoConvert_api_ImageImportProgress_To_v1_ImageImportProgress(in *image_api.ImageImportProgress, out *ImageImportProgress, s conversion.Scope) error {
+	out.Phase = ImageImportPhase(in.Phase)
+	out.BytesTransferred = in.BytesTransferred
+	out.Retriable = in.Retriable
+	return nil
+}
+
+func Convert_api_ImageImportProgress_To_v1_ImageImportProgress(in *image_api.ImageImportProgress, out *ImageImportProgress, s conversion.Scope) error {
+	return autoConvert_api_ImageImportProgress_To_v1_ImageImportProgress(in, out, s)
+}
+
 func autoConvert_v1_ImageImportStatus_To_api_ImageImportStatus(in *ImageImportStatus, out *image_api.ImageImportStatus, s conversion.Scope) error {
 	out.Status = in.Status
 	if in.Image != nil {
@@ -162,6 +188,15 @@ func autoConvert_v1_ImageImportStatus_To_api_ImageImportStatus(in *ImageImportSt
 		out.Image = nil
 	}
 	out.Tag = in.Tag
+	if in.Progress != nil {
+		in, out := &in.Progress, &out.Progress
+		*out = new(image_api.ImageImportProgress)
+		if err := Convert_v1_ImageImportProgress_To_api_ImageImportProgress(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Progress = nil
+	}
 	return nil
 }
 
@@ -181,6 +216,15 @@ func autoConvert_api_ImageImportStatus_To_v1_ImageImportStatus(in *image_api.Ima
 	} else {
 		out.Image = nil
 	}
+	if in.Progress != nil {
+		in, out := &in.Progress, &out.Progress
+		*out = new(ImageImportProgress)
+		if err := Convert_api_ImageImportProgress_To_v1_ImageImportProgress(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Progress = nil
+	}
 	return nil
 }
 
@@ -656,6 +700,44 @@ func Convert_api_ImageStreamList_To_v1_ImageStreamList(in *image_api.ImageStream
 	return autoConvert_api_ImageStreamList_To_v1_ImageStreamList(in, out, s)
 }
 
+func autoConvert_v1_ImageStreamManifestList_To_api_ImageStreamManifestList(in *ImageStreamManifestList, out *image_api.ImageStreamManifestList, s conversion.Scope) error {
+	if err := api.Convert_unversioned_TypeMeta_To_unversioned_TypeMeta(&in.TypeMeta, &out.TypeMeta, s); err != nil {
+		return err
+	}
+	if err := api_v1.Convert_v1_ObjectMeta_To_api_ObjectMeta(&in.ObjectMeta, &out.ObjectMeta, s); err != nil {
+		return err
+	}
+	out.Tag = in.Tag
+	out.SourceTags = in.SourceTags
+	if err := Convert_v1_Image_To_api_Image(&in.Image, &out.Image, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+func Convert_v1_ImageStreamManifestList_To_api_ImageStreamManifestList(in *ImageStreamManifestList, out *image_api.ImageStreamManifestList, s conversion.Scope) error {
+	return autoConvert_v1_ImageStreamManifestList_To_api_ImageStreamManifestList(in, out, s)
+}
+
+func autoConvert_api_ImageStreamManifestList_To_v1_ImageStreamManifestList(in *image_api.ImageStreamManifestList, out *ImageStreamManifestList, s conversion.Scope) error {
+	if err := api.Convert_unversioned_TypeMeta_To_unversioned_TypeMeta(&in.TypeMeta, &out.TypeMeta, s); err != nil {
+		return err
+	}
+	if err := api_v1.Convert_api_ObjectMeta_To_v1_ObjectMeta(&in.ObjectMeta, &out.ObjectMeta, s); err != nil {
+		return err
+	}
+	out.Tag = in.Tag
+	out.SourceTags = in.SourceTags
+	if err := Convert_api_Image_To_v1_Image(&in.Image, &out.Image, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+func Convert_api_ImageStreamManifestList_To_v1_ImageStreamManifestList(in *image_api.ImageStreamManifestList, out *ImageStreamManifestList, s conversion.Scope) error {
+	return autoConvert_api_ImageStreamManifestList_To_v1_ImageStreamManifestList(in, out, s)
+}
+
 func autoConvert_v1_ImageStreamMapping_To_api_ImageStreamMapping(in *ImageStreamMapping, out *image_api.ImageStreamMapping, s conversion.Scope) error {
 	if err := api.Convert_unversioned_TypeMeta_To_unversioned_TypeMeta(&in.TypeMeta, &out.TypeMeta, s); err != nil {
 		return err
@@ -977,6 +1059,7 @@ func autoConvert_v1_TagEvent_To_api_TagEvent(in *TagEvent, out *image_api.TagEve
 	out.DockerImageReference = in.DockerImageReference
 	out.Image = in.Image
 	out.Generation = in.Generation
+	out.Platforms = in.Platforms
 	return nil
 }
 
@@ -991,6 +1074,7 @@ func autoConvert_api_TagEvent_To_v1_TagEvent(in *image_api.TagEvent, out *TagEve
 	out.DockerImageReference = in.DockerImageReference
 	out.Image = in.Image
 	out.Generation = in.Generation
+	out.Platforms = in.Platforms
 	return nil
 }
 
@@ -1033,6 +1117,7 @@ func Convert_api_TagEventCondition_To_v1_TagEventCondition(in *image_api.TagEven
 func autoConvert_v1_TagImportPolicy_To_api_TagImportPolicy(in *TagImportPolicy, out *image_api.TagImportPolicy, s conversion.Scope) error {
 	out.Insecure = in.Insecure
 	out.Scheduled = in.Scheduled
+	out.Platforms = in.Platforms
 	return nil
 }
 
@@ -1043,6 +1128,7 @@ func Convert_v1_TagImportPolicy_To_api_TagImportPolicy(in *TagImportPolicy, out
 func autoConvert_api_TagImportPolicy_To_v1_TagImportPolicy(in *image_api.TagImportPolicy, out *TagImportPolicy, s conversion.Scope) error {
 	out.Insecure = in.Insecure
 	out.Scheduled = in.Scheduled
+	out.Platforms = in.Platforms
 	return nil
 }
 
@@ -16,6 +16,7 @@ func init() {
 	if err := api.Scheme.AddGeneratedDeepCopyFuncs(
 		DeepCopy_v1_DockerImageReference,
 		DeepCopy_v1_Image,
+		DeepCopy_v1_ImageImportProgress,
 		DeepCopy_v1_ImageImportSpec,
 		DeepCopy_v1_ImageImportStatus,
 		DeepCopy_v1_ImageLayer,
@@ -27,6 +28,7 @@ func init() {
 		DeepCopy_v1_ImageStreamImportSpec,
 		DeepCopy_v1_ImageStreamImportStatus,
 		DeepCopy_v1_ImageStreamList,
+		DeepCopy_v1_ImageStreamManifestList,
 		DeepCopy_v1_ImageStreamMapping,
 		DeepCopy_v1_ImageStreamSpec,
 		DeepCopy_v1_ImageStreamStatus,
@@ -131,6 +133,13 @@ func DeepCopy_v1_ImageImportSpec(in ImageImportSpec, out *ImageImportSpec, c *co
 	return nil
 }
 
+func DeepCopy_v1_ImageImportProgress(in ImageImportProgress, out *ImageImportProgress, c *conversion.Cloner) error {
+	out.Phase = in.Phase
+	out.BytesTransferred = in.BytesTransferred
+	out.Retriable = in.Retriable
+	return nil
+}
+
 func DeepCopy_v1_ImageImportStatus(in ImageImportStatus, out *ImageImportStatus, c *conversion.Cloner) error {
 	if err := unversioned.DeepCopy_unversioned_Status(in.Status, &out.Status, c); err != nil {
 		return err
@@ -145,6 +154,15 @@ func DeepCopy_v1_ImageImportStatus(in ImageImportStatus, out *ImageImportStatus,
 		out.Image = nil
 	}
 	out.Tag = in.Tag
+	if in.Progress != nil {
+		in, out := in.Progress, &out.Progress
+		*out = new(ImageImportProgress)
+		if err := DeepCopy_v1_ImageImportProgress(*in, *out, c); err != nil {
+			return err
+		}
+	} else {
+		out.Progress = nil
+	}
 	return nil
 }
 
@@ -366,6 +384,27 @@ func DeepCopy_v1_ImageStreamList(in ImageStreamList, out *ImageStreamList, c *co
 	return nil
 }
 
+func DeepCopy_v1_ImageStreamManifestList(in ImageStreamManifestList, out *ImageStreamManifestList, c *conversion.Cloner) error {
+	if err := unversioned.DeepCopy_unversioned_TypeMeta(in.TypeMeta, &out.TypeMeta, c); err != nil {
+		return err
+	}
+	if err := api_v1.DeepCopy_v1_ObjectMeta(in.ObjectMeta, &out.ObjectMeta, c); err != nil {
+		return err
+	}
+	out.Tag = in.Tag
+	if in.SourceTags != nil {
+		in, out := in.SourceTags, &out.SourceTags
+		*out = make([]string, len(in))
+		copy(*out, in)
+	} else {
+		out.SourceTags = nil
+	}
+	if err := DeepCopy_v1_Image(in.Image, &out.Image, c); err != nil {
+		return err
+	}
+	return nil
+}
+
 func DeepCopy_v1_ImageStreamMapping(in ImageStreamMapping, out *ImageStreamMapping, c *conversion.Cloner) error {
 	if err := unversioned.DeepCopy_unversioned_TypeMeta(in.TypeMeta, &out.TypeMeta, c); err != nil {
 		return err
@@ -398,6 +437,15 @@ func DeepCopy_v1_ImageStreamSpec(in ImageStreamSpec, out *ImageStreamSpec, c *co
 
 func DeepCopy_v1_ImageStreamStatus(in ImageStreamStatus, out *ImageStreamStatus, c *conversion.Cloner) error {
 	out.DockerImageRepository = in.DockerImageRepository
+	if in.DockerImageRepositoryVariants != nil {
+		in, out := in.DockerImageRepositoryVariants, &out.DockerImageRepositoryVariants
+		*out = make(map[string]string)
+		for key, val := range in {
+			(*out)[key] = val
+		}
+	} else {
+		out.DockerImageRepositoryVariants = nil
+	}
 	if in.Tags != nil {
 		in, out := in.Tags, &out.Tags
 		*out = make([]NamedTagEventList, len(in))
@@ -572,6 +620,13 @@ func DeepCopy_v1_TagEvent(in TagEvent, out *TagEvent, c *conversion.Cloner) erro
 	out.DockerImageReference = in.DockerImageReference
 	out.Image = in.Image
 	out.Generation = in.Generation
+	if in.Platforms != nil {
+		in, out := in.Platforms, &out.Platforms
+		*out = make([]string, len(in))
+		copy(*out, in)
+	} else {
+		out.Platforms = nil
+	}
 	return nil
 }
 
@@ -590,6 +645,13 @@ func DeepCopy_v1_TagEventCondition(in TagEventCondition, out *TagEventCondition,
 func DeepCopy_v1_TagImportPolicy(in TagImportPolicy, out *TagImportPolicy, c *conversion.Cloner) error {
 	out.Insecure = in.Insecure
 	out.Scheduled = in.Scheduled
+	if in.Platforms != nil {
+		in, out := in.Platforms, &out.Platforms
+		*out = make([]string, len(in))
+		copy(*out, in)
+	} else {
+		out.Platforms = nil
+	}
 	return nil
 }
 
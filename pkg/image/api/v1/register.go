@@ -30,10 +30,13 @@ func addKnownTypes(scheme *runtime.Scheme) {
 		&ImageStream{},
 		&ImageStreamList{},
 		&ImageStreamMapping{},
+		&ImageStreamManifestList{},
 		&ImageStreamTag{},
 		&ImageStreamTagList{},
 		&ImageStreamImage{},
 		&ImageStreamImport{},
+		&ImagePromotionPolicy{},
+		&ImagePromotionPolicyList{},
 	)
 }
 
@@ -43,7 +46,14 @@ func (obj *ImageSignature) GetObjectKind() unversioned.ObjectKind     { return &
 func (obj *ImageStream) GetObjectKind() unversioned.ObjectKind        { return &obj.TypeMeta }
 func (obj *ImageStreamList) GetObjectKind() unversioned.ObjectKind    { return &obj.TypeMeta }
 func (obj *ImageStreamMapping) GetObjectKind() unversioned.ObjectKind { return &obj.TypeMeta }
-func (obj *ImageStreamTag) GetObjectKind() unversioned.ObjectKind     { return &obj.TypeMeta }
-func (obj *ImageStreamTagList) GetObjectKind() unversioned.ObjectKind { return &obj.TypeMeta }
-func (obj *ImageStreamImage) GetObjectKind() unversioned.ObjectKind   { return &obj.TypeMeta }
-func (obj *ImageStreamImport) GetObjectKind() unversioned.ObjectKind  { return &obj.TypeMeta }
+func (obj *ImageStreamManifestList) GetObjectKind() unversioned.ObjectKind {
+	return &obj.TypeMeta
+}
+func (obj *ImageStreamTag) GetObjectKind() unversioned.ObjectKind       { return &obj.TypeMeta }
+func (obj *ImageStreamTagList) GetObjectKind() unversioned.ObjectKind   { return &obj.TypeMeta }
+func (obj *ImageStreamImage) GetObjectKind() unversioned.ObjectKind     { return &obj.TypeMeta }
+func (obj *ImageStreamImport) GetObjectKind() unversioned.ObjectKind    { return &obj.TypeMeta }
+func (obj *ImagePromotionPolicy) GetObjectKind() unversioned.ObjectKind { return &obj.TypeMeta }
+func (obj *ImagePromotionPolicyList) GetObjectKind() unversioned.ObjectKind {
+	return &obj.TypeMeta
+}
@@ -186,6 +186,10 @@ type TagImportPolicy struct {
 	Insecure bool `json:"insecure,omitempty" protobuf:"varint,1,opt,name=insecure"`
 	// Scheduled indicates to the server that this tag should be periodically checked to ensure it is up to date, and imported
 	Scheduled bool `json:"scheduled,omitempty" protobuf:"varint,2,opt,name=scheduled"`
+	// Platforms restricts which platforms are imported when the remote tag is a manifest list. Each entry is
+	// either "os/architecture" or a bare architecture (implying linux). When set and the remote tag is a
+	// manifest list, only the first matching platform image is imported. Ignored for single platform images.
+	Platforms []string `json:"platforms,omitempty" protobuf:"bytes,3,rep,name=platforms"`
 }
 
 // ImageStreamStatus contains information about the state of this image stream.
@@ -193,6 +197,10 @@ type ImageStreamStatus struct {
 	// DockerImageRepository represents the effective location this stream may be accessed at.
 	// May be empty until the server determines where the repository is located
 	DockerImageRepository string `json:"dockerImageRepository" protobuf:"bytes,1,opt,name=dockerImageRepository"`
+	// DockerImageRepositoryVariants lists alternative locations this stream may be accessed at,
+	// keyed by variant name (e.g. "external" for the registry route), so consumers can pick the
+	// endpoint matching their network location. Derived from master configuration; may be empty.
+	DockerImageRepositoryVariants map[string]string `json:"dockerImageRepositoryVariants,omitempty" protobuf:"bytes,3,rep,name=dockerImageRepositoryVariants"`
 	// Tags are a historical record of images associated with each tag. The first entry in the
 	// TagEvent array is the currently tagged image.
 	Tags []NamedTagEventList `json:"tags,omitempty" protobuf:"bytes,2,rep,name=tags"`
@@ -218,6 +226,9 @@ type TagEvent struct {
 	Image string `json:"image" protobuf:"bytes,3,opt,name=image"`
 	// Generation is the spec tag generation that resulted in this tag being updated
 	Generation int64 `json:"generation" protobuf:"varint,4,opt,name=generation"`
+	// Platforms lists the platforms of the tagged image as "os/architecture" strings. A manifest
+	// list yields one entry per platform image; single platform images have at most one entry.
+	Platforms []string `json:"platforms,omitempty" protobuf:"bytes,5,rep,name=platforms"`
 }
 
 type TagEventConditionType string
@@ -257,6 +268,23 @@ type ImageStreamMapping struct {
 	Tag string `json:"tag" protobuf:"bytes,3,opt,name=tag"`
 }
 
+// ImageStreamManifestList assembles a manifest list from platform specific images that were
+// already pushed to an image stream. Name identifies the stream, SourceTags name the images to
+// combine, and on success Image is filled with the manifest list image recorded under Tag.
+type ImageStreamManifestList struct {
+	unversioned.TypeMeta `json:",inline"`
+	// Standard object's metadata.
+	kapi.ObjectMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+
+	// Tag is the status tag the assembled manifest list is recorded under.
+	Tag string `json:"tag" protobuf:"bytes,2,opt,name=tag"`
+	// SourceTags name tags in the same image stream whose current images become the entries of
+	// the manifest list. Each image must carry platform metadata.
+	SourceTags []string `json:"sourceTags" protobuf:"bytes,3,rep,name=sourceTags"`
+	// Image is set by the server to the manifest list image that was created.
+	Image Image `json:"image,omitempty" protobuf:"bytes,4,opt,name=image"`
+}
+
 // ImageStreamTag represents an Image that is retrieved by tag name from an ImageStream.
 type ImageStreamTag struct {
 	unversioned.TypeMeta `json:",inline"`
@@ -391,4 +419,94 @@ type ImageImportStatus struct {
 	Image *Image `json:"image,omitempty" protobuf:"bytes,2,opt,name=image"`
 	// Tag is the tag this image was located under, if any
 	Tag string `json:"tag,omitempty" protobuf:"bytes,3,opt,name=tag"`
+	// Progress reports how far the import of this image has come, and whether a failed import
+	// may succeed when retried
+	Progress *ImageImportProgress `json:"progress,omitempty" protobuf:"bytes,4,opt,name=progress"`
+}
+
+// ImageImportPhase describes how far a single image import has progressed.
+type ImageImportPhase string
+
+const (
+	// ImageImportPhasePending means the import of this image has not started yet.
+	ImageImportPhasePending ImageImportPhase = "Pending"
+	// ImageImportPhaseImporting means the manifest or blobs of this image are being retrieved.
+	ImageImportPhaseImporting ImageImportPhase = "Importing"
+	// ImageImportPhaseComplete means the image was imported successfully.
+	ImageImportPhaseComplete ImageImportPhase = "Complete"
+	// ImageImportPhaseFailed means the import of this image failed.
+	ImageImportPhaseFailed ImageImportPhase = "Failed"
+)
+
+// ImageImportProgress reports fine grained progress of a single image import.
+type ImageImportProgress struct {
+	// Phase is the current phase of this image import
+	Phase ImageImportPhase `json:"phase" protobuf:"bytes,1,opt,name=phase,casttype=ImageImportPhase"`
+	// BytesTransferred is the number of manifest and blob bytes retrieved so far
+	BytesTransferred int64 `json:"bytesTransferred,omitempty" protobuf:"varint,2,opt,name=bytesTransferred"`
+	// Retriable is true if the error recorded on the status is likely transient and the import
+	// may succeed when repeated; it is false for permanent errors such as denied access or a
+	// missing image
+	Retriable bool `json:"retriable,omitempty" protobuf:"varint,3,opt,name=retriable"`
+}
+
+// ImagePromotionPolicy describes the automatic promotion of images from one image stream tag to
+// another, optionally in a different namespace. When the image currently tagged by the source
+// satisfies every configured condition, the promotion controller retags it to the target. The
+// promotion is a tag event referencing the same image, so the registry serves the promoted tag
+// by linking the blobs it already stores - no image data is copied.
+type ImagePromotionPolicy struct {
+	unversioned.TypeMeta `json:",inline"`
+	// Standard object's metadata.
+	kapi.ObjectMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+
+	// Spec names the source and target tags and the conditions a source image must meet.
+	Spec ImagePromotionPolicySpec `json:"spec" protobuf:"bytes,2,opt,name=spec"`
+	// Status reports the most recent promotion performed by this policy.
+	Status ImagePromotionPolicyStatus `json:"status,omitempty" protobuf:"bytes,3,opt,name=status"`
+}
+
+// ImagePromotionPolicySpec describes what to promote, where to, and under which conditions.
+type ImagePromotionPolicySpec struct {
+	// SourceStream is the name of an image stream in the policy's namespace to promote from.
+	SourceStream string `json:"sourceStream" protobuf:"bytes,1,opt,name=sourceStream"`
+	// SourceTag is the tag on the source stream that is watched for new images.
+	SourceTag string `json:"sourceTag" protobuf:"bytes,2,opt,name=sourceTag"`
+	// TargetNamespace is the namespace of the target stream. Empty means the policy's
+	// namespace.
+	TargetNamespace string `json:"targetNamespace,omitempty" protobuf:"bytes,3,opt,name=targetNamespace"`
+	// TargetStream is the name of the image stream to promote into. Empty means sourceStream.
+	// The stream is created on first promotion if it does not exist.
+	TargetStream string `json:"targetStream,omitempty" protobuf:"bytes,4,opt,name=targetStream"`
+	// TargetTag is the tag the promoted image is recorded under.
+	TargetTag string `json:"targetTag" protobuf:"bytes,5,opt,name=targetTag"`
+	// RequireSignature restricts promotion to images carrying at least one signature.
+	RequireSignature bool `json:"requireSignature,omitempty" protobuf:"varint,6,opt,name=requireSignature"`
+	// RequireScanPassed restricts promotion to images whose scan status annotation reports a
+	// passed scan.
+	RequireScanPassed bool `json:"requireScanPassed,omitempty" protobuf:"varint,7,opt,name=requireScanPassed"`
+	// RequireApproval restricts promotion to images whose digest has been recorded in the
+	// policy's approval annotation.
+	RequireApproval bool `json:"requireApproval,omitempty" protobuf:"varint,8,opt,name=requireApproval"`
+}
+
+// ImagePromotionPolicyStatus reports the outcome of the most recent promotion attempt.
+type ImagePromotionPolicyStatus struct {
+	// PromotedImage is the digest of the image most recently promoted by this policy.
+	PromotedImage string `json:"promotedImage,omitempty" protobuf:"bytes,1,opt,name=promotedImage"`
+	// LastPromotionTime is when promotedImage was promoted.
+	LastPromotionTime *unversioned.Time `json:"lastPromotionTime,omitempty" protobuf:"bytes,2,opt,name=lastPromotionTime"`
+	// Reason explains why the current source image has not been promoted. It is empty while
+	// the source and target tags are in sync.
+	Reason string `json:"reason,omitempty" protobuf:"bytes,3,opt,name=reason"`
+}
+
+// ImagePromotionPolicyList is a list of ImagePromotionPolicy objects.
+type ImagePromotionPolicyList struct {
+	unversioned.TypeMeta `json:",inline"`
+	// Standard object's metadata.
+	unversioned.ListMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+
+	// Items is the list of image promotion policies
+	Items []ImagePromotionPolicy `json:"items" protobuf:"bytes,2,rep,name=items"`
 }
@@ -345,3 +345,70 @@ func ValidateImageStreamImport(isi *api.ImageStreamImport) field.ErrorList {
 	errs = append(errs, validation.ValidateObjectMeta(&isi.ObjectMeta, true, ValidateImageStreamName, field.NewPath("metadata"))...)
 	return errs
 }
+
+// ValidateImageStreamManifestList tests required fields for an ImageStreamManifestList.
+func ValidateImageStreamManifestList(list *api.ImageStreamManifestList) field.ErrorList {
+	errs := validation.ValidateObjectMeta(&list.ObjectMeta, true, ValidateImageStreamName, field.NewPath("metadata"))
+	if len(list.Tag) == 0 {
+		errs = append(errs, field.Required(field.NewPath("tag"), ""))
+	}
+	if len(list.SourceTags) == 0 {
+		errs = append(errs, field.Required(field.NewPath("sourceTags"), "at least one source tag is required"))
+	}
+	for i, tag := range list.SourceTags {
+		if tag == list.Tag {
+			errs = append(errs, field.Invalid(field.NewPath("sourceTags").Index(i), tag, "a manifest list may not include itself"))
+		}
+	}
+	return errs
+}
+
+// ValidateImagePromotionPolicy tests required fields for an ImagePromotionPolicy.
+func ValidateImagePromotionPolicy(policy *api.ImagePromotionPolicy) field.ErrorList {
+	result := validation.ValidateObjectMeta(&policy.ObjectMeta, true, oapi.MinimalNameRequirements, field.NewPath("metadata"))
+	specPath := field.NewPath("spec")
+	spec := policy.Spec
+
+	if len(spec.SourceStream) == 0 {
+		result = append(result, field.Required(specPath.Child("sourceStream"), ""))
+	} else if reasons := ValidateImageStreamName(spec.SourceStream, false); len(reasons) != 0 {
+		result = append(result, field.Invalid(specPath.Child("sourceStream"), spec.SourceStream, strings.Join(reasons, ", ")))
+	}
+	if len(spec.SourceTag) == 0 {
+		result = append(result, field.Required(specPath.Child("sourceTag"), ""))
+	}
+	if len(spec.TargetNamespace) != 0 {
+		if reasons := validation.ValidateNamespaceName(spec.TargetNamespace, false); len(reasons) != 0 {
+			result = append(result, field.Invalid(specPath.Child("targetNamespace"), spec.TargetNamespace, strings.Join(reasons, ", ")))
+		}
+	}
+	if len(spec.TargetStream) != 0 {
+		if reasons := ValidateImageStreamName(spec.TargetStream, false); len(reasons) != 0 {
+			result = append(result, field.Invalid(specPath.Child("targetStream"), spec.TargetStream, strings.Join(reasons, ", ")))
+		}
+	}
+	if len(spec.TargetTag) == 0 {
+		result = append(result, field.Required(specPath.Child("targetTag"), ""))
+	}
+
+	targetNamespace := spec.TargetNamespace
+	if len(targetNamespace) == 0 {
+		targetNamespace = policy.Namespace
+	}
+	targetStream := spec.TargetStream
+	if len(targetStream) == 0 {
+		targetStream = spec.SourceStream
+	}
+	if targetNamespace == policy.Namespace && targetStream == spec.SourceStream && spec.TargetTag == spec.SourceTag {
+		result = append(result, field.Invalid(specPath.Child("targetTag"), spec.TargetTag, "target must differ from source"))
+	}
+
+	return result
+}
+
+// ValidateImagePromotionPolicyUpdate tests updated fields for an ImagePromotionPolicy.
+func ValidateImagePromotionPolicyUpdate(newPolicy, oldPolicy *api.ImagePromotionPolicy) field.ErrorList {
+	result := validation.ValidateObjectMetaUpdate(&newPolicy.ObjectMeta, &oldPolicy.ObjectMeta, field.NewPath("metadata"))
+	result = append(result, ValidateImagePromotionPolicy(newPolicy)...)
+	return result
+}
@@ -835,3 +835,78 @@ func TestValidateImageStreamImport(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateImagePromotionPolicy(t *testing.T) {
+	valid := func() *api.ImagePromotionPolicy {
+		return &api.ImagePromotionPolicy{
+			ObjectMeta: kapi.ObjectMeta{Namespace: "dev", Name: "promote-app"},
+			Spec: api.ImagePromotionPolicySpec{
+				SourceStream:    "app",
+				SourceTag:       "latest",
+				TargetNamespace: "prod",
+				TargetTag:       "stable",
+			},
+		}
+	}
+	if errs := ValidateImagePromotionPolicy(valid()); len(errs) > 0 {
+		t.Errorf("Unexpected non-empty error list: %#v", errs)
+	}
+
+	errorCases := map[string]struct {
+		mutate func(*api.ImagePromotionPolicy)
+		T      field.ErrorType
+		F      string
+	}{
+		"missing source stream": {
+			mutate: func(policy *api.ImagePromotionPolicy) { policy.Spec.SourceStream = "" },
+			T:      field.ErrorTypeRequired,
+			F:      "spec.sourceStream",
+		},
+		"invalid source stream": {
+			mutate: func(policy *api.ImagePromotionPolicy) { policy.Spec.SourceStream = "foo/bar" },
+			T:      field.ErrorTypeInvalid,
+			F:      "spec.sourceStream",
+		},
+		"missing source tag": {
+			mutate: func(policy *api.ImagePromotionPolicy) { policy.Spec.SourceTag = "" },
+			T:      field.ErrorTypeRequired,
+			F:      "spec.sourceTag",
+		},
+		"missing target tag": {
+			mutate: func(policy *api.ImagePromotionPolicy) { policy.Spec.TargetTag = "" },
+			T:      field.ErrorTypeRequired,
+			F:      "spec.targetTag",
+		},
+		"invalid target namespace": {
+			mutate: func(policy *api.ImagePromotionPolicy) { policy.Spec.TargetNamespace = "Not/Valid" },
+			T:      field.ErrorTypeInvalid,
+			F:      "spec.targetNamespace",
+		},
+		"target equals source": {
+			mutate: func(policy *api.ImagePromotionPolicy) {
+				policy.Spec.TargetNamespace = ""
+				policy.Spec.TargetTag = policy.Spec.SourceTag
+			},
+			T: field.ErrorTypeInvalid,
+			F: "spec.targetTag",
+		},
+	}
+	for name, test := range errorCases {
+		policy := valid()
+		test.mutate(policy)
+		errs := ValidateImagePromotionPolicy(policy)
+		if len(errs) == 0 {
+			t.Errorf("%s: expected failure", name)
+			continue
+		}
+		match := false
+		for _, err := range errs {
+			if err.Type == test.T && err.Field == test.F {
+				match = true
+			}
+		}
+		if !match {
+			t.Errorf("%s: expected a %s error on %s, got %#v", name, test.T, test.F, errs)
+		}
+	}
+}
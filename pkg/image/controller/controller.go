@@ -141,6 +141,10 @@ func (c *ImportController) Next(stream *api.ImageStream, notifier Notifier) erro
 			Namespace:       stream.Namespace,
 			ResourceVersion: stream.ResourceVersion,
 			UID:             stream.UID,
+			Annotations: map[string]string{
+				// controller-driven imports yield to user-initiated ones
+				api.BackgroundImportAnnotation: "true",
+			},
 		},
 		Spec: api.ImageStreamImportSpec{Import: true},
 	}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	apierrs "k8s.io/kubernetes/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/client/cache"
+	"k8s.io/kubernetes/pkg/runtime"
+	kutilerrors "k8s.io/kubernetes/pkg/util/errors"
+	"k8s.io/kubernetes/pkg/util/flowcontrol"
+	utilruntime "k8s.io/kubernetes/pkg/util/runtime"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/openshift/origin/pkg/client"
+	"github.com/openshift/origin/pkg/controller"
+	"github.com/openshift/origin/pkg/image/api"
+)
+
+// TagExpirationControllerFactory can create a TagExpirationController.
+type TagExpirationControllerFactory struct {
+	Client         client.Interface
+	ResyncInterval time.Duration
+}
+
+// Create creates a TagExpirationController.
+func (f *TagExpirationControllerFactory) Create() controller.RunnableController {
+	lw := &cache.ListWatch{
+		ListFunc: func(options kapi.ListOptions) (runtime.Object, error) {
+			return f.Client.ImageStreams(kapi.NamespaceAll).List(options)
+		},
+		WatchFunc: func(options kapi.ListOptions) (watch.Interface, error) {
+			return f.Client.ImageStreams(kapi.NamespaceAll).Watch(options)
+		},
+	}
+	q := cache.NewResyncableFIFO(cache.MetaNamespaceKeyFunc)
+	cache.NewReflector(lw, &api.ImageStream{}, q, f.ResyncInterval).Run()
+
+	c := &TagExpirationController{
+		tags: f.Client,
+		now:  time.Now,
+	}
+
+	return &controller.RetryController{
+		Queue: q,
+		RetryManager: controller.NewQueueRetryManager(
+			q,
+			cache.MetaNamespaceKeyFunc,
+			func(obj interface{}, err error, retries controller.Retry) bool {
+				utilruntime.HandleError(err)
+				return retries.Count < 5
+			},
+			flowcontrol.NewTokenBucketRateLimiter(1, 10),
+		),
+		Handle: func(obj interface{}) error {
+			return c.Next(obj.(*api.ImageStream))
+		},
+	}
+}
+
+// TagExpirationController removes spec tags whose time to live annotation has lapsed, making the
+// previously tagged images eligible for pruning. Tags that have not expired yet are picked up on
+// a later resync - the check interval is therefore bounded by the factory's ResyncInterval.
+type TagExpirationController struct {
+	tags client.ImageStreamTagsNamespacer
+	now  func() time.Time
+}
+
+// Next deletes every expired tag of the given image stream. A tag expires the configured duration
+// after the creation of its most recent tag event.
+func (c *TagExpirationController) Next(stream *api.ImageStream) error {
+	errs := []error{}
+	for tag, tagRef := range stream.Spec.Tags {
+		value, ok := tagRef.Annotations[api.TagTimeToLiveAnnotation]
+		if !ok {
+			continue
+		}
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			glog.V(4).Infof("Ignoring invalid %s annotation on %s/%s:%s: %v", api.TagTimeToLiveAnnotation, stream.Namespace, stream.Name, tag, err)
+			continue
+		}
+		if ttl <= 0 {
+			continue
+		}
+		latest := api.LatestTaggedImage(stream, tag)
+		if latest == nil {
+			continue
+		}
+		if c.now().Before(latest.Created.Add(ttl)) {
+			continue
+		}
+		glog.V(4).Infof("Removing expired tag %s/%s:%s", stream.Namespace, stream.Name, tag)
+		if err := c.tags.ImageStreamTags(stream.Namespace).Delete(stream.Name, tag); err != nil && !apierrs.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+	return kutilerrors.NewAggregate(errs)
+}
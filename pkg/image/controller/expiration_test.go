@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+
+	client "github.com/openshift/origin/pkg/client/testclient"
+	"github.com/openshift/origin/pkg/image/api"
+)
+
+func TestTagExpiration(t *testing.T) {
+	now := unversioned.Now()
+	stream := func(annotations map[string]string, created time.Time) *api.ImageStream {
+		return &api.ImageStream{
+			ObjectMeta: kapi.ObjectMeta{Namespace: "test", Name: "scratch"},
+			Spec: api.ImageStreamSpec{
+				Tags: map[string]api.TagReference{
+					"pr-1": {Annotations: annotations},
+				},
+			},
+			Status: api.ImageStreamStatus{
+				Tags: map[string]api.TagEventList{
+					"pr-1": {Items: []api.TagEvent{{Created: unversioned.NewTime(created)}}},
+				},
+			},
+		}
+	}
+
+	testCases := map[string]struct {
+		stream        *api.ImageStream
+		expectDeleted bool
+	}{
+		"no annotation": {
+			stream:        stream(nil, now.Add(-time.Hour)),
+			expectDeleted: false,
+		},
+		"invalid duration": {
+			stream:        stream(map[string]string{api.TagTimeToLiveAnnotation: "soon"}, now.Add(-time.Hour)),
+			expectDeleted: false,
+		},
+		"not yet expired": {
+			stream:        stream(map[string]string{api.TagTimeToLiveAnnotation: "2h"}, now.Add(-time.Hour)),
+			expectDeleted: false,
+		},
+		"expired": {
+			stream:        stream(map[string]string{api.TagTimeToLiveAnnotation: "30m"}, now.Add(-time.Hour)),
+			expectDeleted: true,
+		},
+	}
+
+	for name, test := range testCases {
+		fake := client.NewSimpleFake()
+		c := TagExpirationController{tags: fake, now: func() time.Time { return now.Time }}
+
+		if err := c.Next(test.stream); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+
+		deleted := false
+		for _, action := range fake.Actions() {
+			if action.Matches("delete", "imagestreamtags") {
+				deleted = true
+			}
+		}
+		if deleted != test.expectDeleted {
+			t.Errorf("%s: expected deletion %t, got %t", name, test.expectDeleted, deleted)
+		}
+	}
+}
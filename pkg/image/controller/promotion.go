@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	apierrs "k8s.io/kubernetes/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/client/cache"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/util/flowcontrol"
+	utilruntime "k8s.io/kubernetes/pkg/util/runtime"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/openshift/origin/pkg/client"
+	"github.com/openshift/origin/pkg/controller"
+	"github.com/openshift/origin/pkg/image/api"
+)
+
+// ImagePromotionControllerFactory can create an ImagePromotionController.
+type ImagePromotionControllerFactory struct {
+	Client         client.Interface
+	ResyncInterval time.Duration
+}
+
+// Create creates an ImagePromotionController.
+func (f *ImagePromotionControllerFactory) Create() controller.RunnableController {
+	lw := &cache.ListWatch{
+		ListFunc: func(options kapi.ListOptions) (runtime.Object, error) {
+			return f.Client.ImagePromotionPolicies(kapi.NamespaceAll).List(options)
+		},
+		WatchFunc: func(options kapi.ListOptions) (watch.Interface, error) {
+			return f.Client.ImagePromotionPolicies(kapi.NamespaceAll).Watch(options)
+		},
+	}
+	q := cache.NewResyncableFIFO(cache.MetaNamespaceKeyFunc)
+	cache.NewReflector(lw, &api.ImagePromotionPolicy{}, q, f.ResyncInterval).Run()
+
+	c := &ImagePromotionController{
+		client: f.Client,
+	}
+
+	return &controller.RetryController{
+		Queue: q,
+		RetryManager: controller.NewQueueRetryManager(
+			q,
+			cache.MetaNamespaceKeyFunc,
+			func(obj interface{}, err error, retries controller.Retry) bool {
+				utilruntime.HandleError(err)
+				return retries.Count < 5
+			},
+			flowcontrol.NewTokenBucketRateLimiter(1, 10),
+		),
+		Handle: func(obj interface{}) error {
+			return c.Next(obj.(*api.ImagePromotionPolicy))
+		},
+	}
+}
+
+// ImagePromotionController retags the image currently held by a policy's source tag to the
+// policy's target tag once every configured condition is met. The target tag references the
+// source image by digest, so the registry serves it by linking the blobs it already stores.
+// Approving a digest on the policy triggers promotion through the watch; a new source image is
+// picked up on the next resync.
+type ImagePromotionController struct {
+	client client.Interface
+}
+
+// Next promotes the current source image of the given policy if its conditions are met,
+// recording the outcome - or the reason promotion is withheld - on the policy status.
+func (c *ImagePromotionController) Next(policy *api.ImagePromotionPolicy) error {
+	stream, err := c.client.ImageStreams(policy.Namespace).Get(policy.Spec.SourceStream)
+	if apierrs.IsNotFound(err) {
+		return c.setReason(policy, fmt.Sprintf("source image stream %s does not exist", policy.Spec.SourceStream))
+	}
+	if err != nil {
+		return err
+	}
+
+	latest := api.LatestTaggedImage(stream, policy.Spec.SourceTag)
+	if latest == nil || len(latest.Image) == 0 {
+		return c.setReason(policy, fmt.Sprintf("source tag %s has no image", policy.Spec.SourceTag))
+	}
+	digest := latest.Image
+	if policy.Status.PromotedImage == digest {
+		return nil
+	}
+
+	reason, err := c.unmetCondition(policy, digest)
+	if err != nil {
+		return err
+	}
+	if len(reason) != 0 {
+		return c.setReason(policy, reason)
+	}
+
+	if err := c.promote(policy, digest); err != nil {
+		return err
+	}
+	glog.V(4).Infof("Promoted %s@%s to %s per policy %s/%s", policy.Spec.SourceStream, digest, policy.Spec.TargetTag, policy.Namespace, policy.Name)
+
+	now := unversioned.Now()
+	policy.Status.PromotedImage = digest
+	policy.Status.LastPromotionTime = &now
+	policy.Status.Reason = ""
+	_, err = c.client.ImagePromotionPolicies(policy.Namespace).Update(policy)
+	return err
+}
+
+// unmetCondition returns the reason the given digest may not be promoted yet, or empty when all
+// of the policy's conditions hold.
+func (c *ImagePromotionController) unmetCondition(policy *api.ImagePromotionPolicy, digest string) (string, error) {
+	if policy.Spec.RequireSignature || policy.Spec.RequireScanPassed {
+		image, err := c.client.Images().Get(digest)
+		if err != nil {
+			return "", err
+		}
+		if policy.Spec.RequireSignature && len(image.Signatures) == 0 && len(image.DockerImageSignatures) == 0 {
+			return fmt.Sprintf("image %s is not signed", digest), nil
+		}
+		if policy.Spec.RequireScanPassed && image.Annotations[api.ImageScanStatusAnnotation] != api.ImageScanStatusPassed {
+			return fmt.Sprintf("image %s has not passed a scan", digest), nil
+		}
+	}
+	if policy.Spec.RequireApproval && policy.Annotations[api.PromotionApprovedAnnotation] != digest {
+		return fmt.Sprintf("waiting for approval of image %s", digest), nil
+	}
+	return "", nil
+}
+
+// promote records the digest under the policy's target tag by referencing the source image,
+// creating the target stream if necessary.
+func (c *ImagePromotionController) promote(policy *api.ImagePromotionPolicy, digest string) error {
+	targetNamespace := policy.Spec.TargetNamespace
+	if len(targetNamespace) == 0 {
+		targetNamespace = policy.Namespace
+	}
+	targetName := policy.Spec.TargetStream
+	if len(targetName) == 0 {
+		targetName = policy.Spec.SourceStream
+	}
+	from := &kapi.ObjectReference{
+		Kind:      "ImageStreamImage",
+		Namespace: policy.Namespace,
+		Name:      fmt.Sprintf("%s@%s", policy.Spec.SourceStream, digest),
+	}
+
+	target, err := c.client.ImageStreams(targetNamespace).Get(targetName)
+	if apierrs.IsNotFound(err) {
+		target = &api.ImageStream{
+			ObjectMeta: kapi.ObjectMeta{Namespace: targetNamespace, Name: targetName},
+			Spec: api.ImageStreamSpec{
+				Tags: map[string]api.TagReference{
+					policy.Spec.TargetTag: {Name: policy.Spec.TargetTag, From: from},
+				},
+			},
+		}
+		_, err = c.client.ImageStreams(targetNamespace).Create(target)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if target.Spec.Tags == nil {
+		target.Spec.Tags = map[string]api.TagReference{}
+	}
+	tagRef := target.Spec.Tags[policy.Spec.TargetTag]
+	tagRef.Name = policy.Spec.TargetTag
+	tagRef.From = from
+	target.Spec.Tags[policy.Spec.TargetTag] = tagRef
+	_, err = c.client.ImageStreams(targetNamespace).Update(target)
+	return err
+}
+
+// setReason records why the policy has not promoted its current source image. An unchanged
+// reason is not written again, so a blocked policy does not loop on its own status updates.
+func (c *ImagePromotionController) setReason(policy *api.ImagePromotionPolicy, reason string) error {
+	if policy.Status.Reason == reason {
+		return nil
+	}
+	policy.Status.Reason = reason
+	_, err := c.client.ImagePromotionPolicies(policy.Namespace).Update(policy)
+	return err
+}
@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"testing"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+
+	client "github.com/openshift/origin/pkg/client/testclient"
+	"github.com/openshift/origin/pkg/image/api"
+)
+
+func TestImagePromotionConditions(t *testing.T) {
+	const digest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+
+	stream := &api.ImageStream{
+		ObjectMeta: kapi.ObjectMeta{Namespace: "test", Name: "app"},
+		Status: api.ImageStreamStatus{
+			Tags: map[string]api.TagEventList{
+				"latest": {Items: []api.TagEvent{{Image: digest}}},
+			},
+		},
+	}
+	image := func(annotations map[string]string, signed bool) *api.Image {
+		image := &api.Image{ObjectMeta: kapi.ObjectMeta{Name: digest, Annotations: annotations}}
+		if signed {
+			image.DockerImageSignatures = [][]byte{[]byte("signature")}
+		}
+		return image
+	}
+	policy := func(requireSignature, requireScanPassed, requireApproval bool, annotations map[string]string) *api.ImagePromotionPolicy {
+		return &api.ImagePromotionPolicy{
+			ObjectMeta: kapi.ObjectMeta{Namespace: "test", Name: "promote-app", Annotations: annotations},
+			Spec: api.ImagePromotionPolicySpec{
+				SourceStream:      "app",
+				SourceTag:         "latest",
+				TargetTag:         "prod",
+				RequireSignature:  requireSignature,
+				RequireScanPassed: requireScanPassed,
+				RequireApproval:   requireApproval,
+			},
+		}
+	}
+	scanPassed := map[string]string{api.ImageScanStatusAnnotation: api.ImageScanStatusPassed}
+	approved := map[string]string{api.PromotionApprovedAnnotation: digest}
+
+	testCases := map[string]struct {
+		policy         *api.ImagePromotionPolicy
+		image          *api.Image
+		expectPromoted bool
+	}{
+		"no conditions": {
+			policy:         policy(false, false, false, nil),
+			image:          image(nil, false),
+			expectPromoted: true,
+		},
+		"unsigned image": {
+			policy:         policy(true, false, false, nil),
+			image:          image(nil, false),
+			expectPromoted: false,
+		},
+		"signed image": {
+			policy:         policy(true, false, false, nil),
+			image:          image(nil, true),
+			expectPromoted: true,
+		},
+		"scan not passed": {
+			policy:         policy(false, true, false, nil),
+			image:          image(nil, false),
+			expectPromoted: false,
+		},
+		"scan passed": {
+			policy:         policy(false, true, false, nil),
+			image:          image(scanPassed, false),
+			expectPromoted: true,
+		},
+		"not approved": {
+			policy:         policy(false, false, true, nil),
+			image:          image(nil, false),
+			expectPromoted: false,
+		},
+		"approved digest": {
+			policy:         policy(false, false, true, approved),
+			image:          image(nil, false),
+			expectPromoted: true,
+		},
+		"stale approval": {
+			policy:         policy(false, false, true, map[string]string{api.PromotionApprovedAnnotation: "sha256:other"}),
+			image:          image(nil, false),
+			expectPromoted: false,
+		},
+	}
+
+	for name, test := range testCases {
+		fake := client.NewSimpleFake(stream, test.image, test.policy)
+		c := ImagePromotionController{client: fake}
+
+		if err := c.Next(test.policy); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+
+		promoted := false
+		for _, action := range fake.Actions() {
+			if action.Matches("update", "imagestreams") {
+				promoted = true
+			}
+		}
+		if promoted != test.expectPromoted {
+			t.Errorf("%s: expected promotion %t, got %t", name, test.expectPromoted, promoted)
+		}
+		if test.expectPromoted {
+			if test.policy.Status.PromotedImage != digest {
+				t.Errorf("%s: status does not record the promoted digest: %#v", name, test.policy.Status)
+			}
+		} else if len(test.policy.Status.Reason) == 0 {
+			t.Errorf("%s: expected a reason explaining the withheld promotion", name)
+		}
+	}
+}
+
+func TestImagePromotionAlreadyPromoted(t *testing.T) {
+	const digest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+
+	stream := &api.ImageStream{
+		ObjectMeta: kapi.ObjectMeta{Namespace: "test", Name: "app"},
+		Status: api.ImageStreamStatus{
+			Tags: map[string]api.TagEventList{
+				"latest": {Items: []api.TagEvent{{Image: digest}}},
+			},
+		},
+	}
+	policy := &api.ImagePromotionPolicy{
+		ObjectMeta: kapi.ObjectMeta{Namespace: "test", Name: "promote-app"},
+		Spec: api.ImagePromotionPolicySpec{
+			SourceStream: "app",
+			SourceTag:    "latest",
+			TargetTag:    "prod",
+		},
+		Status: api.ImagePromotionPolicyStatus{PromotedImage: digest},
+	}
+
+	fake := client.NewSimpleFake(stream, policy)
+	c := ImagePromotionController{client: fake}
+
+	if err := c.Next(policy); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, action := range fake.Actions() {
+		if action.Matches("update", "imagestreams") || action.Matches("update", "imagepromotionpolicies") {
+			t.Errorf("unexpected write for an up to date policy: %v", action)
+		}
+	}
+}
@@ -57,7 +57,7 @@ type Context struct {
 func (c Context) WithCredentials(credentials auth.CredentialStore) RepositoryRetriever {
 	return &repositoryRetriever{
 		context:     c,
-		credentials: credentials,
+		credentials: WithRegisteredCredentials(credentials),
 
 		pings:    make(map[url.URL]error),
 		redirect: make(map[url.URL]*url.URL),
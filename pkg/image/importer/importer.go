@@ -10,6 +10,7 @@ import (
 
 	"github.com/docker/distribution"
 	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/manifest/manifestlist"
 	"github.com/docker/distribution/manifest/schema1"
 	"github.com/docker/distribution/manifest/schema2"
 	"github.com/docker/distribution/reference"
@@ -83,13 +84,83 @@ func (i *ImageStreamImporter) contextImageCache(ctx gocontext.Context) {
 }
 
 // Import tries to complete the provided isi object with images loaded from remote registries.
+// Imports run in a global bounded pool that also limits concurrency per upstream registry;
+// scheduled background imports yield to user-initiated ones.
 func (i *ImageStreamImporter) Import(ctx gocontext.Context, isi *api.ImageStreamImport) error {
+	priority := priorityUser
+	if isi.Annotations[api.BackgroundImportAnnotation] == "true" {
+		priority = priorityScheduled
+	}
+	registry := upstreamRegistry(isi)
+	pool.acquire(priority, registry)
+	defer pool.release(registry)
+
 	i.contextImageCache(ctx)
 	i.importImages(ctx, i.retriever, isi, i.limiter)
 	i.importFromRepository(ctx, i.retriever, isi, i.maximumTagsPerRepo, i.limiter)
+	fillImportProgress(isi)
 	return nil
 }
 
+// upstreamRegistry returns the registry host most of the import will talk to, used to limit
+// concurrency per upstream.
+func upstreamRegistry(isi *api.ImageStreamImport) string {
+	name := ""
+	if isi.Spec.Repository != nil {
+		name = isi.Spec.Repository.From.Name
+	} else if len(isi.Spec.Images) > 0 {
+		name = isi.Spec.Images[0].From.Name
+	}
+	ref, err := api.ParseDockerImageReference(name)
+	if err != nil {
+		return ""
+	}
+	return ref.DockerClientDefaults().Registry
+}
+
+// fillImportProgress records final import progress on every image status so callers can
+// distinguish retriable failures from permanent ones and report transferred bytes.
+func fillImportProgress(isi *api.ImageStreamImport) {
+	for i := range isi.Status.Images {
+		isi.Status.Images[i].Progress = importProgress(&isi.Status.Images[i])
+	}
+	if isi.Status.Repository != nil {
+		for i := range isi.Status.Repository.Images {
+			isi.Status.Repository.Images[i].Progress = importProgress(&isi.Status.Repository.Images[i])
+		}
+	}
+}
+
+func importProgress(status *api.ImageImportStatus) *api.ImageImportProgress {
+	progress := &api.ImageImportProgress{}
+	switch status.Status.Status {
+	case unversioned.StatusSuccess:
+		progress.Phase = api.ImageImportPhaseComplete
+		if status.Image != nil {
+			progress.BytesTransferred = status.Image.DockerImageMetadata.Size
+		}
+	case "":
+		progress.Phase = api.ImageImportPhasePending
+	default:
+		progress.Phase = api.ImageImportPhaseFailed
+		progress.Retriable = isRetriableImportError(&status.Status)
+	}
+	return progress
+}
+
+// isRetriableImportError returns true if the recorded failure is likely transient - e.g. the
+// remote registry timed out or answered with a server error - and false for permanent errors
+// such as denied access or a missing image.
+func isRetriableImportError(status *unversioned.Status) bool {
+	switch status.Reason {
+	case unversioned.StatusReasonUnauthorized, unversioned.StatusReasonForbidden,
+		unversioned.StatusReasonNotFound, unversioned.StatusReasonInvalid,
+		unversioned.StatusReasonBadRequest, unversioned.StatusReasonMethodNotAllowed:
+		return false
+	}
+	return true
+}
+
 // importImages updates the passed ImageStreamImport object and sets Status for each image based on whether the import
 // succeeded or failed. Cache is updated with any loaded images. Limiter is optional and controls how fast images are updated.
 func (i *ImageStreamImporter) importImages(ctx gocontext.Context, retriever RepositoryRetriever, isi *api.ImageStreamImport, limiter flowcontrol.RateLimiter) {
@@ -118,10 +189,11 @@ func (i *ImageStreamImporter) importImages(ctx gocontext.Context, retriever Repo
 		repo, ok := repositories[key]
 		if !ok {
 			repo = &importRepository{
-				Ref:      ref,
-				Registry: &key.url,
-				Name:     key.name,
-				Insecure: spec.ImportPolicy.Insecure,
+				Ref:       ref,
+				Registry:  &key.url,
+				Name:      key.name,
+				Insecure:  spec.ImportPolicy.Insecure,
+				Platforms: spec.ImportPolicy.Platforms,
 			}
 			repositories[key] = repo
 		}
@@ -227,6 +299,7 @@ func (i *ImageStreamImporter) importFromRepository(ctx gocontext.Context, retrie
 		Registry:    &key.url,
 		Name:        key.name,
 		Insecure:    spec.ImportPolicy.Insecure,
+		Platforms:   spec.ImportPolicy.Platforms,
 		MaximumTags: maximumTags,
 	}
 	i.importRepositoryFromDocker(ctx, retriever, repo, limiter)
@@ -439,6 +512,14 @@ func (isi *ImageStreamImporter) importRepositoryFromDocker(ctx gocontext.Context
 			continue
 		}
 
+		if list, isList := manifest.(*manifestlist.DeserializedManifestList); isList {
+			manifest, d, err = manifestFromManifestList(ctx, s, list, repository.Ref, repository.Platforms)
+			if err != nil {
+				importDigest.Err = err
+				continue
+			}
+		}
+
 		if signedManifest, isSchema1 := manifest.(*schema1.SignedManifest); isSchema1 {
 			importDigest.Image, err = schema1ToImage(signedManifest, d)
 		} else if deserializedManifest, isSchema2 := manifest.(*schema2.DeserializedManifest); isSchema2 {
@@ -497,6 +578,14 @@ func (isi *ImageStreamImporter) importRepositoryFromDocker(ctx gocontext.Context
 			continue
 		}
 
+		if list, isList := manifest.(*manifestlist.DeserializedManifestList); isList {
+			manifest, _, err = manifestFromManifestList(ctx, s, list, repository.Ref, repository.Platforms)
+			if err != nil {
+				importTag.Err = err
+				continue
+			}
+		}
+
 		if signedManifest, isSchema1 := manifest.(*schema1.SignedManifest); isSchema1 {
 			importTag.Image, err = schema1ToImage(signedManifest, "")
 		} else if deserializedManifest, isSchema2 := manifest.(*schema2.DeserializedManifest); isSchema2 {
@@ -529,6 +618,34 @@ func (isi *ImageStreamImporter) importRepositoryFromDocker(ctx gocontext.Context
 	}
 }
 
+// manifestFromManifestList resolves a manifest list to the sub-manifest of the first platform that
+// matches one of the requested platforms. Platforms are specified as "os/architecture", or a bare
+// architecture which implies linux. When no platforms are requested linux/amd64 is chosen, matching
+// the behavior of Docker clients pulling a multi-arch tag.
+func manifestFromManifestList(ctx gocontext.Context, s distribution.ManifestService, list *manifestlist.DeserializedManifestList, ref api.DockerImageReference, platforms []string) (distribution.Manifest, digest.Digest, error) {
+	if len(platforms) == 0 {
+		platforms = []string{"linux/amd64"}
+	}
+	for _, platform := range platforms {
+		os, arch := "linux", platform
+		if i := strings.Index(platform, "/"); i != -1 {
+			os, arch = platform[:i], platform[i+1:]
+		}
+		for _, desc := range list.Manifests {
+			if desc.Platform.OS != os || desc.Platform.Architecture != arch {
+				continue
+			}
+			manifest, err := s.Get(ctx, desc.Digest)
+			if err != nil {
+				glog.V(5).Infof("unable to access platform %s/%s manifest %q for %q: %#v", os, arch, desc.Digest, ref.Exact(), err)
+				return nil, "", err
+			}
+			return manifest, desc.Digest, nil
+		}
+	}
+	return nil, "", kapierrors.NewBadRequest(fmt.Sprintf("the manifest list %q does not contain an image for platforms %v", ref.Exact(), platforms))
+}
+
 func importRepositoryFromDockerV1(ctx gocontext.Context, repository *importRepository, limiter flowcontrol.RateLimiter) {
 	value := ctx.Value(ContextKeyV1RegistryClient)
 	if value == nil {
@@ -634,10 +751,11 @@ type importDigest struct {
 }
 
 type importRepository struct {
-	Ref      api.DockerImageReference
-	Registry *url.URL
-	Name     string
-	Insecure bool
+	Ref       api.DockerImageReference
+	Registry  *url.URL
+	Name      string
+	Insecure  bool
+	Platforms []string
 
 	Tags    []importTag
 	Digests []importDigest
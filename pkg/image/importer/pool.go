@@ -0,0 +1,80 @@
+package importer
+
+import (
+	"sync"
+)
+
+const (
+	// defaultImportWorkers bounds how many imports may run concurrently in one process.
+	defaultImportWorkers = 8
+	// defaultPerRegistryImports bounds how many of those may talk to the same upstream
+	// registry, so many streams importing from one upstream don't stampede it.
+	defaultPerRegistryImports = 4
+)
+
+// importPool is the global bounded pool import operations run in. User-initiated imports are
+// admitted before scheduled background ones, so interactive `oc import-image` calls stay
+// responsive while the scheduled re-import controller churns.
+var pool = newImportPool(defaultImportWorkers, defaultPerRegistryImports)
+
+type importPriority int
+
+const (
+	priorityUser importPriority = iota
+	priorityScheduled
+)
+
+type importPool struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	workers     int
+	perRegistry int
+
+	active      int
+	byRegistry  map[string]int
+	userWaiting int
+}
+
+func newImportPool(workers, perRegistry int) *importPool {
+	p := &importPool{
+		workers:     workers,
+		perRegistry: perRegistry,
+		byRegistry:  make(map[string]int),
+	}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+// acquire blocks until a slot for the registry is free. Scheduled imports additionally wait
+// until no user-initiated import is queued.
+func (p *importPool) acquire(priority importPriority, registry string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if priority == priorityUser {
+		p.userWaiting++
+	}
+	for p.active >= p.workers ||
+		p.byRegistry[registry] >= p.perRegistry ||
+		(priority == priorityScheduled && p.userWaiting > 0) {
+		p.cond.Wait()
+	}
+	if priority == priorityUser {
+		p.userWaiting--
+	}
+	p.active++
+	p.byRegistry[registry]++
+}
+
+// release frees the slot taken by acquire.
+func (p *importPool) release(registry string) {
+	p.mu.Lock()
+	p.active--
+	p.byRegistry[registry]--
+	if p.byRegistry[registry] == 0 {
+		delete(p.byRegistry, registry)
+	}
+	p.mu.Unlock()
+	p.cond.Broadcast()
+}
@@ -0,0 +1,123 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sync"
+
+	"github.com/golang/glog"
+
+	"github.com/docker/distribution/registry/client/auth"
+
+	"k8s.io/kubernetes/pkg/credentialprovider"
+)
+
+// CredentialProvider supplies credentials for upstream registries. Providers are consulted by
+// import and pull-through in addition to whatever per-request credentials (e.g. dockercfg
+// secrets) apply, so cluster-wide sources like a mounted credentials file or a cloud
+// provider's credential helper can be added without touching the importer core.
+type CredentialProvider interface {
+	// Name identifies the provider in log messages.
+	Name() string
+	// Basic returns basic auth credentials for the URL and whether the provider has any.
+	Basic(url *url.URL) (username, password string, ok bool)
+}
+
+var (
+	credentialProvidersMu sync.Mutex
+	credentialProviders   []CredentialProvider
+)
+
+// RegisterCredentialProvider adds a provider consulted for every upstream registry request.
+// Providers are tried in registration order. It panics on a duplicate name, which is always a
+// programmer error.
+func RegisterCredentialProvider(provider CredentialProvider) {
+	credentialProvidersMu.Lock()
+	defer credentialProvidersMu.Unlock()
+	for _, existing := range credentialProviders {
+		if existing.Name() == provider.Name() {
+			panic(fmt.Sprintf("credential provider %q registered twice", provider.Name()))
+		}
+	}
+	credentialProviders = append(credentialProviders, provider)
+}
+
+func registeredCredentialProviders() []CredentialProvider {
+	credentialProvidersMu.Lock()
+	defer credentialProvidersMu.Unlock()
+	return credentialProviders
+}
+
+// WithRegisteredCredentials returns a credential store that consults the registered providers
+// first and falls back to the given store. With no providers registered the store is returned
+// unchanged.
+func WithRegisteredCredentials(fallback auth.CredentialStore) auth.CredentialStore {
+	if len(registeredCredentialProviders()) == 0 {
+		return fallback
+	}
+	return &providersCredentialStore{fallback: fallback}
+}
+
+type providersCredentialStore struct {
+	fallback auth.CredentialStore
+}
+
+func (s *providersCredentialStore) Basic(url *url.URL) (string, string) {
+	for _, provider := range registeredCredentialProviders() {
+		if username, password, ok := provider.Basic(url); ok {
+			glog.V(5).Infof("credential provider %q matched %s", provider.Name(), url)
+			return username, password
+		}
+	}
+	return s.fallback.Basic(url)
+}
+
+func (s *providersCredentialStore) RefreshToken(url *url.URL, service string) string {
+	return s.fallback.RefreshToken(url, service)
+}
+
+func (s *providersCredentialStore) SetRefreshToken(url *url.URL, service string, token string) {
+	s.fallback.SetRefreshToken(url, service, token)
+}
+
+// NewFileCredentialProvider reads a .dockercfg or docker config.json file and serves the
+// credentials in it. The file is parsed once; callers that need rotation should register a
+// new provider under a different name.
+func NewFileCredentialProvider(name, path string) (CredentialProvider, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := credentialprovider.DockerConfig{}
+	var cfgJSON credentialprovider.DockerConfigJson
+	if err := json.Unmarshal(contents, &cfgJSON); err == nil && len(cfgJSON.Auths) > 0 {
+		cfg = cfgJSON.Auths
+	} else if err := json.Unmarshal(contents, &cfg); err != nil {
+		return nil, fmt.Errorf("%s is neither a docker config.json nor a .dockercfg file: %v", path, err)
+	}
+	keyring := &credentialprovider.BasicDockerKeyring{}
+	keyring.Add(cfg)
+	return &keyringCredentialProvider{name: name, keyring: keyring}, nil
+}
+
+// NewKeyringCredentialProvider serves credentials from the node's docker keyring, which
+// includes any cloud credential providers (ECR, GCR, ACR style) compiled into the binary.
+func NewKeyringCredentialProvider(name string) CredentialProvider {
+	return &keyringCredentialProvider{name: name, keyring: credentialprovider.NewDockerKeyring()}
+}
+
+type keyringCredentialProvider struct {
+	name    string
+	keyring credentialprovider.DockerKeyring
+}
+
+func (p *keyringCredentialProvider) Name() string {
+	return p.name
+}
+
+func (p *keyringCredentialProvider) Basic(url *url.URL) (string, string, bool) {
+	username, password := basicCredentialsFromKeyring(p.keyring, url)
+	return username, password, len(username) > 0 || len(password) > 0
+}
@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/docker/distribution/manifest/manifestlist"
 	"github.com/docker/distribution/registry/api/errcode"
 	"github.com/golang/glog"
 	gonum "github.com/gonum/graph"
@@ -13,6 +15,7 @@ import (
 	kapi "k8s.io/kubernetes/pkg/api"
 	"k8s.io/kubernetes/pkg/api/resource"
 	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/apis/extensions"
 	kerrors "k8s.io/kubernetes/pkg/util/errors"
 	"k8s.io/kubernetes/pkg/util/sets"
 
@@ -42,6 +45,11 @@ const (
 	// ReferencedImageLayerEdgeKind defines an edge from an ImageStreamNode or an
 	// ImageNode to an ImageLayerNode.
 	ReferencedImageLayerEdgeKind = "ReferencedImageLayer"
+
+	// ParentManifestListEdgeKind defines an edge from an ImageNode holding a manifest list to
+	// an ImageNode holding one of its sub-manifests. The sub-manifest must be kept while any
+	// parent list is retained, and becomes prunable once every parent list is prunable.
+	ParentManifestListEdgeKind = "ParentManifestList"
 )
 
 // pruneAlgorithm contains the various settings to use when evaluating images
@@ -117,6 +125,12 @@ type PrunerOptions struct {
 	// DCs is the entire list of deployment configs across all namespaces in the
 	// cluster.
 	DCs *deployapi.DeploymentConfigList
+	// Deployments is the entire list of upstream extensions deployments across
+	// all namespaces in the cluster. May be nil when the resource is disabled.
+	Deployments *extensions.DeploymentList
+	// RSs is the entire list of replica sets across all namespaces in the
+	// cluster. May be nil when the resource is disabled.
+	RSs *extensions.ReplicaSetList
 	// LimitRanges is a map of LimitRanges across namespaces, being keys in this map.
 	LimitRanges map[string][]*kapi.LimitRange
 	// DryRun indicates that no changes will be made to the cluster and nothing
@@ -228,6 +242,8 @@ func (*dryRunRegistryPinger) ping(registry string) error {
 // - any pending pods
 // - any replication controllers
 // - any deployment configs
+// - any deployments
+// - any replica sets
 // - any build configs
 // - any builds
 // - the n most recent tag revisions in an image stream's status.tags
@@ -261,6 +277,8 @@ func NewPruner(options PrunerOptions) Pruner {
 	addBuildConfigsToGraph(g, options.BCs)
 	addBuildsToGraph(g, options.Builds)
 	addDeploymentConfigsToGraph(g, options.DCs)
+	addDeploymentsToGraph(g, options.Deployments)
+	addReplicaSetsToGraph(g, options.RSs)
 
 	var rp registryPinger
 	if options.DryRun {
@@ -312,6 +330,33 @@ func addImagesToGraph(g graph.Graph, images *imageapi.ImageList, algorithm prune
 			g.AddEdge(imageNode, layerNode, ReferencedImageLayerEdgeKind)
 		}
 	}
+
+	// link manifest lists to their sub-manifests once all image nodes exist
+	for i := range images.Items {
+		image := &images.Items[i]
+		if image.DockerImageManifestMediaType != manifestlist.MediaTypeManifestList {
+			continue
+		}
+		listNode := imagegraph.FindImage(g, image.Name)
+		if listNode == nil {
+			continue
+		}
+
+		var list manifestlist.ManifestList
+		if err := json.Unmarshal([]byte(image.DockerImageManifest), &list); err != nil {
+			glog.V(2).Infof("Unable to parse manifest list %q: %v", image.Name, err)
+			continue
+		}
+		for _, descriptor := range list.Manifests {
+			childNode := imagegraph.FindImage(g, descriptor.Digest.String())
+			if childNode == nil {
+				glog.V(4).Infof("Manifest list %q references image %q not present in the graph - skipping", image.Name, descriptor.Digest.String())
+				continue
+			}
+			glog.V(4).Infof("Adding manifest list edge from %q to sub-manifest %q", image.Name, descriptor.Digest.String())
+			g.AddEdge(listNode, childNode, ParentManifestListEdgeKind)
+		}
+	}
 }
 
 // addImageStreamsToGraph adds all the streams to the graph. The most recent n
@@ -454,9 +499,43 @@ func addPodsToGraph(g graph.Graph, pods *kapi.PodList, algorithm pruneAlgorithm)
 		podNode := kubegraph.EnsurePodNode(g, pod)
 
 		addPodSpecToGraph(g, &pod.Spec, podNode)
+		addPodContainerStatusesToGraph(g, pod, podNode)
+	}
+}
+
+// addPodContainerStatusesToGraph adds edges from the pod to the images its containers actually
+// run, taken from the container statuses. A container started from a tag carries no image ID
+// in the pod spec, so without this a running workload would not protect its image.
+func addPodContainerStatusesToGraph(g graph.Graph, pod *kapi.Pod, podNode gonum.Node) {
+	for _, status := range pod.Status.ContainerStatuses {
+		imageID := strippedImageID(status.ImageID)
+		if len(imageID) == 0 {
+			continue
+		}
+		imageNode := imagegraph.FindImage(g, imageID)
+		if imageNode == nil {
+			glog.V(4).Infof("Unable to find image %q from container status in the graph - skipping", imageID)
+			continue
+		}
+		glog.V(4).Infof("Adding edge from pod to image %q from container status", imageID)
+		g.AddEdge(podNode, imageNode, ReferencedImageEdgeKind)
 	}
 }
 
+// strippedImageID reduces a container status image ID such as
+// docker-pullable://registry/repo@sha256:abc or docker://sha256:abc to the bare digest.
+func strippedImageID(imageID string) string {
+	if i := strings.Index(imageID, "@"); i != -1 {
+		imageID = imageID[i+1:]
+	} else if i := strings.Index(imageID, "://"); i != -1 {
+		imageID = imageID[i+len("://"):]
+	}
+	if !strings.HasPrefix(imageID, "sha256:") {
+		return ""
+	}
+	return imageID
+}
+
 // Edges are added to the graph from each predecessor (pod or replication
 // controller) to the images specified by the pod spec's list of containers, as
 // long as the image is managed by OpenShift.
@@ -516,6 +595,41 @@ func addDeploymentConfigsToGraph(g graph.Graph, dcs *deployapi.DeploymentConfigL
 	}
 }
 
+// addDeploymentsToGraph adds upstream extensions deployments to the graph.
+//
+// Edges are added to the graph from each deployment to the images specified by
+// its pod spec's list of containers, as long as the image is managed by
+// OpenShift.
+func addDeploymentsToGraph(g graph.Graph, deployments *extensions.DeploymentList) {
+	if deployments == nil {
+		return
+	}
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		glog.V(4).Infof("Examining Deployment %s/%s", deployment.Namespace, deployment.Name)
+		deploymentNode := kubegraph.EnsureDeploymentNode(g, deployment)
+		addPodSpecToGraph(g, &deployment.Spec.Template.Spec, deploymentNode)
+	}
+}
+
+// addReplicaSetsToGraph adds replica sets to the graph. Replica sets kept for a deployment's
+// rollback history reference the images a rollback would run, so those stay protected as well.
+//
+// Edges are added to the graph from each replica set to the images specified by
+// its pod spec's list of containers, as long as the image is managed by
+// OpenShift.
+func addReplicaSetsToGraph(g graph.Graph, rss *extensions.ReplicaSetList) {
+	if rss == nil {
+		return
+	}
+	for i := range rss.Items {
+		rs := &rss.Items[i]
+		glog.V(4).Infof("Examining ReplicaSet %s/%s", rs.Namespace, rs.Name)
+		rsNode := kubegraph.EnsureReplicaSetNode(g, rs)
+		addPodSpecToGraph(g, &rs.Spec.Template.Spec, rsNode)
+	}
+}
+
 // addBuildConfigsToGraph adds build configs to the graph.
 //
 // Edges are added to the graph from each build config to the image specified by its strategy.from.
@@ -609,12 +723,17 @@ func edgeKind(g graph.Graph, from, to gonum.Node, desiredKind string) bool {
 // from its predecessors to it. A weak reference to an image is a reference
 // from an image stream to an image where the image is not the current image
 // for a tag and the image stream is at least as old as the minimum pruning
-// age.
-func imageIsPrunable(g graph.Graph, imageNode *imagegraph.ImageNode) bool {
+// age. A reference from a manifest list that is itself prunable is also
+// considered weak - the sub-manifest must not outlive its parent lists.
+func imageIsPrunable(g graph.Graph, imageNode *imagegraph.ImageNode, prunableImageIDs graph.NodeSet) bool {
 	onlyWeakReferences := true
 
 	for _, n := range g.To(imageNode) {
 		glog.V(4).Infof("Examining predecessor %#v", n)
+		if edgeKind(g, n, imageNode, ParentManifestListEdgeKind) && prunableImageIDs.Has(n.ID()) {
+			glog.V(4).Infof("Reference from prunable manifest list detected")
+			continue
+		}
 		if !edgeKind(g, n, imageNode, WeakReferencedImageEdgeKind) {
 			glog.V(4).Infof("Strong reference detected")
 			onlyWeakReferences = false
@@ -627,18 +746,30 @@ func imageIsPrunable(g graph.Graph, imageNode *imagegraph.ImageNode) bool {
 }
 
 // calculatePrunableImages returns the list of prunable images and a
-// graph.NodeSet containing the image node IDs.
+// graph.NodeSet containing the image node IDs. The calculation is repeated
+// until it settles, because a sub-manifest of a manifest list only becomes
+// prunable once every list referencing it has been found prunable.
 func calculatePrunableImages(g graph.Graph, imageNodes []*imagegraph.ImageNode) ([]*imagegraph.ImageNode, graph.NodeSet) {
 	prunable := []*imagegraph.ImageNode{}
 	ids := make(graph.NodeSet)
 
-	for _, imageNode := range imageNodes {
-		glog.V(4).Infof("Examining image %q", imageNode.Image.Name)
+	for {
+		changed := false
+		for _, imageNode := range imageNodes {
+			if ids.Has(imageNode.ID()) {
+				continue
+			}
+			glog.V(4).Infof("Examining image %q", imageNode.Image.Name)
 
-		if imageIsPrunable(g, imageNode) {
-			glog.V(4).Infof("Image %q is prunable", imageNode.Image.Name)
-			prunable = append(prunable, imageNode)
-			ids.Add(imageNode.ID())
+			if imageIsPrunable(g, imageNode, ids) {
+				glog.V(4).Infof("Image %q is prunable", imageNode.Image.Name)
+				prunable = append(prunable, imageNode)
+				ids.Add(imageNode.ID())
+				changed = true
+			}
+		}
+		if !changed {
+			break
 		}
 	}
 
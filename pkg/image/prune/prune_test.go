@@ -14,6 +14,7 @@ import (
 	kapi "k8s.io/kubernetes/pkg/api"
 	"k8s.io/kubernetes/pkg/api/resource"
 	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/apis/extensions"
 	"k8s.io/kubernetes/pkg/client/unversioned/fake"
 	ktc "k8s.io/kubernetes/pkg/client/unversioned/testclient"
 	"k8s.io/kubernetes/pkg/runtime"
@@ -246,6 +247,46 @@ func dc(namespace, name string, containerImages ...string) deployapi.DeploymentC
 	}
 }
 
+func deploymentList(deployments ...extensions.Deployment) extensions.DeploymentList {
+	return extensions.DeploymentList{
+		Items: deployments,
+	}
+}
+
+func deployment(namespace, name string, containerImages ...string) extensions.Deployment {
+	return extensions.Deployment{
+		ObjectMeta: kapi.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Spec: extensions.DeploymentSpec{
+			Template: kapi.PodTemplateSpec{
+				Spec: podSpec(containerImages...),
+			},
+		},
+	}
+}
+
+func rsList(rss ...extensions.ReplicaSet) extensions.ReplicaSetList {
+	return extensions.ReplicaSetList{
+		Items: rss,
+	}
+}
+
+func rs(namespace, name string, containerImages ...string) extensions.ReplicaSet {
+	return extensions.ReplicaSet{
+		ObjectMeta: kapi.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Spec: extensions.ReplicaSetSpec{
+			Template: kapi.PodTemplateSpec{
+				Spec: podSpec(containerImages...),
+			},
+		},
+	}
+}
+
 func bcList(bcs ...buildapi.BuildConfig) buildapi.BuildConfigList {
 	return buildapi.BuildConfigList{
 		Items: bcs,
@@ -413,6 +454,8 @@ func TestImagePruning(t *testing.T) {
 		bcs                    buildapi.BuildConfigList
 		builds                 buildapi.BuildList
 		dcs                    deployapi.DeploymentConfigList
+		deployments            extensions.DeploymentList
+		rss                    extensions.ReplicaSetList
 		limits                 map[string][]*kapi.LimitRange
 		expectedImageDeletions []string
 		expectedStreamUpdates  []string
@@ -548,6 +591,16 @@ func TestImagePruning(t *testing.T) {
 			rcs:    rcList(rc("foo", "rc1", registryURL+"/foo/bar@id")),
 			expectedImageDeletions: []string{},
 		},
+		"referenced by upstream deployment - don't prune": {
+			images:      imageList(image("id", registryURL+"/foo/bar@id")),
+			deployments: deploymentList(deployment("foo", "deployment1", registryURL+"/foo/bar@id")),
+			expectedImageDeletions: []string{},
+		},
+		"referenced by replica set - don't prune": {
+			images: imageList(image("id", registryURL+"/foo/bar@id")),
+			rss:    rsList(rs("foo", "rs1", registryURL+"/foo/bar@id")),
+			expectedImageDeletions: []string{},
+		},
 		"referenced by dc - don't prune": {
 			images: imageList(image("id", registryURL+"/foo/bar@id")),
 			dcs:    dcList(dc("foo", "rc1", registryURL+"/foo/bar@id")),
@@ -837,6 +890,8 @@ func TestImagePruning(t *testing.T) {
 			BCs:         &test.bcs,
 			Builds:      &test.builds,
 			DCs:         &test.dcs,
+			Deployments: &test.deployments,
+			RSs:         &test.rss,
 			LimitRanges: test.limits,
 		}
 		if test.pruneOverSizeLimit != nil {
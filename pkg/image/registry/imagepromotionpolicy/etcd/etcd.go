@@ -0,0 +1,51 @@
+package etcd
+
+import (
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/fields"
+	"k8s.io/kubernetes/pkg/labels"
+	"k8s.io/kubernetes/pkg/registry/generic"
+	"k8s.io/kubernetes/pkg/registry/generic/registry"
+	"k8s.io/kubernetes/pkg/runtime"
+
+	"github.com/openshift/origin/pkg/image/api"
+	"github.com/openshift/origin/pkg/image/registry/imagepromotionpolicy"
+	"github.com/openshift/origin/pkg/util/restoptions"
+)
+
+// REST implements a RESTStorage for image promotion policies against etcd.
+type REST struct {
+	*registry.Store
+}
+
+// NewREST returns a new REST.
+func NewREST(optsGetter restoptions.Getter) (*REST, error) {
+	prefix := "/imagepromotionpolicies"
+
+	store := &registry.Store{
+		NewFunc:     func() runtime.Object { return &api.ImagePromotionPolicy{} },
+		NewListFunc: func() runtime.Object { return &api.ImagePromotionPolicyList{} },
+		KeyRootFunc: func(ctx kapi.Context) string {
+			return registry.NamespaceKeyRootFunc(ctx, prefix)
+		},
+		KeyFunc: func(ctx kapi.Context, name string) (string, error) {
+			return registry.NamespaceKeyFunc(ctx, prefix, name)
+		},
+		ObjectNameFunc: func(obj runtime.Object) (string, error) {
+			return obj.(*api.ImagePromotionPolicy).Name, nil
+		},
+		PredicateFunc: func(label labels.Selector, field fields.Selector) generic.Matcher {
+			return imagepromotionpolicy.Matcher(label, field)
+		},
+		QualifiedResource: api.Resource("imagepromotionpolicies"),
+
+		CreateStrategy: imagepromotionpolicy.Strategy,
+		UpdateStrategy: imagepromotionpolicy.Strategy,
+	}
+
+	if err := restoptions.ApplyOptions(optsGetter, store, prefix); err != nil {
+		return nil, err
+	}
+
+	return &REST{store}, nil
+}
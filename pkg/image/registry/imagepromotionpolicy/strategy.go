@@ -0,0 +1,77 @@
+package imagepromotionpolicy
+
+import (
+	"fmt"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/fields"
+	"k8s.io/kubernetes/pkg/labels"
+	"k8s.io/kubernetes/pkg/registry/generic"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/util/validation/field"
+
+	"github.com/openshift/origin/pkg/image/api"
+	"github.com/openshift/origin/pkg/image/api/validation"
+)
+
+// policyStrategy implements behavior for ImagePromotionPolicies.
+type policyStrategy struct {
+	runtime.ObjectTyper
+}
+
+// Strategy is the default logic that applies when creating and updating ImagePromotionPolicy
+// objects via the REST API.
+var Strategy = policyStrategy{kapi.Scheme}
+
+// NamespaceScoped is true for image promotion policies.
+func (policyStrategy) NamespaceScoped() bool {
+	return true
+}
+
+func (policyStrategy) GenerateName(base string) string {
+	return base
+}
+
+// PrepareForCreate clears the status; only the promotion controller fills it in.
+func (policyStrategy) PrepareForCreate(obj runtime.Object) {
+	policy := obj.(*api.ImagePromotionPolicy)
+	policy.Status = api.ImagePromotionPolicyStatus{}
+}
+
+func (policyStrategy) PrepareForUpdate(obj, old runtime.Object) {
+}
+
+// Canonicalize normalizes the object after validation.
+func (policyStrategy) Canonicalize(obj runtime.Object) {
+}
+
+// Validate validates a new image promotion policy.
+func (policyStrategy) Validate(ctx kapi.Context, obj runtime.Object) field.ErrorList {
+	return validation.ValidateImagePromotionPolicy(obj.(*api.ImagePromotionPolicy))
+}
+
+// AllowCreateOnUpdate is false for image promotion policies.
+func (policyStrategy) AllowCreateOnUpdate() bool {
+	return false
+}
+
+func (policyStrategy) AllowUnconditionalUpdate() bool {
+	return false
+}
+
+// ValidateUpdate is the default update validation for an ImagePromotionPolicy.
+func (policyStrategy) ValidateUpdate(ctx kapi.Context, obj, old runtime.Object) field.ErrorList {
+	return validation.ValidateImagePromotionPolicyUpdate(obj.(*api.ImagePromotionPolicy), old.(*api.ImagePromotionPolicy))
+}
+
+// Matcher returns a generic matcher for a given label and field selector.
+func Matcher(label labels.Selector, field fields.Selector) generic.Matcher {
+	return generic.MatcherFunc(func(obj runtime.Object) (bool, error) {
+		policy, ok := obj.(*api.ImagePromotionPolicy)
+		if !ok {
+			return false, fmt.Errorf("not an ImagePromotionPolicy")
+		}
+		fields := api.ImagePromotionPolicyToSelectableFields(policy)
+		return label.Matches(labels.Set(policy.Labels)) && field.Matches(fields), nil
+	})
+}
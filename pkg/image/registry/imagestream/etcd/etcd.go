@@ -22,8 +22,9 @@ type REST struct {
 	subjectAccessReviewRegistry subjectaccessreview.Registry
 }
 
-// NewREST returns a new REST.
-func NewREST(optsGetter restoptions.Getter, defaultRegistry api.DefaultRegistry, subjectAccessReviewRegistry subjectaccessreview.Registry, limitVerifier imageadmission.LimitVerifier) (*REST, *StatusREST, *InternalREST, error) {
+// NewREST returns a new REST. registryVariants may be nil when no alternative registry
+// locations are configured.
+func NewREST(optsGetter restoptions.Getter, defaultRegistry api.DefaultRegistry, registryVariants api.RegistryVariants, subjectAccessReviewRegistry subjectaccessreview.Registry, limitVerifier imageadmission.LimitVerifier) (*REST, *StatusREST, *InternalREST, error) {
 	prefix := "/imagestreams"
 
 	store := registry.Store{
@@ -57,6 +58,7 @@ func NewREST(optsGetter restoptions.Getter, defaultRegistry api.DefaultRegistry,
 	strategy := imagestream.NewStrategy(defaultRegistry, subjectAccessReviewRegistry, limitVerifier)
 	rest := &REST{Store: &store, subjectAccessReviewRegistry: subjectAccessReviewRegistry}
 	strategy.ImageStreamGetter = rest
+	strategy.RegistryVariants = registryVariants
 
 	store.CreateStrategy = strategy
 	store.UpdateStrategy = strategy
@@ -35,6 +35,9 @@ type Strategy struct {
 	tagVerifier       *TagVerifier
 	limitVerifier     imageadmission.LimitVerifier
 	ImageStreamGetter ResourceGetter
+	// RegistryVariants, when set, supplies the alternative registry locations published in
+	// status.dockerImageRepositoryVariants.
+	RegistryVariants api.RegistryVariants
 }
 
 // NewStrategy is the default logic that applies when creating and updating
@@ -60,8 +63,9 @@ func (s Strategy) NamespaceScoped() bool {
 func (s Strategy) PrepareForCreate(obj runtime.Object) {
 	stream := obj.(*api.ImageStream)
 	stream.Status = api.ImageStreamStatus{
-		DockerImageRepository: s.dockerImageRepository(stream),
-		Tags: make(map[string]api.TagEventList),
+		DockerImageRepository:         s.dockerImageRepository(stream),
+		DockerImageRepositoryVariants: s.dockerImageRepositoryVariants(stream),
+		Tags:                          make(map[string]api.TagEventList),
 	}
 	stream.Generation = 1
 	for tag, ref := range stream.Spec.Tags {
@@ -122,6 +126,31 @@ func (s Strategy) dockerImageRepository(stream *api.ImageStream) string {
 	return ref.String()
 }
 
+// dockerImageRepositoryVariants returns the alternative locations this stream may be pulled
+// from, keyed by variant name, or nil when no variants are configured.
+func (s Strategy) dockerImageRepositoryVariants(stream *api.ImageStream) map[string]string {
+	if s.RegistryVariants == nil {
+		return nil
+	}
+	hosts := s.RegistryVariants.RegistryVariants()
+	if len(hosts) == 0 {
+		return nil
+	}
+	if len(stream.Namespace) == 0 {
+		stream.Namespace = kapi.NamespaceDefault
+	}
+	variants := map[string]string{}
+	for name, registry := range hosts {
+		ref := api.DockerImageReference{
+			Registry:  registry,
+			Namespace: stream.Namespace,
+			Name:      stream.Name,
+		}
+		variants[name] = ref.String()
+	}
+	return variants
+}
+
 func parseFromReference(stream *api.ImageStream, from *kapi.ObjectReference) (string, string, error) {
 	splitChar := ""
 	refType := ""
@@ -467,6 +496,7 @@ func (s Strategy) prepareForUpdate(obj, old runtime.Object, resetStatus bool) {
 		stream.Status = oldStream.Status
 	}
 	stream.Status.DockerImageRepository = s.dockerImageRepository(stream)
+	stream.Status.DockerImageRepositoryVariants = s.dockerImageRepositoryVariants(stream)
 
 	// ensure that users cannot change spec tag generation to any value except 0
 	updateSpecTagGenerationsForUpdate(stream, oldStream)
@@ -517,6 +547,7 @@ func (s Strategy) ValidateUpdate(ctx kapi.Context, obj, old runtime.Object) fiel
 func (s Strategy) Decorate(obj runtime.Object) error {
 	ir := obj.(*api.ImageStream)
 	ir.Status.DockerImageRepository = s.dockerImageRepository(ir)
+	ir.Status.DockerImageRepositoryVariants = s.dockerImageRepositoryVariants(ir)
 	return nil
 }
 
@@ -594,6 +625,7 @@ func (s InternalStrategy) PrepareForCreate(obj runtime.Object) {
 	stream := obj.(*api.ImageStream)
 
 	stream.Status.DockerImageRepository = s.dockerImageRepository(stream)
+	stream.Status.DockerImageRepositoryVariants = s.dockerImageRepositoryVariants(stream)
 	stream.Generation = 1
 	for tag, ref := range stream.Spec.Tags {
 		ref.Generation = &stream.Generation
@@ -0,0 +1,38 @@
+package imagestreamimage
+
+import (
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/runtime"
+
+	"github.com/openshift/origin/pkg/image/api"
+)
+
+// ConfigREST implements the imagestreamimages/config subresource. It returns the parsed
+// container config of an image that has previously been tagged into an image stream, so
+// consumers do not need to pull and parse manifests from the registry themselves.
+type ConfigREST struct {
+	imageREST *REST
+}
+
+// NewConfigREST returns a new ConfigREST backed by the given imagestreamimage storage.
+func NewConfigREST(imageREST *REST) *ConfigREST {
+	return &ConfigREST{imageREST: imageREST}
+}
+
+// New is only implemented to make ConfigREST implement RESTStorage
+func (r *ConfigREST) New() runtime.Object {
+	return &api.DockerImage{}
+}
+
+// Get returns the container config of an image by ID that has previously been tagged into an
+// image stream. `id` is of the form <repo name>@<image id>.
+func (r *ConfigREST) Get(ctx kapi.Context, id string) (runtime.Object, error) {
+	obj, err := r.imageREST.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	isi := obj.(*api.ImageStreamImage)
+
+	config := isi.Image.DockerImageMetadata
+	return &config, nil
+}
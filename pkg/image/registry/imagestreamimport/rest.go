@@ -3,6 +3,7 @@ package imagestreamimport
 import (
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/golang/glog"
@@ -14,10 +15,12 @@ import (
 	"k8s.io/kubernetes/pkg/api/unversioned"
 	"k8s.io/kubernetes/pkg/runtime"
 	"k8s.io/kubernetes/pkg/util/diff"
+	"k8s.io/kubernetes/pkg/util/flowcontrol"
 	utilruntime "k8s.io/kubernetes/pkg/util/runtime"
 	"k8s.io/kubernetes/pkg/util/validation/field"
 
 	"github.com/openshift/origin/pkg/client"
+	serverapi "github.com/openshift/origin/pkg/cmd/server/api"
 	"github.com/openshift/origin/pkg/dockerregistry"
 	"github.com/openshift/origin/pkg/image/api"
 	"github.com/openshift/origin/pkg/image/importer"
@@ -41,6 +44,12 @@ type REST struct {
 	transport         http.RoundTripper
 	insecureTransport http.RoundTripper
 	clientFn          ImporterDockerRegistryFunc
+	registryPolicy    *serverapi.ImportRegistryPolicy
+
+	// limiterLock guards registryLimiters, which holds per-registry rate limiters lazily created
+	// when the policy sets a maximum number of imports per minute.
+	limiterLock      sync.Mutex
+	registryLimiters map[string]flowcontrol.RateLimiter
 }
 
 // NewREST returns a REST storage implementation that handles importing images. The clientFn argument is optional
@@ -50,6 +59,7 @@ func NewREST(importFn ImporterFunc, streams imagestream.Registry, internalStream
 	images rest.Creater, secrets client.ImageStreamSecretsNamespacer,
 	transport, insecureTransport http.RoundTripper,
 	clientFn ImporterDockerRegistryFunc,
+	registryPolicy *serverapi.ImportRegistryPolicy,
 ) *REST {
 	return &REST{
 		importFn:          importFn,
@@ -60,6 +70,8 @@ func NewREST(importFn ImporterFunc, streams imagestream.Registry, internalStream
 		transport:         transport,
 		insecureTransport: insecureTransport,
 		clientFn:          clientFn,
+		registryPolicy:    registryPolicy,
+		registryLimiters:  make(map[string]flowcontrol.RateLimiter),
 	}
 }
 
@@ -85,6 +97,10 @@ func (r *REST) Create(ctx kapi.Context, obj runtime.Object) (runtime.Object, err
 		return nil, kapierrors.NewBadRequest("a namespace must be specified to import images")
 	}
 
+	if err := r.checkImportPolicy(isi); err != nil {
+		return nil, err
+	}
+
 	if r.clientFn != nil {
 		if client := r.clientFn(); client != nil {
 			ctx = kapi.WithValue(ctx, importer.ContextKeyV1RegistryClient, client)
@@ -339,6 +355,7 @@ func (r *REST) importSuccessful(
 		DockerImageReference: pullSpec,
 		Image:                image.Name,
 		Generation:           nextGeneration,
+		Platforms:            api.ImagePlatforms(image),
 	}
 
 	if stream.Spec.Tags == nil {
@@ -429,3 +446,81 @@ func newImportFailedCondition(err error, gen int64, now unversioned.Time) api.Ta
 func invalidStatus(kind, position string, errs ...*field.Error) unversioned.Status {
 	return kapierrors.NewInvalid(api.Kind(kind), position, errs).ErrStatus
 }
+
+// checkImportPolicy verifies every external registry referenced by the import against the cluster
+// import registry policy and enforces the per-registry rate limit.
+func (r *REST) checkImportPolicy(isi *api.ImageStreamImport) error {
+	if r.registryPolicy == nil {
+		return nil
+	}
+
+	type registryRef struct {
+		host     string
+		insecure bool
+	}
+	refs := []registryRef{}
+	if spec := isi.Spec.Repository; spec != nil {
+		ref, err := api.ParseDockerImageReference(spec.From.Name)
+		if err == nil {
+			refs = append(refs, registryRef{host: ref.DockerClientDefaults().Registry, insecure: spec.ImportPolicy.Insecure})
+		}
+	}
+	for _, spec := range isi.Spec.Images {
+		if spec.From.Kind != "DockerImage" {
+			continue
+		}
+		ref, err := api.ParseDockerImageReference(spec.From.Name)
+		if err != nil {
+			continue
+		}
+		refs = append(refs, registryRef{host: ref.DockerClientDefaults().Registry, insecure: spec.ImportPolicy.Insecure})
+	}
+
+	seen := map[registryRef]struct{}{}
+	for _, ref := range refs {
+		if _, ok := seen[ref]; ok {
+			continue
+		}
+		seen[ref] = struct{}{}
+
+		// denied entries take precedence and match on domain name only
+		if serverapi.MatchesRegistryLocations(r.registryPolicy.Denied, ref.host, false) {
+			return kapierrors.NewForbidden(api.Resource("imagestreamimports"), isi.Name, fmt.Errorf("this cluster does not allow imports from registry %s", ref.host))
+		}
+		if len(r.registryPolicy.Allowed) > 0 && !serverapi.MatchesRegistryLocations(r.registryPolicy.Allowed, ref.host, ref.insecure) {
+			if ref.insecure && serverapi.MatchesRegistryLocations(r.registryPolicy.Allowed, ref.host, false) {
+				return kapierrors.NewForbidden(api.Resource("imagestreamimports"), isi.Name, fmt.Errorf("this cluster requires TLS for imports from registry %s", ref.host))
+			}
+			return kapierrors.NewForbidden(api.Resource("imagestreamimports"), isi.Name, fmt.Errorf("this cluster does not allow imports from registry %s", ref.host))
+		}
+		if err := r.admitRegistryRate(ref.host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// admitRegistryRate enforces the per-registry import rate limit from the policy, lazily creating a
+// token bucket limiter for each registry host.
+func (r *REST) admitRegistryRate(host string) error {
+	if r.registryPolicy.MaxImportsPerMinute <= 0 {
+		return nil
+	}
+
+	r.limiterLock.Lock()
+	limiter, ok := r.registryLimiters[host]
+	if !ok {
+		qps := float32(r.registryPolicy.MaxImportsPerMinute) / float32(time.Minute/time.Second)
+		if qps < 1.0/float32(time.Minute/time.Second) {
+			qps = 1.0 / float32(time.Minute/time.Second)
+		}
+		limiter = flowcontrol.NewTokenBucketRateLimiter(qps, r.registryPolicy.MaxImportsPerMinute)
+		r.registryLimiters[host] = limiter
+	}
+	r.limiterLock.Unlock()
+
+	if !limiter.TryAccept() {
+		return kapierrors.NewTimeoutError(fmt.Sprintf("imports from registry %s are rate limited, try again later", host), 60)
+	}
+	return nil
+}
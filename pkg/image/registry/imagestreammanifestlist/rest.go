@@ -0,0 +1,175 @@
+package imagestreammanifestlist
+
+import (
+	"strings"
+
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/util/validation/field"
+	"k8s.io/kubernetes/pkg/util/wait"
+
+	"github.com/openshift/origin/pkg/image/api"
+	"github.com/openshift/origin/pkg/image/api/validation"
+	"github.com/openshift/origin/pkg/image/registry/image"
+	"github.com/openshift/origin/pkg/image/registry/imagestream"
+)
+
+// maxRetriesOnConflict is the maximum retry count for status updates which
+// result in resource conflicts.
+const maxRetriesOnConflict = 10
+
+// REST implements the RESTStorage interface in terms of an image registry and
+// image stream registry. It only supports the Create method and assembles a
+// manifest list image from platform specific images already present in an
+// image stream, so multi-arch tags can be published without external tooling.
+type REST struct {
+	imageRegistry       image.Registry
+	imageStreamRegistry imagestream.Registry
+}
+
+// NewREST returns a new REST.
+func NewREST(imageRegistry image.Registry, imageStreamRegistry imagestream.Registry) *REST {
+	return &REST{
+		imageRegistry:       imageRegistry,
+		imageStreamRegistry: imageStreamRegistry,
+	}
+}
+
+// New returns a new ImageStreamManifestList for use with Create.
+func (r *REST) New() runtime.Object {
+	return &api.ImageStreamManifestList{}
+}
+
+// Create assembles a manifest list from the images currently tagged by the
+// source tags, registers the resulting image, and records it as a tag event
+// under the destination tag. The source images must have been pushed or
+// imported beforehand so their manifests are available on the server.
+func (r *REST) Create(ctx kapi.Context, obj runtime.Object) (runtime.Object, error) {
+	list := obj.(*api.ImageStreamManifestList)
+	if errs := validation.ValidateImageStreamManifestList(list); len(errs) > 0 {
+		return nil, errors.NewInvalid(api.Kind("ImageStreamManifestList"), list.Name, errs)
+	}
+
+	stream, err := r.imageStreamRegistry.GetImageStream(ctx, list.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptors := make([]manifestlist.ManifestDescriptor, 0, len(list.SourceTags))
+	platforms := []string{}
+	for i, sourceTag := range list.SourceTags {
+		fldPath := field.NewPath("sourceTags").Index(i)
+		event := api.LatestTaggedImage(stream, sourceTag)
+		if event == nil || len(event.Image) == 0 {
+			return nil, errors.NewInvalid(api.Kind("ImageStreamManifestList"), list.Name, field.ErrorList{
+				field.Invalid(fldPath, sourceTag, "the tag does not reference an image in this stream"),
+			})
+		}
+		sourceImage, err := r.imageRegistry.GetImage(ctx, event.Image)
+		if err != nil {
+			return nil, err
+		}
+		if len(sourceImage.DockerImageManifest) == 0 {
+			return nil, errors.NewInvalid(api.Kind("ImageStreamManifestList"), list.Name, field.ErrorList{
+				field.Invalid(fldPath, sourceTag, "the tagged image has no manifest stored on the server"),
+			})
+		}
+		imagePlatforms := api.ImagePlatforms(sourceImage)
+		if len(imagePlatforms) == 0 {
+			return nil, errors.NewInvalid(api.Kind("ImageStreamManifestList"), list.Name, field.ErrorList{
+				field.Invalid(fldPath, sourceTag, "the tagged image carries no platform metadata"),
+			})
+		}
+		os, arch := splitPlatform(imagePlatforms[0])
+		mediaType := sourceImage.DockerImageManifestMediaType
+		if len(mediaType) == 0 {
+			mediaType = schema2.MediaTypeManifest
+		}
+		descriptor := manifestlist.ManifestDescriptor{
+			Platform: manifestlist.PlatformSpec{
+				OS:           os,
+				Architecture: arch,
+			},
+		}
+		descriptor.Digest = digest.Digest(sourceImage.Name)
+		descriptor.Size = int64(len(sourceImage.DockerImageManifest))
+		descriptor.MediaType = mediaType
+		descriptors = append(descriptors, descriptor)
+		platforms = append(platforms, imagePlatforms[0])
+	}
+
+	deserialized, err := manifestlist.FromDescriptors(descriptors)
+	if err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	_, payload, err := deserialized.Payload()
+	if err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	dgst := digest.FromBytes(payload)
+
+	listImage := api.Image{
+		ObjectMeta: kapi.ObjectMeta{
+			Name: dgst.String(),
+			Annotations: map[string]string{
+				api.ManagedByOpenShiftAnnotation: "true",
+			},
+		},
+		DockerImageReference:         stream.Status.DockerImageRepository + "@" + dgst.String(),
+		DockerImageManifest:          string(payload),
+		DockerImageManifestMediaType: manifestlist.MediaTypeManifestList,
+	}
+	if err := r.imageRegistry.CreateImage(ctx, &listImage); err != nil && !errors.IsAlreadyExists(err) {
+		return nil, err
+	}
+
+	next := api.TagEvent{
+		Created:              unversioned.Now(),
+		DockerImageReference: listImage.DockerImageReference,
+		Image:                listImage.Name,
+		Platforms:            platforms,
+	}
+	err = wait.ExponentialBackoff(wait.Backoff{Steps: maxRetriesOnConflict}, func() (bool, error) {
+		next.Generation = stream.Generation
+		if !api.AddTagEventToImageStream(stream, list.Tag, next) {
+			return true, nil
+		}
+		api.UpdateTrackingTags(stream, list.Tag, next)
+		if _, err := r.imageStreamRegistry.UpdateImageStreamStatus(ctx, stream); err != nil {
+			if !errors.IsConflict(err) {
+				return false, err
+			}
+			latest, getErr := r.imageStreamRegistry.GetImageStream(ctx, list.Name)
+			if getErr != nil {
+				return false, getErr
+			}
+			stream = latest
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := *list
+	result.Image = listImage
+	result.Image.DockerImageManifest = ""
+	return &result, nil
+}
+
+// splitPlatform splits an "os/architecture" platform string, defaulting the
+// operating system to linux when only an architecture is given.
+func splitPlatform(platform string) (os, arch string) {
+	os, arch = "linux", platform
+	if i := strings.Index(platform, "/"); i != -1 {
+		os, arch = platform[:i], platform[i+1:]
+	}
+	return os, arch
+}
@@ -72,6 +72,7 @@ func (s *REST) Create(ctx kapi.Context, obj runtime.Object) (runtime.Object, err
 		Created:              unversioned.Now(),
 		DockerImageReference: image.DockerImageReference,
 		Image:                image.Name,
+		Platforms:            api.ImagePlatforms(&image),
 	}
 
 	err = wait.ExponentialBackoff(wait.Backoff{Steps: maxRetriesOnConflict}, func() (bool, error) {
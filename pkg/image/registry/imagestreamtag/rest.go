@@ -2,6 +2,7 @@ package imagestreamtag
 
 import (
 	"fmt"
+	"strings"
 
 	kapi "k8s.io/kubernetes/pkg/api"
 	kapierrors "k8s.io/kubernetes/pkg/api/errors"
@@ -307,6 +308,13 @@ func newISTag(tag string, imageStream *api.ImageStream, image *api.Image, allowE
 		if err := api.ImageWithMetadata(image); err != nil {
 			return nil, err
 		}
+		platforms := event.Platforms
+		if len(platforms) == 0 {
+			platforms = api.ImagePlatforms(image)
+		}
+		if len(platforms) > 0 {
+			ist.Annotations[api.ImagePlatformsAnnotation] = strings.Join(platforms, ",")
+		}
 		image.DockerImageManifest = ""
 		ist.Image = *image
 	} else {
@@ -33,6 +33,11 @@ type DockerRegistryServiceControllerOptions struct {
 	RegistryNamespace   string
 	RegistryServiceName string
 
+	// AdditionalRegistryURLs are locations of the registry outside the cluster, such as the
+	// host of its route. They are included in every generated dockercfg secret alongside the
+	// locations derived from the registry service.
+	AdditionalRegistryURLs []string
+
 	DockercfgController *DockercfgController
 
 	// DockerURLsIntialized is used to send a signal to the DockercfgController that it has the correct set of docker urls
@@ -42,13 +47,14 @@ type DockerRegistryServiceControllerOptions struct {
 // NewDockerRegistryServiceController returns a new *DockerRegistryServiceController.
 func NewDockerRegistryServiceController(cl client.Interface, options DockerRegistryServiceControllerOptions) *DockerRegistryServiceController {
 	e := &DockerRegistryServiceController{
-		client:                cl,
-		dockercfgController:   options.DockercfgController,
-		registryLocationQueue: workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
-		secretsToUpdate:       workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
-		serviceName:           options.RegistryServiceName,
-		serviceNamespace:      options.RegistryNamespace,
-		dockerURLsIntialized:  options.DockerURLsIntialized,
+		client:                 cl,
+		dockercfgController:    options.DockercfgController,
+		registryLocationQueue:  workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		secretsToUpdate:        workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		serviceName:            options.RegistryServiceName,
+		serviceNamespace:       options.RegistryNamespace,
+		additionalRegistryURLs: options.AdditionalRegistryURLs,
+		dockerURLsIntialized:   options.DockerURLsIntialized,
 	}
 
 	e.serviceCache, e.serviceController = framework.NewInformer(
@@ -103,8 +109,9 @@ func NewDockerRegistryServiceController(cl client.Interface, options DockerRegis
 type DockerRegistryServiceController struct {
 	client client.Interface
 
-	serviceName      string
-	serviceNamespace string
+	serviceName            string
+	serviceNamespace       string
+	additionalRegistryURLs []string
 
 	dockercfgController *DockercfgController
 
@@ -227,31 +234,32 @@ func (e *DockerRegistryServiceController) watchForDockerURLChanges() {
 	}
 }
 
-// getDockerRegistryLocations returns the dns form and the ip form of the secret
+// getDockerRegistryLocations returns the dns form and the ip form of the secret, plus any
+// additional locations the registry is known under outside the cluster
 func (e *DockerRegistryServiceController) getDockerRegistryLocations() []string {
 	key, err := controller.KeyFunc(&kapi.Service{ObjectMeta: kapi.ObjectMeta{Name: e.serviceName, Namespace: e.serviceNamespace}})
 	if err != nil {
-		return []string{}
+		return e.additionalRegistryURLs
 	}
 
 	obj, exists, err := e.serviceCache.GetByKey(key)
 	if err != nil {
-		return []string{}
+		return e.additionalRegistryURLs
 	}
 	if !exists {
-		return []string{}
+		return e.additionalRegistryURLs
 	}
 	service := obj.(*kapi.Service)
 
 	hasPortalIP := (len(service.Spec.ClusterIP) > 0) && (net.ParseIP(service.Spec.ClusterIP) != nil)
 	if hasPortalIP && len(service.Spec.Ports) > 0 {
-		return []string{
+		return append([]string{
 			net.JoinHostPort(service.Spec.ClusterIP, fmt.Sprintf("%d", service.Spec.Ports[0].Port)),
 			net.JoinHostPort(fmt.Sprintf("%s.%s.svc", service.Name, service.Namespace), fmt.Sprintf("%d", service.Spec.Ports[0].Port)),
-		}
+		}, e.additionalRegistryURLs...)
 	}
 
-	return []string{}
+	return e.additionalRegistryURLs
 }
 
 // syncRegistryLocationChange goes through all service account dockercfg secrets and updates them to point at a new docker-registry location